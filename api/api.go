@@ -0,0 +1,213 @@
+// Package api serves a versioned JSON read API, authenticated by personal API tokens.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/tokens"
+	"github.com/henvic/climetrics/cursor"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/users"
+	log "github.com/sirupsen/logrus"
+)
+
+// Prefix of the API routes.
+const Prefix = "/api/v1"
+
+const (
+	defaultPerPage = 100
+	maxPerPage     = 1000
+)
+
+var router = server.Instance.Mux
+
+func init() {
+	router().Handle(Prefix+"/metrics", Require(tokens.ReadMetrics, metricsHandler))
+	router().Handle(Prefix+"/metrics/{id}", Require(tokens.ReadMetrics, metricHandler))
+	router().Handle(Prefix+"/diagnostics", Require(tokens.ReadDiagnostics, diagnosticsHandler))
+	router().Handle(Prefix+"/diagnostics/{id}", Require(tokens.ReadDiagnostics, diagnosticHandler))
+	router().Handle(Prefix+"/users", Require(tokens.ReadUsers, usersHandler))
+	router().Handle(Prefix+"/users/{user_id}", Require(tokens.ReadUsers, userHandler))
+
+	// keep errors in JSON for unknown API routes
+	router().PathPrefix("/api/").HandlerFunc(notFoundHandler)
+}
+
+// Context of an authenticated API request.
+type Context struct {
+	Token tokens.Token
+	User  users.User
+}
+
+// HandlerFunc for an API request.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request, c Context)
+
+// Handler for API requests with a token with a given scope.
+type Handler struct {
+	Scope   tokens.Scope
+	Handler HandlerFunc
+}
+
+// Require scope for a handler.
+func Require(s tokens.Scope, h HandlerFunc) *Handler {
+	return &Handler{
+		Scope:   s,
+		Handler: h,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var secret = bearer(r)
+
+	if secret == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="climetrics"`)
+		Error(w, "Missing bearer token. Create a personal API token on your account page.", http.StatusUnauthorized)
+		return
+	}
+
+	t, err := tokens.Authenticate(r.Context(), secret)
+
+	switch err {
+	case nil:
+	case tokens.ErrInvalid, tokens.ErrExpired:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="climetrics", error="invalid_token"`)
+		Error(w, fmt.Sprintf("Access restricted: %v.", err), http.StatusUnauthorized)
+		return
+	default:
+		log.Errorf("can't authenticate API token: %v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	u, err := users.Get(r.Context(), t.UserID)
+
+	if err != nil || u.Role == users.Revoked {
+		Error(w, "Access restricted: invalid token.", http.StatusUnauthorized)
+		return
+	}
+
+	if !h.Allowed(t, u) {
+		server.Audit(r, audit.Entry{
+			ActorID: u.UserID,
+			Actor:   u.Username,
+			Action:  audit.AccessDenied,
+			Target:  r.URL.Path,
+			Details: fmt.Sprintf("API token %s without %s", t.ID, h.Scope),
+		})
+
+		Error(w, fmt.Sprintf("Access forbidden. The token needs the %s scope and its owner the %s permission.",
+			h.Scope, h.Scope.Permission()), http.StatusForbidden)
+		return
+	}
+
+	h.Handler(w, r, Context{
+		Token: t,
+		User:  u,
+	})
+}
+
+// Allowed tells if the token has the scope of the handler, and its owner the permission required by it.
+func (h *Handler) Allowed(t tokens.Token, u users.User) bool {
+	return t.Has(h.Scope) && u.Can(h.Scope.Permission())
+}
+
+func bearer(r *http.Request) string {
+	var a = r.Header.Get("Authorization")
+	const scheme = "bearer "
+
+	if len(a) <= len(scheme) || !strings.EqualFold(a[:len(scheme)], scheme) {
+		return ""
+	}
+
+	return strings.TrimSpace(a[len(scheme):])
+}
+
+// Error response, in the same format used by the server for JSON requests.
+func Error(w http.ResponseWriter, message string, code int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+	w.WriteHeader(code)
+
+	b, _ := json.MarshalIndent(map[string]interface{}{
+		"status":  code,
+		"message": message,
+	}, "", "    ")
+
+	_, _ = fmt.Fprintf(w, "%s\n", b)
+}
+
+func respond(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf8")
+
+	b, err := json.MarshalIndent(v, "", "    ")
+
+	if err != nil {
+		log.Errorf("can't encode API response: %v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "%s\n", b)
+}
+
+// Page of a list. Pass NextCursor as the cursor parameter to get the next page.
+type Page struct {
+	Data       interface{} `json:"data"`
+	Total      int         `json:"total"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// pagination parameters (cursor and per_page).
+func pagination(query url.Values) (c *cursor.Cursor, perPage int, err error) {
+	perPage = defaultPerPage
+
+	if v := query.Get("per_page"); v != "" {
+		perPage, err = strconv.Atoi(v)
+
+		if err != nil || perPage < 1 || perPage > maxPerPage {
+			return nil, 0, fmt.Errorf("per_page must be a number between 1 and %d", maxPerPage)
+		}
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		if c, err = cursor.Parse(v); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if query.Get("page") != "" {
+		return nil, 0, fmt.Errorf("page is not supported: use cursor instead")
+	}
+
+	return c, perPage, nil
+}
+
+// nextCursor for a page with the given number of items, ending on an item with the given sync time and ID.
+func nextCursor(items, perPage int, syncTime, id string) (string, error) {
+	if items < perPage {
+		return "", nil
+	}
+
+	c, err := cursor.New(syncTime, id)
+
+	if err != nil {
+		return "", err
+	}
+
+	return c.String(), nil
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/henvic/climetrics/auth/tokens"
+	"github.com/henvic/climetrics/cursor"
+)
+
+func TestBearer(t *testing.T) {
+	var cases = map[string]string{
+		"":                    "",
+		"Bearer":              "",
+		"Bearer ":             "",
+		"Basic dXNlcjpwYXNz":  "",
+		"Bearer cmt_abc":      "cmt_abc",
+		"bearer  cmt_abc ":    "cmt_abc",
+		"BEARER cmt_abc\t":    "cmt_abc",
+		"Bearercmt_abcdefgh":  "",
+		"Token cmt_abcdefghi": "",
+	}
+
+	for header, want := range cases {
+		var r = httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+		r.Header.Set("Authorization", header)
+
+		if got := bearer(r); got != want {
+			t.Errorf("Expected bearer token %q for header %q, got %q instead", want, header, got)
+		}
+	}
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	var m map[string]interface{}
+
+	if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+		t.Fatalf("Expected JSON error response, got %v instead", err)
+	}
+
+	if m["status"] != float64(w.Code) || m["message"] == "" {
+		t.Errorf("Unexpected JSON error response %v", m)
+	}
+
+	return m
+}
+
+func TestHandlerUnauthorized(t *testing.T) {
+	var h = Require(tokens.ReadMetrics, func(w http.ResponseWriter, r *http.Request, c Context) {
+		t.Error("Handler should not be called")
+	})
+
+	var cases = []struct {
+		authorization string
+		authenticate  string
+	}{
+		{"", `Bearer realm="climetrics"`},
+		{"Bearer not-a-token", `Bearer realm="climetrics", error="invalid_token"`},
+	}
+
+	for _, c := range cases {
+		var r = httptest.NewRequest(http.MethodGet, "/api/v1/metrics", nil)
+		r.Header.Set("Authorization", c.authorization)
+		var w = httptest.NewRecorder()
+
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d instead", http.StatusUnauthorized, w.Code)
+		}
+
+		if got := w.Header().Get("WWW-Authenticate"); got != c.authenticate {
+			t.Errorf("Expected WWW-Authenticate header %v, got %v instead", c.authenticate, got)
+		}
+
+		decodeError(t, w)
+	}
+}
+
+func TestHandlerMethodNotAllowed(t *testing.T) {
+	var h = Require(tokens.ReadMetrics, func(w http.ResponseWriter, r *http.Request, c Context) {
+		t.Error("Handler should not be called")
+	})
+
+	var r = httptest.NewRequest(http.MethodPost, "/api/v1/metrics", nil)
+	var w = httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("Expected status %d, got %d instead", http.StatusMethodNotAllowed, w.Code)
+	}
+
+	decodeError(t, w)
+}
+
+func TestNotFound(t *testing.T) {
+	var w = httptest.NewRecorder()
+	notFoundHandler(w, httptest.NewRequest(http.MethodGet, "/api/v2/metrics", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d instead", http.StatusNotFound, w.Code)
+	}
+
+	decodeError(t, w)
+}
+
+func TestPagination(t *testing.T) {
+	c, perPage, err := pagination(url.Values{})
+
+	if c != nil || perPage != defaultPerPage || err != nil {
+		t.Errorf("Unexpected default pagination: %v, %d, %v", c, perPage, err)
+	}
+
+	var want = cursor.Cursor{ID: "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4"}
+	want.Time = want.Time.AddDate(2018, 0, 0)
+
+	c, perPage, err = pagination(url.Values{
+		"cursor":   {want.String()},
+		"per_page": {"20"},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if c == nil || c.ID != want.ID || !c.Time.Equal(want.Time) || perPage != 20 {
+		t.Errorf("Unexpected pagination: %v, %d", c, perPage)
+	}
+}
+
+func TestPaginationFailure(t *testing.T) {
+	var cases = []url.Values{
+		{"per_page": {"0"}},
+		{"per_page": {"1001"}},
+		{"per_page": {"x"}},
+		{"cursor": {"invalid"}},
+		{"page": {"2"}},
+	}
+
+	for _, c := range cases {
+		if _, _, err := pagination(c); err == nil {
+			t.Errorf("Expected error for pagination parameters %v", c)
+		}
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	if next, err := nextCursor(10, 20, "2018-10-20T03:00:00Z", "a"); next != "" || err != nil {
+		t.Errorf("Expected no next cursor for the last page, got %q (%v) instead", next, err)
+	}
+
+	next, err := nextCursor(20, 20, "2018-10-20T03:00:00Z", "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	c, err := cursor.Parse(next)
+
+	if err != nil || c.ID != "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4" {
+		t.Errorf("Unexpected next cursor %v (%v)", c, err)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/diagnostics"
+	"github.com/henvic/climetrics/server"
+	log "github.com/sirupsen/logrus"
+)
+
+func diagnosticsHandler(w http.ResponseWriter, r *http.Request, c Context) {
+	var query = r.URL.Query()
+	f, err := diagnostics.ParseFilter(query)
+
+	if err == nil {
+		f.Cursor, f.PerPage, err = pagination(query)
+	}
+
+	if err != nil {
+		Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count, err := diagnostics.Count(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to count number of diagnostics: %+v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	list, err := diagnostics.List(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to list diagnostics: %+v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var p = Page{
+		Data:  list,
+		Total: count,
+	}
+
+	if list == nil {
+		p.Data = []diagnostics.Report{}
+	}
+
+	if len(list) != 0 {
+		var last = list[len(list)-1]
+
+		if p.NextCursor, err = nextCursor(len(list), f.PerPage, last.SyncTime, last.ID); err != nil {
+			log.Errorf("failed to create cursor: %+v", err)
+			Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respond(w, p)
+}
+
+func diagnosticHandler(w http.ResponseWriter, r *http.Request, c Context) {
+	report, err := diagnostics.Get(r.Context(), mux.Vars(r)["id"])
+
+	if err == sql.ErrNoRows {
+		Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	server.Audit(r, audit.Entry{
+		ActorID: c.User.UserID,
+		Actor:   c.User.Username,
+		Action:  audit.DiagnosticsView,
+		Target:  report.ID,
+		Details: "API token " + c.Token.ID,
+	})
+
+	respond(w, report)
+}
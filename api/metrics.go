@@ -0,0 +1,78 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+func metricsHandler(w http.ResponseWriter, r *http.Request, c Context) {
+	var query = r.URL.Query()
+	f, err := metrics.ParseFilter(query)
+
+	if err == nil {
+		f.Cursor, f.PerPage, err = pagination(query)
+	}
+
+	if err != nil {
+		Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count, err := metrics.Count(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to count number of metrics: %+v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	list, err := metrics.List(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to list metrics: %+v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var p = Page{
+		Data:  list,
+		Total: count,
+	}
+
+	if list == nil {
+		p.Data = []metrics.Metric{}
+	}
+
+	if len(list) != 0 {
+		var last = list[len(list)-1]
+
+		if p.NextCursor, err = nextCursor(len(list), f.PerPage, last.SyncTime, last.ID); err != nil {
+			log.Errorf("failed to create cursor: %+v", err)
+			Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respond(w, p)
+}
+
+func metricHandler(w http.ResponseWriter, r *http.Request, c Context) {
+	m, err := metrics.Get(r.Context(), mux.Vars(r)["id"])
+
+	if err == sql.ErrNoRows {
+		Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, m)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/users"
+	log "github.com/sirupsen/logrus"
+)
+
+// user data exposed on the API (without credentials).
+type user struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	TwoFactor bool   `json:"two_factor"`
+}
+
+func newUser(u users.User) user {
+	return user{
+		UserID:    u.UserID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role,
+		TwoFactor: u.TwoFactor(),
+	}
+}
+
+func usersHandler(w http.ResponseWriter, r *http.Request, c Context) {
+	var f = users.Filter{}
+
+	switch show := r.URL.Query().Get("show"); show {
+	case "", "active":
+		f.Active = true
+	case "all":
+	default:
+		Error(w, `show must be "active" or "all"`, http.StatusBadRequest)
+		return
+	}
+
+	list, err := users.List(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to list users: %+v", err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var data = []user{}
+
+	for _, u := range list {
+		data = append(data, newUser(u))
+	}
+
+	respond(w, Page{
+		Data:  data,
+		Total: len(data),
+	})
+}
+
+func userHandler(w http.ResponseWriter, r *http.Request, c Context) {
+	u, err := users.Get(r.Context(), mux.Vars(r)["user_id"])
+
+	if err == sql.ErrNoRows {
+		Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Error(err)
+		Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	respond(w, newUser(u))
+}
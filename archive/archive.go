@@ -0,0 +1,280 @@
+// Package archive moves closed months of metrics out of the database to compressed files,
+// listed on a manifest, from where they can be restored or read with the metrics.
+//
+// Each file is a gzipped NDJSON file of metrics, on the format of /metrics/bulk with the sync information,
+// and describes itself on the comment of its gzip header.
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/henvic/climetrics/metrics"
+)
+
+const (
+	// format of the archive files, on their description.
+	format = "climetrics-archive"
+
+	// formatVersion of the archive files and manifest.
+	formatVersion = 1
+)
+
+// Archiver of metrics.
+type Archiver struct {
+	// Dir of the archive files and their manifest.
+	Dir string
+
+	// BatchSize is the maximum number of metrics deleted or restored at once.
+	BatchSize int
+
+	// Pause between batches.
+	Pause time.Duration
+}
+
+// Default archiver, used by the server and the commands.
+var Default = &Archiver{
+	Dir:       "archives",
+	BatchSize: 1000,
+	Pause:     100 * time.Millisecond,
+}
+
+// Validate the archiver settings.
+func (a *Archiver) Validate() error {
+	if a.Dir == "" {
+		return errors.New("the archive directory can't be empty")
+	}
+
+	if a.BatchSize < 1 {
+		return errors.New("the archive batch size must be at least 1")
+	}
+
+	if a.Pause < 0 {
+		return errors.New("the archive pause can't be negative")
+	}
+
+	return nil
+}
+
+// Description of an archive file, on the comment of its gzip header.
+type Description struct {
+	Format   string    `json:"format"`
+	Version  int       `json:"version"`
+	Table    string    `json:"table"`
+	Encoding string    `json:"encoding"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Created  time.Time `json:"created"`
+}
+
+// filename for the metrics of the month of from, not used by any other file.
+func (a *Archiver) filename(m *Manifest, from time.Time) string {
+	for n := 1; ; n++ {
+		var name = "metrics-" + from.Format("2006-01")
+
+		if n > 1 {
+			name += fmt.Sprintf(".%d", n)
+		}
+
+		name += ".ndjson.gz"
+
+		if _, err := os.Stat(filepath.Join(a.Dir, name)); m.File(name) == nil && os.IsNotExist(err) {
+			return name
+		}
+	}
+}
+
+// walker of the metrics to archive.
+type walker func(fn func(metrics.Metric) error) error
+
+// write the metrics synced between from and to (exclusive) to a new archive file.
+func (a *Archiver) write(name string, from, to time.Time, walk walker) (f *File, err error) {
+	f = &File{
+		Name:    name,
+		Table:   "metrics",
+		From:    from,
+		To:      to,
+		Created: time.Now().UTC(),
+	}
+
+	tmp, err := ioutil.TempFile(a.Dir, "."+name+".tmp")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	comment, err := json.Marshal(Description{
+		Format:   format,
+		Version:  formatVersion,
+		Table:    f.Table,
+		Encoding: "ndjson",
+		From:     from,
+		To:       to,
+		Created:  f.Created,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var h = sha256.New()
+	var cw = &countWriter{w: io.MultiWriter(tmp, h)}
+	gw, err := gzip.NewWriterLevel(cw, gzip.BestCompression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	gw.Header = gzip.Header{
+		Name:    name[:len(name)-len(".gz")],
+		Comment: string(comment),
+		ModTime: f.Created,
+	}
+
+	var bw = bufio.NewWriterSize(gw, 32*1024)
+	var enc = json.NewEncoder(bw)
+
+	if err = walk(func(m metrics.Metric) error {
+		f.Rows++
+		return enc.Encode(m)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err = bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	if err = gw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return nil, err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	f.Size = cw.n
+	f.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return f, os.Rename(tmp.Name(), filepath.Join(a.Dir, name))
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (n int, err error) {
+	n, err = c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// read the metrics of an archive file, returning its description and checksum.
+func (a *Archiver) read(ctx context.Context, name string, fn func(metrics.Metric) error) (d Description, rows int64, sum string, err error) {
+	file, err := os.Open(filepath.Join(a.Dir, name))
+
+	if err != nil {
+		return d, 0, "", err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var h = sha256.New()
+	var tr = io.TeeReader(bufio.NewReader(file), h)
+	gr, err := gzip.NewReader(tr)
+
+	if err != nil {
+		return d, 0, "", fmt.Errorf("%s: %v", name, err)
+	}
+
+	if err = json.Unmarshal([]byte(gr.Comment), &d); err != nil || d.Format != format {
+		return d, 0, "", fmt.Errorf("%s: not a climetrics archive", name)
+	}
+
+	if d.Version > formatVersion || d.Table != "metrics" || d.Encoding != "ndjson" {
+		return d, 0, "", fmt.Errorf("%s: unsupported archive (version %d of %s, as %s)", name, d.Version, d.Table, d.Encoding)
+	}
+
+	var br = bufio.NewReader(gr)
+
+	for {
+		line, rerr := br.ReadBytes('\n')
+
+		if len(line) != 0 {
+			var m metrics.Metric
+
+			if err = json.Unmarshal(line, &m); err != nil {
+				return d, rows, "", fmt.Errorf("%s: line %d: %v", name, rows+1, err)
+			}
+
+			rows++
+
+			if err = fn(m); err != nil {
+				return d, rows, "", err
+			}
+		}
+
+		if rerr == io.EOF {
+			break
+		}
+
+		if rerr != nil {
+			return d, rows, "", fmt.Errorf("%s: %v", name, rerr)
+		}
+
+		if rows%1000 == 0 && ctx.Err() != nil {
+			return d, rows, "", ctx.Err()
+		}
+	}
+
+	// anything after the gzip stream is still part of the checksum.
+	if _, err = io.Copy(ioutil.Discard, tr); err != nil {
+		return d, rows, "", err
+	}
+
+	return d, rows, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify the file can be read entirely, and has the rows and checksum on the manifest.
+func (a *Archiver) Verify(ctx context.Context, f *File) error {
+	d, rows, sum, err := a.read(ctx, f.Name, func(metrics.Metric) error {
+		return nil
+	})
+
+	switch {
+	case err != nil:
+		return err
+	case sum != f.SHA256:
+		return fmt.Errorf("%s: checksum mismatch (expected %s, got %s)", f.Name, f.SHA256, sum)
+	case rows != f.Rows:
+		return fmt.Errorf("%s: expected %d rows, got %d", f.Name, f.Rows, rows)
+	case !d.From.Equal(f.From) || !d.To.Equal(f.To):
+		return fmt.Errorf("%s: range of the file doesn't match the manifest", f.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,349 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/metrics"
+)
+
+var (
+	september = time.Date(2018, 9, 1, 0, 0, 0, 0, time.UTC)
+	october   = time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+)
+
+var sample = []metrics.Metric{
+	{
+		ID:       "5e1c6e1c-8d2b-4b8e-9f6c-2a0b2c1d3e4f",
+		Type:     "cmd",
+		Text:     "deploy",
+		Tags:     metrics.Tags{"remote"},
+		SID:      "0f8f6a53-2ab9-4e60-8a2f-5b8d6d0b9c1a",
+		Version:  "1.2.0",
+		SyncTime: "2018-09-20T10:00:00Z",
+		SyncIP:   "203.0.113.1",
+		SyncLocation: &metrics.Location{
+			Country:      "BR",
+			Organization: "AS15169 Google LLC",
+		},
+	},
+	{
+		ID:       "9a0c2d5e-1f3b-4c7d-8e9f-0a1b2c3d4e5f",
+		Type:     "debug",
+		Text:     "trace",
+		SID:      "0f8f6a53-2ab9-4e60-8a2f-5b8d6d0b9c1a",
+		Version:  "1.1.0",
+		SyncTime: "2018-09-02T08:30:00Z",
+	},
+}
+
+func walkSample(fn func(metrics.Metric) error) error {
+	for _, m := range sample {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newArchiver on a temporary directory, removed by calling remove.
+func newArchiver(t *testing.T) (a *Archiver, remove func()) {
+	dir, err := ioutil.TempDir("", "climetrics-archive")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Archiver{Dir: dir, BatchSize: 10}, func() {
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	a, remove := newArchiver(t)
+	defer remove()
+
+	f, err := a.write("metrics-2018-09.ndjson.gz", september, october, walkSample)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if f.Rows != 2 || f.Size == 0 || len(f.SHA256) != 64 {
+		t.Errorf("Unexpected archive file %+v", f)
+	}
+
+	if err = a.Verify(context.Background(), f); err != nil {
+		t.Errorf("Expected file to be verified, got %v instead", err)
+	}
+
+	var got []metrics.Metric
+
+	d, rows, sum, err := a.read(context.Background(), f.Name, func(m metrics.Metric) error {
+		got = append(got, m)
+		return nil
+	})
+
+	if err != nil || rows != 2 || sum != f.SHA256 {
+		t.Fatalf("Expected 2 rows with checksum %s, got %d with %s (error: %v) instead", f.SHA256, rows, sum, err)
+	}
+
+	if !d.From.Equal(september) || !d.To.Equal(october) || d.Table != "metrics" || d.Format != format {
+		t.Errorf("Unexpected description %+v", d)
+	}
+
+	if len(got) != 2 || got[0].ID != sample[0].ID || !reflect.DeepEqual(got[0].SyncLocation, sample[0].SyncLocation) {
+		t.Errorf("Expected metrics %+v, got %+v instead", sample, got)
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(a.Dir, ".*.tmp*")); len(files) != 0 {
+		t.Errorf("Expected no temporary files, got %v instead", files)
+	}
+}
+
+func TestSelfDescribing(t *testing.T) {
+	a, remove := newArchiver(t)
+	defer remove()
+
+	f, err := a.write("metrics-2018-09.ndjson.gz", september, october, walkSample)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	file, err := os.Open(filepath.Join(a.Dir, f.Name))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	gr, err := gzip.NewReader(file)
+
+	if err != nil {
+		t.Fatalf("Expected gzip file, got %v instead", err)
+	}
+
+	var d Description
+
+	if err = json.Unmarshal([]byte(gr.Comment), &d); err != nil || d.Encoding != "ndjson" || d.Version != formatVersion {
+		t.Errorf("Expected description on the gzip comment, got %q instead", gr.Comment)
+	}
+
+	if gr.Name != "metrics-2018-09.ndjson" {
+		t.Errorf("Expected name metrics-2018-09.ndjson, got %v instead", gr.Name)
+	}
+
+	b, err := ioutil.ReadAll(gr)
+
+	if err != nil || strings.Count(string(b), "\n") != 2 || !strings.Contains(string(b), `"event_type":"cmd"`) {
+		t.Errorf("Expected NDJSON metrics, got %q (error: %v) instead", b, err)
+	}
+}
+
+func TestVerifyFailure(t *testing.T) {
+	a, remove := newArchiver(t)
+	defer remove()
+
+	f, err := a.write("metrics-2018-09.ndjson.gz", september, october, walkSample)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var wrong = *f
+	wrong.Rows = 3
+
+	if err = a.Verify(context.Background(), &wrong); err == nil || !strings.Contains(err.Error(), "expected 3 rows") {
+		t.Errorf("Expected rows mismatch, got %v instead", err)
+	}
+
+	var name = filepath.Join(a.Dir, f.Name)
+	b, err := ioutil.ReadFile(name)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// trailing garbage changes the checksum, even if the gzip stream is intact
+	if err = ioutil.WriteFile(name, append(b, 0), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = a.Verify(context.Background(), f); err == nil {
+		t.Errorf("Expected error verifying a changed file, got nil instead")
+	}
+
+	if err = ioutil.WriteFile(name, []byte("not gzip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = a.Verify(context.Background(), f); err == nil {
+		t.Errorf("Expected error verifying a broken file, got nil instead")
+	}
+}
+
+func TestManifest(t *testing.T) {
+	a, remove := newArchiver(t)
+	defer remove()
+
+	m, err := a.Manifest()
+
+	if err != nil || len(m.Files) != 0 || m.Version != formatVersion {
+		t.Fatalf("Expected empty manifest, got %+v (error: %v) instead", m, err)
+	}
+
+	m.Files = append(m.Files,
+		&File{Name: "metrics-2018-08.ndjson.gz", From: september.AddDate(0, -1, 0), To: september},
+		&File{Name: "metrics-2018-09.ndjson.gz", From: september, To: october, Rows: 2})
+
+	if err = a.save(m); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if m, err = a.Manifest(); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if len(m.Files) != 2 || m.Files[0].Name != "metrics-2018-09.ndjson.gz" || m.Files[0].Rows != 2 {
+		t.Errorf("Expected files from the most recent, got %+v instead", m.Files)
+	}
+
+	if m.File("metrics-2018-08.ndjson.gz") == nil || m.File("metrics-2018-07.ndjson.gz") != nil {
+		t.Errorf("Unexpected files by name on %+v", m.Files)
+	}
+
+	if got := a.filename(m, september); got != "metrics-2018-09.2.ndjson.gz" {
+		t.Errorf("Expected new name for a month archived already, got %v instead", got)
+	}
+
+	if got := a.filename(m, october); got != "metrics-2018-10.ndjson.gz" {
+		t.Errorf("Expected name metrics-2018-10.ndjson.gz, got %v instead", got)
+	}
+}
+
+func TestWalk(t *testing.T) {
+	a, remove := newArchiver(t)
+	defer remove()
+
+	f, err := a.write("metrics-2018-09.ndjson.gz", september, october, walkSample)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if err = a.save(&Manifest{Version: formatVersion, Files: []*File{f}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var walk = func(f metrics.Filter) (ids []string) {
+		if err := a.Walk(context.Background(), f, func(m metrics.Metric) error {
+			ids = append(ids, m.ID)
+			return nil
+		}); err != nil {
+			t.Fatalf("Expected no error, got %v instead", err)
+		}
+
+		return ids
+	}
+
+	if got := walk(metrics.Filter{Type: "cmd"}); !reflect.DeepEqual(got, []string{sample[0].ID}) {
+		t.Errorf("Expected only the cmd metric, got %v instead", got)
+	}
+
+	if got := walk(metrics.Filter{Since: october}); len(got) != 0 {
+		t.Errorf("Expected file out of range to be skipped, got %v instead", got)
+	}
+
+	var now = time.Now()
+	f.Restored = &now
+
+	if err = a.save(&Manifest{Version: formatVersion, Files: []*File{f}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := walk(metrics.Filter{}); len(got) != 0 {
+		t.Errorf("Expected restored file to be skipped, got %v instead", got)
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	var f = File{From: september, To: october}
+
+	var cases = []struct {
+		since, until time.Time
+		want         bool
+	}{
+		{time.Time{}, time.Time{}, true},
+		{september.AddDate(0, 0, 10), time.Time{}, true},
+		{october, time.Time{}, false},
+		{time.Time{}, september, false},
+		{time.Time{}, september.AddDate(0, 0, 1), true},
+	}
+
+	for _, c := range cases {
+		if got := f.Overlaps(c.since, c.until); got != c.want {
+			t.Errorf("Expected overlap of %v and %v to be %v, got %v instead", c.since, c.until, c.want, got)
+		}
+	}
+}
+
+func TestClosedBefore(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+	if got := closedBefore(time.Date(2018, 9, 15, 0, 0, 0, 0, time.UTC), now); !got.Equal(september) {
+		t.Errorf("Expected %v, got %v instead", september, got)
+	}
+
+	if got := closedBefore(now.AddDate(1, 0, 0), now); !got.Equal(october) {
+		t.Errorf("Expected the current month to be open, got %v instead", got)
+	}
+}
+
+func TestLock(t *testing.T) {
+	a, remove := newArchiver(t)
+	defer remove()
+
+	a.Dir = filepath.Join(a.Dir, "new")
+	unlock, err := a.lock()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if _, err = a.lock(); err == nil {
+		t.Errorf("Expected archive to be locked already")
+	}
+
+	unlock()
+
+	if unlock, err = a.lock(); err != nil {
+		t.Errorf("Expected no error after unlocking, got %v instead", err)
+	} else {
+		unlock()
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default.Validate(); err != nil {
+		t.Errorf("Expected default archiver to be valid, got %v instead", err)
+	}
+
+	for _, a := range []Archiver{{BatchSize: 1}, {Dir: "archives"}, {Dir: "archives", BatchSize: 1, Pause: -time.Second}} {
+		if err := a.Validate(); err == nil {
+			t.Errorf("Expected error for %+v, got nil instead", a)
+		}
+	}
+}
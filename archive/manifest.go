@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// manifestFilename on the archive directory.
+const manifestFilename = "manifest.json"
+
+// Manifest of the archive files.
+type Manifest struct {
+	Version int     `json:"version"`
+	Files   []*File `json:"files"`
+}
+
+// File of archived metrics.
+type File struct {
+	Name  string `json:"name"`
+	Table string `json:"table"`
+
+	// From and To (exclusive) sync time of the archived rows.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Rows   int64  `json:"rows"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+
+	Created time.Time `json:"created"`
+
+	// Deleted rows from the database, after the file was verified.
+	Deleted int64 `json:"deleted"`
+
+	// Restored to the database, so the file is no longer read with the metrics.
+	Restored *time.Time `json:"restored,omitempty"`
+}
+
+// Overlaps tells if the file has rows synced between since and until (exclusive). Zero times are unbounded.
+func (f File) Overlaps(since, until time.Time) bool {
+	return (since.IsZero() || f.To.After(since)) && (until.IsZero() || f.From.Before(until))
+}
+
+// Manifest of the archive directory, empty if there is none yet.
+func (a *Archiver) Manifest() (*Manifest, error) {
+	var m = &Manifest{
+		Version: formatVersion,
+		Files:   []*File{},
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(a.Dir, manifestFilename))
+
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("invalid archive manifest: %v", err)
+	}
+
+	return m, nil
+}
+
+// File by name.
+func (m *Manifest) File(name string) *File {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// save the manifest atomically, with the files from the most recent.
+func (a *Archiver) save(m *Manifest) error {
+	sort.SliceStable(m.Files, func(i, j int) bool {
+		return m.Files[i].From.After(m.Files[j].From)
+	})
+
+	b, err := json.MarshalIndent(m, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(a.Dir, manifestFilename+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(append(b, '\n')); err == nil {
+		err = tmp.Sync()
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(a.Dir, manifestFilename))
+}
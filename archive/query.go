@@ -0,0 +1,53 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/henvic/climetrics/metrics"
+)
+
+// Walk through the archived metrics matching the filter, ignoring its pagination,
+// from the most recent file. Restored files are skipped.
+func (a *Archiver) Walk(ctx context.Context, f metrics.Filter, fn func(metrics.Metric) error) error {
+	m, err := a.Manifest()
+
+	if err != nil {
+		return err
+	}
+
+	for _, file := range m.Files {
+		if file.Restored != nil || !file.Overlaps(f.Since, f.Until) {
+			continue
+		}
+
+		if _, _, _, err = a.read(ctx, file.Name, func(metric metrics.Metric) error {
+			if !f.Match(metric) {
+				return nil
+			}
+
+			return fn(metric)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Aggregate the metrics matching the filter, on the database and archived, by a key.
+// Every metric is read, so it is much slower than the aggregates on the database alone.
+func (a *Archiver) Aggregate(ctx context.Context, f metrics.Filter, key func(metrics.Metric) string) (*metrics.Aggregate, error) {
+	var agg = metrics.NewAggregate(key)
+	var all = f
+	all.Cursor, all.Page, all.PerPage = nil, 0, 0
+
+	if err := metrics.Walk(ctx, all, agg.Add); err != nil {
+		return nil, err
+	}
+
+	if err := a.Walk(ctx, f, agg.Add); err != nil {
+		return nil, err
+	}
+
+	return agg, nil
+}
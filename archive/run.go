@@ -0,0 +1,316 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/metrics"
+	"github.com/lib/pq"
+)
+
+// Report of an archiving run.
+type Report struct {
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	DryRun   bool      `json:"dry_run"`
+
+	// Before is when the last archived month ends.
+	Before time.Time `json:"before"`
+
+	// Files archived, or, on a dry run, the months that would be archived (without names).
+	Files []*File `json:"files"`
+
+	Rows    int64 `json:"rows"`
+	Deleted int64 `json:"deleted"`
+}
+
+// Details of the report, as recorded on the audit log.
+func (r Report) Details() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// closedBefore is the start of the month of t, or of the current month if earlier (UTC):
+// only months ending by then are archived.
+func closedBefore(t, now time.Time) time.Time {
+	if now.Before(t) {
+		t = now
+	}
+
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Archive the months of metrics synced before the given time, from the oldest.
+// Each month is written to a file, verified, added to the manifest, and only then deleted from the database.
+// A dry run counts the metrics of each month instead.
+func (a *Archiver) Archive(ctx context.Context, before time.Time, dryRun bool) (r Report, err error) {
+	r = Report{
+		Started: time.Now(),
+		DryRun:  dryRun,
+		Before:  closedBefore(before, time.Now()),
+		Files:   []*File{},
+	}
+
+	defer func() {
+		r.Duration = time.Since(r.Started).Round(time.Millisecond).String()
+	}()
+
+	oldest, err := oldestMetric(ctx, r.Before)
+
+	if err != nil || oldest.IsZero() {
+		return r, err
+	}
+
+	var m *Manifest
+
+	if !dryRun {
+		unlock, err := a.lock()
+
+		if err != nil {
+			return r, err
+		}
+
+		defer unlock()
+
+		if m, err = a.Manifest(); err != nil {
+			return r, err
+		}
+	}
+
+	for from := closedBefore(oldest, oldest); from.Before(r.Before); from = from.AddDate(0, 1, 0) {
+		var to = from.AddDate(0, 1, 0)
+		n, err := count(ctx, from, to)
+
+		if err != nil {
+			return r, err
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		if dryRun {
+			r.Files = append(r.Files, &File{Table: "metrics", From: from, To: to, Rows: n})
+			r.Rows += n
+			continue
+		}
+
+		f, err := a.archive(ctx, m, from, to)
+
+		if f != nil {
+			r.Files = append(r.Files, f)
+			r.Rows += f.Rows
+			r.Deleted += f.Deleted
+		}
+
+		if err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+func oldestMetric(ctx context.Context, before time.Time) (oldest time.Time, err error) {
+	var t pq.NullTime
+	err = db.QueryRow(ctx, `SELECT MIN(sync_time) FROM metrics WHERE sync_time < $1`, []interface{}{before}, &t)
+	return t.Time, err
+}
+
+func count(ctx context.Context, from, to time.Time) (n int64, err error) {
+	err = db.QueryRow(ctx, `SELECT COUNT(*) FROM metrics WHERE sync_time >= $1 AND sync_time < $2`, []interface{}{from, to}, &n)
+	return n, err
+}
+
+// archive a month: write, verify, add to the manifest, and delete the archived metrics.
+func (a *Archiver) archive(ctx context.Context, m *Manifest, from, to time.Time) (*File, error) {
+	f, err := a.write(a.filename(m, from), from, to, func(fn func(metrics.Metric) error) error {
+		return metrics.Walk(ctx, metrics.Filter{Since: from, Until: to}, fn)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.Verify(ctx, f); err != nil {
+		_ = os.Remove(filepath.Join(a.Dir, f.Name))
+		return nil, err
+	}
+
+	m.Files = append(m.Files, f)
+
+	if err = a.save(m); err != nil {
+		_ = os.Remove(filepath.Join(a.Dir, f.Name))
+		return nil, err
+	}
+
+	err = a.delete(ctx, f)
+
+	// the number of deleted rows is kept even if deleting them failed midway.
+	if serr := a.save(m); err == nil {
+		err = serr
+	}
+
+	return f, err
+}
+
+// delete the metrics of the file from the database, in batches.
+// Only the archived metrics are deleted, even if others were synced on the same month meanwhile.
+func (a *Archiver) delete(ctx context.Context, f *File) error {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM metrics WHERE sync_time >= $1 AND sync_time < $2 AND id = ANY($3::uuid[])`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	var ids = make([]string, 0, a.BatchSize)
+
+	var flush = func() error {
+		if len(ids) == 0 {
+			return nil
+		}
+
+		res, err := stmt.ExecContext(ctx, f.From, f.To, pq.StringArray(ids))
+
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		f.Deleted += n
+		ids = ids[:0]
+
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(a.Pause):
+			return nil
+		}
+	}
+
+	if _, _, _, err := a.read(ctx, f.Name, func(m metrics.Metric) error {
+		if ids = append(ids, m.ID); len(ids) < a.BatchSize {
+			return nil
+		}
+
+		return flush()
+	}); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// Restored metrics of an archive file.
+type Restored struct {
+	File string `json:"file"`
+
+	metrics.BulkStats
+}
+
+// Restore the metrics of an archive file to the database. Metrics on the database already are counted as noop.
+// The file is kept, but no longer read with the metrics.
+func (a *Archiver) Restore(ctx context.Context, name string) (r Restored, err error) {
+	r.File = name
+
+	unlock, err := a.lock()
+
+	if err != nil {
+		return r, err
+	}
+
+	defer unlock()
+
+	m, err := a.Manifest()
+
+	if err != nil {
+		return r, err
+	}
+
+	var f = m.File(name)
+
+	switch {
+	case f == nil:
+		return r, fmt.Errorf(`archive file "%s" not found on the manifest`, name)
+	case f.Restored != nil:
+		return r, fmt.Errorf(`archive file "%s" was restored already on %v`, name, f.Restored.Format(time.RFC3339))
+	}
+
+	if err = a.Verify(ctx, f); err != nil {
+		return r, err
+	}
+
+	var line int
+
+	if _, _, _, err = a.read(ctx, name, func(metric metrics.Metric) error {
+		line++
+		added, err := metrics.Create(ctx, metric)
+
+		// the context is checked, as metrics.Create fails on all the next lines otherwise
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		r.Count(line, added, err)
+
+		if line%a.BatchSize != 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(a.Pause):
+			return nil
+		}
+	}); err != nil {
+		return r, err
+	}
+
+	var now = time.Now().UTC()
+	f.Restored = &now
+	return r, a.save(m)
+}
+
+// lockFilename on the archive directory, while it is changed.
+const lockFilename = "manifest.lock"
+
+// lock the archive directory, creating it if needed, so only one command changes it at a time.
+func (a *Archiver) lock() (unlock func(), err error) {
+	if err = os.MkdirAll(a.Dir, 0700); err != nil {
+		return nil, err
+	}
+
+	var name = filepath.Join(a.Dir, lockFilename)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("the archive is being changed by another command (remove %s if not)", name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, _ = fmt.Fprintf(file, "%d\n", os.Getpid())
+	_ = file.Close()
+
+	return func() {
+		_ = os.Remove(name)
+	}, nil
+}
@@ -0,0 +1,408 @@
+// Package audit records who did what on the append-only audit log.
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/cursor"
+	"github.com/henvic/climetrics/db"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Action recorded on the audit log.
+type Action string
+
+const (
+	// Login of an user.
+	Login Action = "login"
+
+	// LoginFailed is a login attempt with wrong credentials.
+	LoginFailed Action = "login.failed"
+
+	// LoginLocked is an account or IP locked out after too many failed login attempts.
+	LoginLocked Action = "login.locked"
+
+	// Logout of an user.
+	Logout Action = "logout"
+
+	// AccessDenied is a request to a page the user has no permission to access.
+	AccessDenied Action = "access.denied"
+
+	// Request is a change request not recorded with a more specific action.
+	Request Action = "request"
+
+	// UserCreate is the creation of an user.
+	UserCreate Action = "user.create"
+
+	// UserUpdate is a change on the username, email, role, or password of an user.
+	UserUpdate Action = "user.update"
+
+	// UserRevoke is an update revoking the access of an user.
+	UserRevoke Action = "user.revoke"
+
+	// UserInvite is an admin inviting an user by email.
+	UserInvite Action = "user.invite"
+
+	// InvitationAccept is an invited user setting their password.
+	InvitationAccept Action = "user.invitation_accept"
+
+	// PasswordResetRequest is a request for a password reset email.
+	PasswordResetRequest Action = "password.reset_request"
+
+	// PasswordReset is an user setting a new password with a password reset email.
+	PasswordReset Action = "password.reset"
+
+	// UserUnlock is an admin unlocking an user locked out after too many failed login attempts.
+	UserUnlock Action = "user.unlock"
+
+	// TwoFactorEnable is an user enabling two-factor authentication.
+	TwoFactorEnable Action = "two_factor.enable"
+
+	// TwoFactorDisable is an user disabling two-factor authentication.
+	TwoFactorDisable Action = "two_factor.disable"
+
+	// TwoFactorReset is an admin resetting the two-factor authentication of an user.
+	TwoFactorReset Action = "two_factor.reset"
+
+	// TwoFactorPolicy is a change on the two-factor authentication policy.
+	TwoFactorPolicy Action = "two_factor.policy"
+
+	// TokenCreate is the creation of an API token.
+	TokenCreate Action = "token.create"
+
+	// TokenRevoke is the revocation of an API token.
+	TokenRevoke Action = "token.revoke"
+
+	// SessionRevoke is the revocation of a session of an user.
+	SessionRevoke Action = "session.revoke"
+
+	// SessionRevokeAll is the revocation of all sessions of an user, such as with "log out everywhere".
+	SessionRevokeAll Action = "session.revoke_all"
+
+	// DiagnosticsView is an user viewing a diagnostics report.
+	DiagnosticsView Action = "diagnostics.view"
+
+	// AuditExport is an export of the audit log.
+	AuditExport Action = "audit.export"
+
+	// MetricsExport is an export of metrics.
+	MetricsExport Action = "metrics.export"
+
+	// DiagnosticsExport is an export of diagnostics reports.
+	DiagnosticsExport Action = "diagnostics.export"
+
+	// RetentionPrune is the deletion of metrics and diagnostics past their retention.
+	RetentionPrune Action = "retention.prune"
+
+	// MetricsArchive is the archival of metrics to files, deleting them from the database.
+	MetricsArchive Action = "metrics.archive"
+
+	// MetricsRestore is the restoration of archived metrics to the database.
+	MetricsRestore Action = "metrics.restore"
+)
+
+// Actions available.
+var Actions = []Action{
+	Login,
+	LoginFailed,
+	LoginLocked,
+	Logout,
+	AccessDenied,
+	Request,
+	UserCreate,
+	UserUpdate,
+	UserRevoke,
+	UserInvite,
+	InvitationAccept,
+	PasswordResetRequest,
+	PasswordReset,
+	UserUnlock,
+	TwoFactorEnable,
+	TwoFactorDisable,
+	TwoFactorReset,
+	TwoFactorPolicy,
+	TokenCreate,
+	TokenRevoke,
+	SessionRevoke,
+	SessionRevokeAll,
+	DiagnosticsView,
+	AuditExport,
+	MetricsExport,
+	DiagnosticsExport,
+	RetentionPrune,
+	MetricsArchive,
+	MetricsRestore,
+}
+
+// Valid tells if the action exists.
+func (a Action) Valid() bool {
+	for _, action := range Actions {
+		if a == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entry of the audit log.
+type Entry struct {
+	ID      string    `db:"id" json:"id"`
+	Created time.Time `db:"created" json:"time"`
+
+	// ActorID and Actor (username) of who did it, if known.
+	ActorID string `db:"actor_id" json:"actor_id,omitempty"`
+	Actor   string `db:"actor" json:"actor,omitempty"`
+
+	Action Action `db:"action" json:"action"`
+	Target string `db:"target" json:"target,omitempty"`
+
+	// Details of the action, such as the changes.
+	Details string `db:"details" json:"details,omitempty"`
+
+	IP     string `db:"ip" json:"ip,omitempty"`
+	Method string `db:"method" json:"method,omitempty"`
+	Path   string `db:"path" json:"path,omitempty"`
+}
+
+// Record entry on the audit log.
+func Record(ctx context.Context, e Entry) error {
+	if !e.Action.Valid() {
+		return fmt.Errorf(`invalid audit action "%s"`, e.Action)
+	}
+
+	if e.ID == "" {
+		e.ID = uuid.NewV4().String()
+	}
+
+	if e.Created.IsZero() {
+		e.Created = time.Now()
+	}
+
+	conn := db.Conn()
+	_, err := conn.NamedExecContext(ctx, `INSERT INTO audit_log
+(id, created, actor_id, actor, action, target, details, ip, method, path)
+VALUES (:id, :created, :actor_id, :actor, :action, :target, :details, :ip, :method, :path)`, e)
+	return err
+}
+
+// Filter for the audit log.
+type Filter struct {
+	Actor  string
+	Action Action
+	Target string
+	IP     string
+
+	Since time.Time
+	Until time.Time
+
+	// Cursor to list entries after.
+	Cursor *cursor.Cursor
+
+	PerPage int
+}
+
+// dateLayout for the since and until filters.
+const dateLayout = "2006-01-02"
+
+// ParseFilter from query parameters.
+func ParseFilter(query url.Values) (f Filter, err error) {
+	f = Filter{
+		Actor:  strings.TrimSpace(query.Get("actor")),
+		Action: Action(query.Get("action")),
+		Target: strings.TrimSpace(query.Get("target")),
+		IP:     strings.TrimSpace(query.Get("ip")),
+
+		PerPage: 100,
+	}
+
+	if f.Action != "" && !f.Action.Valid() {
+		return f, fmt.Errorf(`invalid audit action "%s"`, f.Action)
+	}
+
+	if s := query.Get("since"); s != "" {
+		if f.Since, err = time.Parse(dateLayout, s); err != nil {
+			return f, fmt.Errorf("invalid since date: %v", err)
+		}
+	}
+
+	if s := query.Get("until"); s != "" {
+		if f.Until, err = time.Parse(dateLayout, s); err != nil {
+			return f, fmt.Errorf("invalid until date: %v", err)
+		}
+
+		// until is inclusive
+		f.Until = f.Until.AddDate(0, 0, 1)
+	}
+
+	if s := query.Get("per_page"); s != "" {
+		if f.PerPage, err = strconv.Atoi(s); err != nil || f.PerPage < 1 || f.PerPage > 1000 {
+			return f, fmt.Errorf("invalid per_page value %q", s)
+		}
+	}
+
+	if s := query.Get("cursor"); s != "" {
+		if f.Cursor, err = cursor.Parse(s); err != nil {
+			return f, err
+		}
+	}
+
+	return f, nil
+}
+
+// Query parameters of the filter, without the cursor.
+func (f Filter) Query() url.Values {
+	var q = url.Values{}
+
+	for k, v := range map[string]string{
+		"actor":  f.Actor,
+		"action": string(f.Action),
+		"target": f.Target,
+		"ip":     f.IP,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+
+	if !f.Since.IsZero() {
+		q.Set("since", f.Since.Format(dateLayout))
+	}
+
+	if !f.Until.IsZero() {
+		q.Set("until", f.Until.AddDate(0, 0, -1).Format(dateLayout))
+	}
+
+	return q
+}
+
+// Changed tells if values are not default (besides pagination)
+func (f Filter) Changed() bool {
+	return len(f.Query()) != 0
+}
+
+func (f Filter) where() (args []interface{}, where string) {
+	var w = []string{}
+
+	var add = func(cond string, arg interface{}) {
+		args = append(args, arg)
+		w = append(w, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.Actor != "" {
+		add("actor = $%d", f.Actor)
+	}
+
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+
+	if f.Target != "" {
+		add("target = $%d", f.Target)
+	}
+
+	if f.IP != "" {
+		add("ip = $%d", f.IP)
+	}
+
+	if !f.Since.IsZero() {
+		add("created >= $%d", f.Since)
+	}
+
+	if !f.Until.IsZero() {
+		add("created < $%d", f.Until)
+	}
+
+	if f.Cursor != nil {
+		cond, cargs := f.Cursor.Condition("created", "id", len(args)+1)
+		w = append(w, cond)
+		args = append(args, cargs...)
+	}
+
+	return args, strings.Join(w, " AND ")
+}
+
+// Walk through the entries matching the filter, most recent first.
+// There is no limit if PerPage is zero.
+func Walk(ctx context.Context, f Filter, fn func(Entry) error) error {
+	var q = []string{
+		"SELECT id, created, actor_id, actor, action, target, details, ip, method, path FROM audit_log",
+	}
+
+	args, where := f.where()
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	q = append(q, "ORDER BY created DESC, id DESC")
+
+	if f.PerPage != 0 {
+		args = append(args, f.PerPage)
+		q = append(q, fmt.Sprintf("LIMIT $%d", len(args)))
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var e Entry
+
+		if err = rows.StructScan(&e); err != nil {
+			return err
+		}
+
+		if err = fn(e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// List entries of the audit log.
+func List(ctx context.Context, f Filter) (entries []Entry, err error) {
+	err = Walk(ctx, f, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+
+	return entries, err
+}
+
+// Next cursor after the entries, if the page is full.
+func Next(f Filter, entries []Entry) *cursor.Cursor {
+	if len(entries) == 0 || len(entries) < f.PerPage {
+		return nil
+	}
+
+	var last = entries[len(entries)-1]
+
+	return &cursor.Cursor{
+		Time: last.Created,
+		ID:   last.ID,
+	}
+}
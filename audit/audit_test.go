@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/cursor"
+)
+
+func TestActionValid(t *testing.T) {
+	for _, a := range Actions {
+		if !a.Valid() {
+			t.Errorf("Expected action %s to be valid", a)
+		}
+	}
+
+	if Action("user.delete").Valid() {
+		t.Error("Expected unknown action to be invalid")
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	var c = cursor.Cursor{
+		Time: time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC),
+		ID:   "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4",
+	}
+
+	f, err := ParseFilter(url.Values{
+		"actor":    {"alice"},
+		"action":   {"user.revoke"},
+		"target":   {" bob "},
+		"ip":       {"203.0.113.7"},
+		"since":    {"2018-10-01"},
+		"until":    {"2018-10-20"},
+		"per_page": {"20"},
+		"cursor":   {c.String()},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = Filter{
+		Actor:   "alice",
+		Action:  UserRevoke,
+		Target:  "bob",
+		IP:      "203.0.113.7",
+		Since:   time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC),
+		Until:   time.Date(2018, 10, 21, 0, 0, 0, 0, time.UTC),
+		Cursor:  &c,
+		PerPage: 20,
+	}
+
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("Expected filter to be %+v, got %+v instead", want, f)
+	}
+
+	var q = f.Query()
+
+	if q.Get("until") != "2018-10-20" || q.Get("since") != "2018-10-01" || q.Get("target") != "bob" || q.Get("cursor") != "" {
+		t.Errorf("Unexpected query %v", q)
+	}
+
+	if !f.Changed() {
+		t.Error("Expected filter to be changed")
+	}
+}
+
+func TestParseFilterDefault(t *testing.T) {
+	f, err := ParseFilter(url.Values{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if f.Changed() || f.PerPage != 100 || f.Cursor != nil {
+		t.Errorf("Unexpected default filter %+v", f)
+	}
+
+	args, where := f.where()
+
+	if len(args) != 0 || where != "" {
+		t.Errorf("Expected no conditions, got %q (%v) instead", where, args)
+	}
+}
+
+func TestParseFilterFailure(t *testing.T) {
+	var cases = []url.Values{
+		{"action": {"user.delete"}},
+		{"since": {"yesterday"}},
+		{"until": {"2018-13-01"}},
+		{"per_page": {"0"}},
+		{"per_page": {"5000"}},
+		{"cursor": {"invalid"}},
+	}
+
+	for _, c := range cases {
+		if _, err := ParseFilter(c); err == nil {
+			t.Errorf("Expected error for filter %v", c)
+		}
+	}
+}
+
+func TestFilterWhere(t *testing.T) {
+	var f = Filter{
+		Actor:  "alice",
+		Action: Login,
+		Since:  time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC),
+		Cursor: &cursor.Cursor{
+			Time: time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC),
+			ID:   "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4",
+		},
+	}
+
+	args, where := f.where()
+
+	var want = "actor = $1 AND action = $2 AND created >= $3 AND (created, id) < ($4::timestamptz, $5::uuid)"
+
+	if where != want {
+		t.Errorf("Expected condition %q, got %q instead", want, where)
+	}
+
+	if len(args) != 5 || args[0] != "alice" || args[1] != Login {
+		t.Errorf("Unexpected arguments %v", args)
+	}
+}
+
+func TestNext(t *testing.T) {
+	var entries = []Entry{
+		{ID: "a", Created: time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)},
+		{ID: "b", Created: time.Date(2018, 10, 19, 3, 0, 0, 0, time.UTC)},
+	}
+
+	if c := Next(Filter{PerPage: 3}, entries); c != nil {
+		t.Errorf("Expected no next cursor for the last page, got %v instead", c)
+	}
+
+	c := Next(Filter{PerPage: 2}, entries)
+
+	if c == nil || c.ID != "b" || !c.Time.Equal(entries[1].Created) {
+		t.Errorf("Unexpected next cursor %v", c)
+	}
+}
@@ -0,0 +1,114 @@
+package audithandlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
+	log "github.com/sirupsen/logrus"
+)
+
+var router = server.Instance.Mux
+
+func init() {
+	router().Handle("/audit", server.RequirePermission(users.ViewAuditLog, listHandler))
+	router().Handle("/audit/export", server.RequirePermission(users.ViewAuditLog, exportHandler))
+}
+
+func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	f, err := audit.ParseFilter(r.URL.Query())
+
+	if err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := audit.List(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to list audit log: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var query = f.Query()
+	var next string
+
+	if c := audit.Next(f, list); c != nil {
+		var q = f.Query()
+		q.Set("cursor", c.String())
+		next = "/audit?" + q.Encode()
+	}
+
+	var t = &server.Template{
+		Title:     "Audit log",
+		Section:   "audit",
+		Filenames: []string{"gui/audit/list.html"},
+		Data: map[string]interface{}{
+			"List":    list,
+			"Actions": audit.Actions,
+			"Filter":  f,
+			"Query":   query.Encode(),
+			"Since":   query.Get("since"),
+			"Until":   query.Get("until"),
+			"Next":    next,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+// exportHandler streams the entries matching the filter as newline-delimited JSON.
+func exportHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	f, err := audit.ParseFilter(r.URL.Query())
+
+	if err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("per_page") == "" {
+		f.PerPage = 0
+	}
+
+	server.Audit(r, audit.Entry{
+		Action:  audit.AuditExport,
+		Details: f.Query().Encode(),
+	})
+
+	var encoder = json.NewEncoder(w)
+	var started bool
+
+	err = audit.Walk(r.Context(), f, func(e audit.Entry) error {
+		if !started {
+			exportHeaders(w)
+			started = true
+		}
+
+		return encoder.Encode(e)
+	})
+
+	switch {
+	case err != nil && started:
+		// the response is already on its way, so the export ends truncated.
+		log.Errorf("failed to export audit log: %+v", err)
+	case err != nil:
+		log.Errorf("failed to export audit log: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	case !started:
+		exportHeaders(w)
+	}
+}
+
+func exportHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.ndjson"`,
+		time.Now().UTC().Format("20060102T150405Z")))
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 
 	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/users"
 	"github.com/kisielk/sqlstruct"
 )
 
@@ -15,6 +16,9 @@ type Authentication struct {
 	Email    string `schema:"email"`
 	Role     string `schema:"role"`
 	Password string `schema:"password"`
+
+	// TOTPSecret for two-factor authentication (empty if not enabled).
+	TOTPSecret string `schema:"-" sql:"totp_secret"`
 }
 
 // Get user for authentication (not revoked)
@@ -24,7 +28,7 @@ func Get(ctx context.Context, email string) (a Authentication, err error) {
 	conn := db.Conn()
 
 	stmt, err = conn.PrepareContext(ctx,
-		`SELECT user_id, username, email, password, role FROM authentication
+		`SELECT user_id, username, email, password, role, totp_secret FROM authentication
 		WHERE (email = $1 OR username = $1) AND role != $2 LIMIT 1`)
 
 	if err != nil {
@@ -36,7 +40,7 @@ func Get(ctx context.Context, email string) (a Authentication, err error) {
 	}()
 
 	var rows *sql.Rows
-	rows, err = stmt.QueryContext(ctx, email, "revoked")
+	rows, err = stmt.QueryContext(ctx, email, users.Revoked)
 
 	if err != nil {
 		return a, err
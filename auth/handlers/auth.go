@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/gorilla/schema"
+	"github.com/gorilla/sessions"
+	"github.com/henvic/climetrics/audit"
 	"github.com/henvic/climetrics/auth"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/usersessions"
 	"github.com/henvic/climetrics/server"
 	"github.com/henvic/climetrics/us"
+	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
+	"github.com/tomasen/realip"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -73,30 +80,115 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 	var a auth.Authentication
 	a, err = auth.Get(r.Context(), login.Username)
 
-	if err == sql.ErrNoRows {
-		server.ErrorHandler(w, r, "Wrong credentials.", http.StatusUnauthorized)
+	if err != nil && err != sql.ErrNoRows {
+		log.Errorf("Error getting authentication data from DB: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	var known = err == nil
+	var accountKey = lockout.AccountKey(login.Username)
+	var match bool
+
+	// the password is checked even when throttled, so the response time is the same
+	if known {
+		accountKey = lockout.AccountKey(a.UserID)
+		match = bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(login.Password)) == nil
+	} else {
+		compareDummyHash(login.Password)
+	}
+
+	throttle, err := throttled(r, accountKey)
+
 	if err != nil {
-		log.Errorf("Error getting authentication data from DB: %v", err)
+		log.Errorf("can't check failed login attempts: %v", err)
 		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(login.Password)); err != nil {
-		server.ErrorHandler(w, r, "Wrong credentials.", http.StatusUnauthorized)
+	// throttled attempts get the same response as wrong credentials, so it doesn't tell if an user exists
+	var e = audit.Entry{
+		Target: login.Username,
+	}
+
+	if known {
+		e.ActorID, e.Actor, e.Target = a.UserID, a.Username, a.Username
+	}
+
+	switch {
+	case throttle:
+		e.Action = audit.LoginFailed
+		e.Details = "too many failed attempts"
+		server.Audit(r, e)
+	case !known:
+		e.Details = "unknown user"
+		loginFailed(r, accountKey, e)
+	case !match:
+		e.Details = "wrong password"
+		loginFailed(r, accountKey, e)
+	default:
+		signIn(w, r, session, a.UserID, a.Username, a.TOTPSecret != "")
 		return
 	}
 
-	session.Values["user_id"] = a.UserID
-	session.Values["authenticated"] = true
+	server.ErrorHandler(w, r, "Wrong credentials.", http.StatusUnauthorized)
+}
+
+// signIn the user, or ask for the second factor if the user has two-factor authentication enabled.
+func signIn(w http.ResponseWriter, r *http.Request, session *sessions.Session, userID, username string, twoFactor bool) {
+	if twoFactor {
+		session.Values["two_factor_user_id"] = userID
+		session.Values["two_factor_time"] = time.Now().Unix()
+		session.Values["two_factor_attempts"] = 0
+	} else {
+		session.Values["user_id"] = userID
+		session.Values["authenticated"] = true
+
+		// a new session key on sign in prevents session fixation
+		if !session.IsNew {
+			if err := usersessions.End(r.Context(), session.ID); err != nil {
+				log.Errorf("can't end session before signing in: %v", err)
+			}
+		}
+
+		session.ID = ""
+		session.IsNew = true
+	}
 
-	if err = session.Save(r, w); err != nil {
+	if err := session.Save(r, w); err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if twoFactor {
+		http.Redirect(w, r, "/login/two-factor", http.StatusSeeOther)
+		return
+	}
+
+	var now = time.Now()
+
+	if err := usersessions.Create(r.Context(), usersessions.Session{
+		ID:        uuid.NewV4().String(),
+		Key:       session.ID,
+		UserID:    userID,
+		Created:   now,
+		LastSeen:  now,
+		IP:        realip.FromRequest(r),
+		UserAgent: r.UserAgent(),
+	}); err != nil {
+		log.Errorf("can't create session for user %s: %v", username, err)
 		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	loginSucceeded(r, lockout.AccountKey(userID))
+	server.Audit(r, audit.Entry{
+		ActorID: userID,
+		Actor:   username,
+		Action:  audit.Login,
+		Target:  username,
+	})
+
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
@@ -106,7 +198,17 @@ func logoutHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 		return
 	}
 
+	server.Audit(r, audit.Entry{
+		Action: audit.Logout,
+		Target: s.User.Username,
+	})
+
 	session := s.Session
+
+	if err := usersessions.End(r.Context(), session.ID); err != nil {
+		log.Errorf("can't end session: %v", err)
+	}
+
 	session.Values = map[interface{}]interface{}{}
 	session.Options.MaxAge = -1
 
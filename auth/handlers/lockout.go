@@ -0,0 +1,130 @@
+package authhandlers
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/tomasen/realip"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// lockoutPruneInterval for removing forgotten failed login attempts.
+const lockoutPruneInterval = time.Hour
+
+func init() {
+	server.Instance.Background(pruneLoginFailures)
+}
+
+func pruneLoginFailures(ctx context.Context) {
+	var ticker = time.NewTicker(lockoutPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var c = lockout.Current()
+		var window = c.Account.Window
+
+		if c.IP.Window > window {
+			window = c.IP.Window
+		}
+
+		if _, err := lockout.Prune(ctx, window); err != nil && ctx.Err() == nil {
+			log.Errorf("can't prune failed login attempts: %v", err)
+		}
+	}
+}
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// compareDummyHash takes about as long as checking the password of an existing user,
+// so response times don't tell if an user exists.
+func compareDummyHash(password string) {
+	dummyHashOnce.Do(func() {
+		var b = make([]byte, 32)
+
+		if _, err := rand.Read(b); err != nil {
+			panic(err)
+		}
+
+		dummyHash, _ = bcrypt.GenerateFromPassword(b, bcrypt.DefaultCost)
+	})
+
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// throttled tells if login attempts for the account or from the IP of the request have to wait.
+func throttled(r *http.Request, accountKey string) (bool, error) {
+	var c = lockout.Current()
+	var now = time.Now()
+
+	account, err := lockout.Get(r.Context(), accountKey)
+
+	if err != nil {
+		return false, err
+	}
+
+	ip, err := lockout.Get(r.Context(), lockout.IPKey(realip.FromRequest(r)))
+
+	if err != nil {
+		return false, err
+	}
+
+	return c.Account.Wait(account, now) > 0 || c.IP.Wait(ip, now) > 0, nil
+}
+
+// loginFailed registers a failed attempt for the account and the IP of the request,
+// and records it (and any lockout it caused) on the audit log.
+func loginFailed(r *http.Request, accountKey string, e audit.Entry) {
+	var c = lockout.Current()
+	var now = time.Now()
+
+	e.Action = audit.LoginFailed
+	server.Audit(r, e)
+
+	for _, f := range []struct {
+		kind   string
+		key    string
+		policy lockout.Policy
+	}{
+		{"account", accountKey, c.Account},
+		{"IP", lockout.IPKey(realip.FromRequest(r)), c.IP},
+	} {
+		counter, err := lockout.Fail(r.Context(), f.policy, f.key, now)
+
+		if err != nil {
+			log.Errorf("can't register failed login attempt for %s: %v", f.key, err)
+			continue
+		}
+
+		if counter.Failures != f.policy.MaxFailures {
+			continue
+		}
+
+		e.Action = audit.LoginLocked
+		e.Details = fmt.Sprintf("%s locked out for %v after %d failed attempts", f.kind, f.policy.Lockout, counter.Failures)
+		server.Audit(r, e)
+	}
+}
+
+// loginSucceeded forgets the failed attempts of the account.
+func loginSucceeded(r *http.Request, accountKey string) {
+	if err := lockout.Reset(r.Context(), accountKey); err != nil {
+		log.Errorf("can't reset failed login attempts for %s: %v", accountKey, err)
+	}
+}
@@ -0,0 +1,260 @@
+package authhandlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/oidc"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/users"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	router().HandleFunc("/login/oidc", oidcLoginHandler)
+	router().HandleFunc("/login/oidc/callback", oidcCallbackHandler)
+}
+
+func oidcLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var p = oidc.Current()
+
+	if p == nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	session, err := server.SessionStore.Get(r, server.UserSessionName)
+
+	if err != nil {
+		log.Errorf("Session store error: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if ok, iok := session.Values["authenticated"].(bool); iok && ok {
+		http.Redirect(w, r, "/", http.StatusFound)
+		return
+	}
+
+	var values = map[string]string{}
+
+	for _, k := range []string{"oidc_state", "oidc_nonce", "oidc_verifier"} {
+		if values[k], err = oidc.Random(); err != nil {
+			log.Errorf("can't generate random value for OpenID Connect: %v", err)
+			server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		session.Values[k] = values[k]
+	}
+
+	if err = session.Save(r, w); err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, p.AuthCodeURL(values["oidc_state"], values["oidc_nonce"], values["oidc_verifier"]), http.StatusFound)
+}
+
+func oidcCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	var p = oidc.Current()
+
+	if p == nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	session, err := server.SessionStore.Get(r, server.UserSessionName)
+
+	if err != nil {
+		log.Errorf("Session store error: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var query = r.URL.Query()
+	state, _ := session.Values["oidc_state"].(string)
+	nonce, _ := session.Values["oidc_nonce"].(string)
+	verifier, _ := session.Values["oidc_verifier"].(string)
+
+	delete(session.Values, "oidc_state")
+	delete(session.Values, "oidc_nonce")
+	delete(session.Values, "oidc_verifier")
+
+	if e := query.Get("error"); e != "" {
+		log.Debugf("OpenID Connect authentication error: %s: %s", e, query.Get("error_description"))
+		server.ErrorHandler(w, r, fmt.Sprintf("Single sign-on failed: %s", e), http.StatusUnauthorized)
+		return
+	}
+
+	if state == "" || query.Get("state") != state {
+		server.ErrorHandler(w, r, "Invalid or expired single sign-on request. Please try again.", http.StatusBadRequest)
+		return
+	}
+
+	idToken, err := p.Exchange(r.Context(), query.Get("code"), verifier)
+
+	if err != nil {
+		log.Errorf("OpenID Connect: %v", err)
+		server.ErrorHandler(w, r, "Single sign-on failed.", http.StatusBadGateway)
+		return
+	}
+
+	claims, err := p.Verify(r.Context(), idToken, nonce)
+
+	if err != nil {
+		log.Errorf("OpenID Connect: %v", err)
+		server.ErrorHandler(w, r, "Single sign-on failed.", http.StatusUnauthorized)
+		return
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		server.ErrorHandler(w, r, "Single sign-on failed: your identity provider didn't share a verified email.", http.StatusForbidden)
+		return
+	}
+
+	u, err := ssoUser(r.Context(), p.Config, claims)
+
+	switch err {
+	case nil:
+	case oidc.ErrNoRole, oidc.ErrRevoked, errNotProvisioned:
+		server.Audit(r, audit.Entry{
+			Action:  audit.LoginFailed,
+			Target:  claims.Email,
+			Details: "single sign-on: " + err.Error(),
+		})
+
+		server.ErrorHandler(w, r, fmt.Sprintf("Access forbidden: %v.", err), http.StatusForbidden)
+		return
+	default:
+		log.Errorf("can't get user for OpenID Connect login of %s: %v", claims.Email, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	signIn(w, r, session, u.UserID, u.Username, u.TwoFactor())
+}
+
+var errNotProvisioned = fmt.Errorf("there is no user for your email")
+
+// ssoUser gets the user for the claims, updating their role or creating the user if needed.
+func ssoUser(ctx context.Context, c oidc.Config, claims oidc.Claims) (u users.User, err error) {
+	u, err = users.GetByEmail(ctx, claims.Email)
+
+	if err == sql.ErrNoRows {
+		if !c.CanProvision(claims.Email) {
+			return u, errNotProvisioned
+		}
+
+		return provision(ctx, c, claims)
+	}
+
+	if err != nil {
+		return u, err
+	}
+
+	role, err := c.Role(claims, u.Role)
+
+	if err != nil || role == u.Role {
+		return u, err
+	}
+
+	log.Infof("changing role of user %s from %s to %s due to their groups", u.Username, u.Role, role)
+	u.Role = role
+	err = users.Update(ctx, u)
+	return u, err
+}
+
+var notUsernameRegex = regexp.MustCompile("[^a-z0-9]")
+
+func provision(ctx context.Context, c oidc.Config, claims oidc.Claims) (u users.User, err error) {
+	role, err := c.Role(claims, "")
+
+	if err != nil {
+		return u, err
+	}
+
+	username, err := availableUsername(ctx, claims.Email)
+
+	if err != nil {
+		return u, err
+	}
+
+	// users created by single sign-on can't log in with a password until one is set.
+	random, err := oidc.Random()
+
+	if err != nil {
+		return u, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(random), bcrypt.DefaultCost)
+
+	if err != nil {
+		return u, err
+	}
+
+	u = users.User{
+		UserID:   uuid.NewV4().String(),
+		Username: username,
+		Email:    claims.Email,
+		Role:     role,
+		Password: string(hash),
+	}
+
+	if err = users.Validate(u); err != nil {
+		return u, err
+	}
+
+	if err = users.Create(ctx, u); err != nil {
+		return u, err
+	}
+
+	log.Infof("user %s (%s) created with role %s on single sign-on", u.Username, u.Email, u.Role)
+	return u, nil
+}
+
+func availableUsername(ctx context.Context, email string) (string, error) {
+	var base = strings.ToLower(email)
+
+	if at := strings.LastIndex(base, "@"); at != -1 {
+		base = base[:at]
+	}
+
+	base = notUsernameRegex.ReplaceAllString(base, "")
+
+	if base == "" {
+		base = "user"
+	}
+
+	if len(base) > 30 {
+		base = base[:30]
+	}
+
+	for i := 1; i < 1000; i++ {
+		var username = base
+
+		if i > 1 {
+			username = fmt.Sprintf("%s%d", base, i)
+		}
+
+		taken, err := users.UsernameTaken(ctx, username)
+
+		if err != nil || !taken {
+			return username, err
+		}
+	}
+
+	return "", fmt.Errorf("can't find an available username for %s", email)
+}
@@ -0,0 +1,280 @@
+package authhandlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/onetime"
+	"github.com/henvic/climetrics/auth/usersessions"
+	"github.com/henvic/climetrics/mailer"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/users"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// passwordResetInterval between password reset emails to the same user.
+const passwordResetInterval = time.Minute
+
+// onetimePruneInterval for removing used and expired tokens.
+const onetimePruneInterval = time.Hour
+
+func init() {
+	router().HandleFunc("/password/forgot", forgotPasswordHandler)
+	router().HandleFunc("/password/reset/{token}", resetPasswordHandler)
+	router().HandleFunc("/invitation/{token}", invitationHandler)
+
+	server.Instance.Background(pruneOnetimeTokens)
+}
+
+func pruneOnetimeTokens(ctx context.Context) {
+	var ticker = time.NewTicker(onetimePruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := onetime.Prune(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("can't prune used and expired tokens: %v", err)
+		}
+	}
+}
+
+func forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var data = map[string]interface{}{}
+	var t = &server.Template{
+		Title:          "Forgot your password?",
+		Filenames:      []string{"gui/auth/forgot-password.html"},
+		Data:           data,
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		t.Respond()
+		return
+	case http.MethodPost:
+	default:
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var username = r.PostFormValue("username")
+
+	if username == "" {
+		server.ErrorHandler(w, r, "Missing username or email", http.StatusBadRequest)
+		return
+	}
+
+	a, err := auth.Get(r.Context(), username)
+
+	switch {
+	case err == sql.ErrNoRows:
+		server.Audit(r, audit.Entry{
+			Action:  audit.PasswordResetRequest,
+			Target:  username,
+			Details: "unknown user",
+		})
+	case err != nil:
+		log.Errorf("Error getting authentication data from DB: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	default:
+		server.Audit(r, audit.Entry{
+			ActorID: a.UserID,
+			Actor:   a.Username,
+			Action:  audit.PasswordResetRequest,
+			Target:  a.Username,
+		})
+
+		// sent in the background, so the response time doesn't tell if an user exists
+		go sendPasswordReset(a)
+	}
+
+	// the response is the same whether the user exists or not
+	data["Sent"] = true
+	t.Respond()
+}
+
+func sendPasswordReset(a auth.Authentication) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	created, pending, err := onetime.Pending(ctx, a.UserID, onetime.PasswordReset)
+
+	if err != nil {
+		log.Errorf("can't check pending password reset of user %s: %v", a.Username, err)
+		return
+	}
+
+	if pending && time.Since(created) < passwordResetInterval {
+		log.Infof("not sending another password reset email to user %s so soon", a.Username)
+		return
+	}
+
+	secret, expires, err := onetime.Create(ctx, a.UserID, onetime.PasswordReset)
+
+	if err != nil {
+		log.Errorf("can't create password reset token for user %s: %v", a.Username, err)
+		return
+	}
+
+	body, err := mailer.Render("gui/email/password-reset.txt", map[string]interface{}{
+		"Username": a.Username,
+		"Link":     server.URL("/password/reset/" + secret),
+		"Expires":  expires,
+	})
+
+	if err == nil {
+		err = mailer.Send(ctx, mailer.Message{
+			To:      a.Email,
+			Subject: "Reset your CLI metrics password",
+			Body:    body,
+		})
+	}
+
+	if err != nil {
+		log.Errorf("can't send password reset email to user %s: %v", a.Username, err)
+	}
+}
+
+func resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	setPasswordHandler(w, r, setPassword{
+		purpose: onetime.PasswordReset,
+		title:   "Reset your password",
+		action:  audit.PasswordReset,
+	})
+}
+
+func invitationHandler(w http.ResponseWriter, r *http.Request) {
+	setPasswordHandler(w, r, setPassword{
+		purpose: onetime.Invitation,
+		title:   "Welcome to CLI metrics",
+		action:  audit.InvitationAccept,
+	})
+}
+
+// setPassword flow using a token sent by email.
+type setPassword struct {
+	purpose onetime.Purpose
+	title   string
+	action  audit.Action
+}
+
+func setPasswordHandler(w http.ResponseWriter, r *http.Request, sp setPassword) {
+	// the token is on the URL, so it must not leak to other sites
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	var secret = mux.Vars(r)["token"]
+	t, err := onetime.Get(r.Context(), secret, sp.purpose)
+
+	if err == onetime.ErrInvalid {
+		server.ErrorHandler(w, r, "This link is invalid or expired.", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't get %s token: %v", sp.purpose, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	u, err := users.Get(r.Context(), t.UserID)
+
+	if err == sql.ErrNoRows || (err == nil && u.Role == users.Revoked) {
+		server.ErrorHandler(w, r, "This link is invalid or expired.", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't get user for %s token: %v", sp.purpose, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var tmpl = &server.Template{
+			Title:     sp.title,
+			Filenames: []string{"gui/auth/set-password.html"},
+			Data: map[string]interface{}{
+				"Title":             sp.title,
+				"Username":          u.Username,
+				"MinPasswordLength": users.MinPasswordLength,
+			},
+			Request:        r,
+			ResponseWriter: w,
+		}
+
+		tmpl.Respond()
+		return
+	case http.MethodPost:
+	default:
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var password = r.PostFormValue("password")
+
+	if err = users.ValidatePassword(password); err != nil {
+		server.ErrorHandler(w, r, "Invalid password: "+err.Error()+".", http.StatusBadRequest)
+		return
+	}
+
+	if password != r.PostFormValue("confirm") {
+		server.ErrorHandler(w, r, "The passwords don't match.", http.StatusBadRequest)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err = users.SetPasswordWithToken(r.Context(), secret, sp.purpose, string(hash)); err == onetime.ErrInvalid {
+		server.ErrorHandler(w, r, "This link is invalid or expired.", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't set password of user %s with %s token: %v", u.Username, sp.purpose, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// whoever knew the previous password is logged out
+	if _, err = usersessions.RevokeAll(r.Context(), u.UserID, ""); err != nil {
+		log.Errorf("can't revoke sessions of user %s: %v", u.Username, err)
+	}
+
+	loginSucceeded(r, lockout.AccountKey(u.UserID))
+	server.Audit(r, audit.Entry{
+		ActorID: u.UserID,
+		Actor:   u.Username,
+		Action:  sp.action,
+		Target:  u.Username,
+	})
+
+	session, err := server.SessionStore.Get(r, server.UserSessionName)
+
+	if err != nil {
+		log.Errorf("Session store error: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	signIn(w, r, session, u.UserID, u.Username, u.TwoFactor())
+}
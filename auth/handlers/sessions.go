@@ -0,0 +1,127 @@
+package authhandlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/usersessions"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/us"
+	log "github.com/sirupsen/logrus"
+)
+
+// sessionsPruneInterval for removing expired sessions.
+const sessionsPruneInterval = time.Hour
+
+func init() {
+	router().Handle("/account/sessions", server.AuthenticatedHandler(sessionsHandler))
+	router().Handle("/account/sessions/revoke", server.AuthenticatedHandler(revokeAllSessionsHandler))
+	router().Handle("/account/sessions/{id}/revoke", server.AuthenticatedHandler(revokeSessionHandler))
+
+	server.Instance.Background(pruneSessions)
+}
+
+func pruneSessions(ctx context.Context) {
+	var ticker = time.NewTicker(sessionsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := usersessions.Prune(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("can't prune expired sessions: %v", err)
+		}
+	}
+}
+
+func sessionsHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodGet {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	list, err := usersessions.List(r.Context(), s.User.UserID)
+
+	if err != nil {
+		log.Errorf("can't list sessions of user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var t = &server.Template{
+		Title:     "Sessions",
+		Filenames: []string{"gui/account/sessions.html"},
+		Data: map[string]interface{}{
+			"Sessions": list,
+			"Current":  s.Session.ID,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+func revokeSessionHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var id = mux.Vars(r)["id"]
+	err := usersessions.Revoke(r.Context(), s.User.UserID, id)
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't revoke session %s of user %s: %v", id, s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("user %s revoked session %s", s.User.Username, id)
+	server.Audit(r, audit.Entry{
+		Action:  audit.SessionRevoke,
+		Target:  s.User.Username,
+		Details: id,
+	})
+
+	http.Redirect(w, r, "/account/sessions", http.StatusSeeOther)
+}
+
+// revokeAllSessionsHandler logs the user out everywhere else.
+func revokeAllSessionsHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	n, err := usersessions.RevokeAll(r.Context(), s.User.UserID, s.Session.ID)
+
+	if err != nil {
+		log.Errorf("can't revoke sessions of user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("user %s revoked %d other sessions", s.User.Username, n)
+	server.Audit(r, audit.Entry{
+		Action:  audit.SessionRevokeAll,
+		Target:  s.User.Username,
+		Details: fmt.Sprintf("%d other sessions", n),
+	})
+
+	http.Redirect(w, r, "/account/sessions", http.StatusSeeOther)
+}
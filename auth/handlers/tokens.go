@@ -0,0 +1,171 @@
+package authhandlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/tokens"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/us"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// tokenExpirations offered when creating a token, in days.
+var tokenExpirations = []int{7, 30, 90, 365}
+
+func init() {
+	router().Handle("/account", server.AuthenticatedHandler(accountHandler))
+	router().Handle("/account/tokens", server.AuthenticatedHandler(createTokenHandler))
+	router().Handle("/account/tokens/{id}/revoke", server.AuthenticatedHandler(revokeTokenHandler))
+}
+
+func accountHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodGet {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	renderAccount(w, r, s, nil, "")
+}
+
+func renderAccount(w http.ResponseWriter, r *http.Request, s us.Session, created *tokens.Token, secret string) {
+	list, err := tokens.List(r.Context(), s.User.UserID)
+
+	if err != nil {
+		log.Errorf("can't list tokens of user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var available []tokens.Scope
+
+	for _, sc := range tokens.Scopes {
+		if s.User.Can(sc.Permission()) {
+			available = append(available, sc)
+		}
+	}
+
+	var t = &server.Template{
+		Title:     "Account",
+		Filenames: []string{"gui/account/account.html"},
+		Data: map[string]interface{}{
+			"Tokens":      list,
+			"Scopes":      available,
+			"Expirations": tokenExpirations,
+			"Created":     created,
+			"Secret":      secret,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+func createTokenHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		server.ErrorHandler(w, r, "Invalid form", http.StatusBadRequest)
+		return
+	}
+
+	days, err := strconv.Atoi(r.PostFormValue("expiration"))
+
+	if err != nil || !validExpiration(days) {
+		server.ErrorHandler(w, r, "Missing / invalid expiration", http.StatusBadRequest)
+		return
+	}
+
+	var t = tokens.Token{
+		UserID:  s.User.UserID,
+		Name:    r.PostFormValue("name"),
+		Scopes:  pq.StringArray(r.PostForm["scopes"]),
+		Expires: time.Now().AddDate(0, 0, days),
+	}
+
+	for _, sc := range t.Scopes {
+		if !s.User.Can(tokens.Scope(sc).Permission()) {
+			server.ErrorHandler(w, r, "You can't create a token with the "+sc+" scope.", http.StatusForbidden)
+			return
+		}
+	}
+
+	if err = t.Validate(time.Now()); err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t, secret, err := tokens.Create(r.Context(), t)
+
+	if err != nil {
+		log.Errorf("can't create token for user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("user %s created API token %s (%s)", s.User.Username, t.ID, t.Name)
+	server.Audit(r, audit.Entry{
+		Action:  audit.TokenCreate,
+		Target:  t.ID,
+		Details: fmt.Sprintf("%s (scopes: %s, expires: %s)", t.Name, strings.Join(t.Scopes, ", "), t.Expires.Format(time.RFC3339)),
+	})
+
+	renderAccount(w, r, s, &t, secret)
+}
+
+func validExpiration(days int) bool {
+	for _, d := range tokenExpirations {
+		if d == days {
+			return true
+		}
+	}
+
+	return false
+}
+
+func revokeTokenHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var id = mux.Vars(r)["id"]
+
+	if _, err := uuid.FromString(id); err != nil {
+		server.ErrorHandler(w, r, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	err := tokens.Revoke(r.Context(), s.User.UserID, id)
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't revoke token %s of user %s: %v", id, s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("user %s revoked API token %s", s.User.Username, id)
+	server.Audit(r, audit.Entry{
+		Action: audit.TokenRevoke,
+		Target: id,
+	})
+
+	http.Redirect(w, r, "/account", http.StatusSeeOther)
+}
@@ -0,0 +1,403 @@
+package authhandlers
+
+import (
+	"context"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/totp"
+	"github.com/henvic/climetrics/qrcode"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// twoFactorTimeout for entering the second factor after the password is checked.
+	twoFactorTimeout = 5 * time.Minute
+
+	// maxTwoFactorAttempts before having to enter the password again.
+	maxTwoFactorAttempts = 5
+
+	// recoveryCodes generated for each user.
+	recoveryCodes = 10
+
+	totpIssuer = "CLI metrics"
+)
+
+func init() {
+	router().HandleFunc("/login/two-factor", loginTwoFactorHandler)
+	router().Handle(server.TwoFactorPath, server.AuthenticatedHandler(accountTwoFactorHandler))
+}
+
+func clearTwoFactorLogin(session *sessions.Session) {
+	delete(session.Values, "two_factor_user_id")
+	delete(session.Values, "two_factor_time")
+	delete(session.Values, "two_factor_attempts")
+}
+
+func loginTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	session, err := server.SessionStore.Get(r, server.UserSessionName)
+
+	if err != nil {
+		log.Errorf("Session store error: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	userID, ok := session.Values["two_factor_user_id"].(string)
+	started, _ := session.Values["two_factor_time"].(int64)
+
+	if !ok || time.Since(time.Unix(started, 0)) > twoFactorTimeout {
+		clearTwoFactorLogin(session)
+		_ = session.Save(r, w)
+		server.ErrorHandler(w, r, "Your sign in expired. Please log in again.", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var t = &server.Template{
+			Title:          "Two-factor authentication",
+			Filenames:      []string{"gui/auth/two-factor.html"},
+			Data:           map[string]interface{}{},
+			Request:        r,
+			ResponseWriter: w,
+		}
+
+		t.Respond()
+		return
+	case http.MethodPost:
+	default:
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	u, err := users.Get(r.Context(), userID)
+
+	if err != nil || u.Role == users.Revoked || !u.TwoFactor() {
+		clearTwoFactorLogin(session)
+		_ = session.Save(r, w)
+		server.ErrorHandler(w, r, "Wrong credentials.", http.StatusUnauthorized)
+		return
+	}
+
+	var accountKey = lockout.AccountKey(u.UserID)
+	throttle, err := throttled(r, accountKey)
+
+	if err != nil {
+		log.Errorf("can't check failed login attempts: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !throttle {
+		ok, err = verifySecondFactor(r.Context(), u, r.PostFormValue("code"))
+	}
+
+	if err != nil {
+		log.Errorf("can't verify second factor for user %s: %v", u.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if throttle || !ok {
+		attempts, _ := session.Values["two_factor_attempts"].(int)
+		session.Values["two_factor_attempts"] = attempts + 1
+
+		if attempts+1 >= maxTwoFactorAttempts {
+			clearTwoFactorLogin(session)
+		}
+
+		_ = session.Save(r, w)
+
+		var e = audit.Entry{
+			ActorID: u.UserID,
+			Actor:   u.Username,
+			Action:  audit.LoginFailed,
+			Target:  u.Username,
+			Details: "wrong two-factor authentication code",
+		}
+
+		if throttle {
+			e.Details = "too many failed attempts"
+			server.Audit(r, e)
+		} else {
+			loginFailed(r, accountKey, e)
+		}
+
+		server.ErrorHandler(w, r, "Wrong code.", http.StatusUnauthorized)
+		return
+	}
+
+	clearTwoFactorLogin(session)
+	signIn(w, r, session, u.UserID, u.Username, false)
+}
+
+// verifySecondFactor accepts a TOTP code or an unused recovery code.
+func verifySecondFactor(ctx context.Context, u users.User, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if counter, ok := totp.Validate(u.TOTPSecret, code, time.Now()); ok {
+		return users.UseTOTPCounter(ctx, u.UserID, counter)
+	}
+
+	if len(code) <= totp.Digits {
+		return false, nil
+	}
+
+	ok, err := users.UseRecoveryCode(ctx, u.UserID, totp.HashRecoveryCode(code))
+
+	if ok {
+		log.Infof("user %s signed in with a recovery code", u.Username)
+	}
+
+	return ok, err
+}
+
+func accountTwoFactorHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	switch r.Method {
+	case http.MethodGet:
+		renderTwoFactor(w, r, s, nil)
+		return
+	case http.MethodPost:
+	default:
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.PostFormValue("action") {
+	case "enable":
+		enableTwoFactor(w, r, s)
+	case "recovery-codes":
+		regenerateRecoveryCodes(w, r, s)
+	case "disable":
+		disableTwoFactor(w, r, s)
+	default:
+		server.ErrorHandler(w, r, "Invalid action", http.StatusBadRequest)
+	}
+}
+
+func renderTwoFactor(w http.ResponseWriter, r *http.Request, s us.Session, codes []string) {
+	policy, err := users.GetTwoFactorPolicy(r.Context())
+
+	if err != nil {
+		log.Errorf("can't get two-factor authentication policy: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var data = map[string]interface{}{
+		"Enabled":       s.User.TwoFactor(),
+		"Required":      policy.Requires(s.User.Role),
+		"RecoveryCodes": codes,
+	}
+
+	if s.User.TwoFactor() {
+		left, err := users.RecoveryCodesLeft(r.Context(), s.User.UserID)
+
+		if err != nil {
+			log.Errorf("can't count recovery codes: %v", err)
+			server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		data["RecoveryCodesLeft"] = left
+	} else if err := enrollmentData(w, r, s, data); err != nil {
+		log.Errorf("can't start two-factor authentication enrollment: %v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var t = &server.Template{
+		Title:          "Two-factor authentication",
+		Filenames:      []string{"gui/account/two-factor.html"},
+		Data:           data,
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+// enrollmentData adds the secret being enrolled (kept on the session until confirmed) and its QR code.
+func enrollmentData(w http.ResponseWriter, r *http.Request, s us.Session, data map[string]interface{}) error {
+	secret, ok := s.Session.Values["totp_enroll_secret"].(string)
+
+	if !ok {
+		var err error
+
+		if secret, err = totp.NewSecret(); err != nil {
+			return err
+		}
+
+		s.Session.Values["totp_enroll_secret"] = secret
+
+		if err = s.Session.Save(r, w); err != nil {
+			return err
+		}
+	}
+
+	code, err := qrcode.Encode(totp.URI(totpIssuer, s.User.Username, secret))
+
+	if err != nil {
+		return err
+	}
+
+	b, err := code.PNG(4)
+
+	if err != nil {
+		return err
+	}
+
+	data["Secret"] = secret
+	data["QRCode"] = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(b))
+	return nil
+}
+
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	if codes, err = totp.RecoveryCodes(recoveryCodes); err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range codes {
+		hashes = append(hashes, totp.HashRecoveryCode(c))
+	}
+
+	return codes, hashes, nil
+}
+
+func enableTwoFactor(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if s.User.TwoFactor() {
+		server.ErrorHandler(w, r, "Two-factor authentication is already enabled.", http.StatusConflict)
+		return
+	}
+
+	secret, ok := s.Session.Values["totp_enroll_secret"].(string)
+
+	if !ok {
+		server.ErrorHandler(w, r, "Two-factor authentication setup expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+
+	counter, ok := totp.Validate(secret, r.PostFormValue("code"), time.Now())
+
+	if !ok {
+		server.ErrorHandler(w, r, "Wrong code. Make sure the clock of your device is correct and try again.", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if err = users.EnableTOTP(r.Context(), s.User.UserID, secret, counter, hashes); err != nil {
+		log.Errorf("can't enable two-factor authentication for user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	delete(s.Session.Values, "totp_enroll_secret")
+
+	if err = s.Session.Save(r, w); err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("user %s enabled two-factor authentication", s.User.Username)
+	server.Audit(r, audit.Entry{
+		Action: audit.TwoFactorEnable,
+		Target: s.User.Username,
+	})
+
+	s.User.TOTPSecret = secret
+	renderTwoFactor(w, r, s, codes)
+}
+
+func regenerateRecoveryCodes(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if !s.User.TwoFactor() {
+		server.ErrorHandler(w, r, "Two-factor authentication is not enabled.", http.StatusBadRequest)
+		return
+	}
+
+	ok, err := verifySecondFactor(r.Context(), s.User, r.PostFormValue("code"))
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !ok {
+		server.ErrorHandler(w, r, "Wrong code.", http.StatusBadRequest)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+
+	if err == nil {
+		err = users.ReplaceRecoveryCodes(r.Context(), s.User.UserID, hashes)
+	}
+
+	if err != nil {
+		log.Errorf("can't replace recovery codes for user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	renderTwoFactor(w, r, s, codes)
+}
+
+func disableTwoFactor(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if !s.User.TwoFactor() {
+		server.ErrorHandler(w, r, "Two-factor authentication is not enabled.", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := users.GetTwoFactorPolicy(r.Context())
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if policy.Requires(s.User.Role) {
+		server.ErrorHandler(w, r, "Two-factor authentication is required for your role.", http.StatusForbidden)
+		return
+	}
+
+	ok, err := verifySecondFactor(r.Context(), s.User, r.PostFormValue("code"))
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !ok {
+		server.ErrorHandler(w, r, "Wrong code.", http.StatusBadRequest)
+		return
+	}
+
+	if err = users.DisableTOTP(r.Context(), s.User.UserID); err != nil {
+		log.Errorf("can't disable two-factor authentication for user %s: %v", s.User.Username, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("user %s disabled two-factor authentication", s.User.Username)
+	server.Audit(r, audit.Entry{
+		Action: audit.TwoFactorDisable,
+		Target: s.User.Username,
+	})
+
+	http.Redirect(w, r, server.TwoFactorPath, http.StatusSeeOther)
+}
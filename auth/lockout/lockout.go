@@ -0,0 +1,230 @@
+// Package lockout slows down and locks out repeated failed sign in attempts per account and per IP.
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+)
+
+// Policy for failed attempts.
+// After each failure, the next attempt must wait Delay, doubled on every further failure (up to Lockout).
+// After MaxFailures, attempts are locked out for Lockout. Failures are forgotten after Window without failures.
+type Policy struct {
+	MaxFailures int
+	Delay       time.Duration
+	Lockout     time.Duration
+	Window      time.Duration
+}
+
+// Validate policy.
+func (p Policy) Validate() error {
+	if p.MaxFailures < 1 {
+		return errors.New("maximum number of failed attempts must be at least 1")
+	}
+
+	if p.Delay < 0 || p.Lockout < p.Delay {
+		return errors.New("lockout duration must be greater than the delay between failed attempts")
+	}
+
+	if p.Window < p.Lockout {
+		return errors.New("failed attempts window must be greater than the lockout duration")
+	}
+
+	return nil
+}
+
+// Config of the policies per account and per IP.
+type Config struct {
+	Account Policy
+	IP      Policy
+}
+
+// Validate config.
+func (c Config) Validate() error {
+	if err := c.Account.Validate(); err != nil {
+		return errors.New("account lockout: " + err.Error())
+	}
+
+	if err := c.IP.Validate(); err != nil {
+		return errors.New("IP lockout: " + err.Error())
+	}
+
+	return nil
+}
+
+// DefaultConfig is lenient with IPs, as many users might share one.
+var DefaultConfig = Config{
+	Account: Policy{
+		MaxFailures: 10,
+		Delay:       time.Second,
+		Lockout:     15 * time.Minute,
+		Window:      24 * time.Hour,
+	},
+	IP: Policy{
+		MaxFailures: 100,
+		Delay:       0,
+		Lockout:     15 * time.Minute,
+		Window:      24 * time.Hour,
+	},
+}
+
+var (
+	current  = DefaultConfig
+	currentM sync.RWMutex
+)
+
+// Use config.
+func Use(c Config) {
+	currentM.Lock()
+	defer currentM.Unlock()
+	current = c
+}
+
+// Current config.
+func Current() Config {
+	currentM.RLock()
+	defer currentM.RUnlock()
+	return current
+}
+
+// AccountKey for the failures counter of an account (user ID or the unknown username or email tried).
+func AccountKey(id string) string {
+	return "account:" + strings.ToLower(id)
+}
+
+// IPKey for the failures counter of an IP.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Counter of failed attempts.
+type Counter struct {
+	Key         string    `db:"key"`
+	Failures    int       `db:"failures"`
+	LastFailure time.Time `db:"last_failure"`
+}
+
+// active tells if the failures weren't forgotten yet.
+func (p Policy) active(c Counter, now time.Time) bool {
+	return c.Failures != 0 && now.Sub(c.LastFailure) < p.Window
+}
+
+// Wait until the next attempt is allowed.
+func (p Policy) Wait(c Counter, now time.Time) time.Duration {
+	if !p.active(c, now) {
+		return 0
+	}
+
+	var wait = p.Lockout
+
+	if c.Failures < p.MaxFailures {
+		wait = p.Delay
+
+		for i := 1; i < c.Failures && wait < p.Lockout; i++ {
+			wait *= 2
+		}
+
+		if wait > p.Lockout {
+			wait = p.Lockout
+		}
+	}
+
+	if wait -= now.Sub(c.LastFailure); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// Locked tells if the attempts are locked out (rather than just delayed).
+func (p Policy) Locked(c Counter, now time.Time) bool {
+	return c.Failures >= p.MaxFailures && p.Wait(c, now) > 0
+}
+
+// Get counter of failed attempts.
+func Get(ctx context.Context, key string) (c Counter, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `SELECT key, failures, last_failure FROM login_failures WHERE key = $1`)
+
+	if err != nil {
+		return c, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, key).StructScan(&c)
+
+	if err == sql.ErrNoRows {
+		return Counter{Key: key}, nil
+	}
+
+	return c, err
+}
+
+// Fail registers a failed attempt, restarting the count if the previous failures were forgotten.
+func Fail(ctx context.Context, p Policy, key string, now time.Time) (c Counter, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `INSERT INTO login_failures (key, failures, last_failure) VALUES ($1, 1, $2)
+ON CONFLICT (key) DO UPDATE SET
+	failures = CASE WHEN login_failures.last_failure < $3 THEN 1 ELSE login_failures.failures + 1 END,
+	last_failure = EXCLUDED.last_failure
+RETURNING key, failures, last_failure`)
+
+	if err != nil {
+		return c, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, key, now, now.Add(-p.Window)).StructScan(&c)
+	return c, err
+}
+
+// Reset counter of failed attempts (on a successful attempt, or to unlock it).
+func Reset(ctx context.Context, key string) error {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM login_failures WHERE key = $1`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, key)
+	return err
+}
+
+// Prune counters of failures older than the window.
+func Prune(ctx context.Context, window time.Duration) (int64, error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM login_failures WHERE last_failure < $1`)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, time.Now().Add(-window))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,116 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+)
+
+var policy = Policy{
+	MaxFailures: 5,
+	Delay:       time.Second,
+	Lockout:     10 * time.Second,
+	Window:      time.Hour,
+}
+
+func TestWait(t *testing.T) {
+	var last = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+	var cases = []struct {
+		failures int
+		elapsed  time.Duration
+		want     time.Duration
+		locked   bool
+	}{
+		{0, 0, 0, false},
+		{1, 0, time.Second, false},
+		{1, 500 * time.Millisecond, 500 * time.Millisecond, false},
+		{1, time.Second, 0, false},
+		{2, 0, 2 * time.Second, false},
+		{3, time.Second, 3 * time.Second, false},
+		{4, 0, 8 * time.Second, false},
+		{5, 0, 10 * time.Second, true},
+		{5, 9 * time.Second, time.Second, true},
+		{5, 10 * time.Second, 0, false},
+		{40, 0, 10 * time.Second, true},
+		{40, time.Hour, 0, false},
+	}
+
+	for _, c := range cases {
+		var counter = Counter{
+			Key:         AccountKey("alice"),
+			Failures:    c.failures,
+			LastFailure: last,
+		}
+
+		var now = last.Add(c.elapsed)
+
+		if got := policy.Wait(counter, now); got != c.want {
+			t.Errorf("Expected wait after %d failures and %v to be %v, got %v instead", c.failures, c.elapsed, c.want, got)
+		}
+
+		if got := policy.Locked(counter, now); got != c.locked {
+			t.Errorf("Expected locked after %d failures and %v to be %v, got %v instead", c.failures, c.elapsed, c.locked, got)
+		}
+	}
+}
+
+func TestWaitLongBackoff(t *testing.T) {
+	var p = Policy{
+		MaxFailures: 1000,
+		Delay:       time.Second,
+		Lockout:     time.Minute,
+		Window:      time.Hour,
+	}
+
+	var now = time.Now()
+
+	if got := p.Wait(Counter{Failures: 999, LastFailure: now}, now); got != time.Minute {
+		t.Errorf("Expected backoff to be capped to the lockout duration, got %v instead", got)
+	}
+}
+
+func TestWaitNoDelay(t *testing.T) {
+	var p = DefaultConfig.IP
+	var now = time.Now()
+
+	if got := p.Wait(Counter{Failures: p.MaxFailures - 1, LastFailure: now}, now); got != 0 {
+		t.Errorf("Expected no wait before the IP is locked out, got %v instead", got)
+	}
+
+	if !p.Locked(Counter{Failures: p.MaxFailures, LastFailure: now}, now) {
+		t.Error("Expected IP to be locked out")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := DefaultConfig.Validate(); err != nil {
+		t.Errorf("Expected default config to be valid, got %v instead", err)
+	}
+
+	var cases = []Policy{
+		{MaxFailures: 0, Delay: time.Second, Lockout: time.Minute, Window: time.Hour},
+		{MaxFailures: 5, Delay: -time.Second, Lockout: time.Minute, Window: time.Hour},
+		{MaxFailures: 5, Delay: time.Hour, Lockout: time.Minute, Window: time.Hour},
+		{MaxFailures: 5, Delay: time.Second, Lockout: time.Hour, Window: time.Minute},
+	}
+
+	for _, c := range cases {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Expected policy %+v to be invalid", c)
+		}
+
+		if err := (Config{Account: policy, IP: c}).Validate(); err == nil {
+			t.Errorf("Expected config with IP policy %+v to be invalid", c)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	if got := AccountKey("Alice@Example.com"); got != "account:alice@example.com" {
+		t.Errorf("Unexpected account key %q", got)
+	}
+
+	if got := IPKey("203.0.113.7"); got != "ip:203.0.113.7" {
+		t.Errorf("Unexpected IP key %q", got)
+	}
+}
@@ -0,0 +1,498 @@
+// Package oidc implements OpenID Connect single sign-on
+// using the authorization code flow with PKCE.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config of the OpenID Connect identity provider.
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	// Scopes requested (default: openid, email, and profile).
+	Scopes []string
+
+	// AllowedDomains of the emails of users created on their first login.
+	// Users are not created automatically if empty.
+	AllowedDomains []string
+
+	// DefaultRole of users created on their first login (default: member).
+	DefaultRole string
+
+	// GroupsClaim is the name of the claim with the groups of the user (default: groups).
+	GroupsClaim string
+
+	// GroupRoles maps groups to roles. If set, the role of the user is enforced on every login.
+	GroupRoles map[string]string
+}
+
+// Enabled tells if OpenID Connect is configured.
+func (c Config) Enabled() bool {
+	return c.Issuer != ""
+}
+
+// Validate configuration.
+func (c Config) Validate() error {
+	switch {
+	case c.ClientID == "":
+		return errors.New("missing OpenID Connect client ID")
+	case c.RedirectURL == "":
+		return errors.New("missing OpenID Connect redirect URL")
+	}
+
+	for group, role := range c.GroupRoles {
+		if _, ok := roleRank[role]; !ok {
+			return fmt.Errorf(`invalid role "%s" for group "%s"`, role, group)
+		}
+	}
+
+	if _, ok := roleRank[c.defaultRole()]; !ok {
+		return fmt.Errorf(`invalid default role "%s"`, c.DefaultRole)
+	}
+
+	return nil
+}
+
+func (c Config) scopes() []string {
+	if len(c.Scopes) == 0 {
+		return []string{"openid", "email", "profile"}
+	}
+
+	return c.Scopes
+}
+
+func (c Config) groupsClaim() string {
+	if c.GroupsClaim == "" {
+		return "groups"
+	}
+
+	return c.GroupsClaim
+}
+
+// metadata of the provider, from its discovery document.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider of identities.
+type Provider struct {
+	Config Config
+	Client *http.Client
+
+	metadata metadata
+	keys     map[string]*rsa.PublicKey
+	m        sync.RWMutex
+
+	// now is replaceable for testing
+	now func() time.Time
+}
+
+var (
+	current  *Provider
+	currentM sync.RWMutex
+)
+
+// Use provider for single sign-on (nil to disable it).
+func Use(p *Provider) {
+	currentM.Lock()
+	defer currentM.Unlock()
+	current = p
+}
+
+// Current provider, or nil if single sign-on is disabled.
+func Current() *Provider {
+	currentM.RLock()
+	defer currentM.RUnlock()
+	return current
+}
+
+// New provider, using OpenID Connect discovery.
+func New(ctx context.Context, c Config) (*Provider, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		Config: c,
+		Client: http.DefaultClient,
+		now:    time.Now,
+	}
+
+	var wellKnown = strings.TrimSuffix(c.Issuer, "/") + "/.well-known/openid-configuration"
+
+	if err := p.get(ctx, wellKnown, &p.metadata); err != nil {
+		return nil, fmt.Errorf("can't get OpenID Connect discovery document: %v", err)
+	}
+
+	if strings.TrimSuffix(p.metadata.Issuer, "/") != strings.TrimSuffix(c.Issuer, "/") {
+		return nil, fmt.Errorf(`OpenID Connect issuer "%s" doesn't match the configured issuer "%s"`,
+			p.metadata.Issuer, c.Issuer)
+	}
+
+	if p.metadata.AuthorizationEndpoint == "" || p.metadata.TokenEndpoint == "" || p.metadata.JWKSURI == "" {
+		return nil, errors.New("OpenID Connect discovery document is missing endpoints")
+	}
+
+	return p, nil
+}
+
+func (p *Provider) get(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	return p.do(req.WithContext(ctx), v)
+}
+
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	resp, err := p.Client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s (status code %d)", e.Error, e.Description, resp.StatusCode)
+		}
+
+		return fmt.Errorf("response has status code %d", resp.StatusCode)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// Random returns a random URL-safe string, for use as state, nonce, or PKCE code verifier.
+func Random() (string, error) {
+	var b = make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Challenge for a PKCE code verifier (S256 method).
+func Challenge(verifier string) string {
+	h := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// AuthCodeURL to redirect the user to for authentication.
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) string {
+	var v = url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.Config.ClientID},
+		"redirect_uri":          {p.Config.RedirectURL},
+		"scope":                 {strings.Join(p.Config.scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {Challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+
+	var sep = "?"
+
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+
+	return p.metadata.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Exchange authorization code for an ID token.
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (idToken string, err error) {
+	var v = url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.Config.RedirectURL},
+		"client_id":     {p.Config.ClientID},
+		"code_verifier": {verifier},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(v.Encode()))
+
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if p.Config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+	}
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+
+	if err = p.do(req.WithContext(ctx), &token); err != nil {
+		return "", fmt.Errorf("can't exchange authorization code: %v", err)
+	}
+
+	if token.IDToken == "" {
+		return "", errors.New("token response has no ID token")
+	}
+
+	return token.IDToken, nil
+}
+
+// Claims of an ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Groups        []string
+}
+
+// leeway for clock skew when validating the ID token expiration.
+const leeway = time.Minute
+
+// Verify ID token, returning its claims.
+func (p *Provider) Verify(ctx context.Context, idToken, nonce string) (c Claims, err error) {
+	var parts = strings.Split(idToken, ".")
+
+	if len(parts) != 3 {
+		return c, errors.New("malformed ID token")
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+
+	if err = decodeSegment(parts[0], &header); err != nil {
+		return c, fmt.Errorf("malformed ID token header: %v", err)
+	}
+
+	if header.Algorithm != "RS256" {
+		return c, fmt.Errorf(`unsupported ID token signing algorithm "%s"`, header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return c, fmt.Errorf("malformed ID token signature: %v", err)
+	}
+
+	key, err := p.key(ctx, header.KeyID)
+
+	if err != nil {
+		return c, err
+	}
+
+	var h = sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, h[:], signature); err != nil {
+		return c, errors.New("invalid ID token signature")
+	}
+
+	var claims map[string]interface{}
+
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return c, fmt.Errorf("malformed ID token claims: %v", err)
+	}
+
+	if err = p.validate(claims, nonce); err != nil {
+		return c, err
+	}
+
+	c.Subject, _ = claims["sub"].(string)
+	c.Email, _ = claims["email"].(string)
+
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		c.EmailVerified = v
+	case string:
+		c.EmailVerified = v == "true"
+	}
+
+	switch groups := claims[p.Config.groupsClaim()].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				c.Groups = append(c.Groups, s)
+			}
+		}
+	case string:
+		c.Groups = []string{groups}
+	}
+
+	return c, nil
+}
+
+func (p *Provider) validate(claims map[string]interface{}, nonce string) error {
+	if iss, _ := claims["iss"].(string); iss != p.metadata.Issuer {
+		return fmt.Errorf(`invalid ID token issuer "%s"`, iss)
+	}
+
+	var audience bool
+
+	switch aud := claims["aud"].(type) {
+	case string:
+		audience = aud == p.Config.ClientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == p.Config.ClientID {
+				audience = true
+			}
+		}
+	}
+
+	if !audience {
+		return errors.New("ID token was not issued for this client")
+	}
+
+	exp, ok := claims["exp"].(float64)
+
+	if !ok {
+		return errors.New("ID token has no expiration")
+	}
+
+	if p.now().Add(-leeway).After(time.Unix(int64(exp), 0)) {
+		return errors.New("ID token is expired")
+	}
+
+	if n, _ := claims["nonce"].(string); nonce == "" || n != nonce {
+		return errors.New("invalid ID token nonce")
+	}
+
+	return nil
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// key gets the public key used to sign ID tokens, refreshing the key set if the key is unknown.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.m.RLock()
+	key, ok := p.lookup(kid)
+	p.m.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if err := p.refreshKeys(ctx); err != nil {
+		return nil, fmt.Errorf("can't get OpenID Connect signing keys: %v", err)
+	}
+
+	if key, ok = p.lookup(kid); !ok {
+		return nil, fmt.Errorf(`unknown ID token signing key "%s"`, kid)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid != "" {
+		key, ok := p.keys[kid]
+		return key, ok
+	}
+
+	// tokens without a key ID are only accepted if there is a single key.
+	if len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+func (p *Provider) refreshKeys(ctx context.Context) error {
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+
+	if err := p.get(ctx, p.metadata.JWKSURI, &set); err != nil {
+		return err
+	}
+
+	var keys = map[string]*rsa.PublicKey{}
+
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+
+		if err != nil {
+			return fmt.Errorf(`invalid modulus for key "%s": %v`, k.KeyID, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+
+		if err != nil {
+			return fmt.Errorf(`invalid exponent for key "%s": %v`, k.KeyID, err)
+		}
+
+		keys[k.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.keys = keys
+	return nil
+}
@@ -0,0 +1,326 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockIdP is an identity provider issuing ID tokens for the PKCE code challenges it receives.
+type mockIdP struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// claims overrides the default claims of the issued ID tokens.
+	claims map[string]interface{}
+
+	codes map[string]authRequest
+	m     sync.Mutex
+}
+
+type authRequest struct {
+	nonce     string
+	challenge string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var idp = &mockIdP{
+		key:    key,
+		claims: map[string]interface{}{},
+		codes:  map[string]authRequest{},
+	}
+
+	var mux = http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discoveryHandler)
+	mux.HandleFunc("/keys", idp.keysHandler)
+	mux.HandleFunc("/token", idp.tokenHandler)
+	idp.server = httptest.NewServer(mux)
+	return idp
+}
+
+func (idp *mockIdP) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 idp.server.URL,
+		"authorization_endpoint": idp.server.URL + "/authorize",
+		"token_endpoint":         idp.server.URL + "/token",
+		"jwks_uri":               idp.server.URL + "/keys",
+	})
+}
+
+func (idp *mockIdP) keysHandler(w http.ResponseWriter, r *http.Request) {
+	var pub = idp.key.PublicKey
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			},
+		},
+	})
+}
+
+// authorize simulates the user authenticating on the authorization endpoint, returning the code.
+func (idp *mockIdP) authorize(t *testing.T, authCodeURL string) (code, state string) {
+	u, err := url.Parse(authCodeURL)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var q = u.Query()
+
+	if q.Get("code_challenge_method") != "S256" {
+		t.Errorf("Expected S256 code challenge method, got %v instead", q.Get("code_challenge_method"))
+	}
+
+	code = "code-" + q.Get("state")
+
+	idp.m.Lock()
+	idp.codes[code] = authRequest{
+		nonce:     q.Get("nonce"),
+		challenge: q.Get("code_challenge"),
+	}
+	idp.m.Unlock()
+
+	return code, q.Get("state")
+}
+
+func (idp *mockIdP) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	idp.m.Lock()
+	ar, ok := idp.codes[r.PostFormValue("code")]
+	delete(idp.codes, r.PostFormValue("code"))
+	idp.m.Unlock()
+
+	if !ok || Challenge(r.PostFormValue("code_verifier")) != ar.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error": "invalid_grant", "error_description": "invalid code or verifier"}`))
+		return
+	}
+
+	var claims = map[string]interface{}{
+		"iss":            idp.server.URL,
+		"aud":            r.PostFormValue("client_id"),
+		"sub":            "12345",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"groups":         []string{"engineering"},
+		"nonce":          ar.nonce,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access",
+		"id_token":     idp.sign(claims, idp.key),
+	})
+}
+
+func (idp *mockIdP) sign(claims map[string]interface{}, key *rsa.PrivateKey) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+
+	var signed = base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	var h = sha256.Sum256([]byte(signed))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (idp *mockIdP) provider(t *testing.T) *Provider {
+	p, err := New(context.Background(), Config{
+		Issuer:       idp.server.URL,
+		ClientID:     "climetrics",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/login/oidc/callback",
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	return p
+}
+
+// login goes through the authorization code flow, returning the result of the ID token verification.
+func login(t *testing.T, idp *mockIdP, p *Provider, verifierOverride, nonceOverride string) (Claims, error) {
+	state, _ := Random()
+	nonce, _ := Random()
+	verifier, _ := Random()
+
+	code, gotState := idp.authorize(t, p.AuthCodeURL(state, nonce, verifier))
+
+	if gotState != state {
+		t.Errorf("Expected state %v, got %v instead", state, gotState)
+	}
+
+	if verifierOverride != "" {
+		verifier = verifierOverride
+	}
+
+	idToken, err := p.Exchange(context.Background(), code, verifier)
+
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if nonceOverride != "" {
+		nonce = nonceOverride
+	}
+
+	return p.Verify(context.Background(), idToken, nonce)
+}
+
+func TestLogin(t *testing.T) {
+	var idp = newMockIdP(t)
+	defer idp.server.Close()
+
+	claims, err := login(t, idp, idp.provider(t), "", "")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = Claims{
+		Subject:       "12345",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+		Groups:        []string{"engineering"},
+	}
+
+	if !reflect.DeepEqual(claims, want) {
+		t.Errorf("Expected claims %+v, got %+v instead", want, claims)
+	}
+}
+
+func TestLoginFailure(t *testing.T) {
+	var cases = []struct {
+		name     string
+		claims   map[string]interface{}
+		verifier string
+		nonce    string
+		want     string
+	}{
+		{
+			name:  "bad nonce",
+			nonce: "other",
+			want:  "invalid ID token nonce",
+		},
+		{
+			name:   "wrong audience",
+			claims: map[string]interface{}{"aud": "other"},
+			want:   "ID token was not issued for this client",
+		},
+		{
+			name:   "wrong issuer",
+			claims: map[string]interface{}{"iss": "https://example.com"},
+			want:   `invalid ID token issuer "https://example.com"`,
+		},
+		{
+			name:   "expired",
+			claims: map[string]interface{}{"exp": time.Now().Add(-2 * time.Minute).Unix()},
+			want:   "ID token is expired",
+		},
+		{
+			name:     "bad PKCE verifier",
+			verifier: "wrong",
+			want:     "can't exchange authorization code: invalid_grant: invalid code or verifier (status code 400)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var idp = newMockIdP(t)
+			defer idp.server.Close()
+
+			for k, v := range c.claims {
+				idp.claims[k] = v
+			}
+
+			_, err := login(t, idp, idp.provider(t), c.verifier, c.nonce)
+
+			if err == nil || err.Error() != c.want {
+				t.Errorf("Expected error %v, got %v instead", c.want, err)
+			}
+		})
+	}
+}
+
+func TestVerifyBadSignature(t *testing.T) {
+	var idp = newMockIdP(t)
+	defer idp.server.Close()
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var idToken = idp.sign(map[string]interface{}{
+		"iss":   idp.server.URL,
+		"aud":   "climetrics",
+		"nonce": "nonce",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}, other)
+
+	var want = "invalid ID token signature"
+
+	if _, err := idp.provider(t).Verify(context.Background(), idToken, "nonce"); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v instead", want, err)
+	}
+}
+
+func TestVerifyUnsupportedAlgorithm(t *testing.T) {
+	var idp = newMockIdP(t)
+	defer idp.server.Close()
+
+	var idToken = idp.sign(map[string]interface{}{"iss": idp.server.URL}, idp.key)
+	var parts = strings.Split(idToken, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+
+	var want = `unsupported ID token signing algorithm "none"`
+
+	if _, err := idp.provider(t).Verify(context.Background(), strings.Join(parts, "."), "nonce"); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v instead", want, err)
+	}
+}
+
+func TestNewIssuerMismatch(t *testing.T) {
+	var idp = newMockIdP(t)
+	defer idp.server.Close()
+
+	_, err := New(context.Background(), Config{
+		Issuer:      idp.server.URL + "/other",
+		ClientID:    "climetrics",
+		RedirectURL: "http://localhost:8080/login/oidc/callback",
+	})
+
+	if err == nil {
+		t.Errorf("Expected error for mismatching issuer")
+	}
+}
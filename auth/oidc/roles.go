@@ -0,0 +1,113 @@
+package oidc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/henvic/climetrics/users"
+)
+
+// ErrNoRole is returned when group to role mapping is enforced and none of the user groups is mapped.
+var ErrNoRole = errors.New("none of your groups is allowed to access this service")
+
+// ErrRevoked is returned for users whose access is revoked.
+var ErrRevoked = errors.New("your access is revoked")
+
+// roleRank is used to pick the role with more permissions when the user is on multiple groups.
+var roleRank = map[string]int{
+	users.Revoked: 0,
+	users.Member:  1,
+	users.Admin:   2,
+}
+
+func (c Config) defaultRole() string {
+	if c.DefaultRole == "" {
+		return users.Member
+	}
+
+	return c.DefaultRole
+}
+
+// ParseGroupRoles parses a group to role mapping (i.e., "engineering=member,ops=admin").
+func ParseGroupRoles(s string) (map[string]string, error) {
+	var m = map[string]string{}
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		var i = strings.LastIndex(pair, "=")
+
+		if i <= 0 {
+			return nil, fmt.Errorf(`invalid group to role mapping "%s": use group=role`, pair)
+		}
+
+		var group, role = strings.TrimSpace(pair[:i]), strings.TrimSpace(pair[i+1:])
+
+		if _, ok := roleRank[role]; !ok {
+			return nil, fmt.Errorf(`invalid role "%s" for group "%s"`, role, group)
+		}
+
+		m[group] = role
+	}
+
+	return m, nil
+}
+
+// CanProvision tells if a user can be created on first login.
+func (c Config) CanProvision(email string) bool {
+	var at = strings.LastIndex(email, "@")
+
+	if at == -1 {
+		return false
+	}
+
+	var domain = strings.ToLower(email[at+1:])
+
+	for _, d := range c.AllowedDomains {
+		if strings.ToLower(strings.TrimSpace(d)) == domain {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Role for a user logging in, given the current role of the user ("" for new users).
+// Users revoked locally stay revoked, regardless of their groups.
+func (c Config) Role(claims Claims, current string) (string, error) {
+	if current == users.Revoked {
+		return "", ErrRevoked
+	}
+
+	if len(c.GroupRoles) == 0 {
+		if current != "" {
+			return current, nil
+		}
+
+		return c.defaultRole(), nil
+	}
+
+	var role string
+	var mapped bool
+
+	for _, g := range claims.Groups {
+		r, ok := c.GroupRoles[g]
+
+		if ok && (!mapped || roleRank[r] > roleRank[role]) {
+			role = r
+			mapped = true
+		}
+	}
+
+	switch {
+	case !mapped:
+		return "", ErrNoRole
+	case role == users.Revoked:
+		return "", ErrRevoked
+	}
+
+	return role, nil
+}
@@ -0,0 +1,101 @@
+package oidc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupRoles(t *testing.T) {
+	got, err := ParseGroupRoles("engineering=member, ops=admin,,contractors=revoked")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = map[string]string{
+		"engineering": "member",
+		"ops":         "admin",
+		"contractors": "revoked",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v instead", want, got)
+	}
+}
+
+func TestParseGroupRolesFailure(t *testing.T) {
+	var cases = []string{
+		"engineering",
+		"=admin",
+		"ops=root",
+	}
+
+	for _, c := range cases {
+		if _, err := ParseGroupRoles(c); err == nil {
+			t.Errorf("Expected error parsing %v", c)
+		}
+	}
+}
+
+func TestCanProvision(t *testing.T) {
+	var c = Config{AllowedDomains: []string{"example.com", "Example.org"}}
+
+	var cases = []struct {
+		email string
+		want  bool
+	}{
+		{"alice@example.com", true},
+		{"bob@EXAMPLE.ORG", true},
+		{"carol@example.net", false},
+		{"dave@sub.example.com", false},
+		{"example.com", false},
+	}
+
+	for _, tc := range cases {
+		if got := c.CanProvision(tc.email); got != tc.want {
+			t.Errorf("Expected CanProvision(%v) to be %v, got %v instead", tc.email, tc.want, got)
+		}
+	}
+
+	if (Config{}).CanProvision("alice@example.com") {
+		t.Errorf("Expected no user to be provisioned without allowed domains")
+	}
+}
+
+func TestRole(t *testing.T) {
+	var mapped = Config{
+		GroupRoles: map[string]string{
+			"engineering": "member",
+			"ops":         "admin",
+			"contractors": "revoked",
+		},
+	}
+
+	var cases = []struct {
+		config  Config
+		groups  []string
+		current string
+		want    string
+		err     error
+	}{
+		{Config{}, nil, "", "member", nil},
+		{Config{DefaultRole: "admin"}, nil, "", "admin", nil},
+		{Config{}, nil, "admin", "admin", nil},
+		{Config{}, nil, "revoked", "", ErrRevoked},
+		{mapped, []string{"engineering"}, "", "member", nil},
+		{mapped, []string{"engineering", "ops"}, "member", "admin", nil},
+		{mapped, []string{"engineering"}, "admin", "member", nil},
+		{mapped, []string{"sales"}, "member", "", ErrNoRole},
+		{mapped, []string{"contractors"}, "member", "", ErrRevoked},
+		{mapped, []string{"ops"}, "revoked", "", ErrRevoked},
+	}
+
+	for _, c := range cases {
+		got, err := c.config.Role(Claims{Groups: c.groups}, c.current)
+
+		if got != c.want || err != c.err {
+			t.Errorf("Expected role %v (error: %v) for groups %v and current role %v, got %v (error: %v) instead",
+				c.want, c.err, c.groups, c.current, got, err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package onetime manages single-use, expiring tokens sent by email, such as invitations and password resets.
+package onetime
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/jmoiron/sqlx"
+)
+
+// Purpose of a token.
+type Purpose string
+
+const (
+	// Invitation for a new user to set their password.
+	Invitation Purpose = "invitation"
+
+	// PasswordReset for an user who forgot their password.
+	PasswordReset Purpose = "password_reset"
+)
+
+// TTL of the tokens for each purpose.
+var TTL = map[Purpose]time.Duration{
+	Invitation:    7 * 24 * time.Hour,
+	PasswordReset: time.Hour,
+}
+
+// ErrInvalid is returned when a token doesn't exist, is expired, or was already used.
+var ErrInvalid = errors.New("invalid or expired link")
+
+// Token sent by email. Only its hash is stored.
+type Token struct {
+	UserID  string     `db:"user_id"`
+	Purpose Purpose    `db:"purpose"`
+	Created time.Time  `db:"created"`
+	Expires time.Time  `db:"expires"`
+	Used    *time.Time `db:"used"`
+}
+
+// Hash of the token secret.
+func Hash(secret string) string {
+	var h = sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func generate() (string, error) {
+	var b = make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Create token for the user, replacing any unused token of the user for the same purpose.
+func Create(ctx context.Context, userID string, p Purpose) (secret string, expires time.Time, err error) {
+	ttl, ok := TTL[p]
+
+	if !ok {
+		return "", expires, fmt.Errorf(`invalid token purpose "%s"`, p)
+	}
+
+	if secret, err = generate(); err != nil {
+		return "", expires, err
+	}
+
+	conn := db.Conn()
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return "", expires, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx,
+		`DELETE FROM authentication_tokens WHERE user_id = $1 AND purpose = $2 AND used IS NULL`, userID, p); err != nil {
+		return "", expires, err
+	}
+
+	var now = time.Now()
+	expires = now.Add(ttl)
+
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO authentication_tokens (token_hash, user_id, purpose, created, expires) VALUES ($1, $2, $3, $4, $5)`,
+		Hash(secret), userID, p, now, expires); err != nil {
+		return "", expires, err
+	}
+
+	return secret, expires, tx.Commit()
+}
+
+// Get valid (unused and not expired) token.
+func Get(ctx context.Context, secret string, p Purpose) (t Token, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `SELECT user_id, purpose, created, expires, used FROM authentication_tokens
+WHERE token_hash = $1 AND purpose = $2 AND used IS NULL AND expires > $3`)
+
+	if err != nil {
+		return t, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, Hash(secret), p, time.Now()).StructScan(&t)
+
+	if err == sql.ErrNoRows {
+		return t, ErrInvalid
+	}
+
+	return t, err
+}
+
+// Use token on the transaction, returning the ID of its user. A token can only be used once,
+// so whatever it is used for must be done on the same transaction.
+func Use(ctx context.Context, tx *sqlx.Tx, secret string, p Purpose) (userID string, err error) {
+	var now = time.Now()
+
+	err = tx.QueryRowxContext(ctx, `UPDATE authentication_tokens SET used = $1
+WHERE token_hash = $2 AND purpose = $3 AND used IS NULL AND expires > $1
+RETURNING user_id`, now, Hash(secret), p).Scan(&userID)
+
+	if err == sql.ErrNoRows {
+		return "", ErrInvalid
+	}
+
+	return userID, err
+}
+
+// Pending tells if the user has a valid token for the purpose, and when it was created.
+func Pending(ctx context.Context, userID string, p Purpose) (created time.Time, ok bool, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `SELECT created FROM authentication_tokens
+WHERE user_id = $1 AND purpose = $2 AND used IS NULL AND expires > $3
+ORDER BY created DESC LIMIT 1`)
+
+	if err != nil {
+		return created, false, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, userID, p, time.Now()).Scan(&created)
+
+	if err == sql.ErrNoRows {
+		return created, false, nil
+	}
+
+	return created, err == nil, err
+}
+
+// Prune expired and used tokens.
+func Prune(ctx context.Context) (int64, error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`DELETE FROM authentication_tokens WHERE used IS NOT NULL OR expires < $1`)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, time.Now())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
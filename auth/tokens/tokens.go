@@ -0,0 +1,291 @@
+// Package tokens manages personal API tokens used as bearer tokens on the JSON API.
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/users"
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Prefix of the tokens, to make them easy to recognize (i.e., by secret scanners).
+const Prefix = "cmt_"
+
+var (
+	// ErrInvalid is returned when the token doesn't exist (or was revoked).
+	ErrInvalid = errors.New("invalid token")
+
+	// ErrExpired is returned when the token is expired.
+	ErrExpired = errors.New("token expired")
+)
+
+// Scope of a token.
+type Scope string
+
+const (
+	// ReadMetrics scope.
+	ReadMetrics Scope = "metrics:read"
+
+	// ReadDiagnostics scope.
+	ReadDiagnostics Scope = "diagnostics:read"
+
+	// ReadUsers scope.
+	ReadUsers Scope = "users:read"
+)
+
+// Scopes available.
+var Scopes = []Scope{ReadMetrics, ReadDiagnostics, ReadUsers}
+
+var scopePermissions = map[Scope]users.Permission{
+	ReadMetrics:     users.ViewMetrics,
+	ReadDiagnostics: users.ViewDiagnostics,
+	ReadUsers:       users.ManageUsers,
+}
+
+// Permission required for using the scope.
+// The scope is only effective while the user owning the token has it.
+func (s Scope) Permission() users.Permission {
+	return scopePermissions[s]
+}
+
+// Valid tells if the scope exists.
+func (s Scope) Valid() bool {
+	_, ok := scopePermissions[s]
+	return ok
+}
+
+// Token for the API. The secret is only available on creation.
+type Token struct {
+	ID       string         `db:"id" json:"id"`
+	UserID   string         `db:"user_id" json:"-"`
+	Name     string         `db:"name" json:"name"`
+	Scopes   pq.StringArray `db:"scopes" json:"scopes"`
+	Created  time.Time      `db:"created" json:"created"`
+	Expires  time.Time      `db:"expires" json:"expires"`
+	LastUsed *time.Time     `db:"last_used" json:"last_used,omitempty"`
+}
+
+// Has tells if the token has the given scope.
+func (t Token) Has(s Scope) bool {
+	for _, ts := range t.Scopes {
+		if Scope(ts) == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired tells if the token is expired at the given time.
+func (t Token) Expired(now time.Time) bool {
+	return !now.Before(t.Expires)
+}
+
+// Hash of a token secret, for storage.
+func Hash(secret string) string {
+	var h = sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+// generate a random secret.
+func generate() (string, error) {
+	var b = make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return Prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Validate token name, scopes, and expiration before creating it.
+func (t Token) Validate(now time.Time) error {
+	if strings.TrimSpace(t.Name) == "" || len(t.Name) > 100 {
+		return errors.New("token name must have between 1 and 100 characters")
+	}
+
+	if len(t.Scopes) == 0 {
+		return errors.New("token must have at least one scope")
+	}
+
+	for _, s := range t.Scopes {
+		if !Scope(s).Valid() {
+			return fmt.Errorf(`invalid scope "%s"`, s)
+		}
+	}
+
+	if !t.Expires.After(now) {
+		return errors.New("token expiration must be in the future")
+	}
+
+	return nil
+}
+
+// Create token, returning its secret.
+func Create(ctx context.Context, t Token) (Token, string, error) {
+	t.ID = uuid.NewV4().String()
+	t.Created = time.Now()
+
+	if err := t.Validate(t.Created); err != nil {
+		return t, "", err
+	}
+
+	secret, err := generate()
+
+	if err != nil {
+		return t, "", err
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`INSERT INTO api_tokens (id, user_id, name, token_hash, scopes, created, expires) VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+
+	if err != nil {
+		return t, "", err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	if _, err = stmt.ExecContext(ctx, t.ID, t.UserID, t.Name, Hash(secret), t.Scopes, t.Created, t.Expires); err != nil {
+		return t, "", err
+	}
+
+	return t, secret, nil
+}
+
+// List tokens of a user.
+func List(ctx context.Context, userID string) (ts []Token, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`SELECT id, user_id, name, scopes, created, expires, last_used FROM api_tokens
+		WHERE user_id = $1 ORDER BY created DESC`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var t Token
+
+		if err = rows.StructScan(&t); err != nil {
+			return nil, err
+		}
+
+		ts = append(ts, t)
+	}
+
+	return ts, rows.Err()
+}
+
+// Revoke token of a user.
+func Revoke(ctx context.Context, userID, id string) error {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM api_tokens WHERE user_id = $1 AND id = $2`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, userID, id)
+
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Authenticate token secret, recording its use.
+func Authenticate(ctx context.Context, secret string) (t Token, err error) {
+	if !strings.HasPrefix(secret, Prefix) {
+		return t, ErrInvalid
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`SELECT id, user_id, name, scopes, created, expires, last_used FROM api_tokens WHERE token_hash = $1`)
+
+	if err != nil {
+		return t, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, Hash(secret)).StructScan(&t)
+
+	if err == sql.ErrNoRows {
+		return t, ErrInvalid
+	}
+
+	if err != nil {
+		return t, err
+	}
+
+	var now = time.Now()
+
+	if t.Expired(now) {
+		return t, ErrExpired
+	}
+
+	return t, used(ctx, t.ID, now)
+}
+
+// used records the last use of a token.
+func used(ctx context.Context, id string, now time.Time) error {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `UPDATE api_tokens SET last_used = $1 WHERE id = $2`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, now, id)
+	return err
+}
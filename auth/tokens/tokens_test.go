@@ -0,0 +1,93 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/users"
+	"github.com/lib/pq"
+)
+
+func TestScopePermission(t *testing.T) {
+	var want = map[Scope]users.Permission{
+		ReadMetrics:      users.ViewMetrics,
+		ReadDiagnostics:  users.ViewDiagnostics,
+		ReadUsers:        users.ManageUsers,
+		Scope("unknown"): "",
+	}
+
+	for s, p := range want {
+		if got := s.Permission(); got != p {
+			t.Errorf("Expected scope %s to require permission %q, got %q instead", s, p, got)
+		}
+	}
+
+	for _, s := range Scopes {
+		if !s.Valid() {
+			t.Errorf("Expected scope %s to be valid", s)
+		}
+	}
+}
+
+func TestTokenHas(t *testing.T) {
+	var token = Token{Scopes: pq.StringArray{"metrics:read", "users:read"}}
+
+	if !token.Has(ReadMetrics) || !token.Has(ReadUsers) || token.Has(ReadDiagnostics) {
+		t.Errorf("Unexpected scopes for token %+v", token)
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+	var token = Token{Expires: now}
+
+	if !token.Expired(now) || !token.Expired(now.Add(time.Second)) || token.Expired(now.Add(-time.Second)) {
+		t.Errorf("Unexpected expiration for token %+v", token)
+	}
+}
+
+func TestTokenValidate(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+	var valid = Token{
+		Name:    "scripts",
+		Scopes:  pq.StringArray{"metrics:read"},
+		Expires: now.Add(time.Hour),
+	}
+
+	if err := valid.Validate(now); err != nil {
+		t.Errorf("Expected no error, got %v instead", err)
+	}
+
+	var cases = []Token{
+		{Name: " ", Scopes: valid.Scopes, Expires: valid.Expires},
+		{Name: strings.Repeat("a", 101), Scopes: valid.Scopes, Expires: valid.Expires},
+		{Name: "scripts", Expires: valid.Expires},
+		{Name: "scripts", Scopes: pq.StringArray{"metrics:write"}, Expires: valid.Expires},
+		{Name: "scripts", Scopes: valid.Scopes, Expires: now},
+	}
+
+	for _, c := range cases {
+		if err := c.Validate(now); err == nil {
+			t.Errorf("Expected error validating token %+v", c)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	a, err := generate()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	b, _ := generate()
+
+	if !strings.HasPrefix(a, Prefix) || len(a) != len(Prefix)+43 || a == b {
+		t.Errorf("Expected random tokens with prefix %s, got %v and %v instead", Prefix, a, b)
+	}
+
+	if Hash(a) == Hash(b) || len(Hash(a)) != 64 {
+		t.Errorf("Unexpected token hashes")
+	}
+}
@@ -0,0 +1,134 @@
+// Package totp implements time-based one-time passwords (RFC 6238) for two-factor authentication.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period of each code.
+	Period = 30 * time.Second
+
+	// Digits of each code.
+	Digits = 6
+
+	// Skew is the number of periods before or after the current one accepted to compensate clock drift.
+	Skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewSecret generates a random secret, base32 encoded.
+func NewSecret() (string, error) {
+	var b = make([]byte, 20)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(b), nil
+}
+
+// Counter for a given time.
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code for the given counter (HOTP, RFC 4226).
+func Code(secret string, counter int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.Replace(secret, " ", "", -1)))
+
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %v", err)
+	}
+
+	var msg = make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+
+	var h = hmac.New(sha1.New, key)
+	_, _ = h.Write(msg)
+	var sum = h.Sum(nil)
+
+	var offset = sum[len(sum)-1] & 0x0f
+	var value = binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000), nil
+}
+
+// Validate code at the given time, returning the counter it matched.
+// Callers must reject counters already used to prevent codes from being replayed.
+func Validate(secret, code string, t time.Time) (counter int64, ok bool) {
+	code = strings.Replace(code, " ", "", -1)
+
+	if len(code) != Digits {
+		return 0, false
+	}
+
+	var now = Counter(t)
+
+	for c := now - Skew; c <= now+Skew; c++ {
+		want, err := Code(secret, c)
+
+		if err != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return c, true
+		}
+	}
+
+	return 0, false
+}
+
+// URI for enrolling the secret on an authenticator app (usually shown as a QR code).
+func URI(issuer, account, secret string) string {
+	var v = url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(Digits)},
+		"period":    {fmt.Sprint(int(Period / time.Second))},
+	}
+
+	var label = url.PathEscape(issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// RecoveryCodes generates n single-use recovery codes (i.e., "x7k2m-q9wpt").
+func RecoveryCodes(n int) ([]string, error) {
+	var codes = make([]string, n)
+
+	for i := range codes {
+		var b = make([]byte, 7)
+
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		var s = strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes[i] = s[:5] + "-" + s[5:]
+	}
+
+	return codes, nil
+}
+
+// HashRecoveryCode for storage. Dashes, spaces, and case are ignored.
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+
+	var h = sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
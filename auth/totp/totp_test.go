@@ -0,0 +1,160 @@
+package totp
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret from RFC 6238 test vectors ("12345678901234567890"), base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	// RFC 6238 appendix B (SHA-1), truncated to 6 digits.
+	var cases = []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, c := range cases {
+		got, err := Code(rfcSecret, Counter(time.Unix(c.unix, 0)))
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v instead", err)
+		}
+
+		if got != c.want {
+			t.Errorf("Expected code %v at %d, got %v instead", c.want, c.unix, got)
+		}
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Errorf("Expected error for invalid secret")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var now = time.Unix(1111111111, 0)
+
+	var cases = []struct {
+		code string
+		ok   bool
+	}{
+		{"050471", true},
+		{"050 471", true},
+		{"081804", true}, // previous period
+		{"000000", false},
+		{"05047", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		counter, ok := Validate(rfcSecret, c.code, now)
+
+		if ok != c.ok {
+			t.Errorf("Expected code %v validation to be %v, got %v instead", c.code, c.ok, ok)
+		}
+
+		if ok && counter != Counter(now) && counter != Counter(now)-1 {
+			t.Errorf("Expected counter near %d, got %d instead", Counter(now), counter)
+		}
+	}
+
+	if _, ok := Validate(rfcSecret, "050471", now.Add(2*Period)); ok {
+		t.Errorf("Expected code to be expired after two periods")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	b, _ := NewSecret()
+
+	if len(a) != 32 || a == b {
+		t.Errorf("Expected random 32 characters secrets, got %v and %v instead", a, b)
+	}
+
+	code, err := Code(a, Counter(time.Now()))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if _, ok := Validate(a, code, time.Now()); !ok {
+		t.Errorf("Expected code %v to be valid", code)
+	}
+}
+
+func TestURI(t *testing.T) {
+	u, err := url.Parse(URI("CLI metrics", "alice", rfcSecret))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/CLI metrics:alice" {
+		t.Errorf("Unexpected URI %v", u)
+	}
+
+	var q = u.Query()
+
+	if q.Get("secret") != rfcSecret || q.Get("issuer") != "CLI metrics" || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("Unexpected URI parameters %v", q)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := RecoveryCodes(10)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var format = regexp.MustCompile("^[a-z2-7]{5}-[a-z2-7]{5}$")
+	var seen = map[string]bool{}
+
+	for _, c := range codes {
+		if !format.MatchString(c) {
+			t.Errorf("Unexpected recovery code format: %v", c)
+		}
+
+		if seen[c] {
+			t.Errorf("Repeated recovery code %v", c)
+		}
+
+		seen[c] = true
+	}
+
+	if len(codes) != 10 {
+		t.Errorf("Expected 10 recovery codes, got %d instead", len(codes))
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	var want = HashRecoveryCode("x7k2m-q9wpt")
+
+	for _, c := range []string{"X7K2M-Q9WPT", "x7k2mq9wpt", " x7k2m q9wpt "} {
+		if got := HashRecoveryCode(c); got != want {
+			t.Errorf("Expected hash of %v to match", c)
+		}
+	}
+
+	if HashRecoveryCode("x7k2m-q9wpu") == want || len(want) != 64 || strings.Contains(want, "x7k2m") {
+		t.Errorf("Unexpected recovery code hash %v", want)
+	}
+}
@@ -0,0 +1,283 @@
+// Package usersessions tracks where users are signed in, so they can see and revoke their sessions.
+//
+// The sessions themselves are stored on the http_sessions table by pgstore, under a random key.
+// A session is only valid while it is also on the user_sessions table.
+package usersessions
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+)
+
+// TouchInterval between updates of when a session was last seen.
+const TouchInterval = time.Minute
+
+// maxUserAgentLength stored for a session.
+const maxUserAgentLength = 512
+
+// Session of an user.
+type Session struct {
+	ID string `db:"id"`
+
+	// Key of the session on the http_sessions table. It identifies the session cookie, so it is never shown.
+	Key string `db:"key"`
+
+	UserID    string    `db:"user_id"`
+	Created   time.Time `db:"created"`
+	LastSeen  time.Time `db:"last_seen"`
+	IP        string    `db:"ip"`
+	UserAgent string    `db:"user_agent"`
+}
+
+// Stale tells if the session should be touched again on a request from the given IP.
+func (s Session) Stale(ip string, now time.Time) bool {
+	return s.IP != ip || now.Sub(s.LastSeen) >= TouchInterval
+}
+
+// Device of the session, guessed from the user agent (i.e., "Firefox on macOS").
+func (s Session) Device() string {
+	var ua = s.UserAgent
+
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	var browser = "Unknown browser"
+
+	for _, b := range []struct {
+		token string
+		name  string
+	}{
+		// the order matters: most user agents mention other browsers too
+		{"curl/", "curl"},
+		{"Edge/", "Edge"},
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	var os string
+
+	for _, o := range []struct {
+		token string
+		name  string
+	}{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"Mac OS X", "macOS"},
+		{"Windows", "Windows"},
+		{"CrOS", "Chrome OS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			os = o.name
+			break
+		}
+	}
+
+	if os == "" {
+		return browser
+	}
+
+	return browser + " on " + os
+}
+
+// Create session.
+func Create(ctx context.Context, s Session) error {
+	if len(s.UserAgent) > maxUserAgentLength {
+		s.UserAgent = s.UserAgent[:maxUserAgentLength]
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PrepareNamedContext(ctx, `INSERT INTO user_sessions (id, key, user_id, created, last_seen, ip, user_agent)
+VALUES (:id, :key, :user_id, :created, :last_seen, :ip, :user_agent)`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, s)
+	return err
+}
+
+// Get session by its key. It returns sql.ErrNoRows if the session was revoked.
+func Get(ctx context.Context, key string) (s Session, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `SELECT id, key, user_id, created, last_seen, ip, user_agent
+FROM user_sessions WHERE key = $1`)
+
+	if err != nil {
+		return s, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, key).StructScan(&s)
+	return s, err
+}
+
+// Touch session, updating when and from where it was last seen.
+func Touch(ctx context.Context, key, ip string, now time.Time) error {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `UPDATE user_sessions SET last_seen = $1, ip = $2 WHERE key = $3`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, now, ip, key)
+	return err
+}
+
+// List active sessions of the user, from the most recently seen.
+func List(ctx context.Context, userID string) (ss []Session, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `SELECT s.id, s.key, s.user_id, s.created, s.last_seen, s.ip, s.user_agent
+FROM user_sessions s
+INNER JOIN http_sessions h ON h.key = convert_to(s.key, 'UTF8')
+WHERE s.user_id = $1 AND h.expires_on > $2
+ORDER BY s.last_seen DESC`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, userID, time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var s Session
+
+		if err = rows.StructScan(&s); err != nil {
+			return nil, err
+		}
+
+		ss = append(ss, s)
+	}
+
+	return ss, rows.Err()
+}
+
+// End session by its key, such as when the user logs out.
+func End(ctx context.Context, key string) error {
+	conn := db.Conn()
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM user_sessions WHERE key = $1`, key); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM http_sessions WHERE key = convert_to($1, 'UTF8')`, key); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Revoke session of the user. It returns sql.ErrNoRows if the session doesn't exist.
+func Revoke(ctx context.Context, userID, id string) error {
+	n, err := revoke(ctx, `user_id = $1 AND id = $2`, userID, id)
+
+	if err == nil && n == 0 {
+		err = sql.ErrNoRows
+	}
+
+	return err
+}
+
+// RevokeAll sessions of the user, except the one with the given key (if not empty).
+func RevokeAll(ctx context.Context, userID, exceptKey string) (int64, error) {
+	return revoke(ctx, `user_id = $1 AND key != $2`, userID, exceptKey)
+}
+
+func revoke(ctx context.Context, where string, args ...interface{}) (n int64, err error) {
+	conn := db.Conn()
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var keys []string
+
+	if err = tx.SelectContext(ctx, &keys, `DELETE FROM user_sessions WHERE `+where+` RETURNING key`, args...); err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		if _, err = tx.ExecContext(ctx, `DELETE FROM http_sessions WHERE key = convert_to($1, 'UTF8')`, key); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(keys)), tx.Commit()
+}
+
+// Prune sessions that expired or no longer exist.
+func Prune(ctx context.Context) (int64, error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM user_sessions s WHERE NOT EXISTS (
+	SELECT 1 FROM http_sessions h WHERE h.key = convert_to(s.key, 'UTF8') AND h.expires_on > $1
+)`)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, time.Now())
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
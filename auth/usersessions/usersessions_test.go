@@ -0,0 +1,50 @@
+package usersessions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStale(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+	var s = Session{
+		IP:       "203.0.113.7",
+		LastSeen: now.Add(-30 * time.Second),
+	}
+
+	if s.Stale("203.0.113.7", now) {
+		t.Error("Expected session seen recently from the same IP not to be stale")
+	}
+
+	if !s.Stale("198.51.100.1", now) {
+		t.Error("Expected session seen from another IP to be stale")
+	}
+
+	if !s.Stale("203.0.113.7", now.Add(TouchInterval)) {
+		t.Error("Expected session not seen for a while to be stale")
+	}
+}
+
+func TestDevice(t *testing.T) {
+	var cases = []struct {
+		ua   string
+		want string
+	}{
+		{"", "Unknown device"},
+		{"curl/7.54.0", "curl"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.14; rv:62.0) Gecko/20100101 Firefox/62.0", "Firefox on macOS"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/69.0.3497.100 Safari/537.36", "Chrome on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/64.0.3282.140 Safari/537.36 Edge/17.17134", "Edge on Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 12_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/12.0 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Linux; Android 9; Pixel 2) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/69.0.3497.100 Mobile Safari/537.36", "Chrome on Android"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:62.0) Gecko/20100101 Firefox/62.0", "Firefox on Linux"},
+		{"Lynx/2.8.9rel.1", "Unknown browser"},
+	}
+
+	for _, c := range cases {
+		if got := (Session{UserAgent: c.ua}).Device(); got != c.want {
+			t.Errorf("Expected device of %q to be %q, got %q instead", c.ua, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/henvic/climetrics/archive"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/config"
+)
+
+func init() {
+	Register(&Command{
+		Name:    "archive",
+		Summary: "Archive old metrics to compressed files, and restore them",
+		Subcommands: []*Command{
+			{
+				Name: "metrics",
+				Summary: `Archive the months of metrics synced before -before to files (one per month), verify them,
+and delete the archived metrics from the database`,
+				Flags:    archiveMetricsFlags,
+				Database: true,
+				Output:   true,
+				Run:      archiveMetrics,
+			},
+			{
+				Name:    "list",
+				Summary: "List the archive files on the manifest",
+				Flags:   archiveFlags,
+				Output:  true,
+				Run:     archiveList,
+			},
+			{
+				Name:    "verify",
+				Args:    "[file ...]",
+				Summary: "Verify the checksum and rows of the archive files (default: all of them)",
+				Flags:   archiveFlags,
+				Output:  true,
+				Run:     archiveVerify,
+			},
+			{
+				Name:     "restore",
+				Args:     "file",
+				Summary:  "Restore the metrics of an archive file to the database (the file is kept, but no longer read with the metrics)",
+				Flags:    archiveFlags,
+				Database: true,
+				Output:   true,
+				Run:      archiveRestore,
+			},
+		},
+	})
+}
+
+var (
+	archiveBefore string
+	archiveDryRun bool
+)
+
+func archiveFlags(c *config.Config) {
+	c.Archive(archive.Default)
+	c.Own("archive")
+}
+
+func archiveMetricsFlags(c *config.Config) {
+	archiveFlags(c)
+
+	var flags = c.FlagSet()
+	flags.StringVar(&archiveBefore, "before", "", "Archive the months ending before a duration (i.e., 8760h) ago, date, or RFC 3339 timestamp (required)")
+	flags.BoolVar(&archiveDryRun, "dry-run", false, "Count the metrics of each month without archiving them")
+}
+
+func archiveMetrics(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	before, err := parseTime("before", archiveBefore, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	if before.IsZero() {
+		return errors.New("missing -before: use a duration (i.e., 8760h) ago, date, or RFC 3339 timestamp")
+	}
+
+	r, err := archive.Default.Archive(ctx, before, archiveDryRun)
+
+	if !r.DryRun && r.Rows != 0 {
+		record(ctx, audit.MetricsArchive, "", r.Details(), "archive metrics")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return output(r, func(w io.Writer) error {
+		if len(r.Files) == 0 {
+			_, err := fmt.Fprintf(w, "No metrics synced before %s.\n", r.Before.Format("2006-01-02"))
+			return err
+		}
+
+		err := table(w, "MONTH\tFILE\tROWS\tDELETED", func(w io.Writer) {
+			for _, f := range r.Files {
+				var name = f.Name
+
+				if name == "" {
+					name = "-"
+				}
+
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", f.From.Format("2006-01"), name, f.Rows, f.Deleted)
+			}
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if r.DryRun {
+			_, err = fmt.Fprintf(w, "Would archive %d metrics.\n", r.Rows)
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "Archived %d metrics, deleting %d from the database.\n", r.Rows, r.Deleted)
+		return err
+	})
+}
+
+func archiveList(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	m, err := archive.Default.Manifest()
+
+	if err != nil {
+		return err
+	}
+
+	return output(m.Files, func(w io.Writer) error {
+		if len(m.Files) == 0 {
+			_, err := fmt.Fprintf(w, "No archive files on %s.\n", archive.Default.Dir)
+			return err
+		}
+
+		return table(w, "FILE\tFROM\tTO\tROWS\tSIZE\tRESTORED", func(w io.Writer) {
+			for _, f := range m.Files {
+				var restored = "-"
+
+				if f.Restored != nil {
+					restored = f.Restored.Format("2006-01-02")
+				}
+
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", f.Name, f.From.Format("2006-01-02"), f.To.Format("2006-01-02"),
+					f.Rows, humanize.Bytes(uint64(f.Size)), restored)
+			}
+		})
+	})
+}
+
+// verified archive file.
+type verified struct {
+	File  string `json:"file"`
+	Error string `json:"error,omitempty"`
+}
+
+func archiveVerify(ctx context.Context, args []string) error {
+	m, err := archive.Default.Manifest()
+
+	if err != nil {
+		return err
+	}
+
+	var names = args
+
+	if len(names) == 0 {
+		for _, f := range m.Files {
+			names = append(names, f.Name)
+		}
+	}
+
+	var vs = []verified{}
+	var failed int
+
+	for _, name := range names {
+		var v = verified{File: name}
+
+		switch f := m.File(name); {
+		case f == nil:
+			v.Error = "not on the manifest"
+		default:
+			if err := archive.Default.Verify(ctx, f); err != nil {
+				v.Error = err.Error()
+			}
+		}
+
+		if v.Error != "" {
+			failed++
+		}
+
+		vs = append(vs, v)
+	}
+
+	err = output(vs, func(w io.Writer) error {
+		for _, v := range vs {
+			var status = "ok"
+
+			if v.Error != "" {
+				status = v.Error
+			}
+
+			if _, err := fmt.Fprintf(w, "%s: %s\n", v.File, status); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil && failed != 0 {
+		err = fmt.Errorf("%d of %d archive files failed verification", failed, len(vs))
+	}
+
+	return err
+}
+
+func archiveRestore(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 1); err != nil {
+		return err
+	}
+
+	// the file can be given by its path
+	r, err := archive.Default.Restore(ctx, filepath.Base(args[0]))
+
+	if r.Added != 0 {
+		record(ctx, audit.MetricsRestore, r.File, fmt.Sprintf("added=%d noop=%d error=%d", r.Added, r.Noop, r.Error), "archive restore")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return output(r, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "Restored %s: %d added, %d noop, %d errors.\n", r.File, r.Added, r.Noop, r.Error)
+		return err
+	})
+}
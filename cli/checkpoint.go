@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpoint of a run, allowing an interrupted run to continue where it left off.
+type checkpoint struct {
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+
+	// Pending IPs, in order.
+	Pending []string `json:"pending"`
+
+	// Failed IPs are skipped when resuming, and retried on the next run.
+	Failed []string `json:"failed,omitempty"`
+}
+
+func loadCheckpoint(filename string) (c *checkpoint, err error) {
+	c = &checkpoint{}
+
+	if found, err := readJSONFile(filename, c); !found || err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// save checkpoint atomically.
+func (c *checkpoint) save(filename string) error {
+	c.Updated = time.Now()
+	return writeJSONFile(filename, c)
+}
+
+// readJSONFile into v, if the file exists.
+func readJSONFile(filename string, v interface{}) (found bool, err error) {
+	b, err := ioutil.ReadFile(filename)
+
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, json.Unmarshal(b, v)
+}
+
+// writeJSONFile atomically.
+func writeJSONFile(filename string, v interface{}) error {
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+func removeCheckpoint(filename string) error {
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
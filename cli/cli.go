@@ -0,0 +1,242 @@
+// Package cli implements the subcommands of the climetrics command-line interface,
+// such as "climetrics users add" and "climetrics geoip fix".
+//
+// Every command reads its settings with package config, so they share the configuration file,
+// environment variables, and the database setup of the server.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/ctxsignal"
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// Name of the program.
+const Name = "climetrics"
+
+// Command of the CLI.
+type Command struct {
+	Name    string
+	Args    string
+	Summary string
+
+	// Subcommands of a group of commands (i.e., users list).
+	Subcommands []*Command
+
+	// Flags registers the settings and flags of the command.
+	Flags func(c *config.Config)
+
+	// Database connection is loaded before running the command.
+	Database bool
+
+	// Output of results, either as text or JSON (with the -json flag).
+	Output bool
+
+	// Prompts for missing input on a terminal, unless the -non-interactive flag is used.
+	Prompts bool
+
+	// Run the command with the remaining arguments.
+	Run func(ctx context.Context, args []string) error
+}
+
+// Commands of the CLI.
+var Commands []*Command
+
+// Default command, used when no command is given (i.e., climetrics -addr :8080).
+var Default = "serve"
+
+// Register command.
+func Register(c *Command) {
+	Commands = append(Commands, c)
+
+	sort.Slice(Commands, func(i, j int) bool {
+		return Commands[i].Name < Commands[j].Name
+	})
+}
+
+// stdout, stderr, and stdin are replaceable for testing.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+	stdin  io.Reader = os.Stdin
+)
+
+var (
+	dsn            string
+	jsonOutput     bool
+	nonInteractive bool
+)
+
+// find the command for the arguments, returning its path and the remaining arguments.
+func find(commands []*Command, args []string) (path []*Command, rest []string) {
+	for len(args) != 0 {
+		var found *Command
+
+		for _, c := range commands {
+			if c.Name == args[0] {
+				found = c
+			}
+		}
+
+		if found == nil {
+			break
+		}
+
+		path = append(path, found)
+		commands = found.Subcommands
+		args = args[1:]
+	}
+
+	return path, args
+}
+
+func fullName(path []*Command) string {
+	var names = []string{Name}
+
+	for _, c := range path {
+		names = append(names, c.Name)
+	}
+
+	return strings.Join(names, " ")
+}
+
+// Main runs the command for the arguments (without the program name).
+func Main(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+		args = append([]string{Default}, args...)
+	}
+
+	path, rest := find(Commands, args)
+
+	if len(path) == 0 {
+		usage(stderr, nil, Commands)
+
+		if len(args) != 0 && !strings.HasPrefix(args[0], "-") && args[0] != "help" {
+			return fmt.Errorf(`unknown command "%s"`, args[0])
+		}
+
+		return nil
+	}
+
+	var cmd = path[len(path)-1]
+
+	if cmd.Run == nil {
+		usage(stderr, path, cmd.Subcommands)
+
+		if len(rest) != 0 && !strings.HasPrefix(rest[0], "-") {
+			return fmt.Errorf(`unknown command "%s %s"`, fullName(path), rest[0])
+		}
+
+		return errors.New("missing command")
+	}
+
+	var conf = config.New(fullName(path))
+	var flags = conf.FlagSet()
+
+	flags.Usage = func() {
+		var w = flags.Output()
+		_, _ = fmt.Fprintf(w, "Usage: %s [flags] %s\n\n%s\n\nFlags:\n", fullName(path), cmd.Args, cmd.Summary)
+		conf.PrintDefaults()
+	}
+
+	if cmd.Database {
+		conf.Database(&dsn)
+	}
+
+	if cmd.Output {
+		flags.BoolVar(&jsonOutput, "json", false, "Print results as JSON")
+	}
+
+	if cmd.Prompts {
+		flags.BoolVar(&nonInteractive, "non-interactive", false, "Never prompt for input (the default when the standard input isn't a terminal)")
+	}
+
+	if cmd.Flags != nil {
+		cmd.Flags(conf)
+	}
+
+	if err := conf.Load(rest); err != nil {
+		return err
+	}
+
+	if ok, err := conf.Command(stdout); ok {
+		return err
+	}
+
+	ctx, cancel := ctxsignal.WithTermination(context.Background())
+	defer cancel()
+
+	if cmd.Database {
+		if _, err := db.Load(ctx, dsn); err != nil {
+			return err
+		}
+	}
+
+	return cmd.Run(ctx, conf.Args())
+}
+
+func usage(w io.Writer, path []*Command, commands []*Command) {
+	_, _ = fmt.Fprintf(w, "Usage: %s <command> [flags] [arguments]\n\nCommands:\n", fullName(path))
+	var tw = tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	for _, c := range commands {
+		var name = c.Name
+
+		if c.Args != "" {
+			name += " " + c.Args
+		}
+
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\n", name, c.Summary)
+	}
+
+	_ = tw.Flush()
+	_, _ = fmt.Fprintf(w, "\nUse \"%s <command> -h\" for the flags of a command, and \"%s <command> config print\" for its effective configuration.\n",
+		fullName(path), fullName(path))
+}
+
+// output v as JSON, or as text with the given function.
+func output(v interface{}, text func(w io.Writer) error) error {
+	if !jsonOutput {
+		return text(stdout)
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "%s\n", b)
+	return err
+}
+
+// table writer for text output.
+func table(w io.Writer, header string, fn func(w io.Writer)) error {
+	var tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, header)
+	fn(tw)
+	return tw.Flush()
+}
+
+// exactArgs checks the number of arguments of a command.
+func exactArgs(args []string, n int) error {
+	switch {
+	case len(args) < n:
+		return errors.New("missing arguments")
+	case len(args) > n:
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args[n:], " "))
+	}
+
+	return nil
+}
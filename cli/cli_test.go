@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useIO replaces the standard input and outputs until restore is called.
+func useIO(in string) (out, errOut *bytes.Buffer, restore func()) {
+	out, errOut = &bytes.Buffer{}, &bytes.Buffer{}
+	var defaultStdout, defaultStderr, defaultStdin = stdout, stderr, stdin
+	stdout, stderr, stdin = out, errOut, strings.NewReader(in)
+	input = nil
+
+	return out, errOut, func() {
+		stdout, stderr, stdin = defaultStdout, defaultStderr, defaultStdin
+		input = nil
+	}
+}
+
+func TestFind(t *testing.T) {
+	path, rest := find(Commands, []string{"users", "edit", "-role", "admin", "alice"})
+
+	if len(path) != 2 || path[0].Name != "users" || path[1].Name != "edit" {
+		t.Fatalf("Expected users edit command, got %v instead", path)
+	}
+
+	if want := []string{"-role", "admin", "alice"}; !reflect.DeepEqual(rest, want) {
+		t.Errorf("Expected remaining arguments to be %v, got %v instead", want, rest)
+	}
+
+	if got := fullName(path); got != "climetrics users edit" {
+		t.Errorf(`Expected full name to be "climetrics users edit", got "%s" instead`, got)
+	}
+
+	if path, _ := find(Commands, []string{"unknown"}); len(path) != 0 {
+		t.Errorf("Expected no command to be found, got %v instead", path)
+	}
+}
+
+func TestMainUnknownCommand(t *testing.T) {
+	_, errOut, restore := useIO("")
+	defer restore()
+
+	if err := Main([]string{"unknown"}); err == nil || err.Error() != `unknown command "unknown"` {
+		t.Errorf("Expected unknown command error, got %v instead", err)
+	}
+
+	if !strings.Contains(errOut.String(), "Usage: climetrics <command>") {
+		t.Errorf("Expected usage to be printed, got %q instead", errOut.String())
+	}
+}
+
+func TestMainHelp(t *testing.T) {
+	_, errOut, restore := useIO("")
+	defer restore()
+
+	if err := Main([]string{"help"}); err != nil {
+		t.Errorf("Expected no error, got %v instead", err)
+	}
+
+	for _, c := range []string{"users", "migrate", "prune", "hash-password"} {
+		if !strings.Contains(errOut.String(), "  "+c) {
+			t.Errorf("Expected command %s to be listed, got %q instead", c, errOut.String())
+		}
+	}
+}
+
+func TestMainMissingSubcommand(t *testing.T) {
+	_, errOut, restore := useIO("")
+	defer restore()
+
+	if err := Main([]string{"users"}); err == nil || err.Error() != "missing command" {
+		t.Errorf("Expected missing command error, got %v instead", err)
+	}
+
+	if err := Main([]string{"users", "remove"}); err == nil || err.Error() != `unknown command "climetrics users remove"` {
+		t.Errorf("Expected unknown command error, got %v instead", err)
+	}
+
+	if !strings.Contains(errOut.String(), "Usage: climetrics users <command>") {
+		t.Errorf("Expected usage of users to be printed, got %q instead", errOut.String())
+	}
+}
+
+func TestMainHashPassword(t *testing.T) {
+	out, _, restore := useIO("secret\n")
+	defer restore()
+
+	if err := Main([]string{"hash-password"}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var hash = strings.TrimSpace(out.String())
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte("secret")); err != nil {
+		t.Errorf("Expected hash of the password, got %v instead", err)
+	}
+}
+
+func TestMainHashPasswordJSON(t *testing.T) {
+	out, _, restore := useIO("secret")
+	defer restore()
+
+	if err := Main([]string{"hash-password", "-json"}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var v map[string]string
+
+	if err := json.Unmarshal(out.Bytes(), &v); err != nil {
+		t.Fatalf("Expected JSON output, got %v instead", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(v["hash"]), []byte("secret")); err != nil {
+		t.Errorf("Expected hash of the password, got %v instead", err)
+	}
+}
+
+func TestMainHashPasswordMissing(t *testing.T) {
+	_, _, restore := useIO("")
+	defer restore()
+
+	if err := Main([]string{"hash-password"}); err == nil || err.Error() != "missing password on the standard input" {
+		t.Errorf("Expected missing password error, got %v instead", err)
+	}
+}
+
+func TestMainUnexpectedArguments(t *testing.T) {
+	_, _, restore := useIO("secret\n")
+	defer restore()
+
+	if err := Main([]string{"hash-password", "foo"}); err == nil || err.Error() != "unexpected arguments: foo" {
+		t.Errorf("Expected unexpected arguments error, got %v instead", err)
+	}
+}
+
+func TestMainDefault(t *testing.T) {
+	var ran []string
+
+	Register(&Command{
+		Name: "test-default",
+		Run: func(ctx context.Context, args []string) error {
+			ran = args
+			return nil
+		},
+	})
+
+	var defaultCommand = Default
+	Default = "test-default"
+
+	defer func() {
+		Default = defaultCommand
+
+		for i, c := range Commands {
+			if c.Name == "test-default" {
+				Commands = append(Commands[:i], Commands[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err := Main([]string{"--", "foo"}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if want := []string{"foo"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("Expected default command to run with %v, got %v instead", want, ran)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	_, _, restore := useIO("alice\n")
+	defer restore()
+
+	if _, err := prompt("Username"); err == nil {
+		t.Errorf("Expected error prompting without a terminal, got nil instead")
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	_, _, restore := useIO("first\r\nsecond")
+	defer restore()
+
+	for _, want := range []string{"first", "second"} {
+		if got, err := readPassword("Password"); err != nil || got != want {
+			t.Errorf(`Expected password "%s", got "%s" (error: %v) instead`, want, got, err)
+		}
+	}
+}
+
+func TestExactArgs(t *testing.T) {
+	var cases = []struct {
+		args []string
+		n    int
+		want string
+	}{
+		{nil, 0, ""},
+		{[]string{"a"}, 1, ""},
+		{nil, 1, "missing arguments"},
+		{[]string{"a", "b", "c"}, 1, "unexpected arguments: b c"},
+	}
+
+	for _, c := range cases {
+		var got string
+
+		if err := exactArgs(c.args, c.n); err != nil {
+			got = err.Error()
+		}
+
+		if got != c.want {
+			t.Errorf(`Expected error "%s" for %v, got "%s" instead`, c.want, c.args, got)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	out, _, restore := useIO("")
+	defer restore()
+	var v = map[string]int{"sessions": 2}
+
+	var text = func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, "text")
+		return err
+	}
+
+	if err := output(v, text); err != nil || out.String() != "text\n" {
+		t.Errorf(`Expected text output, got %q (error: %v) instead`, out.String(), err)
+	}
+
+	out.Reset()
+	jsonOutput = true
+	defer func() { jsonOutput = false }()
+
+	if err := output(v, text); err != nil || out.String() != "{\"sessions\":2}\n" {
+		t.Errorf(`Expected JSON output, got %q (error: %v) instead`, out.String(), err)
+	}
+}
+
+func TestTrackedString(t *testing.T) {
+	var v string
+	var ts = trackedString{&v, "username"}
+	delete(userFlagsSet, "username")
+
+	if err := ts.Set("alice"); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if v != "alice" || ts.String() != "alice" || !userFlagsSet["username"] {
+		t.Errorf("Expected username flag to be set to alice, got %q (set: %v) instead", v, userFlagsSet["username"])
+	}
+
+	if (trackedString{}).String() != "" {
+		t.Errorf("Expected zero value to be empty")
+	}
+}
+
+func TestMigrateNumber(t *testing.T) {
+	if v, err := migrateNumber(nil, "steps", 1); v != 1 || err != nil {
+		t.Errorf("Expected default value 1, got %d (error: %v) instead", v, err)
+	}
+
+	if v, err := migrateNumber([]string{"3"}, "steps", 1); v != 3 || err != nil {
+		t.Errorf("Expected 3, got %d (error: %v) instead", v, err)
+	}
+
+	for _, args := range [][]string{{"0"}, {"x"}, {"1", "2"}} {
+		if _, err := migrateNumber(args, "steps", 1); err == nil {
+			t.Errorf("Expected error for %v, got nil instead", args)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+	var cases = map[string]time.Time{
+		"":                     {},
+		"72h":                  now.Add(-72 * time.Hour),
+		"2018-10-01":           time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC),
+		"2018-10-01T10:00:00Z": time.Date(2018, 10, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	for value, want := range cases {
+		if got, err := parseTime("since", value, now); err != nil || !got.Equal(want) {
+			t.Errorf(`Expected "%s" to be %v, got %v (error: %v) instead`, value, want, got, err)
+		}
+	}
+
+	if _, err := parseTime("since", "yesterday", now); err == nil ||
+		err.Error() != `invalid -since value "yesterday": use a duration (i.e., 72h), date, or RFC 3339 timestamp` {
+		t.Errorf("Expected error for invalid value, got %v instead", err)
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	var got = intersect([]string{"1.1.1.1", "2.2.2.2", "3.3.3.3"}, []string{"3.3.3.3", "1.1.1.1"})
+
+	if want := []string{"1.1.1.1", "3.3.3.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v instead", want, got)
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/henvic/climetrics/archive"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/diagnostics"
+	"github.com/henvic/climetrics/export"
+	"github.com/henvic/climetrics/metrics"
+)
+
+func init() {
+	Register(&Command{
+		Name:     "export",
+		Args:     "metrics|diagnostics",
+		Summary:  "Export metrics or diagnostics as CSV, newline-delimited JSON, or Parquet, from the newest to the oldest",
+		Flags:    exportFlags,
+		Database: true,
+		Run:      runExport,
+	})
+}
+
+// exportValues of the flags of export.
+type exportValues struct {
+	filter    string
+	file      string
+	format    string
+	columns   string
+	anonymize bool
+	archive   bool
+}
+
+var exportFlagValues exportValues
+
+func exportFlags(c *config.Config) {
+	var flags = c.FlagSet()
+	flags.StringVar(&exportFlagValues.filter, "filter", "", `Filter, as on the query string of the pages (i.e., "type=login&version=1.0")`)
+	flags.StringVar(&exportFlagValues.file, "o", "", "Output file (default: standard output)")
+	flags.StringVar(&exportFlagValues.format, "format", "", "Format: csv, ndjson, or parquet (default: from the output file extension, or ndjson)")
+	flags.StringVar(&exportFlagValues.columns, "columns", "", "Comma-separated columns to export, in order (default: all)")
+	flags.BoolVar(&exportFlagValues.anonymize, "anonymize", false, "Keep only the network of IP addresses, and replace session IDs and usernames with pseudonyms")
+	flags.BoolVar(&exportFlagValues.archive, "include-archive", false, "Include the archived metrics, after the ones on the database")
+	c.Archive(archive.Default)
+}
+
+// exportOptions from the flags.
+func exportOptions(v exportValues, d *export.Dataset) (o export.Options, err error) {
+	var format = v.format
+
+	if format == "" {
+		switch ext := strings.TrimPrefix(filepath.Ext(v.file), "."); ext {
+		case string(export.CSV), string(export.Parquet):
+			format = ext
+		default:
+			format = string(export.NDJSON)
+		}
+	}
+
+	var query = url.Values{
+		"format":  []string{format},
+		"columns": []string{v.columns},
+	}
+
+	if v.anonymize {
+		query.Set("anonymize", "1")
+	}
+
+	return export.ParseOptions(d, query)
+}
+
+func runExport(ctx context.Context, args []string) (err error) {
+	if err = exactArgs(args, 1); err != nil {
+		return err
+	}
+
+	var v = exportFlagValues
+	query, err := url.ParseQuery(v.filter)
+
+	if err != nil {
+		return fmt.Errorf("invalid filter: %v", err)
+	}
+
+	var d *export.Dataset
+	var action audit.Action
+	var write func(ew *export.Writer) error
+
+	if v.archive && args[0] == "metrics" {
+		query.Set("archive", "1")
+	}
+
+	switch args[0] {
+	case "metrics":
+		f, err := metrics.ParseFilter(query)
+
+		if err != nil {
+			return err
+		}
+
+		d, action = export.Metrics, audit.MetricsExport
+		write = func(ew *export.Writer) error {
+			return export.WriteMetrics(ctx, ew, f)
+		}
+	case "diagnostics":
+		f, err := diagnostics.ParseFilter(query)
+
+		if err != nil {
+			return err
+		}
+
+		d, action = export.Diagnostics, audit.DiagnosticsExport
+		write = func(ew *export.Writer) error {
+			return export.WriteDiagnostics(ctx, ew, f)
+		}
+	default:
+		return fmt.Errorf(`can't export "%s": use metrics or diagnostics`, args[0])
+	}
+
+	o, err := exportOptions(v, d)
+
+	if err != nil {
+		return err
+	}
+
+	var w = stdout
+
+	if v.file != "" {
+		f, err := os.Create(v.file)
+
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+
+		w = f
+	}
+
+	ew, err := export.NewWriter(w, d, o)
+
+	if err != nil {
+		return err
+	}
+
+	var details = o.Query()
+
+	if v.filter != "" {
+		details.Set("filter", v.filter)
+	}
+
+	record(ctx, action, "", details.Encode(), "export "+args[0])
+
+	if err = write(ew); err == nil {
+		err = ew.Close()
+	}
+
+	_, _ = fmt.Fprintf(stderr, "Exported %d %s.\n", ew.Count(), args[0])
+	return err
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/henvic/climetrics/export"
+)
+
+func TestExportOptions(t *testing.T) {
+	var cases = []struct {
+		file   string
+		format string
+		want   export.Format
+	}{
+		{"", "", export.NDJSON},
+		{"metrics.csv", "", export.CSV},
+		{"metrics.parquet", "", export.Parquet},
+		{"metrics.json", "", export.NDJSON},
+		{"metrics.csv", "ndjson", export.NDJSON},
+	}
+
+	for _, c := range cases {
+		var v = exportValues{file: c.file, format: c.format}
+
+		if o, err := exportOptions(v, export.Metrics); err != nil || o.Format != c.want {
+			t.Errorf("Expected format %v for %q and %q, got %v (error: %v) instead", c.want, c.file, c.format, o.Format, err)
+		}
+	}
+}
+
+func TestExportOptionsColumns(t *testing.T) {
+	var v = exportValues{format: "csv", columns: "id,username", anonymize: true}
+	o, err := exportOptions(v, export.Diagnostics)
+
+	var want = export.Options{
+		Format:    export.CSV,
+		Columns:   []string{"id", "username"},
+		Anonymize: true,
+	}
+
+	if err != nil || !reflect.DeepEqual(o, want) {
+		t.Errorf("Expected options to be %+v, got %+v (error: %v) instead", want, o, err)
+	}
+
+	if _, err = exportOptions(v, export.Metrics); err == nil {
+		t.Errorf("Expected unknown column error, got nil instead")
+	}
+}
@@ -0,0 +1,400 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/geolocation"
+	"github.com/henvic/climetrics/metrics"
+	"github.com/henvic/ctxsignal"
+)
+
+func init() {
+	Register(&Command{
+		Name:    "geoip",
+		Summary: "Manage geolocation information",
+		Subcommands: []*Command{
+			{
+				Name:    "fix",
+				Summary: "Add missing geolocation information to metrics, printing a JSON summary (use it regularly, i.e., on a crontab)",
+				Flags:   geoipFixFlags,
+				Run:     geoipFix,
+			},
+		},
+	})
+}
+
+var (
+	fixOptions geolocation.Options
+
+	fixConcurrency int
+	fixRPS         float64
+	fixMax         int
+	fixDryRun      bool
+	fixSince       string
+	fixCheckpoint  string
+	fixRestart     bool
+)
+
+// summary of the run, printed as JSON on the standard output for monitoring.
+type summary struct {
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	DryRun   bool      `json:"dry_run"`
+	Resumed  bool      `json:"resumed"`
+
+	// Selected IPs for this run (after -max).
+	Selected  int `json:"selected"`
+	Processed int `json:"processed"`
+	Resolved  int `json:"resolved"`
+	NotFound  int `json:"not_found"`
+	Failed    int `json:"failed"`
+
+	// Remaining IPs on the checkpoint, to be processed on the next run.
+	Remaining int `json:"remaining"`
+
+	// Updated metrics.
+	Updated int64 `json:"updated"`
+
+	Interrupted      bool       `json:"interrupted"`
+	RateLimitedUntil *time.Time `json:"rate_limited_until,omitempty"`
+	Error            string     `json:"error,omitempty"`
+}
+
+func fixSetup(ctx context.Context) error {
+	gp, err := geolocation.Parse(fixOptions)
+
+	if err != nil {
+		return err
+	}
+
+	geolocation.Use(gp)
+
+	_, err = db.Load(ctx, dsn)
+	return err
+}
+
+// parseTime of a flag: a duration (i.e., 72h) ago, a date, or a RFC 3339 timestamp.
+func parseTime(flag, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(`invalid -%s value "%s": use a duration (i.e., 72h), date, or RFC 3339 timestamp`, flag, value)
+}
+
+func geoipFix(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	var s = summary{
+		Started: time.Now(),
+		DryRun:  fixDryRun,
+	}
+
+	err := fix(ctx, &s)
+
+	if err != nil {
+		s.Error = err.Error()
+	}
+
+	s.Duration = time.Since(s.Started).Round(time.Millisecond).String()
+
+	b, _ := json.Marshal(s)
+	_, _ = fmt.Fprintf(stdout, "%s\n", b)
+	return err
+}
+
+func fix(ctx context.Context, s *summary) error {
+	if fixConcurrency < 1 {
+		return errors.New("-concurrency must be at least 1")
+	}
+
+	sinceTime, err := parseTime("since", fixSince, s.Started)
+
+	if err != nil {
+		return err
+	}
+
+	if err = fixSetup(ctx); err != nil {
+		return err
+	}
+
+	missing, err := metrics.MissingGeolocation(ctx, sinceTime)
+
+	if err != nil {
+		return err
+	}
+
+	c, resumed, err := resume(missing)
+
+	if err != nil {
+		return err
+	}
+
+	s.Resumed = resumed
+
+	var selected = c.Pending
+
+	if fixMax > 0 && len(selected) > fixMax {
+		selected = selected[:fixMax]
+	}
+
+	s.Selected = len(selected)
+
+	if fixDryRun {
+		for _, ip := range selected {
+			_, _ = fmt.Fprintf(stderr, "would add geolocation for IP %s\n", ip)
+		}
+
+		s.Remaining = len(c.Pending)
+		return nil
+	}
+
+	var r = &runner{
+		checkpoint: c,
+		summary:    s,
+		done:       map[string]bool{},
+	}
+
+	err = r.run(ctx, selected)
+
+	if _, serr := ctxsignal.Closed(ctx); serr == nil {
+		s.Interrupted = true
+	}
+
+	if cerr := r.save(true); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	switch {
+	case err != nil:
+		return err
+	case s.Interrupted:
+		return errors.New("interrupted")
+	case s.RateLimitedUntil != nil:
+		return fmt.Errorf("geolocation requests are paused until %v", s.RateLimitedUntil.Format(time.RFC3339))
+	case s.Failed != 0:
+		return fmt.Errorf("failed to gather geolocation information for %d IPs", s.Failed)
+	}
+
+	return nil
+}
+
+// resume checkpoint, if any, keeping only the IPs still missing geolocation.
+// A new checkpoint is created otherwise.
+func resume(missing []string) (c *checkpoint, resumed bool, err error) {
+	if !fixRestart {
+		if c, err = loadCheckpoint(fixCheckpoint); err != nil {
+			return nil, false, fmt.Errorf("can't load checkpoint (use -restart to ignore it): %v", err)
+		}
+	}
+
+	if c != nil {
+		c.Pending = intersect(c.Pending, missing)
+	}
+
+	if c == nil || len(c.Pending) == 0 {
+		return &checkpoint{
+			Started: time.Now(),
+			Pending: missing,
+		}, false, nil
+	}
+
+	return c, true, nil
+}
+
+// intersect returns the IPs also on the missing list, keeping their order.
+func intersect(ips, missing []string) []string {
+	var isMissing = map[string]bool{}
+
+	for _, ip := range missing {
+		isMissing[ip] = true
+	}
+
+	var pending []string
+
+	for _, ip := range ips {
+		if isMissing[ip] {
+			pending = append(pending, ip)
+		}
+	}
+
+	return pending
+}
+
+type runner struct {
+	checkpoint *checkpoint
+	summary    *summary
+
+	done     map[string]bool
+	lastSave time.Time
+	m        sync.Mutex
+}
+
+func (r *runner) run(ctx context.Context, ips []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var jobs = make(chan string)
+	var wg sync.WaitGroup
+	var tick <-chan time.Time
+
+	if fixRPS > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / fixRPS))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for i := 0; i < fixConcurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for ip := range jobs {
+				r.process(ctx, cancel, ip)
+			}
+		}()
+	}
+
+	var err error
+
+produce:
+	for _, ip := range ips {
+		if tick != nil {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				break produce
+			}
+		}
+
+		select {
+		case jobs <- ip:
+		case <-ctx.Done():
+			break produce
+		}
+
+		if err = r.save(false); err != nil {
+			cancel()
+			break
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	return err
+}
+
+func (r *runner) process(ctx context.Context, cancel context.CancelFunc, ip string) {
+	updated, err := metrics.AddGeolocationIP(ctx, ip)
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err != nil && ctx.Err() != nil {
+		// interrupted: keep IP pending.
+		return
+	}
+
+	r.summary.Processed++
+	r.done[ip] = true
+
+	switch e := err.(type) {
+	case nil:
+		r.summary.Resolved++
+		r.summary.Updated += updated
+		_, _ = fmt.Fprintf(stderr, "[%d/%d] %d entries updated with IP %s\n",
+			r.summary.Processed, r.summary.Selected, updated, ip)
+		return
+	case *geolocation.RateLimitError:
+		// no reason to keep trying: keep IP pending and stop.
+		r.summary.Processed--
+		delete(r.done, ip)
+
+		if r.summary.RateLimitedUntil == nil {
+			r.summary.RateLimitedUntil = &e.Until
+			_, _ = fmt.Fprintf(stderr, "stopping: %v\n", err)
+		}
+
+		cancel()
+		return
+	}
+
+	if err == geolocation.ErrNotFound {
+		r.summary.NotFound++
+	}
+
+	r.summary.Failed++
+	r.checkpoint.Failed = append(r.checkpoint.Failed, ip)
+	_, _ = fmt.Fprintf(stderr, "[%d/%d] cannot find geolocation for IP %s: %v\n",
+		r.summary.Processed, r.summary.Selected, ip, err)
+}
+
+// save checkpoint at most once a second, unless forced.
+// The checkpoint is removed once every IP is processed.
+func (r *runner) save(force bool) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !force && time.Since(r.lastSave) < time.Second {
+		return nil
+	}
+
+	r.lastSave = time.Now()
+
+	var pending = []string{}
+
+	for _, ip := range r.checkpoint.Pending {
+		if !r.done[ip] {
+			pending = append(pending, ip)
+		}
+	}
+
+	r.checkpoint.Pending = pending
+	r.done = map[string]bool{}
+	r.summary.Remaining = len(pending)
+
+	if len(pending) == 0 {
+		return removeCheckpoint(fixCheckpoint)
+	}
+
+	return r.checkpoint.save(fixCheckpoint)
+}
+
+func geoipFixFlags(c *config.Config) {
+	c.Database(&dsn)
+	c.Geolocation(&fixOptions)
+	c.Int(&fixConcurrency, "concurrency", "fixgeoip.concurrency", 4, "Number of IPs processed concurrently")
+	c.Float64(&fixRPS, "rps", "fixgeoip.rps", 0, "Maximum number of IPs processed per second (0 for unlimited)")
+	c.Int(&fixMax, "max", "fixgeoip.max", 0, "Maximum number of IPs processed on this run (0 for unlimited)")
+	c.String(&fixCheckpoint, "checkpoint", "fixgeoip.checkpoint", filepath.Join(os.TempDir(), "climetrics-fixgeoip.json"), "Checkpoint file used to resume interrupted runs")
+
+	c.Own("fixgeoip")
+
+	// options of a single run aren't settings
+	var flags = c.FlagSet()
+	flags.BoolVar(&fixDryRun, "dry-run", false, "List the IPs that would be processed, without changing anything")
+	flags.StringVar(&fixSince, "since", "", "Only process metrics synced since a duration ago (i.e., 72h), date, or RFC 3339 timestamp")
+	flags.BoolVar(&fixRestart, "restart", false, "Ignore any existing checkpoint")
+}
@@ -0,0 +1,599 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/metrics"
+	"github.com/henvic/ctxsignal"
+	uuid "github.com/satori/go.uuid"
+)
+
+func init() {
+	Register(&Command{
+		Name:    "metrics",
+		Summary: "Manage metrics",
+		Subcommands: append([]*Command{
+			{
+				Name:     "import",
+				Args:     "[file ...]",
+				Summary:  "Import metrics from NDJSON files (optionally gzipped) on the format of /metrics/bulk, printing a JSON summary",
+				Flags:    metricsImportFlags,
+				Database: true,
+				Run:      metricsImport,
+			},
+		}, partitionCommands...),
+	})
+}
+
+// replayValues of the flags of metrics import.
+type replayValues struct {
+	syncIP      string
+	syncTime    string
+	requestID   string
+	concurrency int
+	checkpoint  string
+	restart     bool
+	dryRun      bool
+	progress    time.Duration
+}
+
+var replay replayValues
+
+func metricsImportFlags(c *config.Config) {
+	c.Int(&replay.concurrency, "concurrency", "import.concurrency", 4, "Number of metrics saved concurrently")
+	c.String(&replay.checkpoint, "checkpoint", "import.checkpoint", filepath.Join(os.TempDir(), "climetrics-import.json"), "Checkpoint file used to resume interrupted runs")
+	c.Duration(&replay.progress, "progress", "import.progress", 10*time.Second, "Interval between progress reports on the standard error (0 to disable)")
+
+	c.Own("import")
+
+	// options of a single run aren't settings
+	var flags = c.FlagSet()
+	flags.StringVar(&replay.syncIP, "sync-ip", "", "Override the IP the metrics were synced from (required for metrics without one)")
+	flags.StringVar(&replay.syncTime, "sync-time", "", `Override the time the metrics were synced: a duration (i.e., 72h) ago, date, RFC 3339 timestamp, or "time" for the time of each metric (default: from the file, or now)`)
+	flags.StringVar(&replay.requestID, "request-id", "", "Override the request ID (default: from the file, or a new one for each file)")
+	flags.BoolVar(&replay.restart, "restart", false, "Ignore any existing checkpoint")
+	flags.BoolVar(&replay.dryRun, "dry-run", false, "Validate the metrics without saving them")
+}
+
+// importSummary of the run, printed as JSON on the standard output for monitoring.
+type importSummary struct {
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	DryRun   bool      `json:"dry_run"`
+
+	Files []*importedFile `json:"files"`
+
+	Added int `json:"added"`
+	Noop  int `json:"noop"`
+	Error int `json:"error"`
+
+	Interrupted bool   `json:"interrupted"`
+	Failure     string `json:"failure,omitempty"`
+}
+
+// importedFile stats, including lines imported by previous runs.
+type importedFile struct {
+	File string `json:"file"`
+
+	metrics.BulkStats
+
+	// Lines read.
+	Lines int `json:"lines"`
+
+	// Skipped lines, imported by a previous run.
+	Skipped int `json:"skipped"`
+
+	Done bool `json:"done"`
+}
+
+// importCheckpoint of the files of a run, by absolute path.
+type importCheckpoint struct {
+	Started time.Time                        `json:"started"`
+	Updated time.Time                        `json:"updated"`
+	Files   map[string]*importFileCheckpoint `json:"files"`
+}
+
+type importFileCheckpoint struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+
+	// Lines processed, in order.
+	Lines int  `json:"lines"`
+	Done  bool `json:"done"`
+
+	Stats metrics.BulkStats `json:"stats"`
+}
+
+func metricsImport(ctx context.Context, args []string) error {
+	var s = importSummary{
+		Started: time.Now(),
+		DryRun:  replay.dryRun,
+		Files:   []*importedFile{},
+	}
+
+	err := importFiles(ctx, replay, args, &s)
+
+	for _, f := range s.Files {
+		s.Added += f.Added
+		s.Noop += f.Noop
+		s.Error += f.Error
+	}
+
+	if _, serr := ctxsignal.Closed(ctx); serr == nil {
+		s.Interrupted = true
+	}
+
+	switch {
+	case err != nil:
+	case s.Interrupted:
+		err = errors.New("interrupted")
+	case s.Error != 0:
+		err = fmt.Errorf("failed to import %d metrics", s.Error)
+	}
+
+	if err != nil {
+		s.Failure = err.Error()
+	}
+
+	s.Duration = time.Since(s.Started).Round(time.Millisecond).String()
+
+	b, _ := json.Marshal(s)
+	_, _ = fmt.Fprintf(stdout, "%s\n", b)
+	return err
+}
+
+// replayOptions overriding the sync IP, time, and request ID of the metrics.
+type replayOptions struct {
+	SyncIP    string
+	SyncTime  string
+	RequestID string
+
+	// EventTime is used as the sync time.
+	EventTime bool
+}
+
+func parseReplayOptions(r replayValues, now time.Time) (o replayOptions, err error) {
+	if r.concurrency < 1 {
+		return o, errors.New("-concurrency must be at least 1")
+	}
+
+	if r.syncIP != "" && net.ParseIP(r.syncIP) == nil {
+		return o, fmt.Errorf(`invalid -sync-ip value "%s"`, r.syncIP)
+	}
+
+	if r.requestID != "" {
+		if _, err := uuid.FromString(r.requestID); err != nil {
+			return o, fmt.Errorf(`invalid -request-id value "%s": use an UUID`, r.requestID)
+		}
+	}
+
+	o.SyncIP = r.syncIP
+	o.RequestID = r.requestID
+
+	switch r.syncTime {
+	case "":
+	case "time":
+		o.EventTime = true
+	default:
+		t, err := parseTime("sync-time", r.syncTime, now)
+
+		if err != nil {
+			return o, err
+		}
+
+		o.SyncTime = t.Format(time.RFC3339Nano)
+	}
+
+	return o, nil
+}
+
+// override metric values, using the default request ID if the metric has none.
+func (o replayOptions) override(m *metrics.Metric, requestID string) {
+	if o.SyncIP != "" {
+		m.SyncIP = o.SyncIP
+	}
+
+	switch {
+	case o.EventTime:
+		// an invalid timestamp is rejected when the metric is saved
+		if ts, err := time.Parse(time.RubyDate, m.Timestamp); err == nil {
+			m.SyncTime = ts.Format(time.RFC3339Nano)
+		}
+	case o.SyncTime != "":
+		m.SyncTime = o.SyncTime
+	}
+
+	switch {
+	case o.RequestID != "":
+		m.RequestID = o.RequestID
+	case m.RequestID == "":
+		m.RequestID = requestID
+	}
+}
+
+type importer struct {
+	flags      replayValues
+	options    replayOptions
+	checkpoint *importCheckpoint
+
+	lastSave time.Time
+	m        sync.Mutex
+}
+
+func importFiles(ctx context.Context, r replayValues, files []string, s *importSummary) (err error) {
+	var im = &importer{flags: r}
+
+	if im.options, err = parseReplayOptions(r, s.Started); err != nil {
+		return err
+	}
+
+	if im.checkpoint, err = loadImportCheckpoint(r, s.Started); err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	for _, name := range files {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var f = &importedFile{File: name}
+		s.Files = append(s.Files, f)
+
+		if err = im.file(ctx, f); err != nil {
+			err = fmt.Errorf("%s: %v", name, err)
+			break
+		}
+	}
+
+	if r.dryRun {
+		return err
+	}
+
+	for _, f := range s.Files {
+		if !f.Done {
+			if serr := im.save(true); err == nil {
+				err = serr
+			}
+
+			return err
+		}
+	}
+
+	if rerr := removeCheckpoint(r.checkpoint); err == nil {
+		err = rerr
+	}
+
+	return err
+}
+
+func loadImportCheckpoint(r replayValues, started time.Time) (*importCheckpoint, error) {
+	var c = &importCheckpoint{
+		Started: started,
+	}
+
+	if !r.restart && !r.dryRun {
+		if _, err := readJSONFile(r.checkpoint, c); err != nil {
+			return nil, fmt.Errorf("can't load checkpoint (use -restart to ignore it): %v", err)
+		}
+	}
+
+	if c.Files == nil {
+		c.Files = map[string]*importFileCheckpoint{}
+	}
+
+	return c, nil
+}
+
+// save checkpoint at most once a second, unless forced.
+func (im *importer) save(force bool) error {
+	if im.flags.dryRun || !force && time.Since(im.lastSave) < time.Second {
+		return nil
+	}
+
+	im.lastSave = time.Now()
+	im.checkpoint.Updated = im.lastSave
+	return writeJSONFile(im.flags.checkpoint, im.checkpoint)
+}
+
+// state of a file on the checkpoint, restarting it if the file changed.
+// The standard input isn't resumable.
+func (im *importer) state(name string, info os.FileInfo) (*importFileCheckpoint, error) {
+	var state = &importFileCheckpoint{}
+
+	if info == nil {
+		return state, nil
+	}
+
+	abs, err := filepath.Abs(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	im.m.Lock()
+	defer im.m.Unlock()
+
+	if c, ok := im.checkpoint.Files[abs]; ok && c.Size == info.Size() && c.ModTime.Equal(info.ModTime()) {
+		return c, nil
+	}
+
+	state.Size = info.Size()
+	state.ModTime = info.ModTime()
+	im.checkpoint.Files[abs] = state
+	return state, nil
+}
+
+// openImport file, or the standard input ("-").
+func openImport(name string) (r io.Reader, info os.FileInfo, closer io.Closer, err error) {
+	if name == "-" {
+		r, err = decompress(stdin)
+		return r, nil, ioutil.NopCloser(stdin), err
+	}
+
+	f, err := os.Open(name)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if info, err = f.Stat(); err == nil {
+		r, err = decompress(f)
+	}
+
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, nil, err
+	}
+
+	return r, info, f, nil
+}
+
+// decompress gzipped data.
+func decompress(r io.Reader) (io.Reader, error) {
+	var br = bufio.NewReader(r)
+
+	if magic, _ := br.Peek(2); !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return br, nil
+	}
+
+	return gzip.NewReader(br)
+}
+
+// maxLineSize of a metric.
+const maxLineSize = 1 << 20
+
+type importLine struct {
+	n    int
+	text []byte
+}
+
+type importResult struct {
+	added bool
+	err   error
+
+	// blank lines are ignored
+	blank bool
+}
+
+// lineTracker counts the results of the lines in order, so the checkpoint only has lines before any pending one.
+type lineTracker struct {
+	done    int
+	results map[int]importResult
+	stats   *metrics.BulkStats
+}
+
+func (t *lineTracker) complete(n int, r importResult) {
+	t.results[n] = r
+
+	for {
+		r, ok := t.results[t.done+1]
+
+		if !ok {
+			return
+		}
+
+		delete(t.results, t.done+1)
+		t.done++
+
+		if !r.blank {
+			t.stats.Count(t.done, r.added, r.err)
+		}
+	}
+}
+
+func (im *importer) file(ctx context.Context, f *importedFile) error {
+	r, info, closer, err := openImport(f.File)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = closer.Close()
+	}()
+
+	state, err := im.state(f.File, info)
+
+	if err != nil {
+		return err
+	}
+
+	im.m.Lock()
+
+	if state.Stats.RequestID == "" {
+		state.Stats.RequestID = uuid.NewV4().String()
+	}
+
+	if im.options.RequestID != "" {
+		state.Stats.RequestID = im.options.RequestID
+	}
+
+	f.BulkStats = state.Stats
+	f.Skipped = state.Lines
+	f.Lines = state.Lines
+	f.Done = state.Done
+	im.m.Unlock()
+
+	if state.Done {
+		_, _ = fmt.Fprintf(stderr, "%s: skipped (imported by a previous run)\n", f.File)
+		return nil
+	}
+
+	var skip = state.Lines
+	var t = &lineTracker{
+		done:    skip,
+		results: map[int]importResult{},
+		stats:   &f.BulkStats,
+	}
+
+	var jobs = make(chan importLine, im.flags.concurrency)
+	var wg sync.WaitGroup
+
+	for i := 0; i < im.flags.concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for l := range jobs {
+				added, err := im.add(ctx, l.text, f.RequestID)
+
+				if err != nil && ctx.Err() != nil {
+					// interrupted: keep line pending
+					continue
+				}
+
+				if err != nil {
+					_, _ = fmt.Fprintf(stderr, "%s:%d: %v\n", f.File, l.n, err)
+				}
+
+				im.m.Lock()
+				t.complete(l.n, importResult{added: added, err: err})
+				state.Lines, state.Stats = t.done, f.BulkStats
+				serr := im.save(false)
+				im.m.Unlock()
+
+				if serr != nil {
+					_, _ = fmt.Fprintf(stderr, "can't save checkpoint: %v\n", serr)
+				}
+			}
+		}()
+	}
+
+	var stopProgress = im.progress(f)
+	var scanner = bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	var n int
+
+scan:
+	for scanner.Scan() {
+		n++
+
+		if n <= skip {
+			continue
+		}
+
+		var text = bytes.TrimSpace(scanner.Bytes())
+
+		if len(text) == 0 {
+			im.m.Lock()
+			t.complete(n, importResult{blank: true})
+			im.m.Unlock()
+			continue
+		}
+
+		select {
+		case jobs <- importLine{n, append([]byte{}, text...)}:
+		case <-ctx.Done():
+			break scan
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+	stopProgress()
+
+	im.m.Lock()
+	defer im.m.Unlock()
+
+	err = scanner.Err()
+	f.Lines = n
+	state.Lines, state.Stats = t.done, f.BulkStats
+	state.Done = err == nil && ctx.Err() == nil && t.done == n
+	f.Done = state.Done
+
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(stderr, "%s: %d lines, %d added, %d noop, %d errors\n", f.File, t.done, f.Added, f.Noop, f.Error)
+	return nil
+}
+
+// add metric, or only validate it on a dry run.
+func (im *importer) add(ctx context.Context, text []byte, requestID string) (added bool, err error) {
+	var m metrics.Metric
+
+	if err = json.Unmarshal(text, &m); err != nil {
+		return false, err
+	}
+
+	im.options.override(&m, requestID)
+
+	// metrics on the format of /metrics/bulk don't have it
+	if m.SyncIP == "" {
+		return false, errors.New("missing sync IP: use -sync-ip to set it")
+	}
+
+	if im.flags.dryRun {
+		return true, metrics.Normalize(&m)
+	}
+
+	return metrics.Create(ctx, m)
+}
+
+// progress of the file, reported regularly until stopped.
+func (im *importer) progress(f *importedFile) (stop func()) {
+	if im.flags.progress <= 0 {
+		return func() {}
+	}
+
+	var ticker = time.NewTicker(im.flags.progress)
+	var done = make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				im.m.Lock()
+				_, _ = fmt.Fprintf(stderr, "%s: %d added, %d noop, %d errors so far\n", f.File, f.Added, f.Noop, f.Error)
+				im.m.Unlock()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
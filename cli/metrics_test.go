@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/metrics"
+)
+
+const importData = `{"id":"a6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c","event_type":"cmd","time":"Sat Oct 20 03:00:00 +0000 2018","sync_ip":"203.0.113.1"}
+
+{"id":"invalid","event_type":"cmd","time":"Sat Oct 20 03:00:00 +0000 2018"}
+not json
+{"id":"b6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c","event_type":"cmd","time":"Sat Oct 20 04:00:00 +0000 2018","request_id":"c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c"}
+`
+
+var dryRun = replayValues{
+	concurrency: 2,
+	dryRun:      true,
+}
+
+func TestParseReplayOptions(t *testing.T) {
+	var r = dryRun
+	r.syncIP = "203.0.113.1"
+	r.syncTime = "2018-10-20"
+	r.requestID = "c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c"
+
+	o, err := parseReplayOptions(r, time.Now())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = replayOptions{
+		SyncIP:    "203.0.113.1",
+		SyncTime:  "2018-10-20T00:00:00Z",
+		RequestID: "c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+	}
+
+	if o != want {
+		t.Errorf("Expected options to be %+v, got %+v instead", want, o)
+	}
+
+	r.syncTime = "time"
+
+	if o, err = parseReplayOptions(r, time.Now()); err != nil || !o.EventTime || o.SyncTime != "" {
+		t.Errorf("Expected sync time to be the time of the metrics, got %+v (error: %v) instead", o, err)
+	}
+}
+
+func TestParseReplayOptionsFailure(t *testing.T) {
+	var cases = map[string]func(r *replayValues){
+		"concurrency": func(r *replayValues) { r.concurrency = 0 },
+		"sync IP":     func(r *replayValues) { r.syncIP = "localhost" },
+		"sync time":   func(r *replayValues) { r.syncTime = "yesterday" },
+		"request ID":  func(r *replayValues) { r.requestID = "foo" },
+	}
+
+	for name, change := range cases {
+		var r = dryRun
+		change(&r)
+
+		if _, err := parseReplayOptions(r, time.Now()); err == nil {
+			t.Errorf("Expected error for invalid %s, got nil instead", name)
+		}
+	}
+}
+
+func TestReplayOverride(t *testing.T) {
+	var m = metrics.Metric{
+		Timestamp: "Sat Oct 20 03:00:00 +0000 2018",
+		SyncIP:    "203.0.113.1",
+		SyncTime:  "2018-10-21T00:00:00Z",
+		RequestID: "c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+	}
+
+	var kept = m
+	(replayOptions{}).override(&kept, "default")
+
+	if !reflect.DeepEqual(kept, m) {
+		t.Errorf("Expected metric not to change, got %+v instead", kept)
+	}
+
+	var none = metrics.Metric{}
+	(replayOptions{}).override(&none, "default")
+
+	if none.RequestID != "default" {
+		t.Errorf("Expected default request ID, got %v instead", none.RequestID)
+	}
+
+	var o = replayOptions{
+		SyncIP:    "198.51.100.1",
+		RequestID: "d6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+		EventTime: true,
+	}
+
+	var overridden = m
+	o.override(&overridden, "default")
+
+	if overridden.SyncIP != o.SyncIP || overridden.RequestID != o.RequestID || overridden.SyncTime != "2018-10-20T03:00:00Z" {
+		t.Errorf("Expected metric to be overridden, got %+v instead", overridden)
+	}
+}
+
+func TestDecompress(t *testing.T) {
+	var buf bytes.Buffer
+	var gz = gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte("gzipped"))
+	_ = gz.Close()
+
+	for in, want := range map[string]string{buf.String(): "gzipped", "plain": "plain", "": ""} {
+		r, err := decompress(bytes.NewBufferString(in))
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v instead", err)
+		}
+
+		if b, _ := ioutil.ReadAll(r); string(b) != want {
+			t.Errorf("Expected %q, got %q instead", want, b)
+		}
+	}
+}
+
+func TestLineTracker(t *testing.T) {
+	var stats metrics.BulkStats
+	var tracker = &lineTracker{
+		done:    2,
+		results: map[int]importResult{},
+		stats:   &stats,
+	}
+
+	tracker.complete(4, importResult{added: true})
+	tracker.complete(5, importResult{blank: true})
+
+	if tracker.done != 2 || stats.Added != 0 {
+		t.Errorf("Expected lines after a pending line not to be counted, got %d lines done and %+v instead", tracker.done, stats)
+	}
+
+	tracker.complete(3, importResult{err: errors.New("invalid UUID")})
+
+	var want = metrics.BulkStats{Added: 1, Error: 1, Broken: []int{3}}
+
+	if tracker.done != 5 || !reflect.DeepEqual(stats, want) {
+		t.Errorf("Expected 5 lines done and %+v, got %d and %+v instead", want, tracker.done, stats)
+	}
+}
+
+// writeImportFiles to a temporary directory, which the caller removes.
+func writeImportFiles(t *testing.T) (dir, plain, gzipped string) {
+	dir, err := ioutil.TempDir("", "climetrics-import")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain = filepath.Join(dir, "metrics.ndjson")
+
+	if err = ioutil.WriteFile(plain, []byte(importData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	var gz = gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte(importData))
+	_ = gz.Close()
+
+	gzipped = filepath.Join(dir, "metrics.ndjson.gz")
+
+	if err = ioutil.WriteFile(gzipped, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, plain, gzipped
+}
+
+func TestImportFilesDryRun(t *testing.T) {
+	_, _, restore := useIO(importData)
+	defer restore()
+	dir, plain, gzipped := writeImportFiles(t)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	var r = dryRun
+	r.syncIP = "203.0.113.2"
+
+	var s importSummary
+
+	if err := importFiles(context.Background(), r, []string{plain, gzipped, "-"}, &s); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if len(s.Files) != 3 {
+		t.Fatalf("Expected 3 files, got %d instead", len(s.Files))
+	}
+
+	for _, f := range s.Files {
+		if f.Added != 2 || f.Noop != 0 || f.Error != 2 || !reflect.DeepEqual(f.Broken, []int{3, 4}) {
+			t.Errorf("Expected 2 metrics added and errors on lines 3 and 4 of %s, got %+v instead", f.File, f.BulkStats)
+		}
+
+		if f.Lines != 5 || !f.Done || f.RequestID == "" {
+			t.Errorf("Expected 5 lines of %s to be done, got %+v instead", f.File, f)
+		}
+	}
+}
+
+func TestImportFilesMissingSyncIP(t *testing.T) {
+	_, errOut, restore := useIO("")
+	defer restore()
+	dir, plain, _ := writeImportFiles(t)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	var s importSummary
+
+	if err := importFiles(context.Background(), dryRun, []string{plain}, &s); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	// the last line has no sync IP, as on requests to /metrics/bulk
+	if f := s.Files[0]; f.Added != 1 || !reflect.DeepEqual(f.Broken, []int{3, 4, 5}) {
+		t.Errorf("Expected only the metric with a sync IP to be added, got %+v instead", f.BulkStats)
+	}
+
+	if got := errOut.String(); !strings.Contains(got, "metrics.ndjson:5: missing sync IP: use -sync-ip to set it") {
+		t.Errorf("Expected missing sync IP error, got %q instead", got)
+	}
+}
+
+func TestImportFilesResume(t *testing.T) {
+	_, _, restore := useIO("")
+	defer restore()
+	dir, plain, _ := writeImportFiles(t)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	info, err := os.Stat(plain)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var im = &importer{
+		flags:   dryRun,
+		options: replayOptions{SyncIP: "203.0.113.2"},
+		checkpoint: &importCheckpoint{
+			Files: map[string]*importFileCheckpoint{
+				plain: {
+					Size:    info.Size(),
+					ModTime: info.ModTime(),
+					Lines:   3,
+					Stats: metrics.BulkStats{
+						RequestID: "c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+						Added:     1,
+						Error:     1,
+						Broken:    []int{3},
+					},
+				},
+			},
+		},
+	}
+
+	im.flags.checkpoint = filepath.Join(dir, "checkpoint.json")
+	var f = &importedFile{File: plain}
+
+	if err := im.file(context.Background(), f); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = metrics.BulkStats{
+		RequestID: "c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+		Added:     2,
+		Error:     2,
+		Broken:    []int{3, 4},
+	}
+
+	if !reflect.DeepEqual(f.BulkStats, want) || f.Skipped != 3 || f.Lines != 5 || !f.Done {
+		t.Errorf("Expected file to resume on line 4, got %+v instead", f)
+	}
+}
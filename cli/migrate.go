@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/db/migrations"
+	"github.com/jmoiron/sqlx"
+)
+
+func init() {
+	Register(&Command{
+		Name: "migrate",
+		Args: "up [version] | down [steps] | status | baseline [version]",
+		Summary: `Migrate the database schema: apply the pending migrations (up to the version), revert the newest ones (down, 1 by default),
+list them (status), or mark a database created from the old climetrics.pgsql dump as migrated (baseline), without changing it`,
+		Database: true,
+		Output:   true,
+		Run:      migrate,
+	})
+}
+
+func migrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing migrate command: use up, down, status, or baseline")
+	}
+
+	var conn = db.Conn()
+	var done []migrations.Migration
+	var action string
+	var err error
+
+	switch args[0] {
+	case "up":
+		var target int64
+
+		if target, err = migrateNumber(args[1:], "version", 0); err == nil {
+			done, err = migrations.Up(ctx, conn, target)
+		}
+
+		action = "Applied"
+	case "down":
+		var steps int64
+
+		if steps, err = migrateNumber(args[1:], "number of steps", 1); err == nil {
+			done, err = migrations.Down(ctx, conn, int(steps))
+		}
+
+		action = "Reverted"
+	case "baseline":
+		var target int64
+
+		if target, err = migrateNumber(args[1:], "version", migrations.BaselineVersion); err == nil {
+			done, err = migrations.Baseline(ctx, conn, target)
+		}
+
+		action = "Marked as applied"
+	case "status":
+		if err := exactArgs(args[1:], 0); err != nil {
+			return err
+		}
+
+		return migrateStatus(ctx, conn)
+	default:
+		return fmt.Errorf(`unknown migrate command "%s"`, args[0])
+	}
+
+	if done == nil {
+		done = []migrations.Migration{}
+	}
+
+	if oerr := output(done, func(w io.Writer) error {
+		for _, m := range done {
+			_, _ = fmt.Fprintf(w, "%s %v\n", action, m)
+		}
+
+		if len(done) == 0 && err == nil {
+			_, _ = fmt.Fprintln(w, "Nothing to do.")
+		}
+
+		return nil
+	}); err == nil {
+		err = oerr
+	}
+
+	return err
+}
+
+func migrateNumber(args []string, name string, value int64) (int64, error) {
+	switch len(args) {
+	case 0:
+		return value, nil
+	case 1:
+		v, err := strconv.ParseInt(args[0], 10, 64)
+
+		if err != nil || v < 1 {
+			return 0, fmt.Errorf("invalid %s %q", name, args[0])
+		}
+
+		return v, nil
+	default:
+		return 0, errors.New("too many arguments")
+	}
+}
+
+func migrateStatus(ctx context.Context, conn *sqlx.DB) error {
+	list, err := migrations.List(ctx, conn)
+
+	if err != nil {
+		return err
+	}
+
+	return output(list, func(w io.Writer) error {
+		return table(w, "MIGRATION\tSTATUS\tAPPLIED", func(w io.Writer) {
+			for _, s := range list {
+				var state, applied = "pending", ""
+
+				if s.Applied != nil {
+					state, applied = "applied", s.Applied.Format(time.RFC3339)
+				}
+
+				if s.Unknown {
+					state = "unknown (newer)"
+				}
+
+				_, _ = fmt.Fprintf(w, "%v\t%s\t%s\n", s.Migration, state, applied)
+			}
+		})
+	})
+}
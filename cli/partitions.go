@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/cursor"
+	"github.com/henvic/climetrics/metrics"
+	"github.com/henvic/climetrics/settings"
+)
+
+var partitionCommands = []*Command{
+	{
+		Name: "partition",
+		Summary: `Partition the metrics by month, after the partition_metrics migration: copy them to metrics_partitioned in batches,
+while changes are mirrored to it, and then replace metrics with it (keeping the original table as metrics_unpartitioned)`,
+		Flags:    metricsPartitionFlags,
+		Database: true,
+		Output:   true,
+		Run:      metricsPartition,
+	},
+	{
+		Name:    "partitions",
+		Summary: "Manage the monthly partitions of the metrics",
+		Subcommands: []*Command{
+			{
+				Name:     "list",
+				Summary:  "List the partitions of the metrics, with their estimated number of rows",
+				Database: true,
+				Output:   true,
+				Run:      metricsPartitionsList,
+			},
+			{
+				Name:     "create",
+				Summary:  "Create the missing monthly partitions (i.e., before importing old metrics)",
+				Flags:    metricsPartitionsCreateFlags,
+				Database: true,
+				Output:   true,
+				Run:      metricsPartitionsCreate,
+			},
+		},
+	},
+}
+
+// partitionCursorSetting saves how far the metrics were copied, so an interrupted run continues where it left off.
+const partitionCursorSetting = "metrics.partition.cursor"
+
+var partitionFlags struct {
+	batchSize int
+	pause     time.Duration
+	progress  time.Duration
+	restart   bool
+	noSwap    bool
+
+	from  string
+	until string
+}
+
+func metricsPartitionFlags(c *config.Config) {
+	var flags = c.FlagSet()
+	flags.IntVar(&partitionFlags.batchSize, "batch-size", 1000, "Maximum number of metrics copied at once, so that locks are short")
+	flags.DurationVar(&partitionFlags.pause, "pause", 100*time.Millisecond, "Pause between batches")
+	flags.DurationVar(&partitionFlags.progress, "progress", 10*time.Second, "Interval between progress reports on the standard error (0 to disable)")
+	flags.BoolVar(&partitionFlags.restart, "restart", false, "Copy from the most recent metrics again, instead of continuing where a previous run left off")
+	flags.BoolVar(&partitionFlags.noSwap, "no-swap", false, "Copy the metrics, but don't replace the metrics table yet")
+
+	c.Validate(func() error {
+		if partitionFlags.batchSize < 1 {
+			return errors.New("the batch size must be at least 1")
+		}
+
+		return nil
+	})
+}
+
+// partitionSummary of the run.
+type partitionSummary struct {
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+
+	Copied      int64 `json:"copied"`
+	Metrics     int64 `json:"metrics"`
+	Partitioned int64 `json:"partitioned"`
+	Swapped     bool  `json:"swapped"`
+}
+
+func metricsPartition(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	var s = partitionSummary{
+		Started: time.Now(),
+	}
+
+	err := partitionMetrics(ctx, &s)
+	s.Duration = time.Since(s.Started).Round(time.Millisecond).String()
+
+	if err != nil {
+		return err
+	}
+
+	return output(s, func(w io.Writer) error {
+		var err error
+
+		switch s.Swapped {
+		case true:
+			_, err = fmt.Fprintf(w, `Copied %d metrics and partitioned the metrics table.
+Drop metrics_unpartitioned once you no longer need it.
+`, s.Copied)
+		default:
+			_, err = fmt.Fprintf(w, "Copied %d metrics (%d of %d on metrics_partitioned).\n", s.Copied, s.Partitioned, s.Metrics)
+		}
+
+		return err
+	})
+}
+
+func partitionMetrics(ctx context.Context, s *partitionSummary) error {
+	state, err := metrics.PartitioningState(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	switch state {
+	case metrics.Partitioned:
+		return errors.New("the metrics are partitioned already")
+	case metrics.Unpartitioned:
+		return errors.New("metrics_partitioned doesn't exist: apply the partition_metrics migration first")
+	}
+
+	if err = copyPartitioned(ctx, s); err != nil {
+		return err
+	}
+
+	// the counts are on the same snapshot, and changes are mirrored by a trigger on the same transaction,
+	// so they only differ if something is wrong (i.e., metrics_partitioned was changed directly).
+	if s.Metrics, s.Partitioned, err = metrics.CountPartitioned(ctx); err != nil {
+		return err
+	}
+
+	if s.Metrics != s.Partitioned {
+		return fmt.Errorf("metrics has %d rows, but metrics_partitioned has %d: run again with -restart", s.Metrics, s.Partitioned)
+	}
+
+	if partitionFlags.noSwap {
+		return nil
+	}
+
+	if err = metrics.SwapPartitioned(ctx); err != nil {
+		return err
+	}
+
+	s.Swapped = true
+	return settings.Set(ctx, partitionCursorSetting, "")
+}
+
+func copyPartitioned(ctx context.Context, s *partitionSummary) error {
+	var after *cursor.Cursor
+
+	if !partitionFlags.restart {
+		saved, err := settings.Get(ctx, partitionCursorSetting, "")
+
+		if err != nil {
+			return err
+		}
+
+		if saved != "" {
+			if after, err = cursor.Parse(saved); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(stderr, "continuing from metrics synced before %v\n", after.Time.UTC().Format(time.RFC3339))
+		}
+	}
+
+	var last = time.Now()
+
+	for {
+		n, next, err := metrics.CopyPartitioned(ctx, after, partitionFlags.batchSize)
+
+		if err != nil {
+			return err
+		}
+
+		s.Copied += int64(n)
+
+		if next == nil {
+			return nil
+		}
+
+		if err = settings.Set(ctx, partitionCursorSetting, next.String()); err != nil {
+			return err
+		}
+
+		after = next
+
+		if partitionFlags.progress > 0 && time.Since(last) >= partitionFlags.progress {
+			_, _ = fmt.Fprintf(stderr, "copied %d metrics so far, synced down to %v\n", s.Copied, after.Time.UTC().Format(time.RFC3339))
+			last = time.Now()
+		}
+
+		if n < partitionFlags.batchSize {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(partitionFlags.pause):
+		}
+	}
+}
+
+func metricsPartitionsList(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	ps, err := metrics.Partitions(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if ps == nil {
+		ps = []metrics.Partition{}
+	}
+
+	return output(ps, func(w io.Writer) error {
+		if len(ps) == 0 {
+			_, err := fmt.Fprintln(w, "The metrics aren't partitioned.")
+			return err
+		}
+
+		return table(w, "NAME\tFROM\tTO\tROWS (ESTIMATE)", func(w io.Writer) {
+			for _, p := range ps {
+				var from, to = "-", "-"
+
+				if p.Monthly() {
+					from, to = p.From.Format("2006-01-02"), p.To.Format("2006-01-02")
+				}
+
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", p.Name, from, to, p.Rows)
+			}
+		})
+	})
+}
+
+func metricsPartitionsCreateFlags(c *config.Config) {
+	var flags = c.FlagSet()
+	flags.StringVar(&partitionFlags.from, "from", "", "Create partitions from the month of a duration (i.e., 8760h) ago, date, or RFC 3339 timestamp (default: now)")
+	flags.StringVar(&partitionFlags.until, "until", "", "Create partitions up to the month of a date or RFC 3339 timestamp (default: three months ahead)")
+}
+
+func metricsPartitionsCreate(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	var now = time.Now()
+	from, err := parseTime("from", partitionFlags.from, now)
+
+	if err != nil {
+		return err
+	}
+
+	until, err := parseTime("until", partitionFlags.until, now)
+
+	if err != nil {
+		return err
+	}
+
+	if from.IsZero() {
+		from = now
+	}
+
+	if until.IsZero() {
+		until = now.AddDate(0, metrics.PartitionJob.Ahead, 0)
+	}
+
+	if until.Before(from) {
+		return errors.New("-until must not be before -from")
+	}
+
+	state, err := metrics.PartitioningState(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if state == metrics.Unpartitioned {
+		return errors.New("the metrics aren't partitioned: apply the partition_metrics migration first")
+	}
+
+	created, err := metrics.CreatePartitions(ctx, from, until)
+
+	if err != nil {
+		return err
+	}
+
+	return output(map[string]int{"created": created}, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "Created %d partitions.\n", created)
+		return err
+	})
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+func init() {
+	Register(&Command{
+		Name:    "hash-password",
+		Summary: "Hash a password with bcrypt (prompted on a terminal, or read from the standard input)",
+		Output:  true,
+		Prompts: true,
+		Run:     hashPasswordCommand,
+	})
+}
+
+func hashPasswordCommand(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	password, err := readPassword("Password")
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(password)
+
+	if err != nil {
+		return err
+	}
+
+	return output(map[string]string{"hash": hash}, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, hash)
+		return err
+	})
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var input *bufio.Reader
+
+// interactive tells if the user can be prompted for input.
+func interactive() bool {
+	return !nonInteractive && isTerminal(stdin)
+}
+
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// readLine from the standard input, without the line break.
+func readLine() (string, error) {
+	if input == nil {
+		input = bufio.NewReader(stdin)
+	}
+
+	line, err := input.ReadString('\n')
+
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// prompt for a value on the terminal.
+func prompt(label string) (string, error) {
+	if !interactive() {
+		return "", fmt.Errorf("missing %s (can't prompt for it without a terminal)", label)
+	}
+
+	_, _ = fmt.Fprintf(stderr, "%s: ", label)
+	return readLine()
+}
+
+// readPassword from the terminal (without echoing it), or from a line of the standard input.
+func readPassword(label string) (string, error) {
+	if !isTerminal(stdin) {
+		password, err := readLine()
+
+		if err == io.EOF {
+			return "", errors.New("missing password on the standard input")
+		}
+
+		return password, err
+	}
+
+	if nonInteractive {
+		return "", errors.New("missing password (can't prompt for it with -non-interactive)")
+	}
+
+	_, _ = fmt.Fprintf(stderr, "%s: ", label)
+	b, err := terminal.ReadPassword(int(stdin.(*os.File).Fd()))
+	_, _ = fmt.Fprintln(stderr, "█")
+	return string(b), err
+}
+
+// hashPassword with bcrypt.
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// generatePassword with 128 bits of entropy.
+func generatePassword() (string, error) {
+	var b = make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/usersessions"
+	"github.com/henvic/climetrics/users"
+	uuid "github.com/satori/go.uuid"
+)
+
+// userRecord to create or update an user, as read by users import.
+type userRecord struct {
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	PasswordHash string `json:"password_hash"`
+}
+
+// userRecordFields on the header of CSV files.
+var userRecordFields = []string{"username", "email", "role", "password_hash"}
+
+// Results of provisioning an user.
+const (
+	resultCreated   = "created"
+	resultUpdated   = "updated"
+	resultUnchanged = "unchanged"
+	resultFailed    = "failed"
+)
+
+// provisioned user.
+type provisioned struct {
+	Line     int    `json:"line,omitempty"`
+	UserID   string `json:"user_id,omitempty"`
+	Username string `json:"username"`
+	Result   string `json:"result"`
+
+	// Password generated for the user, printed only once.
+	Password string `json:"password,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// provisioner creates users, or updates existing users (by username).
+type provisioner struct {
+	// Update existing users, instead of failing.
+	Update bool
+
+	// Generate a password when none is given, even for existing users
+	// (new users without a password always get one).
+	Generate bool
+
+	// DryRun validates the changes without saving them.
+	DryRun bool
+
+	// Command name for the audit log.
+	Command string
+
+	// existing user IDs by username.
+	existing map[string]string
+}
+
+func (p *provisioner) lookup(ctx context.Context, username string) (userID string, ok bool, err error) {
+	if p.existing == nil {
+		list, err := users.List(ctx, users.Filter{})
+
+		if err != nil {
+			return "", false, err
+		}
+
+		p.existing = map[string]string{}
+
+		for _, u := range list {
+			p.existing[u.Username] = u.UserID
+		}
+	}
+
+	userID, ok = p.existing[username]
+	return userID, ok, nil
+}
+
+// generate a password, if needed, returning it.
+func (p *provisioner) generate(rec *userRecord, create bool) (password string, err error) {
+	if rec.PasswordHash != "" || !create && !p.Generate || p.DryRun {
+		return "", nil
+	}
+
+	if password, err = generatePassword(); err != nil {
+		return "", err
+	}
+
+	rec.PasswordHash, err = hashPassword(password)
+	return password, err
+}
+
+// Provision user, creating or updating it.
+func (p *provisioner) Provision(ctx context.Context, rec userRecord) (r provisioned, err error) {
+	r.Username = rec.Username
+
+	if rec.PasswordHash != "" {
+		if err = users.ValidatePasswordHash(rec.PasswordHash); err != nil {
+			return r, err
+		}
+	}
+
+	if err = users.ValidateUsername(rec.Username); err != nil {
+		return r, err
+	}
+
+	userID, exists, err := p.lookup(ctx, rec.Username)
+
+	if err != nil {
+		return r, err
+	}
+
+	if !exists {
+		return p.create(ctx, rec, r)
+	}
+
+	if !p.Update {
+		return r, fmt.Errorf(`user "%s" already exists (use -update to update existing users)`, rec.Username)
+	}
+
+	return p.update(ctx, userID, rec, r)
+}
+
+func (p *provisioner) create(ctx context.Context, rec userRecord, r provisioned) (provisioned, error) {
+	if rec.Role == "" {
+		rec.Role = users.Member
+	}
+
+	var u = users.User{
+		UserID:   uuid.NewV4().String(),
+		Username: rec.Username,
+		Email:    rec.Email,
+		Role:     rec.Role,
+	}
+
+	if err := users.Validate(u); err != nil {
+		return r, err
+	}
+
+	password, err := p.generate(&rec, true)
+
+	if err != nil {
+		return r, err
+	}
+
+	r.UserID, r.Result, r.Password = u.UserID, resultCreated, password
+	u.Password = rec.PasswordHash
+
+	if p.DryRun {
+		return r, nil
+	}
+
+	if err = users.Create(ctx, u); err != nil {
+		return r, err
+	}
+
+	p.existing[u.Username] = u.UserID
+	record(ctx, audit.UserCreate, u.UserID, fmt.Sprintf("username=%s email=%s role=%s", u.Username, u.Email, u.Role), p.Command)
+	return r, nil
+}
+
+func (p *provisioner) update(ctx context.Context, userID string, rec userRecord, r provisioned) (provisioned, error) {
+	u, err := users.Get(ctx, userID)
+
+	if err != nil {
+		return r, err
+	}
+
+	r.UserID = u.UserID
+
+	var changes []string
+	var action = audit.UserUpdate
+
+	if rec.Email != "" && rec.Email != u.Email {
+		changes = append(changes, "email="+rec.Email)
+		u.Email = rec.Email
+	}
+
+	if rec.Role != "" && rec.Role != u.Role {
+		changes = append(changes, "role="+rec.Role)
+		u.Role = rec.Role
+
+		if u.Role == users.Revoked {
+			action = audit.UserRevoke
+		}
+	}
+
+	if err = users.Validate(u); err != nil {
+		return r, err
+	}
+
+	if r.Password, err = p.generate(&rec, false); err != nil {
+		return r, err
+	}
+
+	var passwordChanged = rec.PasswordHash != "" && rec.PasswordHash != u.Password
+
+	if passwordChanged || p.DryRun && p.Generate {
+		changes = append(changes, "password")
+		u.Password = rec.PasswordHash
+	}
+
+	if len(changes) == 0 {
+		r.Result = resultUnchanged
+		return r, nil
+	}
+
+	r.Result = resultUpdated
+
+	if p.DryRun {
+		return r, nil
+	}
+
+	if err = users.Update(ctx, u); err != nil {
+		return r, err
+	}
+
+	// a new password or revoked access logs the user out everywhere
+	if passwordChanged || u.Role == users.Revoked {
+		if _, err = usersessions.RevokeAll(ctx, u.UserID, ""); err != nil {
+			return r, err
+		}
+	}
+
+	record(ctx, action, u.UserID, strings.Join(changes, " "), p.Command)
+	return r, nil
+}
+
+// readUserRecords from a NDJSON or CSV (with a header) file, calling fn for each record.
+// Invalid records are passed to fn with an error, and reading continues.
+func readUserRecords(r io.Reader, format string, fn func(line int, rec userRecord, err error) error) error {
+	switch format {
+	case "ndjson":
+		return readUserRecordsNDJSON(r, fn)
+	case "csv":
+		return readUserRecordsCSV(r, fn)
+	default:
+		return fmt.Errorf(`invalid format "%s": use ndjson or csv`, format)
+	}
+}
+
+func readUserRecordsNDJSON(r io.Reader, fn func(line int, rec userRecord, err error) error) error {
+	var scanner = bufio.NewScanner(r)
+	var line int
+
+	for scanner.Scan() {
+		line++
+		var b = bytes.TrimSpace(scanner.Bytes())
+
+		if len(b) == 0 {
+			continue
+		}
+
+		var rec userRecord
+		var dec = json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		var err = dec.Decode(&rec)
+
+		if err == nil && dec.More() {
+			err = errors.New("unexpected data after the user")
+		}
+
+		if err != nil {
+			err = fmt.Errorf("invalid JSON: %v", err)
+		}
+
+		if err = fn(line, rec, err); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func readUserRecordsCSV(r io.Reader, fn func(line int, rec userRecord, err error) error) error {
+	var cr = csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+
+	if err == io.EOF {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var columns = map[string]int{}
+
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+
+		if !isUserRecordField(h) {
+			return fmt.Errorf(`unknown column "%s" (use %s)`, h, strings.Join(userRecordFields, ", "))
+		}
+
+		columns[h] = i
+	}
+
+	if _, ok := columns["username"]; !ok {
+		return errors.New(`missing column "username"`)
+	}
+
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		var rec userRecord
+
+		switch {
+		case err != nil:
+			if _, ok := err.(*csv.ParseError); !ok {
+				return err
+			}
+		case len(row) != len(header):
+			err = fmt.Errorf("expected %d fields, got %d instead", len(header), len(row))
+		default:
+			var get = func(name string) string {
+				if i, ok := columns[name]; ok {
+					return strings.TrimSpace(row[i])
+				}
+
+				return ""
+			}
+
+			rec = userRecord{
+				Username:     get("username"),
+				Email:        get("email"),
+				Role:         get("role"),
+				PasswordHash: get("password_hash"),
+			}
+		}
+
+		if err = fn(line, rec, err); err != nil {
+			return err
+		}
+	}
+}
+
+func isUserRecordField(name string) bool {
+	for _, f := range userRecordFields {
+		if name == f {
+			return true
+		}
+	}
+
+	return false
+}
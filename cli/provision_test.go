@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type readRecord struct {
+	line int
+	rec  userRecord
+	err  string
+}
+
+func readAll(t *testing.T, in, format string) []readRecord {
+	var got []readRecord
+
+	var err = readUserRecords(strings.NewReader(in), format, func(line int, rec userRecord, err error) error {
+		var r = readRecord{line: line, rec: rec}
+
+		// records aren't used on errors
+		if err != nil {
+			r = readRecord{line: line, err: err.Error()}
+		}
+
+		got = append(got, r)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	return got
+}
+
+func TestReadUserRecordsNDJSON(t *testing.T) {
+	var in = `{"username":"alice","email":"alice@example.com","role":"admin"}
+
+{"username":"bob","password_hash":"$2a$10$hash"}
+{"username":"carol","admin":true}
+not json
+`
+
+	var want = []readRecord{
+		{line: 1, rec: userRecord{Username: "alice", Email: "alice@example.com", Role: "admin"}},
+		{line: 3, rec: userRecord{Username: "bob", PasswordHash: "$2a$10$hash"}},
+		{line: 4, err: `invalid JSON: json: unknown field "admin"`},
+		{line: 5, err: "invalid JSON: invalid character 'o' in literal null (expecting 'u')"},
+	}
+
+	if got := readAll(t, in, "ndjson"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected records to be %+v, got %+v instead", want, got)
+	}
+}
+
+func TestReadUserRecordsCSV(t *testing.T) {
+	var in = `Username, email, role
+alice, alice@example.com, admin
+bob,bob@example.com
+carol,carol@example.com,
+`
+
+	var want = []readRecord{
+		{line: 2, rec: userRecord{Username: "alice", Email: "alice@example.com", Role: "admin"}},
+		{line: 3, err: "expected 3 fields, got 2 instead"},
+		{line: 4, rec: userRecord{Username: "carol", Email: "carol@example.com"}},
+	}
+
+	if got := readAll(t, in, "csv"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected records to be %+v, got %+v instead", want, got)
+	}
+}
+
+func TestReadUserRecordsCSVInvalidHeader(t *testing.T) {
+	var fn = func(line int, rec userRecord, err error) error {
+		t.Errorf("Expected no records to be read")
+		return nil
+	}
+
+	if err := readUserRecords(strings.NewReader("username,password\nalice,secret\n"), "csv", fn); err == nil ||
+		err.Error() != `unknown column "password" (use username, email, role, password_hash)` {
+		t.Errorf("Expected unknown column error, got %v instead", err)
+	}
+
+	if err := readUserRecords(strings.NewReader("email\nalice@example.com\n"), "csv", fn); err == nil ||
+		err.Error() != `missing column "username"` {
+		t.Errorf("Expected missing column error, got %v instead", err)
+	}
+
+	if err := readUserRecords(strings.NewReader(""), "xml", fn); err == nil ||
+		err.Error() != `invalid format "xml": use ndjson or csv` {
+		t.Errorf("Expected invalid format error, got %v instead", err)
+	}
+}
+
+func TestPasswordFromFlagsHashStdin(t *testing.T) {
+	const hash = "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+	_, _, restore := useIO(hash + "\n")
+	defer restore()
+
+	got, generated, err := passwordFromFlags(userValues{passwordHashStdin: true})
+
+	if err != nil || got != hash || generated != "" {
+		t.Errorf("Expected hash read from the standard input, got %q, %q (error: %v) instead", got, generated, err)
+	}
+}
+
+func TestPasswordFromFlagsInvalidHash(t *testing.T) {
+	_, _, restore := useIO("secret password\n")
+	defer restore()
+
+	if _, _, err := passwordFromFlags(userValues{passwordHashStdin: true}); err == nil || err.Error() != "invalid password hash: use a bcrypt hash" {
+		t.Errorf("Expected invalid hash error, got %v instead", err)
+	}
+}
+
+func TestPasswordFromFlagsGenerate(t *testing.T) {
+	_, _, restore := useIO("")
+	defer restore()
+
+	hash, generated, err := passwordFromFlags(userValues{generatePassword: true})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if len(generated) != 22 {
+		t.Errorf("Expected generated password to have 22 characters, got %q instead", generated)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(generated)); err != nil {
+		t.Errorf("Expected hash of the generated password, got %v instead", err)
+	}
+}
+
+func TestPasswordFromFlagsConflict(t *testing.T) {
+	_, _, restore := useIO("")
+	defer restore()
+
+	if _, _, err := passwordFromFlags(userValues{passwordHashStdin: true, generatePassword: true}); err == nil {
+		t.Errorf("Expected error using both -password-hash-stdin and -generate-password, got nil instead")
+	}
+}
+
+func TestPasswordFromFlagsWeakPassword(t *testing.T) {
+	_, _, restore := useIO("short\n")
+	defer restore()
+
+	if _, _, err := passwordFromFlags(userValues{}); err == nil {
+		t.Errorf("Expected weak password error, got nil instead")
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	a, err := generatePassword()
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	b, _ := generatePassword()
+
+	if a == b {
+		t.Errorf("Expected random passwords, got %q twice instead", a)
+	}
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/onetime"
+	"github.com/henvic/climetrics/auth/usersessions"
+	"github.com/henvic/climetrics/config"
+)
+
+func init() {
+	Register(&Command{
+		Name:     "prune",
+		Summary:  "Delete expired sessions, used or expired one-time tokens, and old failed login attempts",
+		Flags:    pruneFlags,
+		Database: true,
+		Output:   true,
+		Run:      prune,
+	})
+}
+
+var pruneWindow time.Duration
+
+func pruneFlags(c *config.Config) {
+	c.Duration(&pruneWindow, "login-failures-window", "lockout.window", lockout.DefaultConfig.Account.Window,
+		"Failed login attempts are forgotten after this duration without failures")
+}
+
+// pruned rows of each kind.
+type pruned struct {
+	Sessions      int64 `json:"sessions"`
+	Tokens        int64 `json:"tokens"`
+	LoginFailures int64 `json:"login_failures"`
+}
+
+func prune(ctx context.Context, args []string) (err error) {
+	if err = exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	var p pruned
+
+	if p.Sessions, err = usersessions.Prune(ctx); err != nil {
+		return err
+	}
+
+	if p.Tokens, err = onetime.Prune(ctx); err != nil {
+		return err
+	}
+
+	if p.LoginFailures, err = lockout.Prune(ctx, pruneWindow); err != nil {
+		return err
+	}
+
+	return output(p, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "Pruned %d sessions, %d one-time tokens, and %d failed login attempt counters.\n",
+			p.Sessions, p.Tokens, p.LoginFailures)
+		return err
+	})
+}
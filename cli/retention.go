@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/retention"
+)
+
+func init() {
+	Register(&Command{
+		Name:    "retention",
+		Summary: "Manage the retention of metrics and diagnostics",
+		Subcommands: []*Command{
+			{
+				Name:     "prune",
+				Summary:  "Delete metrics and diagnostics past their retention, in batches (use it regularly, i.e., on a crontab)",
+				Flags:    retentionPruneFlags,
+				Database: true,
+				Output:   true,
+				Run:      retentionPrune,
+			},
+		},
+	})
+}
+
+var retentionDryRun bool
+
+func retentionPruneFlags(c *config.Config) {
+	c.Retention(retention.Job)
+	c.FlagSet().BoolVar(&retentionDryRun, "dry-run", false, "Count the rows past retention without deleting them")
+	c.Own("retention")
+}
+
+func retentionPrune(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	r, err := retention.Job.Prune(ctx, retentionDryRun)
+
+	if !r.DryRun && r.Rows != 0 {
+		record(ctx, audit.RetentionPrune, "", r.Details(), "retention prune")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return output(r, func(w io.Writer) error {
+		if len(r.Removed) == 0 {
+			_, err := fmt.Fprintln(w, "No retention rules: metrics and diagnostics are kept forever.")
+			return err
+		}
+
+		var verb = "Pruned"
+
+		if r.DryRun {
+			verb = "Would prune"
+		}
+
+		err := table(w, "TABLE\tTYPE\tBEFORE\tROWS", func(w io.Writer) {
+			for _, removed := range r.Removed {
+				var t = removed.Table
+
+				if removed.Partition != "" {
+					t = removed.Partition
+				}
+
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", t, ruleType(removed),
+					removed.Before.UTC().Format("2006-01-02 15:04:05"), removed.Rows)
+			}
+		})
+
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s %d rows.\n", verb, r.Rows)
+		}
+
+		return err
+	})
+}
+
+// ruleType describes the type of metrics of a rule.
+func ruleType(r retention.Removed) string {
+	switch {
+	case r.Table != "metrics":
+		return "-"
+	case r.Partition != "":
+		return "(all)"
+	case r.Type != "":
+		return r.Type
+	default:
+		return "(default)"
+	}
+}
@@ -0,0 +1,566 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/usersessions"
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/users"
+	uuid "github.com/satori/go.uuid"
+)
+
+func init() {
+	Register(&Command{
+		Name:    "users",
+		Summary: "Manage users",
+		Subcommands: []*Command{
+			{
+				Name:     "list",
+				Summary:  "List users",
+				Flags:    usersListFlags,
+				Database: true,
+				Output:   true,
+				Run:      usersList,
+			},
+			{
+				Name:     "add",
+				Summary:  "Add an user, prompting for anything missing on a terminal (the password is read from the standard input otherwise)",
+				Flags:    usersAddFlags,
+				Database: true,
+				Output:   true,
+				Prompts:  true,
+				Run:      usersAdd,
+			},
+			{
+				Name:     "import",
+				Args:     "[file]",
+				Summary:  "Add or update users from a NDJSON or CSV file (or the standard input), generating passwords for new users without a password hash",
+				Flags:    usersImportFlags,
+				Database: true,
+				Output:   true,
+				Run:      usersImport,
+			},
+			{
+				Name:     "edit",
+				Args:     "<user>",
+				Summary:  "Edit the username, email, role, or password (with -password) of an user, by ID, username, or email",
+				Flags:    userFlags,
+				Database: true,
+				Output:   true,
+				Prompts:  true,
+				Run:      usersEdit,
+			},
+			{
+				Name:     "revoke",
+				Args:     "<user>",
+				Summary:  "Revoke the access of an user, by ID, username, or email, ending their sessions",
+				Database: true,
+				Output:   true,
+				Run:      usersRevoke,
+			},
+		},
+	})
+}
+
+// user data printed by the commands (without credentials).
+type user struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	TwoFactor bool   `json:"two_factor"`
+}
+
+func newUser(u users.User) user {
+	return user{
+		UserID:    u.UserID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Role:      u.Role,
+		TwoFactor: u.TwoFactor(),
+	}
+}
+
+// userValues of the flags of the commands adding or editing users.
+type userValues struct {
+	username          string
+	email             string
+	role              string
+	password          bool
+	passwordHashStdin bool
+	generatePassword  bool
+	update            bool
+}
+
+var (
+	listAll bool
+
+	userFlagValues userValues
+
+	// flags explicitly set, for editing
+	userFlagsSet = map[string]bool{}
+)
+
+func usersListFlags(c *config.Config) {
+	c.FlagSet().BoolVar(&listAll, "all", false, "List revoked users too")
+}
+
+func userFlags(c *config.Config) {
+	var flags = c.FlagSet()
+	flags.Var(trackedString{&userFlagValues.username, "username"}, "username", "Username")
+	flags.Var(trackedString{&userFlagValues.email, "email"}, "email", "Email")
+	flags.Var(trackedString{&userFlagValues.role, "role"}, "role", "Role ("+strings.Join(users.Roles, ", ")+")")
+	flags.BoolVar(&userFlagValues.password, "password", false, "Set the password (prompted on a terminal, or read from the standard input)")
+	flags.BoolVar(&userFlagValues.passwordHashStdin, "password-hash-stdin", false, "Set the password with a bcrypt hash read from the standard input")
+	flags.BoolVar(&userFlagValues.generatePassword, "generate-password", false, "Set a random password, printed only once")
+}
+
+func usersAddFlags(c *config.Config) {
+	userFlags(c)
+	c.FlagSet().BoolVar(&userFlagValues.update, "update", false, "Update the user if the username exists, instead of failing")
+}
+
+// trackedString flag, remembering if it was set.
+type trackedString struct {
+	p    *string
+	name string
+}
+
+func (t trackedString) String() string {
+	if t.p == nil {
+		return ""
+	}
+
+	return *t.p
+}
+
+func (t trackedString) Set(v string) error {
+	*t.p = v
+	userFlagsSet[t.name] = true
+	return nil
+}
+
+func usersList(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	list, err := users.List(ctx, users.Filter{
+		Active: !listAll,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	var out = []user{}
+
+	for _, u := range list {
+		out = append(out, newUser(u))
+	}
+
+	return output(out, func(w io.Writer) error {
+		return table(w, "USER ID\tUSERNAME\tEMAIL\tROLE\t2FA", func(w io.Writer) {
+			for _, u := range out {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%v\n", u.UserID, u.Username, u.Email, u.Role, u.TwoFactor)
+			}
+		})
+	})
+}
+
+// findUser by ID, username, or email.
+func findUser(ctx context.Context, id string) (users.User, error) {
+	if _, err := uuid.FromString(id); err == nil {
+		u, err := users.Get(ctx, id)
+
+		if err != sql.ErrNoRows {
+			return u, err
+		}
+	}
+
+	list, err := users.List(ctx, users.Filter{})
+
+	if err != nil {
+		return users.User{}, err
+	}
+
+	for _, u := range list {
+		if u.Username == id || strings.EqualFold(u.Email, id) {
+			return users.Get(ctx, u.UserID)
+		}
+	}
+
+	return users.User{}, fmt.Errorf(`user "%s" not found`, id)
+}
+
+// passwordFromFlags returns the password hash set with -password-hash-stdin, a generated password,
+// or the hash of a password prompted on a terminal (or read from the standard input).
+func passwordFromFlags(v userValues) (hash, generated string, err error) {
+	switch {
+	case v.passwordHashStdin && v.generatePassword:
+		return "", "", errors.New("use either -password-hash-stdin or -generate-password")
+	case v.passwordHashStdin:
+		if hash, err = readLine(); err == io.EOF {
+			err = errors.New("missing password hash on the standard input")
+		}
+
+		if err == nil {
+			err = users.ValidatePasswordHash(hash)
+		}
+
+		return hash, "", err
+	case v.generatePassword:
+		if generated, err = generatePassword(); err != nil {
+			return "", "", err
+		}
+
+		hash, err = hashPassword(generated)
+		return hash, generated, err
+	}
+
+	password, err := readPassword("Password")
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if err = users.ValidatePassword(password); err != nil {
+		return "", "", err
+	}
+
+	hash, err = hashPassword(password)
+	return hash, "", err
+}
+
+// printPassword generated for an user.
+func printPassword(w io.Writer, password string) {
+	if password != "" {
+		_, _ = fmt.Fprintf(w, "Password: %s\n(it won't be shown again)\n", password)
+	}
+}
+
+func usersAdd(ctx context.Context, args []string) (err error) {
+	if err = exactArgs(args, 0); err != nil {
+		return err
+	}
+
+	var v = userFlagValues
+	var rec = userRecord{
+		Username: v.username,
+		Email:    v.email,
+		Role:     v.role,
+	}
+
+	if rec.Username == "" {
+		if rec.Username, err = prompt("Username"); err != nil {
+			return err
+		}
+	}
+
+	if err = users.ValidateUsername(rec.Username); err != nil {
+		return err
+	}
+
+	if rec.Email == "" && !v.update {
+		if rec.Email, err = prompt("Email"); err != nil {
+			return err
+		}
+	}
+
+	if rec.Role == "" && !v.update && interactive() {
+		if rec.Role, err = prompt("Role [admin/member] (default: member)"); err != nil {
+			return err
+		}
+	}
+
+	if rec.Role != "" {
+		if err = users.ValidateRole(rec.Role); err != nil {
+			return err
+		}
+	}
+
+	var p = &provisioner{
+		Update:  v.update,
+		Command: "users add",
+	}
+
+	_, exists, err := p.lookup(ctx, rec.Username)
+
+	if err != nil {
+		return err
+	}
+
+	if exists && !v.update {
+		return fmt.Errorf(`username "%s" is taken (use -update to update the user)`, rec.Username)
+	}
+
+	var generated string
+
+	// existing users keep their password, unless a new one is set explicitly
+	if !exists || v.password || v.passwordHashStdin || v.generatePassword {
+		if rec.PasswordHash, generated, err = passwordFromFlags(v); err != nil {
+			return err
+		}
+	}
+
+	r, err := p.Provision(ctx, rec)
+
+	if err != nil {
+		return err
+	}
+
+	r.Password = generated
+
+	return output(r, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "User \"%s\" %s.\n", r.UserID, r.Result)
+		printPassword(w, r.Password)
+		return err
+	})
+}
+
+func usersEdit(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 1); err != nil {
+		return err
+	}
+
+	u, err := findUser(ctx, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	var v = userFlagValues
+	var changes []string
+	var action = audit.UserUpdate
+
+	if userFlagsSet["username"] && v.username != u.Username {
+		if taken, err := users.UsernameTaken(ctx, v.username); err != nil || taken {
+			if err == nil {
+				err = fmt.Errorf(`username "%s" is taken`, v.username)
+			}
+
+			return err
+		}
+
+		changes = append(changes, "username="+v.username)
+		u.Username = v.username
+	}
+
+	if userFlagsSet["email"] && v.email != u.Email {
+		changes = append(changes, "email="+v.email)
+		u.Email = v.email
+	}
+
+	if userFlagsSet["role"] && v.role != u.Role {
+		changes = append(changes, "role="+v.role)
+		u.Role = v.role
+
+		if u.Role == users.Revoked {
+			action = audit.UserRevoke
+		}
+	}
+
+	var generated string
+
+	if v.password || v.passwordHashStdin || v.generatePassword {
+		if u.Password, generated, err = passwordFromFlags(v); err != nil {
+			return err
+		}
+
+		changes = append(changes, "password")
+	}
+
+	if len(changes) == 0 {
+		return errors.New("nothing to change: use -username, -email, -role, -password, -password-hash-stdin, or -generate-password")
+	}
+
+	if err = users.Validate(u); err != nil {
+		return err
+	}
+
+	if err = users.Update(ctx, u); err != nil {
+		return err
+	}
+
+	// a new password or revoked access logs the user out everywhere
+	if generated != "" || v.password || v.passwordHashStdin || u.Role == users.Revoked {
+		if _, err = usersessions.RevokeAll(ctx, u.UserID, ""); err != nil {
+			return err
+		}
+	}
+
+	record(ctx, action, u.UserID, strings.Join(changes, " "), "users edit")
+
+	var out = struct {
+		user
+		Password string `json:"password,omitempty"`
+	}{newUser(u), generated}
+
+	return output(out, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "User \"%s\" updated.\n", u.UserID)
+		printPassword(w, generated)
+		return err
+	})
+}
+
+func usersRevoke(ctx context.Context, args []string) error {
+	if err := exactArgs(args, 1); err != nil {
+		return err
+	}
+
+	u, err := findUser(ctx, args[0])
+
+	if err != nil {
+		return err
+	}
+
+	if u.Role != users.Revoked {
+		u.Role = users.Revoked
+
+		if err = users.Update(ctx, u); err != nil {
+			return err
+		}
+
+		record(ctx, audit.UserRevoke, u.UserID, "role="+users.Revoked, "users revoke")
+	}
+
+	if _, err = usersessions.RevokeAll(ctx, u.UserID, ""); err != nil {
+		return err
+	}
+
+	return output(newUser(u), func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "User \"%s\" revoked.\n", u.UserID)
+		return err
+	})
+}
+
+// record change made with a command on the audit log.
+func record(ctx context.Context, action audit.Action, target, details, command string) {
+	var err = audit.Record(ctx, audit.Entry{
+		Action:  action,
+		Target:  target,
+		Details: details,
+		Method:  "CLI",
+		Path:    Name + " " + command,
+	})
+
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "can't record %s on the audit log: %v\n", action, err)
+	}
+}
+
+var (
+	importFormat string
+	importUpdate bool
+	importDryRun bool
+)
+
+func usersImportFlags(c *config.Config) {
+	var flags = c.FlagSet()
+	flags.StringVar(&importFormat, "format", "", "Format of the file: ndjson or csv (default: from the file extension, or ndjson)")
+	flags.BoolVar(&importUpdate, "update", false, "Update existing users (by username), instead of failing")
+	flags.BoolVar(&importDryRun, "dry-run", false, "Validate the users without saving them")
+}
+
+func usersImport(ctx context.Context, args []string) (err error) {
+	if len(args) > 1 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args[1:], " "))
+	}
+
+	var r = stdin
+	var format = importFormat
+
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		r = f
+
+		if format == "" && strings.EqualFold(filepath.Ext(args[0]), ".csv") {
+			format = "csv"
+		}
+	}
+
+	if format == "" {
+		format = "ndjson"
+	}
+
+	var p = &provisioner{
+		Update:  importUpdate,
+		DryRun:  importDryRun,
+		Command: "users import",
+	}
+
+	var results = []provisioned{}
+	var counts = map[string]int{}
+
+	err = readUserRecords(r, format, func(line int, rec userRecord, err error) error {
+		var result provisioned
+
+		if err == nil {
+			result, err = p.Provision(ctx, rec)
+		}
+
+		if err != nil {
+			result.Username = rec.Username
+			result.Result = resultFailed
+			result.Error = err.Error()
+		}
+
+		result.Line = line
+		results = append(results, result)
+		counts[result.Result]++
+
+		if err == context.Canceled {
+			return err
+		}
+
+		return nil
+	})
+
+	if oerr := output(results, func(w io.Writer) error {
+		return table(w, "LINE\tUSERNAME\tUSER ID\tRESULT\tPASSWORD", func(w io.Writer) {
+			for _, r := range results {
+				var note = r.Password
+
+				if r.Error != "" {
+					note = r.Error
+				}
+
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", r.Line, r.Username, r.UserID, r.Result, note)
+			}
+		})
+	}); err == nil {
+		err = oerr
+	}
+
+	var dryRun string
+
+	if importDryRun {
+		dryRun = " (dry run)"
+	}
+
+	_, _ = fmt.Fprintf(stderr, "%d created, %d updated, %d unchanged, %d failed%s.\n",
+		counts[resultCreated], counts[resultUpdated], counts[resultUnchanged], counts[resultFailed], dryRun)
+
+	if err == nil && counts[resultFailed] != 0 {
+		err = fmt.Errorf("failed to import %d users", counts[resultFailed])
+	}
+
+	return err
+}
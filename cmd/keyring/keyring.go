@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/keyring"
+)
+
+const usage = `Manage the keys signing the session and CSRF cookies.
+
+Usage:
+  keyring [flags] rotate    add new keys, and remove keys replaced more than -retire ago
+  keyring [flags] list      list the keys (without revealing them)
+  keyring [flags] config print
+
+The newest key of each purpose signs the cookies, and all of them verify,
+so rotating the keys doesn't log anybody out. Restart the servers to use the new keys.
+
+Flags:
+`
+
+var (
+	conf   = config.New("keyring")
+	file   string
+	retire time.Duration
+)
+
+func rotate() error {
+	kr, err := keyring.Load(file)
+
+	if err != nil {
+		return err
+	}
+
+	var now = time.Now()
+
+	if kr, err = kr.Rotate(now, retire); err != nil {
+		return err
+	}
+
+	if err = kr.Save(file); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Rotated keys on %s.\n", file)
+	return list()
+}
+
+func list() error {
+	kr, err := keyring.Load(file)
+
+	if err != nil {
+		return err
+	}
+
+	if len(kr) == 0 {
+		return fmt.Errorf("no keys on %s: create them with the rotate command", file)
+	}
+
+	var w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PURPOSE\tCREATED\tFINGERPRINT\tUSE")
+
+	for _, p := range keyring.Purposes {
+		var use = "signs"
+
+		for _, k := range kr.Entries(p) {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", k.Purpose, k.Created.Format(time.RFC3339), k.Fingerprint(), use)
+			use = "verifies"
+		}
+	}
+
+	return w.Flush()
+}
+
+func run() error {
+	if err := conf.Load(os.Args[1:]); err != nil {
+		return err
+	}
+
+	if ok, err := conf.Command(os.Stdout); ok {
+		return err
+	}
+
+	var command string
+
+	if args := conf.Args(); len(args) != 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "rotate":
+		return rotate()
+	case "list":
+		return list()
+	case "":
+		conf.FlagSet().Usage()
+		return errors.New("missing command")
+	default:
+		conf.FlagSet().Usage()
+		return fmt.Errorf(`unknown command "%s"`, command)
+	}
+}
+
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	conf.String(&file, "file", "keyring.file", "climetrics.keyring", "Keyring file")
+	conf.FlagSet().DurationVar(&retire, "retire", keyring.DefaultRetire, "Remove keys replaced more than this long ago")
+
+	conf.FlagSet().Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, usage)
+		conf.PrintDefaults()
+	}
+}
@@ -0,0 +1,16 @@
+// Command migrate is a shortcut for "climetrics migrate".
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/henvic/climetrics/cli"
+)
+
+func main() {
+	if err := cli.Main(append([]string{"migrate"}, os.Args[1:]...)); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,604 @@
+// Package config reads the settings of the server and commands from a configuration file (TOML or YAML),
+// environment variables, and flags.
+//
+// Flags take precedence over environment variables, which take precedence over the configuration file.
+// Each setting has a flag (i.e., -oidc-client-id), a key on the configuration file (i.e., oidc.client_id),
+// and an environment variable (i.e., CLIMETRICS_OIDC_CLIENT_ID).
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix of the environment variables of the settings.
+const EnvPrefix = "CLIMETRICS_"
+
+// ConfigEnv is the environment variable with the configuration file, if the -config flag is not used.
+const ConfigEnv = EnvPrefix + "CONFIG"
+
+// Source of the value of a setting.
+type Source string
+
+const (
+	// Default value.
+	Default Source = "default"
+
+	// File is the configuration file.
+	File Source = "file"
+
+	// Env is an environment variable.
+	Env Source = "env"
+
+	// Flag is a command-line flag.
+	Flag Source = "flag"
+)
+
+// Setting of the configuration.
+type Setting struct {
+	// Key on the configuration file.
+	Key string
+
+	// Flag name.
+	Flag string
+
+	// Env variables, in order of preference.
+	Env []string
+
+	// Source of the current value.
+	Source Source
+
+	value  flag.Value
+	usage  string
+	secret bool
+	mask   func(string) string
+}
+
+// Secret setting, masked when printed.
+func (s *Setting) Secret() *Setting {
+	s.secret = true
+	return s
+}
+
+// Mask setting with a function when printed, such as to hide the password of an URL.
+func (s *Setting) Mask(fn func(string) string) *Setting {
+	s.mask = fn
+	return s
+}
+
+// Alias for the setting on the environment, taking precedence over the default environment variable.
+func (s *Setting) Alias(env string) *Setting {
+	s.Env = append([]string{env}, s.Env...)
+	return s
+}
+
+// Value of the setting.
+func (s *Setting) Value() string {
+	return s.value.String()
+}
+
+// Printable value of the setting, with secrets masked.
+func (s *Setting) Printable() string {
+	var v = s.value.String()
+
+	switch {
+	case s.secret && v != "":
+		return "********"
+	case s.mask != nil:
+		return s.mask(v)
+	default:
+		return v
+	}
+}
+
+// Config of a program.
+type Config struct {
+	Name string
+
+	flags      *flag.FlagSet
+	settings   []*Setting
+	validators []func() error
+	owned      map[string]bool
+
+	file string
+	args []string
+
+	// Getenv is replaceable for testing.
+	Getenv func(string) string
+}
+
+// New configuration for the program.
+func New(name string) *Config {
+	var c = &Config{
+		Name:   name,
+		flags:  flag.NewFlagSet(name, flag.ExitOnError),
+		Getenv: os.Getenv,
+	}
+
+	c.flags.StringVar(&c.file, "config", "", "Configuration file (TOML or YAML), also set with the "+ConfigEnv+" environment variable")
+	c.flags.Usage = func() {
+		_, _ = fmt.Fprintf(c.flags.Output(), "Usage of %s:\n", c.Name)
+		c.PrintDefaults()
+	}
+
+	return c
+}
+
+// PrintDefaults of the flags, with the keys and environment variables of the settings.
+func (c *Config) PrintDefaults() {
+	var w = c.flags.Output()
+
+	c.flags.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+
+		if s := c.lookupFlag(f.Name); s != nil {
+			name = typeName(s.value)
+			usage = fmt.Sprintf("%s (%s, $%s)", usage, s.Key, strings.Join(s.Env, ", $"))
+		}
+
+		var line = "  -" + f.Name
+
+		if name != "" {
+			line += " " + name
+		}
+
+		line += "\n    \t" + usage
+
+		switch f.DefValue {
+		case "", "0", "false", "0s":
+		default:
+			line += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+
+		_, _ = fmt.Fprintln(w, line)
+	})
+
+	_, _ = fmt.Fprintf(w, "\nPrint the effective configuration with: %s config print\n", c.Name)
+}
+
+func (c *Config) lookupFlag(name string) *Setting {
+	for _, s := range c.settings {
+		if s.Flag == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+func typeName(v flag.Value) string {
+	switch v.(type) {
+	case *intValue:
+		return "int"
+	case *float64Value:
+		return "float"
+	case *boolValue:
+		return ""
+	case *durationValue:
+		return "duration"
+	default:
+		return "string"
+	}
+}
+
+// FlagSet of the configuration.
+func (c *Config) FlagSet() *flag.FlagSet {
+	return c.flags
+}
+
+// Var adds a setting. The key is the name on the configuration file, with sections separated by dots.
+func (c *Config) Var(value flag.Value, name, key, usage string) *Setting {
+	var s = &Setting{
+		Key:    key,
+		Flag:   name,
+		Env:    []string{EnvName(key)},
+		Source: Default,
+		value:  value,
+		usage:  usage,
+	}
+
+	c.flags.Var(value, name, usage)
+	c.settings = append(c.settings, s)
+	return s
+}
+
+// String setting.
+func (c *Config) String(p *string, name, key, value, usage string) *Setting {
+	*p = value
+	return c.Var((*stringValue)(p), name, key, usage)
+}
+
+// Int setting.
+func (c *Config) Int(p *int, name, key string, value int, usage string) *Setting {
+	*p = value
+	return c.Var((*intValue)(p), name, key, usage)
+}
+
+// Float64 setting.
+func (c *Config) Float64(p *float64, name, key string, value float64, usage string) *Setting {
+	*p = value
+	return c.Var((*float64Value)(p), name, key, usage)
+}
+
+// Bool setting.
+func (c *Config) Bool(p *bool, name, key string, value bool, usage string) *Setting {
+	*p = value
+	return c.Var((*boolValue)(p), name, key, usage)
+}
+
+// Duration setting.
+func (c *Config) Duration(p *time.Duration, name, key string, value time.Duration, usage string) *Setting {
+	*p = value
+	return c.Var((*durationValue)(p), name, key, usage)
+}
+
+// Own sections of the configuration file (use "" for the keys without a section).
+// Unknown keys on owned sections are an error, likely a typo, and are ignored on the other sections,
+// so the same file can configure the server and the commands.
+func (c *Config) Own(sections ...string) {
+	if c.owned == nil {
+		c.owned = map[string]bool{}
+	}
+
+	for _, s := range sections {
+		c.owned[s] = true
+	}
+}
+
+// Validate the configuration with fn after loading it.
+func (c *Config) Validate(fn func() error) {
+	c.validators = append(c.validators, fn)
+}
+
+// Settings of the configuration, sorted by key.
+func (c *Config) Settings() []*Setting {
+	var settings = append([]*Setting{}, c.settings...)
+
+	sort.SliceStable(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+
+	return settings
+}
+
+// Lookup setting by key.
+func (c *Config) Lookup(key string) *Setting {
+	for _, s := range c.settings {
+		if s.Key == key {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// Args remaining after the flags.
+func (c *Config) Args() []string {
+	return c.args
+}
+
+// File used, if any.
+func (c *Config) File() string {
+	return c.file
+}
+
+// Load configuration from the command-line arguments (without the program name),
+// the environment, and the configuration file, and validate it.
+func (c *Config) Load(args []string) error {
+	if err := c.flags.Parse(args); err != nil {
+		return err
+	}
+
+	c.args = c.flags.Args()
+
+	var explicit = map[string]bool{}
+
+	c.flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	if c.file == "" {
+		c.file = c.Getenv(ConfigEnv)
+	}
+
+	if c.file != "" {
+		values, err := ReadFile(c.file)
+
+		if err != nil {
+			return err
+		}
+
+		if err = c.apply(values, explicit); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range c.settings {
+		if explicit[s.Flag] {
+			s.Source = Flag
+			continue
+		}
+
+		for _, env := range s.Env {
+			v, ok := c.lookupEnv(env)
+
+			if !ok {
+				continue
+			}
+
+			if err := s.value.Set(v); err != nil {
+				return fmt.Errorf("invalid value for %s on the %s environment variable: %v", s.Key, env, err)
+			}
+
+			s.Source = Env
+			break
+		}
+	}
+
+	// the configuration is printed even when invalid, to help fixing it
+	if len(c.args) != 0 && c.args[0] == "config" {
+		return nil
+	}
+
+	for _, fn := range c.validators {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) lookupEnv(name string) (string, bool) {
+	var v = c.Getenv(name)
+	return v, v != ""
+}
+
+func (c *Config) apply(values map[string]string, explicit map[string]bool) error {
+	var keys []string
+
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var s = c.Lookup(k)
+
+		if s == nil {
+			if section, _ := split(k); c.owned[section] {
+				return fmt.Errorf("%s: unknown setting %s", c.file, k)
+			}
+
+			continue
+		}
+
+		if explicit[s.Flag] {
+			continue
+		}
+
+		if err := s.value.Set(values[k]); err != nil {
+			return fmt.Errorf("%s: invalid value for %s: %v", c.file, k, err)
+		}
+
+		s.Source = File
+	}
+
+	return nil
+}
+
+// Command handles the config command ("config print"), returning true if it was used.
+func (c *Config) Command(w io.Writer) (bool, error) {
+	if len(c.args) == 0 || c.args[0] != "config" {
+		return false, nil
+	}
+
+	if len(c.args) != 2 || c.args[1] != "print" {
+		return true, errors.New(`unknown config command: use "config print"`)
+	}
+
+	return true, c.Print(w)
+}
+
+// Print effective configuration as TOML, with secrets masked.
+func (c *Config) Print(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# effective configuration of %s", c.Name)
+
+	if c.file != "" {
+		fmt.Fprintf(&b, " (file: %s)", c.file)
+	}
+
+	b.WriteString("\n")
+
+	var settings = c.Settings()
+
+	// settings without a section come first, as TOML requires
+	sort.SliceStable(settings, func(i, j int) bool {
+		si, _ := split(settings[i].Key)
+		sj, _ := split(settings[j].Key)
+		return si < sj
+	})
+
+	var section = ""
+
+	for _, s := range settings {
+		var sec, name = split(s.Key)
+
+		if sec != section {
+			fmt.Fprintf(&b, "\n[%s]\n", sec)
+			section = sec
+		}
+
+		fmt.Fprintf(&b, "%s = %s # %s\n", name, tomlValue(s), s.Source)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// split key into its section and name.
+func split(key string) (section, name string) {
+	if i := strings.LastIndex(key, "."); i != -1 {
+		return key[:i], key[i+1:]
+	}
+
+	return "", key
+}
+
+func tomlValue(s *Setting) string {
+	var v = s.Printable()
+
+	switch s.value.(type) {
+	case *intValue, *float64Value, *boolValue:
+		return v
+	default:
+		return strconv.Quote(v)
+	}
+}
+
+// EnvName of the environment variable for a key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// ReadFile with the configuration, as a map of keys (with sections separated by dots) to values.
+// The format is chosen by the file extension: .toml, .yaml, or .yml.
+func ReadFile(filename string) (map[string]string, error) {
+	f, err := os.Open(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var values map[string]string
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		values, err = ParseTOML(f)
+	case ".yaml", ".yml":
+		values, err = ParseYAML(f)
+	default:
+		return nil, fmt.Errorf("%s: unknown configuration file format (use .toml, .yaml, or .yml)", filename)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	return values, nil
+}
+
+// MaskURL hides the password of an URL, such as a DSN.
+func MaskURL(v string) string {
+	u, err := url.Parse(v)
+
+	if err != nil || u.User == nil {
+		return v
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		return v
+	}
+
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return u.String()
+}
+
+type stringValue string
+
+func (s *stringValue) Set(v string) error {
+	*s = stringValue(v)
+	return nil
+}
+
+func (s *stringValue) String() string {
+	return string(*s)
+}
+
+type intValue int
+
+func (i *intValue) Set(v string) error {
+	n, err := strconv.Atoi(v)
+
+	if err != nil {
+		return fmt.Errorf("%q is not an integer", v)
+	}
+
+	*i = intValue(n)
+	return nil
+}
+
+func (i *intValue) String() string {
+	return strconv.Itoa(int(*i))
+}
+
+type float64Value float64
+
+func (f *float64Value) Set(v string) error {
+	n, err := strconv.ParseFloat(v, 64)
+
+	if err != nil {
+		return fmt.Errorf("%q is not a number", v)
+	}
+
+	*f = float64Value(n)
+	return nil
+}
+
+func (f *float64Value) String() string {
+	return strconv.FormatFloat(float64(*f), 'g', -1, 64)
+}
+
+type boolValue bool
+
+func (b *boolValue) Set(v string) error {
+	n, err := strconv.ParseBool(v)
+
+	if err != nil {
+		return fmt.Errorf("%q is not a boolean", v)
+	}
+
+	*b = boolValue(n)
+	return nil
+}
+
+func (b *boolValue) String() string {
+	return strconv.FormatBool(bool(*b))
+}
+
+// IsBoolFlag allows the flag to be used without a value (i.e., -expose-debug).
+func (b *boolValue) IsBoolFlag() bool {
+	return true
+}
+
+type durationValue time.Duration
+
+func (d *durationValue) Set(v string) error {
+	n, err := time.ParseDuration(v)
+
+	if err != nil {
+		return fmt.Errorf("%q is not a duration (i.e., 90s, 15m, 24h)", v)
+	}
+
+	*d = durationValue(n)
+	return nil
+}
+
+func (d *durationValue) String() string {
+	return time.Duration(*d).String()
+}
@@ -0,0 +1,379 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The configuration files are parsed into flat maps of keys (with sections separated by dots) to values.
+// Only what the settings need is supported: tables (sections), strings, numbers, booleans, and arrays,
+// which are joined with commas (i.e., providers = ["mmdb", "ipinfo"] is the same as "mmdb,ipinfo").
+
+// ParseTOML configuration.
+func ParseTOML(r io.Reader) (map[string]string, error) {
+	var values = map[string]string{}
+	var scanner = bufio.NewScanner(r)
+	var section string
+	var n int
+
+	for scanner.Scan() {
+		n++
+		var line = strings.TrimSpace(stripComment(scanner.Text()))
+
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table %s", n, line)
+			}
+
+			var err error
+
+			if section, err = tomlKey(strings.TrimSpace(line[1 : len(line)-1])); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+
+			continue
+		}
+
+		var eq = strings.Index(line, "=")
+
+		if eq == -1 {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+
+		key, err := tomlKey(strings.TrimSpace(line[:eq]))
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		var raw = strings.TrimSpace(line[eq+1:])
+
+		// multi-line arrays
+		for strings.HasPrefix(raw, "[") && strings.Count(raw, "[") > strings.Count(raw, "]") {
+			if !scanner.Scan() {
+				return nil, fmt.Errorf("line %d: unterminated array", n)
+			}
+
+			n++
+			raw += " " + strings.TrimSpace(stripComment(scanner.Text()))
+		}
+
+		value, err := scalarOrArray(raw)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		if section != "" {
+			key = section + "." + key
+		}
+
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %s", n, key)
+		}
+
+		values[key] = value
+	}
+
+	return values, scanner.Err()
+}
+
+func tomlKey(k string) (string, error) {
+	var parts = strings.Split(k, ".")
+
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+
+		if len(p) >= 2 && (p[0] == '"' || p[0] == '\'') {
+			var err error
+
+			if p, err = unquote(p); err != nil {
+				return "", err
+			}
+		}
+
+		if p == "" {
+			return "", fmt.Errorf("invalid key %q", k)
+		}
+
+		parts[i] = p
+	}
+
+	return strings.Join(parts, "."), nil
+}
+
+type yamlLevel struct {
+	indent int
+	key    string
+}
+
+// ParseYAML configuration.
+func ParseYAML(r io.Reader) (map[string]string, error) {
+	var values = map[string]string{}
+	var scanner = bufio.NewScanner(r)
+	var stack []yamlLevel
+	var n int
+
+	// a key waiting for its value: a nested mapping or a block sequence
+	var pending string
+	var list []string
+	var inList bool
+
+	flush := func() {
+		if inList {
+			values[pending] = strings.Join(list, ",")
+		}
+
+		pending, list, inList = "", nil, false
+	}
+
+	for scanner.Scan() {
+		n++
+		var text = scanner.Text()
+
+		if strings.HasPrefix(strings.TrimLeft(text, " "), "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n)
+		}
+
+		var line = strings.TrimRight(stripComment(text), " \t")
+		var content = strings.TrimSpace(line)
+
+		if content == "" || content == "---" {
+			continue
+		}
+
+		var indent = len(line) - len(strings.TrimLeft(line, " "))
+
+		if strings.HasPrefix(content, "- ") || content == "-" {
+			if pending == "" {
+				return nil, fmt.Errorf("line %d: unexpected sequence item", n)
+			}
+
+			item, err := scalar(strings.TrimSpace(strings.TrimPrefix(content, "-")))
+
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", n, err)
+			}
+
+			inList = true
+			list = append(list, item)
+			continue
+		}
+
+		if pending != "" && !inList {
+			// the pending key is a mapping
+			stack = append(stack, yamlLevel{indent: -1, key: pending})
+			pending = ""
+		}
+
+		flush()
+
+		// the first entry of a mapping sets its indentation
+		if len(stack) != 0 && stack[len(stack)-1].indent == -1 {
+			var parent = 0
+
+			if len(stack) > 1 {
+				parent = stack[len(stack)-2].indent
+			}
+
+			if indent <= parent && len(stack) > 1 || indent == 0 {
+				return nil, fmt.Errorf("line %d: expected an indented mapping", n)
+			}
+
+			stack[len(stack)-1].indent = indent
+		}
+
+		for len(stack) != 0 && indent < stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		var expected = 0
+
+		if len(stack) != 0 {
+			expected = stack[len(stack)-1].indent
+		}
+
+		if indent != expected {
+			return nil, fmt.Errorf("line %d: unexpected indentation", n)
+		}
+
+		var colon = yamlColon(content)
+
+		if colon == -1 {
+			return nil, fmt.Errorf("line %d: expected key: value", n)
+		}
+
+		key, err := scalar(strings.TrimSpace(content[:colon]))
+
+		if err != nil || key == "" {
+			return nil, fmt.Errorf("line %d: invalid key", n)
+		}
+
+		// the key of a mapping already has the keys of its parents
+		if len(stack) != 0 {
+			key = stack[len(stack)-1].key + "." + key
+		}
+
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %s", n, key)
+		}
+
+		var raw = strings.TrimSpace(content[colon+1:])
+
+		if raw == "" {
+			pending = key
+			continue
+		}
+
+		value, err := scalarOrArray(raw)
+
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		values[key] = value
+	}
+
+	if pending != "" && !inList {
+		values[pending] = ""
+	}
+
+	flush()
+	return values, scanner.Err()
+}
+
+// yamlColon finds the colon separating a key from its value.
+func yamlColon(s string) int {
+	var quote rune
+
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ':' && (i == len(s)-1 || s[i+1] == ' '):
+			return i
+		}
+	}
+
+	return -1
+}
+
+// stripComment removes a # comment outside of quotes.
+func stripComment(s string) string {
+	var quote rune
+	var escaped bool
+
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || unicode.IsSpace(rune(s[i-1]))):
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+func scalarOrArray(raw string) (string, error) {
+	if !strings.HasPrefix(raw, "[") {
+		return scalar(raw)
+	}
+
+	if !strings.HasSuffix(raw, "]") {
+		return "", errors.New("invalid array")
+	}
+
+	var items []string
+
+	for _, item := range splitArray(raw[1 : len(raw)-1]) {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		v, err := scalar(item)
+
+		if err != nil {
+			return "", err
+		}
+
+		items = append(items, v)
+	}
+
+	return strings.Join(items, ","), nil
+}
+
+// splitArray items by commas outside of quotes.
+func splitArray(s string) []string {
+	var items []string
+	var quote rune
+	var start int
+
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote && (quote == '\'' || i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(items, s[start:])
+}
+
+func scalar(raw string) (string, error) {
+	if len(raw) != 0 && (raw[0] == '"' || raw[0] == '\'') {
+		return unquote(raw)
+	}
+
+	if strings.HasPrefix(raw, "[") || strings.HasPrefix(raw, "{") {
+		return "", fmt.Errorf("unsupported value %s", raw)
+	}
+
+	return raw, nil
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[len(s)-1] != s[0] {
+		return "", fmt.Errorf("unterminated string %s", s)
+	}
+
+	if s[0] == '\'' {
+		// literal strings have no escapes (in YAML, '' is a quote)
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+
+	v, err := strconv.Unquote(s)
+
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+
+	return v, nil
+}
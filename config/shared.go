@@ -0,0 +1,42 @@
+package config
+
+import (
+	"github.com/henvic/climetrics/archive"
+	"github.com/henvic/climetrics/geolocation"
+	"github.com/henvic/climetrics/retention"
+)
+
+// DefaultDSN of the PostgreSQL database.
+const DefaultDSN = "postgres://admin@/climetrics?sslmode=disable"
+
+// Database setting, shared by the server and the commands.
+func (c *Config) Database(dsn *string) *Setting {
+	return c.String(dsn, "dsn", "database.dsn", DefaultDSN, "dsn (PostgreSQL)").Mask(MaskURL)
+}
+
+// Geolocation settings, shared by the server and the commands.
+func (c *Config) Geolocation(o *geolocation.Options) {
+	c.String(&o.Providers, "geolocation", "geolocation.providers", "ipinfo", "Geolocation providers, in order of preference (ipinfo, mmdb)")
+	c.String(&o.Databases, "geolocation-db", "geolocation.databases", "", "MaxMind database files (i.e., GeoLite2-City.mmdb,GeoLite2-ASN.mmdb) for the mmdb geolocation provider")
+	c.Int(&o.DailyQuota, "ipinfo-daily-quota", "geolocation.ipinfo_daily_quota", 0, "Daily quota of requests to ipinfo.io (0 for unlimited)")
+	c.Int(&o.MonthlyQuota, "ipinfo-monthly-quota", "geolocation.ipinfo_monthly_quota", 50000, "Monthly quota of requests to ipinfo.io (0 for unlimited)")
+}
+
+// Retention settings, shared by the server and the commands.
+func (c *Config) Retention(p *retention.Pruner) {
+	c.Var(&p.Default, "retention", "retention.default", "Retention of metrics (i.e., 365d), unless their type has a rule of its own (0 keeps them forever)")
+	c.Var(&p.Types, "retention-types", "retention.types", "Retention of metrics by event type (i.e., cmd=730d,debug=30d)")
+	c.Var(&p.Diagnostics, "retention-diagnostics", "retention.diagnostics", "Retention of diagnostics reports (0 keeps them forever)")
+	c.Int(&p.BatchSize, "retention-batch-size", "retention.batch_size", p.BatchSize, "Maximum number of rows deleted at once when pruning")
+	c.Duration(&p.Pause, "retention-pause", "retention.pause", p.Pause, "Pause between batches when pruning")
+	c.Bool(&p.Rollups, "retention-rollups", "retention.rollups", false, "Keep daily counts of pruned metrics and diagnostics on rollup tables")
+	c.Validate(p.Validate)
+}
+
+// Archive settings, shared by the server and the commands.
+func (c *Config) Archive(a *archive.Archiver) {
+	c.String(&a.Dir, "archive-dir", "archive.dir", a.Dir, "Directory of the archived metrics and their manifest")
+	c.Int(&a.BatchSize, "archive-batch-size", "archive.batch_size", a.BatchSize, "Maximum number of metrics deleted or restored at once")
+	c.Duration(&a.Pause, "archive-pause", "archive.pause", a.Pause, "Pause between batches when deleting or restoring metrics")
+	c.Validate(a.Validate)
+}
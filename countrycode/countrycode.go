@@ -1,259 +1,158 @@
 package countrycode
 
-var countries = map[string]string{
-	"BD": "Bangladesh",
-	"BE": "Belgium",
-	"BF": "Burkina Faso",
-	"BG": "Bulgaria",
-	"BA": "Bosnia and Herzegovina",
-	"BB": "Barbados",
-	"WF": "Wallis and Futuna",
-	"BL": "Saint Barthelemy",
-	"BM": "Bermuda",
-	"BN": "Brunei",
-	"BO": "Bolivia",
-	"BH": "Bahrain",
-	"BI": "Burundi",
-	"BJ": "Benin",
-	"BT": "Bhutan",
-	"JM": "Jamaica",
-	"BV": "Bouvet Island",
-	"BW": "Botswana",
-	"WS": "Samoa",
-	"BQ": "Bonaire, Saint Eustatius and Saba",
-	"BR": "Brazil",
-	"BS": "Bahamas",
-	"JE": "Jersey",
-	"BY": "Belarus",
-	"BZ": "Belize",
-	"RU": "Russia",
-	"RW": "Rwanda",
-	"RS": "Serbia",
-	"TL": "East Timor",
-	"RE": "Reunion",
-	"TM": "Turkmenistan",
-	"TJ": "Tajikistan",
-	"RO": "Romania",
-	"TK": "Tokelau",
-	"GW": "Guinea-Bissau",
-	"GU": "Guam",
-	"GT": "Guatemala",
-	"GS": "South Georgia and the South Sandwich Islands",
-	"GR": "Greece",
-	"GQ": "Equatorial Guinea",
-	"GP": "Guadeloupe",
-	"JP": "Japan",
-	"GY": "Guyana",
-	"GG": "Guernsey",
-	"GF": "French Guiana",
-	"GE": "Georgia",
-	"GD": "Grenada",
-	"GB": "United Kingdom",
-	"GA": "Gabon",
-	"SV": "El Salvador",
-	"GN": "Guinea",
-	"GM": "Gambia",
-	"GL": "Greenland",
-	"GI": "Gibraltar",
-	"GH": "Ghana",
-	"OM": "Oman",
-	"TN": "Tunisia",
-	"JO": "Jordan",
-	"HR": "Croatia",
-	"HT": "Haiti",
-	"HU": "Hungary",
-	"HK": "Hong Kong",
-	"HN": "Honduras",
-	"HM": "Heard Island and McDonald Islands",
-	"VE": "Venezuela",
-	"PR": "Puerto Rico",
-	"PS": "Palestinian Territory",
-	"PW": "Palau",
-	"PT": "Portugal",
-	"SJ": "Svalbard and Jan Mayen",
-	"PY": "Paraguay",
-	"IQ": "Iraq",
-	"PA": "Panama",
-	"PF": "French Polynesia",
-	"PG": "Papua New Guinea",
-	"PE": "Peru",
-	"PK": "Pakistan",
-	"PH": "Philippines",
-	"PN": "Pitcairn",
-	"PL": "Poland",
-	"PM": "Saint Pierre and Miquelon",
-	"ZM": "Zambia",
-	"EH": "Western Sahara",
-	"EE": "Estonia",
-	"EG": "Egypt",
-	"ZA": "South Africa",
-	"EC": "Ecuador",
-	"IT": "Italy",
-	"VN": "Vietnam",
-	"SB": "Solomon Islands",
-	"ET": "Ethiopia",
-	"SO": "Somalia",
-	"ZW": "Zimbabwe",
-	"SA": "Saudi Arabia",
-	"ES": "Spain",
-	"ER": "Eritrea",
-	"ME": "Montenegro",
-	"MD": "Moldova",
-	"MG": "Madagascar",
-	"MF": "Saint Martin",
-	"MA": "Morocco",
-	"MC": "Monaco",
-	"UZ": "Uzbekistan",
-	"MM": "Myanmar",
-	"ML": "Mali",
-	"MO": "Macao",
-	"MN": "Mongolia",
-	"MH": "Marshall Islands",
-	"MK": "Macedonia",
-	"MU": "Mauritius",
-	"MT": "Malta",
-	"MW": "Malawi",
-	"MV": "Maldives",
-	"MQ": "Martinique",
-	"MP": "Northern Mariana Islands",
-	"MS": "Montserrat",
-	"MR": "Mauritania",
-	"IM": "Isle of Man",
-	"UG": "Uganda",
-	"TZ": "Tanzania",
-	"MY": "Malaysia",
-	"MX": "Mexico",
-	"IL": "Israel",
-	"FR": "France",
-	"IO": "British Indian Ocean Territory",
-	"SH": "Saint Helena",
-	"FI": "Finland",
-	"FJ": "Fiji",
-	"FK": "Falkland Islands",
-	"FM": "Micronesia",
-	"FO": "Faroe Islands",
-	"NI": "Nicaragua",
-	"NL": "Netherlands",
-	"NO": "Norway",
-	"NA": "Namibia",
-	"VU": "Vanuatu",
-	"NC": "New Caledonia",
-	"NE": "Niger",
-	"NF": "Norfolk Island",
-	"NG": "Nigeria",
-	"NZ": "New Zealand",
-	"NP": "Nepal",
-	"NR": "Nauru",
-	"NU": "Niue",
-	"CK": "Cook Islands",
-	"XK": "Kosovo",
-	"CI": "Ivory Coast",
-	"CH": "Switzerland",
-	"CO": "Colombia",
-	"CN": "China",
-	"CM": "Cameroon",
-	"CL": "Chile",
-	"CC": "Cocos Islands",
-	"CA": "Canada",
-	"CG": "Republic of the Congo",
-	"CF": "Central African Republic",
-	"CD": "Democratic Republic of the Congo",
-	"CZ": "Czech Republic",
-	"CY": "Cyprus",
-	"CX": "Christmas Island",
-	"CR": "Costa Rica",
-	"CW": "Curacao",
-	"CV": "Cape Verde",
-	"CU": "Cuba",
-	"SZ": "Swaziland",
-	"SY": "Syria",
-	"SX": "Sint Maarten",
-	"KG": "Kyrgyzstan",
-	"KE": "Kenya",
-	"SS": "South Sudan",
-	"SR": "Suriname",
-	"KI": "Kiribati",
-	"KH": "Cambodia",
-	"KN": "Saint Kitts and Nevis",
-	"KM": "Comoros",
-	"ST": "Sao Tome and Principe",
-	"SK": "Slovakia",
-	"KR": "South Korea",
-	"SI": "Slovenia",
-	"KP": "North Korea",
-	"KW": "Kuwait",
-	"SN": "Senegal",
-	"SM": "San Marino",
-	"SL": "Sierra Leone",
-	"SC": "Seychelles",
-	"KZ": "Kazakhstan",
-	"KY": "Cayman Islands",
-	"SG": "Singapore",
-	"SE": "Sweden",
-	"SD": "Sudan",
-	"DO": "Dominican Republic",
-	"DM": "Dominica",
-	"DJ": "Djibouti",
-	"DK": "Denmark",
-	"VG": "British Virgin Islands",
-	"DE": "Germany",
-	"YE": "Yemen",
-	"DZ": "Algeria",
-	"US": "United States",
-	"UY": "Uruguay",
-	"YT": "Mayotte",
-	"UM": "United States Minor Outlying Islands",
-	"LB": "Lebanon",
-	"LC": "Saint Lucia",
-	"LA": "Laos",
-	"TV": "Tuvalu",
-	"TW": "Taiwan",
-	"TT": "Trinidad and Tobago",
-	"TR": "Turkey",
-	"LK": "Sri Lanka",
-	"LI": "Liechtenstein",
-	"LV": "Latvia",
-	"TO": "Tonga",
-	"LT": "Lithuania",
-	"LU": "Luxembourg",
-	"LR": "Liberia",
-	"LS": "Lesotho",
-	"TH": "Thailand",
-	"TF": "French Southern Territories",
-	"TG": "Togo",
-	"TD": "Chad",
-	"TC": "Turks and Caicos Islands",
-	"LY": "Libya",
-	"VA": "Vatican",
-	"VC": "Saint Vincent and the Grenadines",
-	"AE": "United Arab Emirates",
-	"AD": "Andorra",
-	"AG": "Antigua and Barbuda",
-	"AF": "Afghanistan",
-	"AI": "Anguilla",
-	"VI": "U.S. Virgin Islands",
-	"IS": "Iceland",
-	"IR": "Iran",
-	"AM": "Armenia",
-	"AL": "Albania",
-	"AO": "Angola",
-	"AQ": "Antarctica",
-	"AS": "American Samoa",
-	"AR": "Argentina",
-	"AU": "Australia",
-	"AT": "Austria",
-	"AW": "Aruba",
-	"IN": "India",
-	"AX": "Aland Islands",
-	"AZ": "Azerbaijan",
-	"IE": "Ireland",
-	"ID": "Indonesia",
-	"UA": "Ukraine",
-	"QA": "Qatar",
-	"MZ": "Mozambique",
+import (
+	"strings"
+)
+
+// Continent where a country is located.
+type Continent string
+
+const (
+	// Africa continent.
+	Africa Continent = "Africa"
+
+	// Antarctica continent.
+	Antarctica Continent = "Antarctica"
+
+	// Asia continent.
+	Asia Continent = "Asia"
+
+	// Europe continent.
+	Europe Continent = "Europe"
+
+	// NorthAmerica continent (including Central America and the Caribbean).
+	NorthAmerica Continent = "North America"
+
+	// Oceania continent.
+	Oceania Continent = "Oceania"
+
+	// SouthAmerica continent.
+	SouthAmerica Continent = "South America"
+)
+
+// Continents list.
+var Continents = []Continent{
+	Africa,
+	Antarctica,
+	Asia,
+	Europe,
+	NorthAmerica,
+	Oceania,
+	SouthAmerica,
 }
 
-// Get country code.
+// Locales with localized country names available (besides English).
+var Locales = []string{"de", "es", "fr", "ja", "pt-BR"}
+
+// Country on the ISO 3166-1 standard.
+type Country struct {
+	Alpha2    string
+	Alpha3    string
+	Numeric   string
+	Name      string
+	Continent Continent
+	Region    string
+	SubRegion string
+
+	names map[string]string
+}
+
+// LocalizedName of the country. English is used for unknown locales.
+func (c Country) LocalizedName(locale string) string {
+	locale = strings.Replace(locale, "_", "-", -1)
+
+	if name, ok := c.names[locale]; ok {
+		return name
+	}
+
+	// fallback to the language alone, i.e., "pt-BR" for "pt" or "pt-PT".
+	var lang = locale
+
+	if l := strings.Index(locale, "-"); l != -1 {
+		lang = locale[:l]
+	}
+
+	for _, k := range Locales {
+		if k == lang || strings.HasPrefix(k, lang+"-") {
+			return c.names[k]
+		}
+	}
+
+	return c.Name
+}
+
+var (
+	byCode = map[string]int{}
+	byName = map[string]int{}
+)
+
+func init() {
+	for i, c := range countries {
+		byCode[c.Alpha2] = i
+		byCode[c.Alpha3] = i
+
+		// user-assigned codes (i.e., Kosovo) have no numeric code
+		if c.Numeric != "" {
+			byCode[c.Numeric] = i
+		}
+
+		byName[strings.ToLower(c.Name)] = i
+
+		for _, n := range c.names {
+			if _, ok := byName[strings.ToLower(n)]; !ok {
+				byName[strings.ToLower(n)] = i
+			}
+		}
+	}
+}
+
+// Get country name by its alpha-2, alpha-3, or numeric code.
 func Get(code string) string {
-	return countries[code]
+	if c, ok := Lookup(code); ok {
+		return c.Name
+	}
+
+	return ""
+}
+
+// Lookup country by its alpha-2, alpha-3, or numeric code.
+func Lookup(code string) (c Country, ok bool) {
+	i, ok := byCode[strings.ToUpper(strings.TrimSpace(code))]
+
+	if !ok {
+		return c, false
+	}
+
+	return countries[i], true
+}
+
+// Find country by its English or localized name (case insensitive).
+func Find(name string) (c Country, ok bool) {
+	i, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+
+	if !ok {
+		return c, false
+	}
+
+	return countries[i], true
+}
+
+// GetContinent returns the continent of the country with the given code.
+func GetContinent(code string) Continent {
+	if c, ok := Lookup(code); ok {
+		return c.Continent
+	}
+
+	return ""
+}
+
+// List countries of a continent (or all of them, if empty), ordered by alpha-2 code.
+func List(continent Continent) (cs []Country) {
+	for _, c := range countries {
+		if continent == "" || c.Continent == continent {
+			cs = append(cs, c)
+		}
+	}
+
+	return cs
 }
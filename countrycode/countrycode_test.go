@@ -19,3 +19,113 @@ func TestGetFailure(t *testing.T) {
 		t.Errorf("Expected to get %v, got %v instead", want, got)
 	}
 }
+
+func TestLookup(t *testing.T) {
+	for _, code := range []string{"BR", "br", "BRA", "076"} {
+		c, ok := Lookup(code)
+
+		if !ok {
+			t.Errorf("Expected to find country for code %v", code)
+			continue
+		}
+
+		if c.Alpha2 != "BR" || c.Alpha3 != "BRA" || c.Numeric != "076" {
+			t.Errorf("Expected to get Brazil for code %v, got %+v instead", code, c)
+		}
+	}
+}
+
+func TestLookupKosovo(t *testing.T) {
+	for _, code := range []string{"XK", "XKX"} {
+		c, ok := Lookup(code)
+
+		if !ok || c.Name != "Kosovo" || c.Continent != Europe || c.SubRegion != "Southern Europe" {
+			t.Errorf("Expected to get Kosovo for code %v, got %+v instead", code, c)
+		}
+	}
+
+	if got := Get("XK"); got != "Kosovo" {
+		t.Errorf("Expected to get Kosovo, got %v instead", got)
+	}
+
+	var c, _ = Lookup("XK")
+
+	if got := c.LocalizedName("ja"); got != "コソボ" {
+		t.Errorf("Expected localized name コソボ, got %v instead", got)
+	}
+}
+
+func TestLookupFailure(t *testing.T) {
+	for _, code := range []string{"GDR", ""} {
+		if c, ok := Lookup(code); ok {
+			t.Errorf("Expected no country for code %q, got %+v instead", code, c)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	for _, name := range []string{"Germany", "germany", "Deutschland", "Allemagne"} {
+		c, ok := Find(name)
+
+		if !ok || c.Alpha2 != "DE" {
+			t.Errorf("Expected to find Germany by name %v, got %+v instead", name, c)
+		}
+	}
+}
+
+func TestLocalizedName(t *testing.T) {
+	var c, _ = Lookup("DE")
+
+	var cases = map[string]string{
+		"de":    "Deutschland",
+		"pt-BR": "Alemanha",
+		"pt_BR": "Alemanha",
+		"pt":    "Alemanha",
+		"en":    "Germany",
+		"xx-YY": "Germany",
+	}
+
+	for locale, want := range cases {
+		if got := c.LocalizedName(locale); got != want {
+			t.Errorf("Expected name %v for locale %v, got %v instead", want, locale, got)
+		}
+	}
+}
+
+func TestGetContinent(t *testing.T) {
+	var cases = map[string]Continent{
+		"BR":  SouthAmerica,
+		"US":  NorthAmerica,
+		"MX":  NorthAmerica,
+		"NZ":  Oceania,
+		"AQ":  Antarctica,
+		"IS":  Europe,
+		"MZ":  Africa,
+		"JPN": Asia,
+		"XX":  "",
+	}
+
+	for code, want := range cases {
+		if got := GetContinent(code); got != want {
+			t.Errorf("Expected continent %v for %v, got %v instead", want, code, got)
+		}
+	}
+}
+
+func TestListContinents(t *testing.T) {
+	var total = 0
+
+	for _, continent := range Continents {
+		cs := List(continent)
+
+		if len(cs) == 0 {
+			t.Errorf("Expected countries on continent %v", continent)
+		}
+
+		total += len(cs)
+	}
+
+	if all := List(""); total != len(all) {
+		t.Errorf("Expected every country (%d) to be on a continent, got %d instead", len(all), total)
+	}
+}
@@ -0,0 +1,4006 @@
+package countrycode
+
+// countries is built from the ISO 3166-1 list maintained by the iso-codes project
+// (including its translations) and the UN M49 geoscheme for regions.
+// Kosovo (XK) isn't on ISO 3166-1, but its user-assigned codes are used by geolocation providers.
+var countries = []Country{
+	{
+		Alpha2:    "AD",
+		Alpha3:    "AND",
+		Numeric:   "020",
+		Name:      "Andorra",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Andorra",
+			"es":    "Andorra",
+			"fr":    "Andorre",
+			"ja":    "アンドラ",
+			"pt-BR": "Andorra",
+		},
+	},
+	{
+		Alpha2:    "AE",
+		Alpha3:    "ARE",
+		Numeric:   "784",
+		Name:      "United Arab Emirates",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Vereinigte Arabische Emirate",
+			"es":    "Emiratos Árabes Unidos",
+			"fr":    "Émirats arabes unis",
+			"ja":    "アラブ首長国連邦",
+			"pt-BR": "Emirados Árabes Unidos",
+		},
+	},
+	{
+		Alpha2:    "AF",
+		Alpha3:    "AFG",
+		Numeric:   "004",
+		Name:      "Afghanistan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Afghanistan",
+			"es":    "Afganistán",
+			"fr":    "Afghanistan",
+			"ja":    "アフガニスタン",
+			"pt-BR": "Afeganistão",
+		},
+	},
+	{
+		Alpha2:    "AG",
+		Alpha3:    "ATG",
+		Numeric:   "028",
+		Name:      "Antigua and Barbuda",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Antigua und Barbuda",
+			"es":    "Antigua y Barbuda",
+			"fr":    "Antigua-et-Barbuda",
+			"ja":    "アンティグア・バーブーダ",
+			"pt-BR": "Antígua e Barbuda",
+		},
+	},
+	{
+		Alpha2:    "AI",
+		Alpha3:    "AIA",
+		Numeric:   "660",
+		Name:      "Anguilla",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Anguilla",
+			"es":    "Anguila",
+			"fr":    "Anguilla",
+			"ja":    "アングイラ",
+			"pt-BR": "Anguila",
+		},
+	},
+	{
+		Alpha2:    "AL",
+		Alpha3:    "ALB",
+		Numeric:   "008",
+		Name:      "Albania",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Albanien",
+			"es":    "Albania",
+			"fr":    "Albanie",
+			"ja":    "アルバニア",
+			"pt-BR": "Albânia",
+		},
+	},
+	{
+		Alpha2:    "AM",
+		Alpha3:    "ARM",
+		Numeric:   "051",
+		Name:      "Armenia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Armenien",
+			"es":    "Armenia",
+			"fr":    "Arménie",
+			"ja":    "アルメニア",
+			"pt-BR": "Armênia",
+		},
+	},
+	{
+		Alpha2:    "AO",
+		Alpha3:    "AGO",
+		Numeric:   "024",
+		Name:      "Angola",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Angola",
+			"es":    "Angola",
+			"fr":    "Angola",
+			"ja":    "アンゴラ",
+			"pt-BR": "Angola",
+		},
+	},
+	{
+		Alpha2:    "AQ",
+		Alpha3:    "ATA",
+		Numeric:   "010",
+		Name:      "Antarctica",
+		Continent: Antarctica,
+		Region:    "",
+		SubRegion: "",
+		names: map[string]string{
+			"de":    "Antarktis",
+			"es":    "Antártida",
+			"fr":    "Antarctique",
+			"ja":    "南極",
+			"pt-BR": "Antártida",
+		},
+	},
+	{
+		Alpha2:    "AR",
+		Alpha3:    "ARG",
+		Numeric:   "032",
+		Name:      "Argentina",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Argentinien",
+			"es":    "Argentina",
+			"fr":    "Argentine",
+			"ja":    "アルゼンチン",
+			"pt-BR": "Argentina",
+		},
+	},
+	{
+		Alpha2:    "AS",
+		Alpha3:    "ASM",
+		Numeric:   "016",
+		Name:      "American Samoa",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Amerikanisch-Samoa",
+			"es":    "Samoa Estadounidense",
+			"fr":    "Samoa américaines",
+			"ja":    "アメリカ領サモア",
+			"pt-BR": "Samoa Americana",
+		},
+	},
+	{
+		Alpha2:    "AT",
+		Alpha3:    "AUT",
+		Numeric:   "040",
+		Name:      "Austria",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Österreich",
+			"es":    "Austria",
+			"fr":    "Autriche",
+			"ja":    "オーストリア",
+			"pt-BR": "Áustria",
+		},
+	},
+	{
+		Alpha2:    "AU",
+		Alpha3:    "AUS",
+		Numeric:   "036",
+		Name:      "Australia",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Australia and New Zealand",
+		names: map[string]string{
+			"de":    "Australien",
+			"es":    "Australia",
+			"fr":    "Australie",
+			"ja":    "オーストラリア連邦",
+			"pt-BR": "Austrália",
+		},
+	},
+	{
+		Alpha2:    "AW",
+		Alpha3:    "ABW",
+		Numeric:   "533",
+		Name:      "Aruba",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Aruba",
+			"es":    "Aruba",
+			"fr":    "Aruba",
+			"ja":    "アルバ",
+			"pt-BR": "Aruba",
+		},
+	},
+	{
+		Alpha2:    "AX",
+		Alpha3:    "ALA",
+		Numeric:   "248",
+		Name:      "Aland Islands",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Åland",
+			"es":    "Islas Äland",
+			"fr":    "Ahvenanmaa",
+			"ja":    "オーランド諸島",
+			"pt-BR": "Ilhas Åland",
+		},
+	},
+	{
+		Alpha2:    "AZ",
+		Alpha3:    "AZE",
+		Numeric:   "031",
+		Name:      "Azerbaijan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Aserbaidschan",
+			"es":    "Azerbaiyán",
+			"fr":    "Azerbaïdjan",
+			"ja":    "アゼルバイジャン",
+			"pt-BR": "Azerbaidjão",
+		},
+	},
+	{
+		Alpha2:    "BA",
+		Alpha3:    "BIH",
+		Numeric:   "070",
+		Name:      "Bosnia and Herzegovina",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Bosnien und Herzegowina",
+			"es":    "Bosnia y Herzegovina",
+			"fr":    "Bosnie-Herzégovine",
+			"ja":    "ボスニア・ヘルツェゴビナ",
+			"pt-BR": "Bósnia-Herzegóvina",
+		},
+	},
+	{
+		Alpha2:    "BB",
+		Alpha3:    "BRB",
+		Numeric:   "052",
+		Name:      "Barbados",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Barbados",
+			"es":    "Barbados",
+			"fr":    "Barbade",
+			"ja":    "バルバドス",
+			"pt-BR": "Barbados",
+		},
+	},
+	{
+		Alpha2:    "BD",
+		Alpha3:    "BGD",
+		Numeric:   "050",
+		Name:      "Bangladesh",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Bangladesch",
+			"es":    "Bangladés",
+			"fr":    "Bangladesh",
+			"ja":    "バングラデシュ",
+			"pt-BR": "Bangladesh",
+		},
+	},
+	{
+		Alpha2:    "BE",
+		Alpha3:    "BEL",
+		Numeric:   "056",
+		Name:      "Belgium",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Belgien",
+			"es":    "Bélgica",
+			"fr":    "Belgique",
+			"ja":    "ベルギー",
+			"pt-BR": "Bélgica",
+		},
+	},
+	{
+		Alpha2:    "BF",
+		Alpha3:    "BFA",
+		Numeric:   "854",
+		Name:      "Burkina Faso",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Burkina Faso",
+			"es":    "Burquina Faso",
+			"fr":    "Burkina Faso",
+			"ja":    "ブルキナファソ",
+			"pt-BR": "Burquina",
+		},
+	},
+	{
+		Alpha2:    "BG",
+		Alpha3:    "BGR",
+		Numeric:   "100",
+		Name:      "Bulgaria",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Bulgarien",
+			"es":    "Bulgaria",
+			"fr":    "Bulgarie",
+			"ja":    "ブルガリア",
+			"pt-BR": "Bulgária",
+		},
+	},
+	{
+		Alpha2:    "BH",
+		Alpha3:    "BHR",
+		Numeric:   "048",
+		Name:      "Bahrain",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Bahrain",
+			"es":    "Baréin",
+			"fr":    "Bahreïn",
+			"ja":    "バーレーン",
+			"pt-BR": "Barein",
+		},
+	},
+	{
+		Alpha2:    "BI",
+		Alpha3:    "BDI",
+		Numeric:   "108",
+		Name:      "Burundi",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Burundi",
+			"es":    "Burundi",
+			"fr":    "Burundi",
+			"ja":    "ブルンジ",
+			"pt-BR": "Burundi",
+		},
+	},
+	{
+		Alpha2:    "BJ",
+		Alpha3:    "BEN",
+		Numeric:   "204",
+		Name:      "Benin",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Benin",
+			"es":    "Benín",
+			"fr":    "Bénin",
+			"ja":    "ベナン",
+			"pt-BR": "Benin",
+		},
+	},
+	{
+		Alpha2:    "BL",
+		Alpha3:    "BLM",
+		Numeric:   "652",
+		Name:      "Saint Barthelemy",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Saint-Barthélemy",
+			"es":    "San Bartolomé",
+			"fr":    "Saint-Barthélemy",
+			"ja":    "サン・バルテルミー",
+			"pt-BR": "São Bartolomeu",
+		},
+	},
+	{
+		Alpha2:    "BM",
+		Alpha3:    "BMU",
+		Numeric:   "060",
+		Name:      "Bermuda",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Northern America",
+		names: map[string]string{
+			"de":    "Bermuda",
+			"es":    "Islas Bermudas",
+			"fr":    "Bermudes",
+			"ja":    "バミューダ",
+			"pt-BR": "Bermuda",
+		},
+	},
+	{
+		Alpha2:    "BN",
+		Alpha3:    "BRN",
+		Numeric:   "096",
+		Name:      "Brunei",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Brunei",
+			"es":    "Brunei Darussalam",
+			"fr":    "Brunéi Darussalam",
+			"ja":    "ブルネイ・ダルサラーム",
+			"pt-BR": "Brunei",
+		},
+	},
+	{
+		Alpha2:    "BO",
+		Alpha3:    "BOL",
+		Numeric:   "068",
+		Name:      "Bolivia",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Bolivien",
+			"es":    "Bolivia, Estado plurinacional de",
+			"fr":    "Bolivie",
+			"ja":    "ボリビア多民族国",
+			"pt-BR": "Bolívia",
+		},
+	},
+	{
+		Alpha2:    "BQ",
+		Alpha3:    "BES",
+		Numeric:   "535",
+		Name:      "Bonaire, Saint Eustatius and Saba",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Bonaire, Sint Eustatius und Saba",
+			"es":    "Islas BES (Caribe Neerlandés)",
+			"fr":    "Bonaire, Saint-Eustache et Saba",
+			"ja":    "ボネール、シントユースタティウス及びサバ",
+			"pt-BR": "Bonaire, Saba e Santo Eustáquio",
+		},
+	},
+	{
+		Alpha2:    "BR",
+		Alpha3:    "BRA",
+		Numeric:   "076",
+		Name:      "Brazil",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Brasilien",
+			"es":    "Brasil",
+			"fr":    "Brésil",
+			"ja":    "ブラジル",
+			"pt-BR": "Brasil",
+		},
+	},
+	{
+		Alpha2:    "BS",
+		Alpha3:    "BHS",
+		Numeric:   "044",
+		Name:      "Bahamas",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Bahamas",
+			"es":    "Bahamas",
+			"fr":    "Bahamas",
+			"ja":    "バハマ",
+			"pt-BR": "Bahamas",
+		},
+	},
+	{
+		Alpha2:    "BT",
+		Alpha3:    "BTN",
+		Numeric:   "064",
+		Name:      "Bhutan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Bhutan",
+			"es":    "Bután",
+			"fr":    "Bhoutan",
+			"ja":    "ブータン",
+			"pt-BR": "Butão",
+		},
+	},
+	{
+		Alpha2:    "BV",
+		Alpha3:    "BVT",
+		Numeric:   "074",
+		Name:      "Bouvet Island",
+		Continent: Antarctica,
+		Region:    "",
+		SubRegion: "",
+		names: map[string]string{
+			"de":    "Bouvet-Insel",
+			"es":    "Isla Bouvet",
+			"fr":    "Île Bouvet",
+			"ja":    "ブーベ島",
+			"pt-BR": "Ilha Bouvet",
+		},
+	},
+	{
+		Alpha2:    "BW",
+		Alpha3:    "BWA",
+		Numeric:   "072",
+		Name:      "Botswana",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Southern Africa",
+		names: map[string]string{
+			"de":    "Botsuana",
+			"es":    "Botsuana",
+			"fr":    "Botswana",
+			"ja":    "ボツワナ",
+			"pt-BR": "Botsuana",
+		},
+	},
+	{
+		Alpha2:    "BY",
+		Alpha3:    "BLR",
+		Numeric:   "112",
+		Name:      "Belarus",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Weißrussland",
+			"es":    "Bielorrusia",
+			"fr":    "Biélorussie",
+			"ja":    "ベラルーシ",
+			"pt-BR": "Bielo-Rússia",
+		},
+	},
+	{
+		Alpha2:    "BZ",
+		Alpha3:    "BLZ",
+		Numeric:   "084",
+		Name:      "Belize",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Belize",
+			"es":    "Belice",
+			"fr":    "Belize",
+			"ja":    "ベリーズ",
+			"pt-BR": "Belize",
+		},
+	},
+	{
+		Alpha2:    "CA",
+		Alpha3:    "CAN",
+		Numeric:   "124",
+		Name:      "Canada",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Northern America",
+		names: map[string]string{
+			"de":    "Kanada",
+			"es":    "Canadá",
+			"fr":    "Canada",
+			"ja":    "カナダ",
+			"pt-BR": "Canadá",
+		},
+	},
+	{
+		Alpha2:    "CC",
+		Alpha3:    "CCK",
+		Numeric:   "166",
+		Name:      "Cocos Islands",
+		Continent: Asia,
+		Region:    "Oceania",
+		SubRegion: "Australia and New Zealand",
+		names: map[string]string{
+			"de":    "Kokosinseln",
+			"es":    "Islas Cocos (Keeling)",
+			"fr":    "Îles Cocos",
+			"ja":    "ココス (キーリング) 諸島",
+			"pt-BR": "Ilhas Cocos",
+		},
+	},
+	{
+		Alpha2:    "CD",
+		Alpha3:    "COD",
+		Numeric:   "180",
+		Name:      "Democratic Republic of the Congo",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Kongo (Dem. Rep.)",
+			"es":    "Congo, República Democrática del",
+			"fr":    "République démocratique du Congo",
+			"ja":    "コンゴ民主共和国",
+			"pt-BR": "Congo, República Democrática do",
+		},
+	},
+	{
+		Alpha2:    "CF",
+		Alpha3:    "CAF",
+		Numeric:   "140",
+		Name:      "Central African Republic",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Zentralafrikanische Republik",
+			"es":    "República Centroafricana",
+			"fr":    "République centrafricaine",
+			"ja":    "中央アフリカ共和国",
+			"pt-BR": "República Centro-Africana",
+		},
+	},
+	{
+		Alpha2:    "CG",
+		Alpha3:    "COG",
+		Numeric:   "178",
+		Name:      "Republic of the Congo",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Kongo",
+			"es":    "Congo",
+			"fr":    "Congo",
+			"ja":    "コンゴ共和国",
+			"pt-BR": "Congo",
+		},
+	},
+	{
+		Alpha2:    "CH",
+		Alpha3:    "CHE",
+		Numeric:   "756",
+		Name:      "Switzerland",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Schweiz",
+			"es":    "Suiza",
+			"fr":    "Suisse",
+			"ja":    "スイス",
+			"pt-BR": "Suíça",
+		},
+	},
+	{
+		Alpha2:    "CI",
+		Alpha3:    "CIV",
+		Numeric:   "384",
+		Name:      "Ivory Coast",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Elfenbeinküste",
+			"es":    "Costa de Marfíl",
+			"fr":    "Côte d'Ivoire",
+			"ja":    "コートジボワール",
+			"pt-BR": "Costa do Marfim",
+		},
+	},
+	{
+		Alpha2:    "CK",
+		Alpha3:    "COK",
+		Numeric:   "184",
+		Name:      "Cook Islands",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Cookinseln",
+			"es":    "Islas Cook",
+			"fr":    "Îles Cook",
+			"ja":    "クック諸島",
+			"pt-BR": "Ilhas Cook",
+		},
+	},
+	{
+		Alpha2:    "CL",
+		Alpha3:    "CHL",
+		Numeric:   "152",
+		Name:      "Chile",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Chile",
+			"es":    "Chile",
+			"fr":    "Chili",
+			"ja":    "チリ",
+			"pt-BR": "Chile",
+		},
+	},
+	{
+		Alpha2:    "CM",
+		Alpha3:    "CMR",
+		Numeric:   "120",
+		Name:      "Cameroon",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Kamerun",
+			"es":    "Camerún",
+			"fr":    "Cameroun",
+			"ja":    "カメルーン",
+			"pt-BR": "Camarões",
+		},
+	},
+	{
+		Alpha2:    "CN",
+		Alpha3:    "CHN",
+		Numeric:   "156",
+		Name:      "China",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "China",
+			"es":    "China",
+			"fr":    "Chine",
+			"ja":    "中国",
+			"pt-BR": "China",
+		},
+	},
+	{
+		Alpha2:    "CO",
+		Alpha3:    "COL",
+		Numeric:   "170",
+		Name:      "Colombia",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Kolumbien",
+			"es":    "Colombia",
+			"fr":    "Colombie",
+			"ja":    "コロンビア",
+			"pt-BR": "Colômbia",
+		},
+	},
+	{
+		Alpha2:    "CR",
+		Alpha3:    "CRI",
+		Numeric:   "188",
+		Name:      "Costa Rica",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Costa Rica",
+			"es":    "Costa Rica",
+			"fr":    "Costa Rica",
+			"ja":    "コスタリカ",
+			"pt-BR": "Costa Rica",
+		},
+	},
+	{
+		Alpha2:    "CU",
+		Alpha3:    "CUB",
+		Numeric:   "192",
+		Name:      "Cuba",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Kuba",
+			"es":    "Cuba",
+			"fr":    "Cuba",
+			"ja":    "キューバ",
+			"pt-BR": "Cuba",
+		},
+	},
+	{
+		Alpha2:    "CV",
+		Alpha3:    "CPV",
+		Numeric:   "132",
+		Name:      "Cape Verde",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Kap Verde",
+			"es":    "Cabo Verde",
+			"fr":    "Îles du Cap-Vert",
+			"ja":    "カーボベルデ",
+			"pt-BR": "Cabo Verde",
+		},
+	},
+	{
+		Alpha2:    "CW",
+		Alpha3:    "CUW",
+		Numeric:   "531",
+		Name:      "Curacao",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Curaçao",
+			"es":    "Curazao",
+			"fr":    "Curaçao",
+			"ja":    "キュラソー",
+			"pt-BR": "Curaçao",
+		},
+	},
+	{
+		Alpha2:    "CX",
+		Alpha3:    "CXR",
+		Numeric:   "162",
+		Name:      "Christmas Island",
+		Continent: Asia,
+		Region:    "Oceania",
+		SubRegion: "Australia and New Zealand",
+		names: map[string]string{
+			"de":    "Weihnachtsinseln",
+			"es":    "Isla de Navidad",
+			"fr":    "Christmas, Île",
+			"ja":    "クリスマス島",
+			"pt-BR": "Ilha Christmas",
+		},
+	},
+	{
+		Alpha2:    "CY",
+		Alpha3:    "CYP",
+		Numeric:   "196",
+		Name:      "Cyprus",
+		Continent: Asia,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Zypern",
+			"es":    "Chipre",
+			"fr":    "Chypre",
+			"ja":    "キプロス",
+			"pt-BR": "Chipre",
+		},
+	},
+	{
+		Alpha2:    "CZ",
+		Alpha3:    "CZE",
+		Numeric:   "203",
+		Name:      "Czech Republic",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Tschechien",
+			"es":    "Chequia",
+			"fr":    "République tchèque",
+			"ja":    "チェコ",
+			"pt-BR": "Chéquia",
+		},
+	},
+	{
+		Alpha2:    "DE",
+		Alpha3:    "DEU",
+		Numeric:   "276",
+		Name:      "Germany",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Deutschland",
+			"es":    "Alemania",
+			"fr":    "Allemagne",
+			"ja":    "ドイツ",
+			"pt-BR": "Alemanha",
+		},
+	},
+	{
+		Alpha2:    "DJ",
+		Alpha3:    "DJI",
+		Numeric:   "262",
+		Name:      "Djibouti",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Dschibuti",
+			"es":    "Yibuti",
+			"fr":    "Djibouti",
+			"ja":    "ジブチ",
+			"pt-BR": "Djibuti",
+		},
+	},
+	{
+		Alpha2:    "DK",
+		Alpha3:    "DNK",
+		Numeric:   "208",
+		Name:      "Denmark",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Dänemark",
+			"es":    "Dinamarca",
+			"fr":    "Danemark",
+			"ja":    "デンマーク",
+			"pt-BR": "Dinamarca",
+		},
+	},
+	{
+		Alpha2:    "DM",
+		Alpha3:    "DMA",
+		Numeric:   "212",
+		Name:      "Dominica",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Dominica",
+			"es":    "Dominica",
+			"fr":    "Dominique",
+			"ja":    "ドミニカ国",
+			"pt-BR": "Domínica",
+		},
+	},
+	{
+		Alpha2:    "DO",
+		Alpha3:    "DOM",
+		Numeric:   "214",
+		Name:      "Dominican Republic",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Dominikanische Republik",
+			"es":    "República Dominicana",
+			"fr":    "République dominicaine",
+			"ja":    "ドミニカ共和国",
+			"pt-BR": "República Dominicana",
+		},
+	},
+	{
+		Alpha2:    "DZ",
+		Alpha3:    "DZA",
+		Numeric:   "012",
+		Name:      "Algeria",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Algerien",
+			"es":    "Algeria",
+			"fr":    "Algérie",
+			"ja":    "アルジェリア",
+			"pt-BR": "Argélia",
+		},
+	},
+	{
+		Alpha2:    "EC",
+		Alpha3:    "ECU",
+		Numeric:   "218",
+		Name:      "Ecuador",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Ecuador",
+			"es":    "Ecuador",
+			"fr":    "Équateur",
+			"ja":    "エクアドル",
+			"pt-BR": "Equador",
+		},
+	},
+	{
+		Alpha2:    "EE",
+		Alpha3:    "EST",
+		Numeric:   "233",
+		Name:      "Estonia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Estland",
+			"es":    "Estonia",
+			"fr":    "Estonie",
+			"ja":    "エストニア",
+			"pt-BR": "Estônia",
+		},
+	},
+	{
+		Alpha2:    "EG",
+		Alpha3:    "EGY",
+		Numeric:   "818",
+		Name:      "Egypt",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Ägypten",
+			"es":    "Egipto",
+			"fr":    "Égypte",
+			"ja":    "エジプト",
+			"pt-BR": "Egito",
+		},
+	},
+	{
+		Alpha2:    "EH",
+		Alpha3:    "ESH",
+		Numeric:   "732",
+		Name:      "Western Sahara",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Westsahara",
+			"es":    "Sahara Occidental",
+			"fr":    "Sahara Occidental",
+			"ja":    "西サハラ",
+			"pt-BR": "Saara Ocidental",
+		},
+	},
+	{
+		Alpha2:    "ER",
+		Alpha3:    "ERI",
+		Numeric:   "232",
+		Name:      "Eritrea",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Eritrea",
+			"es":    "Eritrea",
+			"fr":    "Érythrée",
+			"ja":    "エリトリア",
+			"pt-BR": "Eritréia",
+		},
+	},
+	{
+		Alpha2:    "ES",
+		Alpha3:    "ESP",
+		Numeric:   "724",
+		Name:      "Spain",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Spanien",
+			"es":    "España",
+			"fr":    "Espagne",
+			"ja":    "スペイン",
+			"pt-BR": "Espanha",
+		},
+	},
+	{
+		Alpha2:    "ET",
+		Alpha3:    "ETH",
+		Numeric:   "231",
+		Name:      "Ethiopia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Äthiopien",
+			"es":    "Etiopía",
+			"fr":    "Éthiopie",
+			"ja":    "エチオピア",
+			"pt-BR": "Etiópia",
+		},
+	},
+	{
+		Alpha2:    "FI",
+		Alpha3:    "FIN",
+		Numeric:   "246",
+		Name:      "Finland",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Finnland",
+			"es":    "Finlandia",
+			"fr":    "Finlande",
+			"ja":    "フィンランド",
+			"pt-BR": "Finlândia",
+		},
+	},
+	{
+		Alpha2:    "FJ",
+		Alpha3:    "FJI",
+		Numeric:   "242",
+		Name:      "Fiji",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Melanesia",
+		names: map[string]string{
+			"de":    "Fidschi",
+			"es":    "Fiyi",
+			"fr":    "Fidji",
+			"ja":    "フィジー",
+			"pt-BR": "Fiji",
+		},
+	},
+	{
+		Alpha2:    "FK",
+		Alpha3:    "FLK",
+		Numeric:   "238",
+		Name:      "Falkland Islands",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Falklandinseln",
+			"es":    "Islas Falkland (Malvinas)",
+			"fr":    "Îles Malouines",
+			"ja":    "フォークランド諸島 (マルビナス)",
+			"pt-BR": "Ilhas Malvinas (Falkland)",
+		},
+	},
+	{
+		Alpha2:    "FM",
+		Alpha3:    "FSM",
+		Numeric:   "583",
+		Name:      "Micronesia",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Mikronesien",
+			"es":    "Micronesia, Estados Federados de",
+			"fr":    "Micronésie",
+			"ja":    "ミクロネシア連邦",
+			"pt-BR": "Micronésia, Estados Federados da",
+		},
+	},
+	{
+		Alpha2:    "FO",
+		Alpha3:    "FRO",
+		Numeric:   "234",
+		Name:      "Faroe Islands",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Färöer-Inseln",
+			"es":    "Islas Feroe",
+			"fr":    "Îles Féroé",
+			"ja":    "フェロー諸島",
+			"pt-BR": "Ilhas Faroe",
+		},
+	},
+	{
+		Alpha2:    "FR",
+		Alpha3:    "FRA",
+		Numeric:   "250",
+		Name:      "France",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Frankreich",
+			"es":    "Francia",
+			"fr":    "France",
+			"ja":    "フランス",
+			"pt-BR": "França",
+		},
+	},
+	{
+		Alpha2:    "GA",
+		Alpha3:    "GAB",
+		Numeric:   "266",
+		Name:      "Gabon",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Gabun",
+			"es":    "Gabón",
+			"fr":    "Gabon",
+			"ja":    "ガボン",
+			"pt-BR": "Gabão",
+		},
+	},
+	{
+		Alpha2:    "GB",
+		Alpha3:    "GBR",
+		Numeric:   "826",
+		Name:      "United Kingdom",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Vereinigtes Königreich",
+			"es":    "Reino Unido",
+			"fr":    "Royaume-Uni",
+			"ja":    "英国",
+			"pt-BR": "Reino Unido",
+		},
+	},
+	{
+		Alpha2:    "GD",
+		Alpha3:    "GRD",
+		Numeric:   "308",
+		Name:      "Grenada",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Grenada",
+			"es":    "Granada",
+			"fr":    "Grenade",
+			"ja":    "グレナダ",
+			"pt-BR": "Granada",
+		},
+	},
+	{
+		Alpha2:    "GE",
+		Alpha3:    "GEO",
+		Numeric:   "268",
+		Name:      "Georgia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Georgien",
+			"es":    "Georgia",
+			"fr":    "Géorgie",
+			"ja":    "グルジア",
+			"pt-BR": "Geórgia",
+		},
+	},
+	{
+		Alpha2:    "GF",
+		Alpha3:    "GUF",
+		Numeric:   "254",
+		Name:      "French Guiana",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Französisch Guyana",
+			"es":    "Guayana Francesa",
+			"fr":    "Guyane",
+			"ja":    "仏領ギアナ",
+			"pt-BR": "Guiana Francesa",
+		},
+	},
+	{
+		Alpha2:    "GG",
+		Alpha3:    "GGY",
+		Numeric:   "831",
+		Name:      "Guernsey",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Guernsey",
+			"es":    "Guernsey",
+			"fr":    "Guernesey",
+			"ja":    "ガーンジー",
+			"pt-BR": "Guernsey",
+		},
+	},
+	{
+		Alpha2:    "GH",
+		Alpha3:    "GHA",
+		Numeric:   "288",
+		Name:      "Ghana",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Ghana",
+			"es":    "Ghana",
+			"fr":    "Ghana",
+			"ja":    "ガーナ",
+			"pt-BR": "Gana",
+		},
+	},
+	{
+		Alpha2:    "GI",
+		Alpha3:    "GIB",
+		Numeric:   "292",
+		Name:      "Gibraltar",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Gibraltar",
+			"es":    "Gibraltar",
+			"fr":    "Gibraltar",
+			"ja":    "ジブラルタル",
+			"pt-BR": "Gibraltar",
+		},
+	},
+	{
+		Alpha2:    "GL",
+		Alpha3:    "GRL",
+		Numeric:   "304",
+		Name:      "Greenland",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Northern America",
+		names: map[string]string{
+			"de":    "Grönland",
+			"es":    "Groenlandia",
+			"fr":    "Groënland",
+			"ja":    "グリーンランド",
+			"pt-BR": "Groenlândia",
+		},
+	},
+	{
+		Alpha2:    "GM",
+		Alpha3:    "GMB",
+		Numeric:   "270",
+		Name:      "Gambia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Gambia",
+			"es":    "Gambia",
+			"fr":    "Gambie",
+			"ja":    "ガンビア",
+			"pt-BR": "Gâmbia",
+		},
+	},
+	{
+		Alpha2:    "GN",
+		Alpha3:    "GIN",
+		Numeric:   "324",
+		Name:      "Guinea",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Guinea",
+			"es":    "Guinea",
+			"fr":    "Guinée",
+			"ja":    "ギニア",
+			"pt-BR": "Guiné",
+		},
+	},
+	{
+		Alpha2:    "GP",
+		Alpha3:    "GLP",
+		Numeric:   "312",
+		Name:      "Guadeloupe",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Guadeloupe",
+			"es":    "Guadalupe",
+			"fr":    "Guadeloupe",
+			"ja":    "グアドループ",
+			"pt-BR": "Guadalupe",
+		},
+	},
+	{
+		Alpha2:    "GQ",
+		Alpha3:    "GNQ",
+		Numeric:   "226",
+		Name:      "Equatorial Guinea",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Äquatorialguinea",
+			"es":    "Guinea Ecuatorial",
+			"fr":    "Guinée équatoriale",
+			"ja":    "赤道ギニア",
+			"pt-BR": "Guiné Equatorial",
+		},
+	},
+	{
+		Alpha2:    "GR",
+		Alpha3:    "GRC",
+		Numeric:   "300",
+		Name:      "Greece",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Griechenland",
+			"es":    "Grecia",
+			"fr":    "Grèce",
+			"ja":    "ギリシャ",
+			"pt-BR": "Grécia",
+		},
+	},
+	{
+		Alpha2:    "GS",
+		Alpha3:    "SGS",
+		Numeric:   "239",
+		Name:      "South Georgia and the South Sandwich Islands",
+		Continent: Antarctica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Südgeorgien und die Südlichen Sandwichinseln",
+			"es":    "Islas Georgias del Sur y Sándwich del Sur",
+			"fr":    "Géorgie du Sud et les îles Sandwich du Sud",
+			"ja":    "サウスジョージア・サウスサンドウィッチ諸島",
+			"pt-BR": "Geórgia do Sul e Ilhas Sandwich do Sul",
+		},
+	},
+	{
+		Alpha2:    "GT",
+		Alpha3:    "GTM",
+		Numeric:   "320",
+		Name:      "Guatemala",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Guatemala",
+			"es":    "Guatemala",
+			"fr":    "Guatemala",
+			"ja":    "グアテマラ",
+			"pt-BR": "Guatemala",
+		},
+	},
+	{
+		Alpha2:    "GU",
+		Alpha3:    "GUM",
+		Numeric:   "316",
+		Name:      "Guam",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Guam",
+			"es":    "Guam",
+			"fr":    "Guam",
+			"ja":    "グアム",
+			"pt-BR": "Guam",
+		},
+	},
+	{
+		Alpha2:    "GW",
+		Alpha3:    "GNB",
+		Numeric:   "624",
+		Name:      "Guinea-Bissau",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Guinea-Bissau",
+			"es":    "Guinea-Bisáu",
+			"fr":    "Guinée-Bissau",
+			"ja":    "ギニアビサウ",
+			"pt-BR": "Guiné-Bissau",
+		},
+	},
+	{
+		Alpha2:    "GY",
+		Alpha3:    "GUY",
+		Numeric:   "328",
+		Name:      "Guyana",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Guyana",
+			"es":    "Guyana",
+			"fr":    "Guyana",
+			"ja":    "ガイアナ",
+			"pt-BR": "Guiana",
+		},
+	},
+	{
+		Alpha2:    "HK",
+		Alpha3:    "HKG",
+		Numeric:   "344",
+		Name:      "Hong Kong",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Hongkong",
+			"es":    "Hong Kong",
+			"fr":    "Hong Kong",
+			"ja":    "香港",
+			"pt-BR": "Hong Kong",
+		},
+	},
+	{
+		Alpha2:    "HM",
+		Alpha3:    "HMD",
+		Numeric:   "334",
+		Name:      "Heard Island and McDonald Islands",
+		Continent: Antarctica,
+		Region:    "",
+		SubRegion: "",
+		names: map[string]string{
+			"de":    "Heard und McDonaldinseln",
+			"es":    "Islas Heard y McDonald",
+			"fr":    "Îles Heard-et-MacDonald",
+			"ja":    "ハード島及びマクドナルド諸島",
+			"pt-BR": "Ilha Heard e Ilhas McDonald",
+		},
+	},
+	{
+		Alpha2:    "HN",
+		Alpha3:    "HND",
+		Numeric:   "340",
+		Name:      "Honduras",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Honduras",
+			"es":    "Honduras",
+			"fr":    "Honduras",
+			"ja":    "ホンジュラス",
+			"pt-BR": "Honduras",
+		},
+	},
+	{
+		Alpha2:    "HR",
+		Alpha3:    "HRV",
+		Numeric:   "191",
+		Name:      "Croatia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Kroatien",
+			"es":    "Croacia",
+			"fr":    "Croatie",
+			"ja":    "クロアチア",
+			"pt-BR": "Croácia",
+		},
+	},
+	{
+		Alpha2:    "HT",
+		Alpha3:    "HTI",
+		Numeric:   "332",
+		Name:      "Haiti",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Haiti",
+			"es":    "Haití",
+			"fr":    "Haïti",
+			"ja":    "ハイチ",
+			"pt-BR": "Haiti",
+		},
+	},
+	{
+		Alpha2:    "HU",
+		Alpha3:    "HUN",
+		Numeric:   "348",
+		Name:      "Hungary",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Ungarn",
+			"es":    "Hungría",
+			"fr":    "Hongrie",
+			"ja":    "ハンガリー",
+			"pt-BR": "Hungria",
+		},
+	},
+	{
+		Alpha2:    "ID",
+		Alpha3:    "IDN",
+		Numeric:   "360",
+		Name:      "Indonesia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Indonesien",
+			"es":    "Indonesia",
+			"fr":    "Indonésie",
+			"ja":    "インドネシア",
+			"pt-BR": "Indonésia",
+		},
+	},
+	{
+		Alpha2:    "IE",
+		Alpha3:    "IRL",
+		Numeric:   "372",
+		Name:      "Ireland",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Irland",
+			"es":    "Irlanda",
+			"fr":    "Irlande",
+			"ja":    "アイルランド",
+			"pt-BR": "Irlanda",
+		},
+	},
+	{
+		Alpha2:    "IL",
+		Alpha3:    "ISR",
+		Numeric:   "376",
+		Name:      "Israel",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Israel",
+			"es":    "Israel",
+			"fr":    "Israël",
+			"ja":    "イスラエル",
+			"pt-BR": "Israel",
+		},
+	},
+	{
+		Alpha2:    "IM",
+		Alpha3:    "IMN",
+		Numeric:   "833",
+		Name:      "Isle of Man",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Insel Man",
+			"es":    "Isla de Man",
+			"fr":    "Île de Man",
+			"ja":    "マン島",
+			"pt-BR": "Ilha de Man",
+		},
+	},
+	{
+		Alpha2:    "IN",
+		Alpha3:    "IND",
+		Numeric:   "356",
+		Name:      "India",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Indien",
+			"es":    "India",
+			"fr":    "Inde",
+			"ja":    "インド",
+			"pt-BR": "Índia",
+		},
+	},
+	{
+		Alpha2:    "IO",
+		Alpha3:    "IOT",
+		Numeric:   "086",
+		Name:      "British Indian Ocean Territory",
+		Continent: Asia,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Britisches Territorium im Indischen Ozean",
+			"es":    "Territorio Británico del Océano Índico",
+			"fr":    "Territoire britannique de l'océan Indien",
+			"ja":    "イギリス領インド洋地域",
+			"pt-BR": "Território Britânico do Oceano Índico",
+		},
+	},
+	{
+		Alpha2:    "IQ",
+		Alpha3:    "IRQ",
+		Numeric:   "368",
+		Name:      "Iraq",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Irak",
+			"es":    "Irak",
+			"fr":    "Irak",
+			"ja":    "イラク",
+			"pt-BR": "Iraque",
+		},
+	},
+	{
+		Alpha2:    "IR",
+		Alpha3:    "IRN",
+		Numeric:   "364",
+		Name:      "Iran",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Iran",
+			"es":    "Irán, República islámica de",
+			"fr":    "Iran",
+			"ja":    "イラン・イスラム共和国",
+			"pt-BR": "Irã, República Islâmica do",
+		},
+	},
+	{
+		Alpha2:    "IS",
+		Alpha3:    "ISL",
+		Numeric:   "352",
+		Name:      "Iceland",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Island",
+			"es":    "Islandia",
+			"fr":    "Islande",
+			"ja":    "アイスランド",
+			"pt-BR": "Islândia",
+		},
+	},
+	{
+		Alpha2:    "IT",
+		Alpha3:    "ITA",
+		Numeric:   "380",
+		Name:      "Italy",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Italien",
+			"es":    "Italia",
+			"fr":    "Italie",
+			"ja":    "イタリア",
+			"pt-BR": "Itália",
+		},
+	},
+	{
+		Alpha2:    "JE",
+		Alpha3:    "JEY",
+		Numeric:   "832",
+		Name:      "Jersey",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Jersey",
+			"es":    "Jersey",
+			"fr":    "Jersey",
+			"ja":    "ジャージー",
+			"pt-BR": "Jersey",
+		},
+	},
+	{
+		Alpha2:    "JM",
+		Alpha3:    "JAM",
+		Numeric:   "388",
+		Name:      "Jamaica",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Jamaika",
+			"es":    "Jamaica",
+			"fr":    "Jamaïque",
+			"ja":    "ジャマイカ",
+			"pt-BR": "Jamaica",
+		},
+	},
+	{
+		Alpha2:    "JO",
+		Alpha3:    "JOR",
+		Numeric:   "400",
+		Name:      "Jordan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Jordanien",
+			"es":    "Jordania",
+			"fr":    "Jordanie",
+			"ja":    "ヨルダン",
+			"pt-BR": "Jordânia",
+		},
+	},
+	{
+		Alpha2:    "JP",
+		Alpha3:    "JPN",
+		Numeric:   "392",
+		Name:      "Japan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Japan",
+			"es":    "Japón",
+			"fr":    "Japon",
+			"ja":    "日本",
+			"pt-BR": "Japão",
+		},
+	},
+	{
+		Alpha2:    "KE",
+		Alpha3:    "KEN",
+		Numeric:   "404",
+		Name:      "Kenya",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Kenia",
+			"es":    "Kenia",
+			"fr":    "Kenya",
+			"ja":    "ケニア",
+			"pt-BR": "Quênia",
+		},
+	},
+	{
+		Alpha2:    "KG",
+		Alpha3:    "KGZ",
+		Numeric:   "417",
+		Name:      "Kyrgyzstan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Central Asia",
+		names: map[string]string{
+			"de":    "Kirgisistan",
+			"es":    "Kirguistán",
+			"fr":    "Kirghizistan",
+			"ja":    "キルギス",
+			"pt-BR": "Quirguistão",
+		},
+	},
+	{
+		Alpha2:    "KH",
+		Alpha3:    "KHM",
+		Numeric:   "116",
+		Name:      "Cambodia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Kambodscha",
+			"es":    "Camboya",
+			"fr":    "Cambodge",
+			"ja":    "カンボジア",
+			"pt-BR": "Camboja",
+		},
+	},
+	{
+		Alpha2:    "KI",
+		Alpha3:    "KIR",
+		Numeric:   "296",
+		Name:      "Kiribati",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Kiribati",
+			"es":    "Kiribati",
+			"fr":    "Kiribati",
+			"ja":    "キリバス",
+			"pt-BR": "Kiribati",
+		},
+	},
+	{
+		Alpha2:    "KM",
+		Alpha3:    "COM",
+		Numeric:   "174",
+		Name:      "Comoros",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Union der Komoren",
+			"es":    "Comores, Islas",
+			"fr":    "Comores",
+			"ja":    "コモロ",
+			"pt-BR": "Comores",
+		},
+	},
+	{
+		Alpha2:    "KN",
+		Alpha3:    "KNA",
+		Numeric:   "659",
+		Name:      "Saint Kitts and Nevis",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Saint Christopher und Nevis",
+			"es":    "San Cristóbal y Nieves",
+			"fr":    "Saint-Christophe-et-Niévès",
+			"ja":    "セントクリストファー・ネイビス",
+			"pt-BR": "São Cristóvão e Névis",
+		},
+	},
+	{
+		Alpha2:    "KP",
+		Alpha3:    "PRK",
+		Numeric:   "408",
+		Name:      "North Korea",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Nordkorea",
+			"es":    "Corea, República Democrática Popular de",
+			"fr":    "Corée du Nord",
+			"ja":    "朝鮮民主主義人民共和国",
+			"pt-BR": "Coreia do Norte",
+		},
+	},
+	{
+		Alpha2:    "KR",
+		Alpha3:    "KOR",
+		Numeric:   "410",
+		Name:      "South Korea",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Südkorea",
+			"es":    "Corea, República de",
+			"fr":    "Corée du Sud",
+			"ja":    "大韓民国 (韓国)",
+			"pt-BR": "Coreia do Sul",
+		},
+	},
+	{
+		Alpha2:    "KW",
+		Alpha3:    "KWT",
+		Numeric:   "414",
+		Name:      "Kuwait",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Kuwait",
+			"es":    "Kuwait",
+			"fr":    "Koweït",
+			"ja":    "クウェート",
+			"pt-BR": "Kuwait",
+		},
+	},
+	{
+		Alpha2:    "KY",
+		Alpha3:    "CYM",
+		Numeric:   "136",
+		Name:      "Cayman Islands",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Kaimaninseln",
+			"es":    "Islas Caimán",
+			"fr":    "îles Caïmans",
+			"ja":    "ケイマン諸島",
+			"pt-BR": "Ilhas Cayman",
+		},
+	},
+	{
+		Alpha2:    "KZ",
+		Alpha3:    "KAZ",
+		Numeric:   "398",
+		Name:      "Kazakhstan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Central Asia",
+		names: map[string]string{
+			"de":    "Kasachstan",
+			"es":    "Kazajistán",
+			"fr":    "Kazakhstan",
+			"ja":    "カザフスタン",
+			"pt-BR": "Cazaquistão",
+		},
+	},
+	{
+		Alpha2:    "LA",
+		Alpha3:    "LAO",
+		Numeric:   "418",
+		Name:      "Laos",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Laos",
+			"es":    "República Democrática Popular de Lao",
+			"fr":    "Laos",
+			"ja":    "ラオス人民民主共和国",
+			"pt-BR": "República Popular Democrática do Laos",
+		},
+	},
+	{
+		Alpha2:    "LB",
+		Alpha3:    "LBN",
+		Numeric:   "422",
+		Name:      "Lebanon",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Libanon",
+			"es":    "Líbano",
+			"fr":    "Liban",
+			"ja":    "レバノン",
+			"pt-BR": "Líbano",
+		},
+	},
+	{
+		Alpha2:    "LC",
+		Alpha3:    "LCA",
+		Numeric:   "662",
+		Name:      "Saint Lucia",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Saint Lucia",
+			"es":    "Santa Lucía",
+			"fr":    "Sainte-Lucie",
+			"ja":    "セントルシア",
+			"pt-BR": "Santa Lúcia",
+		},
+	},
+	{
+		Alpha2:    "LI",
+		Alpha3:    "LIE",
+		Numeric:   "438",
+		Name:      "Liechtenstein",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Liechtenstein",
+			"es":    "Liechtenstein",
+			"fr":    "Liechtenstein",
+			"ja":    "リヒテンシュタイン",
+			"pt-BR": "Liechtenstein",
+		},
+	},
+	{
+		Alpha2:    "LK",
+		Alpha3:    "LKA",
+		Numeric:   "144",
+		Name:      "Sri Lanka",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Sri Lanka",
+			"es":    "Sri Lanka",
+			"fr":    "Sri Lanka",
+			"ja":    "スリランカ",
+			"pt-BR": "Sri Lanka",
+		},
+	},
+	{
+		Alpha2:    "LR",
+		Alpha3:    "LBR",
+		Numeric:   "430",
+		Name:      "Liberia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Liberia",
+			"es":    "Liberia",
+			"fr":    "Liberia",
+			"ja":    "リベリア",
+			"pt-BR": "Libéria",
+		},
+	},
+	{
+		Alpha2:    "LS",
+		Alpha3:    "LSO",
+		Numeric:   "426",
+		Name:      "Lesotho",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Southern Africa",
+		names: map[string]string{
+			"de":    "Lesotho",
+			"es":    "Lesoto",
+			"fr":    "Lesotho",
+			"ja":    "レソト",
+			"pt-BR": "Lesoto",
+		},
+	},
+	{
+		Alpha2:    "LT",
+		Alpha3:    "LTU",
+		Numeric:   "440",
+		Name:      "Lithuania",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Litauen",
+			"es":    "Lituania",
+			"fr":    "Lituanie",
+			"ja":    "リトアニア",
+			"pt-BR": "Lituânia",
+		},
+	},
+	{
+		Alpha2:    "LU",
+		Alpha3:    "LUX",
+		Numeric:   "442",
+		Name:      "Luxembourg",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Luxemburg",
+			"es":    "Luxemburgo",
+			"fr":    "Luxembourg",
+			"ja":    "ルクセンブルク",
+			"pt-BR": "Luxemburgo",
+		},
+	},
+	{
+		Alpha2:    "LV",
+		Alpha3:    "LVA",
+		Numeric:   "428",
+		Name:      "Latvia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Lettland",
+			"es":    "Letonia",
+			"fr":    "Lettonie",
+			"ja":    "ラトビア",
+			"pt-BR": "Letônia",
+		},
+	},
+	{
+		Alpha2:    "LY",
+		Alpha3:    "LBY",
+		Numeric:   "434",
+		Name:      "Libya",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Libyen",
+			"es":    "Libia",
+			"fr":    "Libye",
+			"ja":    "リビア",
+			"pt-BR": "Líbia",
+		},
+	},
+	{
+		Alpha2:    "MA",
+		Alpha3:    "MAR",
+		Numeric:   "504",
+		Name:      "Morocco",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Marokko",
+			"es":    "Marruecos",
+			"fr":    "Maroc",
+			"ja":    "モロッコ",
+			"pt-BR": "Marrocos",
+		},
+	},
+	{
+		Alpha2:    "MC",
+		Alpha3:    "MCO",
+		Numeric:   "492",
+		Name:      "Monaco",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Monaco",
+			"es":    "Mónaco",
+			"fr":    "Monaco",
+			"ja":    "モナコ",
+			"pt-BR": "Mônaco",
+		},
+	},
+	{
+		Alpha2:    "MD",
+		Alpha3:    "MDA",
+		Numeric:   "498",
+		Name:      "Moldova",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Moldawie",
+			"es":    "Moldavia",
+			"fr":    "Moldavie",
+			"ja":    "モルドバ共和国",
+			"pt-BR": "Moldávia",
+		},
+	},
+	{
+		Alpha2:    "ME",
+		Alpha3:    "MNE",
+		Numeric:   "499",
+		Name:      "Montenegro",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Montenegro",
+			"es":    "Montenegro",
+			"fr":    "Monténégro",
+			"ja":    "モンテネグロ",
+			"pt-BR": "Montenegro",
+		},
+	},
+	{
+		Alpha2:    "MF",
+		Alpha3:    "MAF",
+		Numeric:   "663",
+		Name:      "Saint Martin",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Saint Martin",
+			"es":    "San Martín (zona francesa)",
+			"fr":    "Saint-Martin",
+			"ja":    "サン・マルタン（フランス領）",
+			"pt-BR": "São Martim (parte francesa)",
+		},
+	},
+	{
+		Alpha2:    "MG",
+		Alpha3:    "MDG",
+		Numeric:   "450",
+		Name:      "Madagascar",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Madagaskar",
+			"es":    "Madagascar",
+			"fr":    "Madagascar",
+			"ja":    "マダガスカル",
+			"pt-BR": "Madagascar",
+		},
+	},
+	{
+		Alpha2:    "MH",
+		Alpha3:    "MHL",
+		Numeric:   "584",
+		Name:      "Marshall Islands",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Marshallinseln",
+			"es":    "Islas Marshall",
+			"fr":    "Îles Marshall",
+			"ja":    "マーシャル諸島",
+			"pt-BR": "Ilhas Marshall",
+		},
+	},
+	{
+		Alpha2:    "MK",
+		Alpha3:    "MKD",
+		Numeric:   "807",
+		Name:      "Macedonia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Mazedonien",
+			"es":    "Macedonia del Norte",
+			"fr":    "Macédoine du Nord",
+			"ja":    "North Macedonia",
+			"pt-BR": "Macedônia do Norte",
+		},
+	},
+	{
+		Alpha2:    "ML",
+		Alpha3:    "MLI",
+		Numeric:   "466",
+		Name:      "Mali",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Mali",
+			"es":    "Malí",
+			"fr":    "Mali",
+			"ja":    "マリ",
+			"pt-BR": "Mali",
+		},
+	},
+	{
+		Alpha2:    "MM",
+		Alpha3:    "MMR",
+		Numeric:   "104",
+		Name:      "Myanmar",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Myanmar",
+			"es":    "Birmania",
+			"fr":    "Birmanie",
+			"ja":    "ミャンマー",
+			"pt-BR": "Myanmar",
+		},
+	},
+	{
+		Alpha2:    "MN",
+		Alpha3:    "MNG",
+		Numeric:   "496",
+		Name:      "Mongolia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Mongolei",
+			"es":    "Mongolia",
+			"fr":    "Mongolie",
+			"ja":    "モンゴル国",
+			"pt-BR": "Mongólia",
+		},
+	},
+	{
+		Alpha2:    "MO",
+		Alpha3:    "MAC",
+		Numeric:   "446",
+		Name:      "Macao",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Macao",
+			"es":    "Macao",
+			"fr":    "Macau",
+			"ja":    "マカオ",
+			"pt-BR": "Macau",
+		},
+	},
+	{
+		Alpha2:    "MP",
+		Alpha3:    "MNP",
+		Numeric:   "580",
+		Name:      "Northern Mariana Islands",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Nördliche Marianen",
+			"es":    "Islas Marianas del Norte",
+			"fr":    "Îles Mariannes du Nord",
+			"ja":    "北マリアナ諸島",
+			"pt-BR": "Ilhas Marianas do Norte",
+		},
+	},
+	{
+		Alpha2:    "MQ",
+		Alpha3:    "MTQ",
+		Numeric:   "474",
+		Name:      "Martinique",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Martinique",
+			"es":    "Martinica",
+			"fr":    "Martinique",
+			"ja":    "マルティニーク",
+			"pt-BR": "Martinica",
+		},
+	},
+	{
+		Alpha2:    "MR",
+		Alpha3:    "MRT",
+		Numeric:   "478",
+		Name:      "Mauritania",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Mauretanien",
+			"es":    "Mauritania",
+			"fr":    "Mauritanie",
+			"ja":    "モーリタニア",
+			"pt-BR": "Mauritânia",
+		},
+	},
+	{
+		Alpha2:    "MS",
+		Alpha3:    "MSR",
+		Numeric:   "500",
+		Name:      "Montserrat",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Montserrat",
+			"es":    "Montserrat",
+			"fr":    "Montserrat",
+			"ja":    "モントセラト",
+			"pt-BR": "Montserrat",
+		},
+	},
+	{
+		Alpha2:    "MT",
+		Alpha3:    "MLT",
+		Numeric:   "470",
+		Name:      "Malta",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Malta",
+			"es":    "Malta",
+			"fr":    "Malte",
+			"ja":    "マルタ",
+			"pt-BR": "Malta",
+		},
+	},
+	{
+		Alpha2:    "MU",
+		Alpha3:    "MUS",
+		Numeric:   "480",
+		Name:      "Mauritius",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Mauritius",
+			"es":    "Mauricio",
+			"fr":    "Île Maurice",
+			"ja":    "モーリシャス",
+			"pt-BR": "Maurício",
+		},
+	},
+	{
+		Alpha2:    "MV",
+		Alpha3:    "MDV",
+		Numeric:   "462",
+		Name:      "Maldives",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Malediven",
+			"es":    "Islas Maldivas",
+			"fr":    "Maldives",
+			"ja":    "モルディブ",
+			"pt-BR": "Maldivas",
+		},
+	},
+	{
+		Alpha2:    "MW",
+		Alpha3:    "MWI",
+		Numeric:   "454",
+		Name:      "Malawi",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Malawi",
+			"es":    "Malaui",
+			"fr":    "Malawi",
+			"ja":    "マラウイ",
+			"pt-BR": "Malaui",
+		},
+	},
+	{
+		Alpha2:    "MX",
+		Alpha3:    "MEX",
+		Numeric:   "484",
+		Name:      "Mexico",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Mexiko",
+			"es":    "México",
+			"fr":    "Mexique",
+			"ja":    "メキシコ",
+			"pt-BR": "México",
+		},
+	},
+	{
+		Alpha2:    "MY",
+		Alpha3:    "MYS",
+		Numeric:   "458",
+		Name:      "Malaysia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Malaysia",
+			"es":    "Malasia",
+			"fr":    "Malaisie",
+			"ja":    "マレーシア",
+			"pt-BR": "Malásia",
+		},
+	},
+	{
+		Alpha2:    "MZ",
+		Alpha3:    "MOZ",
+		Numeric:   "508",
+		Name:      "Mozambique",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Mosambik",
+			"es":    "Mozambique",
+			"fr":    "Mozambique",
+			"ja":    "モザンビーク",
+			"pt-BR": "Moçambique",
+		},
+	},
+	{
+		Alpha2:    "NA",
+		Alpha3:    "NAM",
+		Numeric:   "516",
+		Name:      "Namibia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Southern Africa",
+		names: map[string]string{
+			"de":    "Namibia",
+			"es":    "Namibia",
+			"fr":    "Namibie",
+			"ja":    "ナミビア",
+			"pt-BR": "Namíbia",
+		},
+	},
+	{
+		Alpha2:    "NC",
+		Alpha3:    "NCL",
+		Numeric:   "540",
+		Name:      "New Caledonia",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Melanesia",
+		names: map[string]string{
+			"de":    "Neukaledonien",
+			"es":    "Nueva Caledonia",
+			"fr":    "Nouvelle-Calédonie",
+			"ja":    "ニューカレドニア",
+			"pt-BR": "Nova Caledônia",
+		},
+	},
+	{
+		Alpha2:    "NE",
+		Alpha3:    "NER",
+		Numeric:   "562",
+		Name:      "Niger",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Niger",
+			"es":    "Niger",
+			"fr":    "Niger",
+			"ja":    "ニジェール",
+			"pt-BR": "Níger",
+		},
+	},
+	{
+		Alpha2:    "NF",
+		Alpha3:    "NFK",
+		Numeric:   "574",
+		Name:      "Norfolk Island",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Australia and New Zealand",
+		names: map[string]string{
+			"de":    "Norfolkinsel",
+			"es":    "Isla Norfolk",
+			"fr":    "Île Norfolk",
+			"ja":    "ノーフォーク島",
+			"pt-BR": "Ilha Norfolk",
+		},
+	},
+	{
+		Alpha2:    "NG",
+		Alpha3:    "NGA",
+		Numeric:   "566",
+		Name:      "Nigeria",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Nigeria",
+			"es":    "Nigeria",
+			"fr":    "Nigéria",
+			"ja":    "ナイジェリア",
+			"pt-BR": "Nigéria",
+		},
+	},
+	{
+		Alpha2:    "NI",
+		Alpha3:    "NIC",
+		Numeric:   "558",
+		Name:      "Nicaragua",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Nicaragua",
+			"es":    "Nicaragua",
+			"fr":    "Nicaragua",
+			"ja":    "ニカラグア",
+			"pt-BR": "Nicarágua",
+		},
+	},
+	{
+		Alpha2:    "NL",
+		Alpha3:    "NLD",
+		Numeric:   "528",
+		Name:      "Netherlands",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Western Europe",
+		names: map[string]string{
+			"de":    "Niederlande",
+			"es":    "Países Bajos",
+			"fr":    "Pays-Bas",
+			"ja":    "オランダ",
+			"pt-BR": "Países Baixos",
+		},
+	},
+	{
+		Alpha2:    "NO",
+		Alpha3:    "NOR",
+		Numeric:   "578",
+		Name:      "Norway",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Norwegen",
+			"es":    "Noruega",
+			"fr":    "Norvège",
+			"ja":    "ノルウェー",
+			"pt-BR": "Noruega",
+		},
+	},
+	{
+		Alpha2:    "NP",
+		Alpha3:    "NPL",
+		Numeric:   "524",
+		Name:      "Nepal",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Népal",
+			"es":    "Nepal",
+			"fr":    "Népal",
+			"ja":    "ネパール",
+			"pt-BR": "Nepal",
+		},
+	},
+	{
+		Alpha2:    "NR",
+		Alpha3:    "NRU",
+		Numeric:   "520",
+		Name:      "Nauru",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Nauru",
+			"es":    "Nauru",
+			"fr":    "Nauru",
+			"ja":    "ナウル",
+			"pt-BR": "Nauru",
+		},
+	},
+	{
+		Alpha2:    "NU",
+		Alpha3:    "NIU",
+		Numeric:   "570",
+		Name:      "Niue",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Niue",
+			"es":    "Niue",
+			"fr":    "Nioue",
+			"ja":    "ニウエ",
+			"pt-BR": "Niue",
+		},
+	},
+	{
+		Alpha2:    "NZ",
+		Alpha3:    "NZL",
+		Numeric:   "554",
+		Name:      "New Zealand",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Australia and New Zealand",
+		names: map[string]string{
+			"de":    "Neuseeland",
+			"es":    "Nueva Zelanda",
+			"fr":    "Nouvelle-Zélande",
+			"ja":    "ニュージーランド",
+			"pt-BR": "Nova Zelândia",
+		},
+	},
+	{
+		Alpha2:    "OM",
+		Alpha3:    "OMN",
+		Numeric:   "512",
+		Name:      "Oman",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Oman",
+			"es":    "Omán",
+			"fr":    "Oman",
+			"ja":    "オマーン",
+			"pt-BR": "Omã",
+		},
+	},
+	{
+		Alpha2:    "PA",
+		Alpha3:    "PAN",
+		Numeric:   "591",
+		Name:      "Panama",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "Panama",
+			"es":    "Panamá",
+			"fr":    "Panama",
+			"ja":    "パナマ",
+			"pt-BR": "Panamá",
+		},
+	},
+	{
+		Alpha2:    "PE",
+		Alpha3:    "PER",
+		Numeric:   "604",
+		Name:      "Peru",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Peru",
+			"es":    "Perú",
+			"fr":    "Pérou",
+			"ja":    "ペルー",
+			"pt-BR": "Peru",
+		},
+	},
+	{
+		Alpha2:    "PF",
+		Alpha3:    "PYF",
+		Numeric:   "258",
+		Name:      "French Polynesia",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Französisch-Polynesien",
+			"es":    "Polinesia Francesa",
+			"fr":    "Polynésie française",
+			"ja":    "仏領ポリネシア",
+			"pt-BR": "Polinésia Francesa",
+		},
+	},
+	{
+		Alpha2:    "PG",
+		Alpha3:    "PNG",
+		Numeric:   "598",
+		Name:      "Papua New Guinea",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Melanesia",
+		names: map[string]string{
+			"de":    "Papua-Neuguinea",
+			"es":    "Papúa Nueva Guinea",
+			"fr":    "Papouasie-Nouvelle-Guinée",
+			"ja":    "パプアニューギニア",
+			"pt-BR": "Papua-Nova Guiné",
+		},
+	},
+	{
+		Alpha2:    "PH",
+		Alpha3:    "PHL",
+		Numeric:   "608",
+		Name:      "Philippines",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Philippinen",
+			"es":    "Filipinas",
+			"fr":    "Philippines",
+			"ja":    "フィリピン",
+			"pt-BR": "Filipinas",
+		},
+	},
+	{
+		Alpha2:    "PK",
+		Alpha3:    "PAK",
+		Numeric:   "586",
+		Name:      "Pakistan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Southern Asia",
+		names: map[string]string{
+			"de":    "Pakistan",
+			"es":    "Pakistán",
+			"fr":    "Pakistan",
+			"ja":    "パキスタン",
+			"pt-BR": "Paquistão",
+		},
+	},
+	{
+		Alpha2:    "PL",
+		Alpha3:    "POL",
+		Numeric:   "616",
+		Name:      "Poland",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Polen",
+			"es":    "Polonia",
+			"fr":    "Pologne",
+			"ja":    "ポーランド",
+			"pt-BR": "Polônia",
+		},
+	},
+	{
+		Alpha2:    "PM",
+		Alpha3:    "SPM",
+		Numeric:   "666",
+		Name:      "Saint Pierre and Miquelon",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Northern America",
+		names: map[string]string{
+			"de":    "Saint-Pierre und Miquelon",
+			"es":    "San Pedro y Miquelon",
+			"fr":    "Saint-Pierre-et-Miquelon",
+			"ja":    "サンピエール及びミクロン",
+			"pt-BR": "São Pedro e Miquelon",
+		},
+	},
+	{
+		Alpha2:    "PN",
+		Alpha3:    "PCN",
+		Numeric:   "612",
+		Name:      "Pitcairn",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Pitcairn",
+			"es":    "Pitcairn",
+			"fr":    "Îles Pitcairn",
+			"ja":    "ピトケアン",
+			"pt-BR": "Pitcairn",
+		},
+	},
+	{
+		Alpha2:    "PR",
+		Alpha3:    "PRI",
+		Numeric:   "630",
+		Name:      "Puerto Rico",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Puerto Rico",
+			"es":    "Puerto Rico",
+			"fr":    "Porto Rico",
+			"ja":    "プエルトリコ",
+			"pt-BR": "Porto Rico",
+		},
+	},
+	{
+		Alpha2:    "PS",
+		Alpha3:    "PSE",
+		Numeric:   "275",
+		Name:      "Palestinian Territory",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Palästina",
+			"es":    "Palestina, Estado de",
+			"fr":    "Palestine, État de",
+			"ja":    "パレスチナ",
+			"pt-BR": "Palestina, Estado da",
+		},
+	},
+	{
+		Alpha2:    "PT",
+		Alpha3:    "PRT",
+		Numeric:   "620",
+		Name:      "Portugal",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Portugal",
+			"es":    "Portugal",
+			"fr":    "Portugal",
+			"ja":    "ポルトガル",
+			"pt-BR": "Portugal",
+		},
+	},
+	{
+		Alpha2:    "PW",
+		Alpha3:    "PLW",
+		Numeric:   "585",
+		Name:      "Palau",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Micronesia",
+		names: map[string]string{
+			"de":    "Palau",
+			"es":    "Palaos",
+			"fr":    "Palaos",
+			"ja":    "パラオ",
+			"pt-BR": "Palau",
+		},
+	},
+	{
+		Alpha2:    "PY",
+		Alpha3:    "PRY",
+		Numeric:   "600",
+		Name:      "Paraguay",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Paraguay",
+			"es":    "Paraguay",
+			"fr":    "Paraguay",
+			"ja":    "パラグアイ",
+			"pt-BR": "Paraguai",
+		},
+	},
+	{
+		Alpha2:    "QA",
+		Alpha3:    "QAT",
+		Numeric:   "634",
+		Name:      "Qatar",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Katar",
+			"es":    "Catar",
+			"fr":    "Qatar",
+			"ja":    "カタール",
+			"pt-BR": "Catar",
+		},
+	},
+	{
+		Alpha2:    "RE",
+		Alpha3:    "REU",
+		Numeric:   "638",
+		Name:      "Reunion",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Réunion",
+			"es":    "Reunión",
+			"fr":    "Réunion, Île de la",
+			"ja":    "レユニオン",
+			"pt-BR": "Reunião",
+		},
+	},
+	{
+		Alpha2:    "RO",
+		Alpha3:    "ROU",
+		Numeric:   "642",
+		Name:      "Romania",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Rumänien",
+			"es":    "Rumanía",
+			"fr":    "Roumanie",
+			"ja":    "ルーマニア",
+			"pt-BR": "Romênia",
+		},
+	},
+	{
+		Alpha2:    "RS",
+		Alpha3:    "SRB",
+		Numeric:   "688",
+		Name:      "Serbia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Serbien",
+			"es":    "Serbia",
+			"fr":    "Serbie",
+			"ja":    "セルビア",
+			"pt-BR": "Sérvia",
+		},
+	},
+	{
+		Alpha2:    "RU",
+		Alpha3:    "RUS",
+		Numeric:   "643",
+		Name:      "Russia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Russland",
+			"es":    "Federación Rusa",
+			"fr":    "Russie",
+			"ja":    "ロシア連邦",
+			"pt-BR": "Federação Russa",
+		},
+	},
+	{
+		Alpha2:    "RW",
+		Alpha3:    "RWA",
+		Numeric:   "646",
+		Name:      "Rwanda",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Ruanda",
+			"es":    "Ruanda",
+			"fr":    "Rwanda",
+			"ja":    "ルワンダ",
+			"pt-BR": "Ruanda",
+		},
+	},
+	{
+		Alpha2:    "SA",
+		Alpha3:    "SAU",
+		Numeric:   "682",
+		Name:      "Saudi Arabia",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Saudi-Arabien",
+			"es":    "Arabia Saudí",
+			"fr":    "Arabie Saoudite",
+			"ja":    "サウジアラビア",
+			"pt-BR": "Arábia Saudita",
+		},
+	},
+	{
+		Alpha2:    "SB",
+		Alpha3:    "SLB",
+		Numeric:   "090",
+		Name:      "Solomon Islands",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Melanesia",
+		names: map[string]string{
+			"de":    "Salomonen",
+			"es":    "Islas Salomón",
+			"fr":    "Îles Salomon",
+			"ja":    "ソロモン諸島",
+			"pt-BR": "Ilhas Salomão",
+		},
+	},
+	{
+		Alpha2:    "SC",
+		Alpha3:    "SYC",
+		Numeric:   "690",
+		Name:      "Seychelles",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Seychellen",
+			"es":    "Seychelles",
+			"fr":    "Seychelles",
+			"ja":    "セーシェル",
+			"pt-BR": "Seychelles",
+		},
+	},
+	{
+		Alpha2:    "SD",
+		Alpha3:    "SDN",
+		Numeric:   "729",
+		Name:      "Sudan",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Sudan",
+			"es":    "Sudán",
+			"fr":    "Soudan",
+			"ja":    "スーダン",
+			"pt-BR": "Sudão",
+		},
+	},
+	{
+		Alpha2:    "SE",
+		Alpha3:    "SWE",
+		Numeric:   "752",
+		Name:      "Sweden",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Schweden",
+			"es":    "Suecia",
+			"fr":    "Suède",
+			"ja":    "スウェーデン",
+			"pt-BR": "Suécia",
+		},
+	},
+	{
+		Alpha2:    "SG",
+		Alpha3:    "SGP",
+		Numeric:   "702",
+		Name:      "Singapore",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Singapur",
+			"es":    "Singapur",
+			"fr":    "Singapour",
+			"ja":    "シンガポール",
+			"pt-BR": "Cingapura",
+		},
+	},
+	{
+		Alpha2:    "SH",
+		Alpha3:    "SHN",
+		Numeric:   "654",
+		Name:      "Saint Helena",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "St. Helena, Ascension und Tristan da Cunha",
+			"es":    "Santa Elena, Ascensión y Tristán de Acuña",
+			"fr":    "Sainte-Hélène, Ascension et Tristan da Cunha",
+			"ja":    "セントヘレナ、アセンション及びトリスタン・ダ・クーニャ",
+			"pt-BR": "Santa Helena, Ascensão e Tristão da Cunha",
+		},
+	},
+	{
+		Alpha2:    "SI",
+		Alpha3:    "SVN",
+		Numeric:   "705",
+		Name:      "Slovenia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Slowenien",
+			"es":    "Eslovenia",
+			"fr":    "Slovénie",
+			"ja":    "スロベニア",
+			"pt-BR": "Eslovênia",
+		},
+	},
+	{
+		Alpha2:    "SJ",
+		Alpha3:    "SJM",
+		Numeric:   "744",
+		Name:      "Svalbard and Jan Mayen",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Northern Europe",
+		names: map[string]string{
+			"de":    "Spitzbergen",
+			"es":    "Svalbard y Jan Mayen",
+			"fr":    "Svalbard et Jan Mayen",
+			"ja":    "スヴァールバル諸島およびヤンマイエン島",
+			"pt-BR": "Svalbard e a Ilha de Jan Mayen",
+		},
+	},
+	{
+		Alpha2:    "SK",
+		Alpha3:    "SVK",
+		Numeric:   "703",
+		Name:      "Slovakia",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Slowakei",
+			"es":    "Eslovaquia",
+			"fr":    "Slovaquie",
+			"ja":    "スロバキア",
+			"pt-BR": "Eslováquia",
+		},
+	},
+	{
+		Alpha2:    "SL",
+		Alpha3:    "SLE",
+		Numeric:   "694",
+		Name:      "Sierra Leone",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Sierra Leone",
+			"es":    "Sierra Leona",
+			"fr":    "Sierra Leone",
+			"ja":    "シエラレオネ",
+			"pt-BR": "Serra Leoa",
+		},
+	},
+	{
+		Alpha2:    "SM",
+		Alpha3:    "SMR",
+		Numeric:   "674",
+		Name:      "San Marino",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "San Marino",
+			"es":    "San Marino",
+			"fr":    "Saint-Marin",
+			"ja":    "サンマリノ",
+			"pt-BR": "São Marino",
+		},
+	},
+	{
+		Alpha2:    "SN",
+		Alpha3:    "SEN",
+		Numeric:   "686",
+		Name:      "Senegal",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Senegal",
+			"es":    "Senegal",
+			"fr":    "Sénégal",
+			"ja":    "セネガル",
+			"pt-BR": "Senegal",
+		},
+	},
+	{
+		Alpha2:    "SO",
+		Alpha3:    "SOM",
+		Numeric:   "706",
+		Name:      "Somalia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Somalia",
+			"es":    "Somalia",
+			"fr":    "Somalie",
+			"ja":    "ソマリア",
+			"pt-BR": "Somália",
+		},
+	},
+	{
+		Alpha2:    "SR",
+		Alpha3:    "SUR",
+		Numeric:   "740",
+		Name:      "Suriname",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Suriname",
+			"es":    "Surinám",
+			"fr":    "Surinam",
+			"ja":    "スリナム",
+			"pt-BR": "Suriname",
+		},
+	},
+	{
+		Alpha2:    "SS",
+		Alpha3:    "SSD",
+		Numeric:   "728",
+		Name:      "South Sudan",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Südsudan",
+			"es":    "Sudán del Sur",
+			"fr":    "Soudan du Sud",
+			"ja":    "南スーダン",
+			"pt-BR": "Sudão do Sul",
+		},
+	},
+	{
+		Alpha2:    "ST",
+		Alpha3:    "STP",
+		Numeric:   "678",
+		Name:      "Sao Tome and Principe",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "São Tomé und Príncipe",
+			"es":    "Santo Tomé y Príncipe",
+			"fr":    "Sao Tomé-et-Principe",
+			"ja":    "サントメ・プリンシペ",
+			"pt-BR": "São Tomé e Príncipe",
+		},
+	},
+	{
+		Alpha2:    "SV",
+		Alpha3:    "SLV",
+		Numeric:   "222",
+		Name:      "El Salvador",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Central America",
+		names: map[string]string{
+			"de":    "El Salvador",
+			"es":    "El Salvador",
+			"fr":    "Salvador",
+			"ja":    "エルサルバドル",
+			"pt-BR": "El Salvador",
+		},
+	},
+	{
+		Alpha2:    "SX",
+		Alpha3:    "SXM",
+		Numeric:   "534",
+		Name:      "Sint Maarten",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Sint Maarten",
+			"es":    "Isla de San Martín (zona holandsea)",
+			"fr":    "Saint-Martin",
+			"ja":    "サンマルタン (オランダ領)",
+			"pt-BR": "São Martim (parte holandesa)",
+		},
+	},
+	{
+		Alpha2:    "SY",
+		Alpha3:    "SYR",
+		Numeric:   "760",
+		Name:      "Syria",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Syrien",
+			"es":    "República árabe de Siria",
+			"fr":    "Syrienne, République arabe",
+			"ja":    "シリア・アラブ共和国",
+			"pt-BR": "República Árabe da Síria",
+		},
+	},
+	{
+		Alpha2:    "SZ",
+		Alpha3:    "SWZ",
+		Numeric:   "748",
+		Name:      "Swaziland",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Southern Africa",
+		names: map[string]string{
+			"de":    "Swasiland",
+			"es":    "Esuatini",
+			"fr":    "Swaziland",
+			"ja":    "スワジランド",
+			"pt-BR": "Suazilândia",
+		},
+	},
+	{
+		Alpha2:    "TC",
+		Alpha3:    "TCA",
+		Numeric:   "796",
+		Name:      "Turks and Caicos Islands",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Turks-und Caicosinseln",
+			"es":    "Islas Turcas y Caicos",
+			"fr":    "îles Turques-et-Caïques",
+			"ja":    "タークス・カイコス諸島",
+			"pt-BR": "Ilhas Turks e Caicos",
+		},
+	},
+	{
+		Alpha2:    "TD",
+		Alpha3:    "TCD",
+		Numeric:   "148",
+		Name:      "Chad",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Middle Africa",
+		names: map[string]string{
+			"de":    "Tschad",
+			"es":    "Chad",
+			"fr":    "Tchad",
+			"ja":    "チャド",
+			"pt-BR": "Chade",
+		},
+	},
+	{
+		Alpha2:    "TF",
+		Alpha3:    "ATF",
+		Numeric:   "260",
+		Name:      "French Southern Territories",
+		Continent: Antarctica,
+		Region:    "",
+		SubRegion: "",
+		names: map[string]string{
+			"de":    "Französische Süd-und Antarktisgebiete",
+			"es":    "Territorios Franceses del Sur",
+			"fr":    "Terres australes françaises",
+			"ja":    "フランス領南方・南極地域",
+			"pt-BR": "Territórios Franceses do Sul",
+		},
+	},
+	{
+		Alpha2:    "TG",
+		Alpha3:    "TGO",
+		Numeric:   "768",
+		Name:      "Togo",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Western Africa",
+		names: map[string]string{
+			"de":    "Togo",
+			"es":    "Togo",
+			"fr":    "Togo",
+			"ja":    "トーゴ",
+			"pt-BR": "Togo",
+		},
+	},
+	{
+		Alpha2:    "TH",
+		Alpha3:    "THA",
+		Numeric:   "764",
+		Name:      "Thailand",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Thailand",
+			"es":    "Tailandia",
+			"fr":    "Thaïlande",
+			"ja":    "タイ",
+			"pt-BR": "Tailândia",
+		},
+	},
+	{
+		Alpha2:    "TJ",
+		Alpha3:    "TJK",
+		Numeric:   "762",
+		Name:      "Tajikistan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Central Asia",
+		names: map[string]string{
+			"de":    "Tadschikistan",
+			"es":    "Tayikistán",
+			"fr":    "Tadjikistan",
+			"ja":    "タジキスタン",
+			"pt-BR": "Tadjiquistão",
+		},
+	},
+	{
+		Alpha2:    "TK",
+		Alpha3:    "TKL",
+		Numeric:   "772",
+		Name:      "Tokelau",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Tokelau",
+			"es":    "Tokelau",
+			"fr":    "Tokelau",
+			"ja":    "トケラウ",
+			"pt-BR": "Toquelau",
+		},
+	},
+	{
+		Alpha2:    "TL",
+		Alpha3:    "TLS",
+		Numeric:   "626",
+		Name:      "East Timor",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Timor-Leste",
+			"es":    "Timor Oriental",
+			"fr":    "Timor oriental",
+			"ja":    "東ティモール",
+			"pt-BR": "Timor Leste",
+		},
+	},
+	{
+		Alpha2:    "TM",
+		Alpha3:    "TKM",
+		Numeric:   "795",
+		Name:      "Turkmenistan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Central Asia",
+		names: map[string]string{
+			"de":    "Turkmenistan",
+			"es":    "Turkmenistán",
+			"fr":    "Turkménistan",
+			"ja":    "トルクメニスタン",
+			"pt-BR": "Turcomenistão",
+		},
+	},
+	{
+		Alpha2:    "TN",
+		Alpha3:    "TUN",
+		Numeric:   "788",
+		Name:      "Tunisia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Northern Africa",
+		names: map[string]string{
+			"de":    "Tunesien",
+			"es":    "Tunez",
+			"fr":    "Tunisie",
+			"ja":    "チュニジア",
+			"pt-BR": "Tunísia",
+		},
+	},
+	{
+		Alpha2:    "TO",
+		Alpha3:    "TON",
+		Numeric:   "776",
+		Name:      "Tonga",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Tonga",
+			"es":    "Tonga",
+			"fr":    "Tonga",
+			"ja":    "トンガ",
+			"pt-BR": "Tonga",
+		},
+	},
+	{
+		Alpha2:    "TR",
+		Alpha3:    "TUR",
+		Numeric:   "792",
+		Name:      "Turkey",
+		Continent: Europe,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Türkei",
+			"es":    "Türkiye",
+			"fr":    "Türkiye",
+			"ja":    "Türkiye",
+			"pt-BR": "Turquia",
+		},
+	},
+	{
+		Alpha2:    "TT",
+		Alpha3:    "TTO",
+		Numeric:   "780",
+		Name:      "Trinidad and Tobago",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Trinidad und Tobago",
+			"es":    "Trinidad y Tobago",
+			"fr":    "Trinité-et-Tobago",
+			"ja":    "トリニダード・トバゴ",
+			"pt-BR": "Trinidade e Tobago",
+		},
+	},
+	{
+		Alpha2:    "TV",
+		Alpha3:    "TUV",
+		Numeric:   "798",
+		Name:      "Tuvalu",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Tuvalu",
+			"es":    "Tuvalu",
+			"fr":    "Tuvalu",
+			"ja":    "ツバル",
+			"pt-BR": "Tuvalu",
+		},
+	},
+	{
+		Alpha2:    "TW",
+		Alpha3:    "TWN",
+		Numeric:   "158",
+		Name:      "Taiwan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Eastern Asia",
+		names: map[string]string{
+			"de":    "Taiwan",
+			"es":    "Taiwán",
+			"fr":    "Taïwan",
+			"ja":    "台湾（台湾省/中華民国）",
+			"pt-BR": "Taiwan, Província da China",
+		},
+	},
+	{
+		Alpha2:    "TZ",
+		Alpha3:    "TZA",
+		Numeric:   "834",
+		Name:      "Tanzania",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Tansania",
+			"es":    "Tanzania, República unida de",
+			"fr":    "Tanzanie",
+			"ja":    "タンザニア",
+			"pt-BR": "Tanzânia",
+		},
+	},
+	{
+		Alpha2:    "UA",
+		Alpha3:    "UKR",
+		Numeric:   "804",
+		Name:      "Ukraine",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Eastern Europe",
+		names: map[string]string{
+			"de":    "Ukraine",
+			"es":    "Ucrania",
+			"fr":    "Ukraine",
+			"ja":    "ウクライナ",
+			"pt-BR": "Ucrânia",
+		},
+	},
+	{
+		Alpha2:    "UG",
+		Alpha3:    "UGA",
+		Numeric:   "800",
+		Name:      "Uganda",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Uganda",
+			"es":    "Uganda",
+			"fr":    "Ouganda",
+			"ja":    "ウガンダ",
+			"pt-BR": "Uganda",
+		},
+	},
+	{
+		Alpha2:    "UM",
+		Alpha3:    "UMI",
+		Numeric:   "581",
+		Name:      "United States Minor Outlying Islands",
+		Continent: Oceania,
+		Region:    "Americas",
+		SubRegion: "Northern America",
+		names: map[string]string{
+			"de":    "United States Minor Outlying Islands",
+			"es":    "Islas Ultramarinas Menores de Estados Unidos",
+			"fr":    "Îles mineures éloignées des États-Unis",
+			"ja":    "アメリカ合衆国外諸島",
+			"pt-BR": "Ilhas Menores Distantes dos Estados Unidos",
+		},
+	},
+	{
+		Alpha2:    "US",
+		Alpha3:    "USA",
+		Numeric:   "840",
+		Name:      "United States",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Northern America",
+		names: map[string]string{
+			"de":    "Vereinigte Staaten",
+			"es":    "Estados Unidos",
+			"fr":    "États-Unis",
+			"ja":    "アメリカ合衆国",
+			"pt-BR": "Estados Unidos",
+		},
+	},
+	{
+		Alpha2:    "UY",
+		Alpha3:    "URY",
+		Numeric:   "858",
+		Name:      "Uruguay",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Uruguay",
+			"es":    "Uruguay",
+			"fr":    "Uruguay",
+			"ja":    "ウルグアイ",
+			"pt-BR": "Uruguai",
+		},
+	},
+	{
+		Alpha2:    "UZ",
+		Alpha3:    "UZB",
+		Numeric:   "860",
+		Name:      "Uzbekistan",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Central Asia",
+		names: map[string]string{
+			"de":    "Usbekistan",
+			"es":    "Uzbekistán",
+			"fr":    "Ouzbékistan",
+			"ja":    "ウズベキスタン",
+			"pt-BR": "Uzbequistão",
+		},
+	},
+	{
+		Alpha2:    "VA",
+		Alpha3:    "VAT",
+		Numeric:   "336",
+		Name:      "Vatican",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Vatikanstadt",
+			"es":    "Santa Sede (Ciudad Estado del Vaticano)",
+			"fr":    "Cité du Vatican",
+			"ja":    "聖庁 (バチカン市国)",
+			"pt-BR": "Santa Sé (Cidade-Estado do Vaticano)",
+		},
+	},
+	{
+		Alpha2:    "VC",
+		Alpha3:    "VCT",
+		Numeric:   "670",
+		Name:      "Saint Vincent and the Grenadines",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "St. Vincent und die Grenadinen",
+			"es":    "San Vicente y las Granadinas",
+			"fr":    "Saint-Vincent-et-les-Grenadines",
+			"ja":    "セントビンセントおよびグレナディーン諸島",
+			"pt-BR": "São Vicente e Granadinas",
+		},
+	},
+	{
+		Alpha2:    "VE",
+		Alpha3:    "VEN",
+		Numeric:   "862",
+		Name:      "Venezuela",
+		Continent: SouthAmerica,
+		Region:    "Americas",
+		SubRegion: "South America",
+		names: map[string]string{
+			"de":    "Venezuela",
+			"es":    "Venezuela, República Bolivariana de",
+			"fr":    "Venezuela",
+			"ja":    "ベネズエラ・ボリバル共和国",
+			"pt-BR": "Venezuela, República Bolivariana da",
+		},
+	},
+	{
+		Alpha2:    "VG",
+		Alpha3:    "VGB",
+		Numeric:   "092",
+		Name:      "British Virgin Islands",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Britische Jungferninseln",
+			"es":    "Islas Vírgenes, Británicas",
+			"fr":    "Îles Vierges britanniques",
+			"ja":    "イギリス領ヴァージン諸島",
+			"pt-BR": "Ilhas Virgens Britânicas",
+		},
+	},
+	{
+		Alpha2:    "VI",
+		Alpha3:    "VIR",
+		Numeric:   "850",
+		Name:      "U.S. Virgin Islands",
+		Continent: NorthAmerica,
+		Region:    "Americas",
+		SubRegion: "Caribbean",
+		names: map[string]string{
+			"de":    "Amerikanische Jungferninseln",
+			"es":    "Islas Vírgenes, de EEUU",
+			"fr":    "Îles Vierges, États-Unis",
+			"ja":    "アメリカ領ヴァージン諸島",
+			"pt-BR": "Ilhas Virgens dos Estados Unidos",
+		},
+	},
+	{
+		Alpha2:    "VN",
+		Alpha3:    "VNM",
+		Numeric:   "704",
+		Name:      "Vietnam",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "South-Eastern Asia",
+		names: map[string]string{
+			"de":    "Vietnam",
+			"es":    "Vietnam",
+			"fr":    "Viêt Nam",
+			"ja":    "ベトナム",
+			"pt-BR": "Vietnã",
+		},
+	},
+	{
+		Alpha2:    "VU",
+		Alpha3:    "VUT",
+		Numeric:   "548",
+		Name:      "Vanuatu",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Melanesia",
+		names: map[string]string{
+			"de":    "Vanuatu",
+			"es":    "Vanuatu",
+			"fr":    "Vanuatu",
+			"ja":    "バヌアツ",
+			"pt-BR": "Vanuatu",
+		},
+	},
+	{
+		Alpha2:    "WF",
+		Alpha3:    "WLF",
+		Numeric:   "876",
+		Name:      "Wallis and Futuna",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Wallis und Futuna",
+			"es":    "Wallis y Futuna",
+			"fr":    "Wallis-et-Futuna",
+			"ja":    "ワリー及びフテュナ",
+			"pt-BR": "Wallis e Futuna",
+		},
+	},
+	{
+		Alpha2:    "WS",
+		Alpha3:    "WSM",
+		Numeric:   "882",
+		Name:      "Samoa",
+		Continent: Oceania,
+		Region:    "Oceania",
+		SubRegion: "Polynesia",
+		names: map[string]string{
+			"de":    "Samoa",
+			"es":    "Samoa",
+			"fr":    "Samoa",
+			"ja":    "サモア",
+			"pt-BR": "Samoa",
+		},
+	},
+	{
+		Alpha2:    "XK",
+		Alpha3:    "XKX",
+		Name:      "Kosovo",
+		Continent: Europe,
+		Region:    "Europe",
+		SubRegion: "Southern Europe",
+		names: map[string]string{
+			"de":    "Kosovo",
+			"es":    "Kosovo",
+			"fr":    "Kosovo",
+			"ja":    "コソボ",
+			"pt-BR": "Kosovo",
+		},
+	},
+	{
+		Alpha2:    "YE",
+		Alpha3:    "YEM",
+		Numeric:   "887",
+		Name:      "Yemen",
+		Continent: Asia,
+		Region:    "Asia",
+		SubRegion: "Western Asia",
+		names: map[string]string{
+			"de":    "Jemen",
+			"es":    "Yemen",
+			"fr":    "Yémen",
+			"ja":    "イエメン",
+			"pt-BR": "Iêmen",
+		},
+	},
+	{
+		Alpha2:    "YT",
+		Alpha3:    "MYT",
+		Numeric:   "175",
+		Name:      "Mayotte",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Mayotte",
+			"es":    "Mayotte",
+			"fr":    "Mayotte",
+			"ja":    "マヨット",
+			"pt-BR": "Maiote",
+		},
+	},
+	{
+		Alpha2:    "ZA",
+		Alpha3:    "ZAF",
+		Numeric:   "710",
+		Name:      "South Africa",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Southern Africa",
+		names: map[string]string{
+			"de":    "Südafrika",
+			"es":    "Sudáfrica",
+			"fr":    "Afrique du Sud",
+			"ja":    "南アフリカ",
+			"pt-BR": "África do Sul",
+		},
+	},
+	{
+		Alpha2:    "ZM",
+		Alpha3:    "ZMB",
+		Numeric:   "894",
+		Name:      "Zambia",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Sambia",
+			"es":    "Zambia",
+			"fr":    "Zambie",
+			"ja":    "ザンビア",
+			"pt-BR": "Zâmbia",
+		},
+	},
+	{
+		Alpha2:    "ZW",
+		Alpha3:    "ZWE",
+		Numeric:   "716",
+		Name:      "Zimbabwe",
+		Continent: Africa,
+		Region:    "Africa",
+		SubRegion: "Eastern Africa",
+		names: map[string]string{
+			"de":    "Simbabwe",
+			"es":    "Zimbabue",
+			"fr":    "Zimbabwe",
+			"ja":    "ジンバブエ",
+			"pt-BR": "Zimbábue",
+		},
+	},
+}
@@ -0,0 +1,69 @@
+// Package cursor encodes positions on lists ordered by time and ID (most recent first) for keyset pagination.
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// ErrInvalid is returned when a cursor can't be decoded.
+var ErrInvalid = errors.New("invalid cursor")
+
+// Cursor points to the last item of a page. The next page starts right after it.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+}
+
+// New cursor for an item with the given RFC 3339 timestamp and ID.
+func New(timestamp, id string) (*Cursor, error) {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+
+	if err != nil {
+		return nil, fmt.Errorf("can't create cursor: %v", err)
+	}
+
+	return &Cursor{
+		Time: t,
+		ID:   id,
+	}, nil
+}
+
+// String encodes the cursor as an opaque URL-safe string.
+func (c Cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Parse cursor encoded with String.
+func Parse(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	var c Cursor
+
+	if err = json.Unmarshal(b, &c); err != nil || c.Time.IsZero() {
+		return nil, ErrInvalid
+	}
+
+	// the ID is compared as an UUID on the database
+	if _, err = uuid.FromString(c.ID); err != nil {
+		return nil, ErrInvalid
+	}
+
+	return &c, nil
+}
+
+// Condition for the items after the cursor, using positional parameters starting at pos.
+func (c Cursor) Condition(timeColumn, idColumn string, pos int) (string, []interface{}) {
+	return fmt.Sprintf("(%s, %s) < ($%d::timestamptz, $%d::uuid)", timeColumn, idColumn, pos, pos+1),
+		[]interface{}{c.Time, c.ID}
+}
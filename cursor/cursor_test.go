@@ -0,0 +1,69 @@
+package cursor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	c, err := New("2018-10-20T03:00:00.123456Z", "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	got, err := Parse(c.String())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if !got.Time.Equal(c.Time) || got.ID != c.ID {
+		t.Errorf("Expected cursor %+v, got %+v instead", c, got)
+	}
+
+	if want := time.Date(2018, 10, 20, 3, 0, 0, 123456000, time.UTC); !got.Time.Equal(want) {
+		t.Errorf("Expected cursor time %v, got %v instead", want, got.Time)
+	}
+}
+
+func TestNewInvalidTime(t *testing.T) {
+	if _, err := New("yesterday", "id"); err == nil {
+		t.Errorf("Expected error for invalid time")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	var cases = []string{
+		"",
+		"not base64!",
+		"e30",             // {}
+		"eyJpZCI6ImEifQ",  // {"id":"a"}
+		"WyJ0IiwgImlkIl0", // ["t", "id"]
+		(&Cursor{Time: time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC), ID: "not-an-uuid"}).String(),
+	}
+
+	for _, c := range cases {
+		if _, err := Parse(c); err != ErrInvalid {
+			t.Errorf("Expected error %v parsing %q, got %v instead", ErrInvalid, c, err)
+		}
+	}
+}
+
+func TestCondition(t *testing.T) {
+	var c = Cursor{
+		Time: time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC),
+		ID:   "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4",
+	}
+
+	cond, args := c.Condition("sync_time", "id", 3)
+
+	if want := "(sync_time, id) < ($3::timestamptz, $4::uuid)"; cond != want {
+		t.Errorf("Expected condition %v, got %v instead", want, cond)
+	}
+
+	if want := []interface{}{c.Time, c.ID}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected arguments %v, got %v instead", want, args)
+	}
+}
@@ -31,3 +31,18 @@ func Load(ctx context.Context, dsn string) (*sqlx.DB, error) {
 
 	return db, nil
 }
+
+// QueryRow prepares the query and scans the row it returns into dest.
+func QueryRow(ctx context.Context, q string, args []interface{}, dest ...interface{}) error {
+	stmt, err := db.PreparexContext(ctx, q)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	return stmt.QueryRowxContext(ctx, args...).Scan(dest...)
+}
@@ -0,0 +1,29 @@
+// Package dbtest connects tests depending on PostgreSQL to a test database.
+package dbtest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/henvic/climetrics/db"
+
+	// PostgreSQL driver
+	_ "github.com/lib/pq"
+)
+
+// DSNEnv is the environment variable with the DSN of the migrated test database.
+const DSNEnv = "CLIMETRICS_TEST_DSN"
+
+// Load the test database, skipping the test if DSNEnv isn't set.
+func Load(t *testing.T) {
+	var dsn = os.Getenv(DSNEnv)
+
+	if dsn == "" {
+		t.Skip(DSNEnv + " not set")
+	}
+
+	if _, err := db.Load(context.Background(), dsn); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,25 @@
+package migrations
+
+// geolocationQuota adds the table counting the requests to the geolocation providers.
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "geolocation_quota",
+		Up:      geolocationQuotaUp,
+		Down:    geolocationQuotaDown,
+	})
+}
+
+const geolocationQuotaUp = `CREATE TABLE IF NOT EXISTS public.geolocation_quota (
+    provider character varying(50) NOT NULL,
+    day date DEFAULT CURRENT_DATE NOT NULL,
+    day_requests integer DEFAULT 0 NOT NULL,
+    month date DEFAULT (date_trunc('month'::text, (CURRENT_DATE)::timestamp with time zone))::date NOT NULL,
+    month_requests integer DEFAULT 0 NOT NULL,
+    backoff_until timestamp with time zone,
+    CONSTRAINT geolocation_quota_pkey PRIMARY KEY (provider)
+);
+`
+
+const geolocationQuotaDown = `DROP TABLE public.geolocation_quota;
+`
@@ -0,0 +1,31 @@
+package migrations
+
+// geolocationOverrides adds the IP range overrides, taking precedence over the geolocation providers.
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "geolocation_overrides",
+		Up:      geolocationOverridesUp,
+		Down:    geolocationOverridesDown,
+	})
+}
+
+const geolocationOverridesUp = `CREATE TABLE IF NOT EXISTS public.geolocation_overrides (
+    id uuid NOT NULL,
+    network cidr NOT NULL,
+    organization character varying(255) DEFAULT ''::character varying NOT NULL,
+    city character varying(255) DEFAULT ''::character varying NOT NULL,
+    region character varying(255) DEFAULT ''::character varying NOT NULL,
+    country character varying(2) DEFAULT ''::character varying NOT NULL,
+    coordinates character varying(50) DEFAULT ''::character varying NOT NULL,
+    note text DEFAULT ''::text NOT NULL,
+    created timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT geolocation_overrides_pkey PRIMARY KEY (id),
+    CONSTRAINT geolocation_overrides_network_key UNIQUE (network)
+);
+
+CREATE INDEX IF NOT EXISTS geolocation_overrides_network_idx ON public.geolocation_overrides USING gist (network inet_ops);
+`
+
+const geolocationOverridesDown = `DROP TABLE public.geolocation_overrides;
+`
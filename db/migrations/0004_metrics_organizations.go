@@ -0,0 +1,31 @@
+package migrations
+
+// metricsOrganizations adds the indexed organization and ASN of the metrics,
+// filling them from the org field of the geolocation of the existing ones.
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "metrics_organizations",
+		Up:      metricsOrganizationsUp,
+		Down:    metricsOrganizationsDown,
+	})
+}
+
+const metricsOrganizationsUp = `ALTER TABLE public.metrics
+    ADD COLUMN IF NOT EXISTS sync_asn character varying(20),
+    ADD COLUMN IF NOT EXISTS sync_org character varying(255);
+
+UPDATE public.metrics SET
+    sync_asn = substring(sync_location->>'org' from '^(AS[0-9]+)'),
+    sync_org = NULLIF(regexp_replace(sync_location->>'org', '^AS[0-9]+\s*', ''), '')
+    WHERE sync_location IS NOT NULL AND sync_asn IS NULL AND sync_org IS NULL;
+
+CREATE INDEX IF NOT EXISTS metrics_sync_asn_idx ON public.metrics USING btree (sync_asn);
+
+CREATE INDEX IF NOT EXISTS metrics_sync_org_idx ON public.metrics USING btree (sync_org);
+`
+
+const metricsOrganizationsDown = `DROP INDEX public.metrics_sync_org_idx;
+DROP INDEX public.metrics_sync_asn_idx;
+ALTER TABLE public.metrics DROP COLUMN sync_org, DROP COLUMN sync_asn;
+`
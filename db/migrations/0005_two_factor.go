@@ -0,0 +1,37 @@
+package migrations
+
+// twoFactor adds the TOTP secret of the users, their recovery codes, and the settings table
+// (where the two-factor authentication policy is stored).
+func init() {
+	register(Migration{
+		Version: 5,
+		Name:    "two_factor",
+		Up:      twoFactorUp,
+		Down:    twoFactorDown,
+	})
+}
+
+const twoFactorUp = `ALTER TABLE public.authentication
+    ADD COLUMN IF NOT EXISTS totp_secret character varying(64) DEFAULT ''::character varying NOT NULL,
+    ADD COLUMN IF NOT EXISTS totp_counter bigint DEFAULT 0 NOT NULL;
+
+CREATE TABLE IF NOT EXISTS public.authentication_recovery_codes (
+    user_id uuid NOT NULL,
+    code_hash character(64) NOT NULL,
+    created timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT authentication_recovery_codes_pkey PRIMARY KEY (user_id, code_hash),
+    CONSTRAINT authentication_recovery_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.authentication(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.settings (
+    name character varying(100) NOT NULL,
+    value text NOT NULL,
+    modified timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT settings_pkey PRIMARY KEY (name)
+);
+`
+
+const twoFactorDown = `DROP TABLE public.settings;
+DROP TABLE public.authentication_recovery_codes;
+ALTER TABLE public.authentication DROP COLUMN totp_counter, DROP COLUMN totp_secret;
+`
@@ -0,0 +1,31 @@
+package migrations
+
+// apiTokens adds the personal API tokens of the users.
+func init() {
+	register(Migration{
+		Version: 6,
+		Name:    "api_tokens",
+		Up:      apiTokensUp,
+		Down:    apiTokensDown,
+	})
+}
+
+const apiTokensUp = `CREATE TABLE IF NOT EXISTS public.api_tokens (
+    id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    name character varying(100) NOT NULL,
+    token_hash character(64) NOT NULL,
+    scopes text[] NOT NULL,
+    created timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires timestamp with time zone NOT NULL,
+    last_used timestamp with time zone,
+    CONSTRAINT api_tokens_pkey PRIMARY KEY (id),
+    CONSTRAINT api_tokens_token_hash_key UNIQUE (token_hash),
+    CONSTRAINT api_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.authentication(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_tokens_user_id_idx ON public.api_tokens USING btree (user_id);
+`
+
+const apiTokensDown = `DROP TABLE public.api_tokens;
+`
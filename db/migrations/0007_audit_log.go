@@ -0,0 +1,50 @@
+package migrations
+
+// auditLog adds the audit log, and the trigger rejecting updates and deletions on it.
+func init() {
+	register(Migration{
+		Version: 7,
+		Name:    "audit_log",
+		Up:      auditLogUp,
+		Down:    auditLogDown,
+	})
+}
+
+const auditLogUp = `CREATE OR REPLACE FUNCTION public.audit_log_append_only() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$;
+
+CREATE TABLE IF NOT EXISTS public.audit_log (
+    id uuid NOT NULL,
+    created timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    actor_id character varying(36) DEFAULT ''::character varying NOT NULL,
+    actor character varying(254) DEFAULT ''::character varying NOT NULL,
+    action character varying(50) NOT NULL,
+    target text DEFAULT ''::text NOT NULL,
+    details text DEFAULT ''::text NOT NULL,
+    ip character varying(45) DEFAULT ''::character varying NOT NULL,
+    method character varying(10) DEFAULT ''::character varying NOT NULL,
+    path text DEFAULT ''::text NOT NULL,
+    CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_action_idx ON public.audit_log USING btree (action, created);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_idx ON public.audit_log USING btree (actor, created);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_idx ON public.audit_log USING btree (created, id);
+
+CREATE INDEX IF NOT EXISTS audit_log_target_idx ON public.audit_log USING btree (target, created);
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON public.audit_log;
+
+CREATE TRIGGER audit_log_append_only BEFORE DELETE OR UPDATE ON public.audit_log FOR EACH ROW EXECUTE PROCEDURE public.audit_log_append_only();
+`
+
+const auditLogDown = `DROP TABLE public.audit_log;
+DROP FUNCTION public.audit_log_append_only();
+`
@@ -0,0 +1,22 @@
+package migrations
+
+// loginFailures adds the failed login attempts, counted by account and by IP.
+func init() {
+	register(Migration{
+		Version: 8,
+		Name:    "login_failures",
+		Up:      loginFailuresUp,
+		Down:    loginFailuresDown,
+	})
+}
+
+const loginFailuresUp = `CREATE TABLE IF NOT EXISTS public.login_failures (
+    key character varying(300) NOT NULL,
+    failures integer NOT NULL,
+    last_failure timestamp with time zone NOT NULL,
+    CONSTRAINT login_failures_pkey PRIMARY KEY (key)
+);
+`
+
+const loginFailuresDown = `DROP TABLE public.login_failures;
+`
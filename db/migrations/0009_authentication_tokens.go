@@ -0,0 +1,28 @@
+package migrations
+
+// authenticationTokens adds the one-time tokens of the invitations and password resets.
+func init() {
+	register(Migration{
+		Version: 9,
+		Name:    "authentication_tokens",
+		Up:      authenticationTokensUp,
+		Down:    authenticationTokensDown,
+	})
+}
+
+const authenticationTokensUp = `CREATE TABLE IF NOT EXISTS public.authentication_tokens (
+    token_hash character(64) NOT NULL,
+    user_id uuid NOT NULL,
+    purpose character varying(20) NOT NULL,
+    created timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires timestamp with time zone NOT NULL,
+    used timestamp with time zone,
+    CONSTRAINT authentication_tokens_pkey PRIMARY KEY (token_hash),
+    CONSTRAINT authentication_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.authentication(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS authentication_tokens_user_id_idx ON public.authentication_tokens USING btree (user_id, purpose);
+`
+
+const authenticationTokensDown = `DROP TABLE public.authentication_tokens;
+`
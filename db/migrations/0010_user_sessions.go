@@ -0,0 +1,34 @@
+package migrations
+
+// userSessions adds the sessions of the users, which they can list and revoke,
+// and an index to find the HTTP session of each one by its key.
+func init() {
+	register(Migration{
+		Version: 10,
+		Name:    "user_sessions",
+		Up:      userSessionsUp,
+		Down:    userSessionsDown,
+	})
+}
+
+const userSessionsUp = `CREATE TABLE IF NOT EXISTS public.user_sessions (
+    id uuid NOT NULL,
+    key character varying(100) NOT NULL,
+    user_id uuid NOT NULL,
+    created timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_seen timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    ip character varying(45) DEFAULT ''::character varying NOT NULL,
+    user_agent character varying(512) DEFAULT ''::character varying NOT NULL,
+    CONSTRAINT user_sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT user_sessions_key_key UNIQUE (key),
+    CONSTRAINT user_sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.authentication(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_id_idx ON public.user_sessions USING btree (user_id, last_seen);
+
+CREATE INDEX IF NOT EXISTS http_sessions_key_idx ON public.http_sessions USING btree (key);
+`
+
+const userSessionsDown = `DROP INDEX public.http_sessions_key_idx;
+DROP TABLE public.user_sessions;
+`
@@ -0,0 +1,50 @@
+package migrations
+
+// retention adds the indexes used to prune old metrics and diagnostics,
+// and the tables with their daily rollups.
+//
+// The indexes are created within the transaction of the migration, which blocks writes to the tables
+// while they are built. They are skipped if they exist, so they can be created concurrently beforehand.
+func init() {
+	register(Migration{
+		Version: 11,
+		Name:    "retention",
+		Up:      retentionUp,
+		Down:    retentionDown,
+	})
+}
+
+const retentionUp = `CREATE INDEX IF NOT EXISTS metrics_sync_time_idx ON public.metrics USING btree (sync_time, id);
+
+CREATE INDEX IF NOT EXISTS metrics_type_sync_time_idx ON public.metrics USING btree (type, sync_time);
+
+CREATE INDEX IF NOT EXISTS diagnostics_sync_time_idx ON public.diagnostics USING btree (sync_time, id);
+
+CREATE TABLE public.metrics_rollups (
+    day date NOT NULL,
+    type character varying(100) NOT NULL,
+    version character varying(20) NOT NULL,
+    os character varying(20) NOT NULL,
+    arch character varying(20) NOT NULL,
+    country character varying(100) NOT NULL,
+    count bigint NOT NULL,
+    CONSTRAINT metrics_rollups_pkey PRIMARY KEY (day, type, version, os, arch, country)
+);
+
+COMMENT ON TABLE public.metrics_rollups IS 'daily number of pruned metrics (UTC), by sync_time';
+
+CREATE TABLE public.diagnostics_rollups (
+    day date NOT NULL,
+    count bigint NOT NULL,
+    CONSTRAINT diagnostics_rollups_pkey PRIMARY KEY (day)
+);
+
+COMMENT ON TABLE public.diagnostics_rollups IS 'daily number of pruned diagnostics reports (UTC), by sync_time';
+`
+
+const retentionDown = `DROP TABLE public.diagnostics_rollups;
+DROP TABLE public.metrics_rollups;
+DROP INDEX public.diagnostics_sync_time_idx;
+DROP INDEX public.metrics_type_sync_time_idx;
+DROP INDEX public.metrics_sync_time_idx;
+`
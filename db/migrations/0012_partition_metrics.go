@@ -0,0 +1,149 @@
+package migrations
+
+// partitionMetrics creates metrics_partitioned, range partitioned by month of sync_time,
+// with partitions from the month of the oldest metric to three months ahead, and a trigger mirroring
+// changes on metrics to it. It doesn't copy the existing metrics: that is done online, in batches,
+// by the metrics partition command, which then replaces metrics with metrics_partitioned.
+//
+// Unique constraints on partitioned tables must include the partition key, so the primary key
+// is (id, sync_time), and it doesn't enforce unique IDs by itself: metrics.Create checks if the ID exists
+// while holding a transaction-level advisory lock on it, so concurrent inserts of the same metric are serialized.
+//
+// Default partitions and primary keys on partitioned tables require PostgreSQL 11.
+func init() {
+	register(Migration{
+		Version: 12,
+		Name:    "partition_metrics",
+		Up:      partitionMetricsUp,
+		Down:    partitionMetricsDown,
+	})
+}
+
+const partitionMetricsUp = `DO $$
+BEGIN
+    IF current_setting('server_version_num')::int < 110000 THEN
+        RAISE EXCEPTION 'PostgreSQL 11 or later is required to partition metrics (running PostgreSQL %)', current_setting('server_version');
+    END IF;
+END;
+$$;
+
+CREATE TABLE public.metrics_partitioned (
+    id uuid NOT NULL,
+    type character varying(100) NOT NULL,
+    text text NOT NULL,
+    tags json NOT NULL,
+    extra json NOT NULL,
+    pid character varying(50) NOT NULL,
+    sid uuid NOT NULL,
+    "timestamp" text NOT NULL,
+    version character varying(20) NOT NULL,
+    os character varying(20) NOT NULL,
+    arch character varying(20) NOT NULL,
+    sync_time timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    request_id uuid NOT NULL,
+    sync_ip inet NOT NULL,
+    sync_location json,
+    timestamp_db timestamp with time zone NOT NULL,
+    sync_asn character varying(20),
+    sync_org character varying(255),
+    CONSTRAINT metrics_partitioned_pkey PRIMARY KEY (id, sync_time)
+) PARTITION BY RANGE (sync_time);
+
+CREATE INDEX metrics_partitioned_id_idx ON public.metrics_partitioned USING btree (id);
+
+CREATE INDEX metrics_partitioned_sync_time_idx ON public.metrics_partitioned USING btree (sync_time, id);
+
+CREATE INDEX metrics_partitioned_type_sync_time_idx ON public.metrics_partitioned USING btree (type, sync_time);
+
+CREATE INDEX metrics_partitioned_request_idx ON public.metrics_partitioned USING btree (request_id);
+
+CREATE INDEX metrics_partitioned_sync_asn_idx ON public.metrics_partitioned USING btree (sync_asn);
+
+CREATE INDEX metrics_partitioned_sync_org_idx ON public.metrics_partitioned USING btree (sync_org);
+
+-- executed dynamically, as PostgreSQL 10 can't parse it, and would fail on a syntax error before the version check
+DO $$
+BEGIN
+    EXECUTE 'CREATE TABLE public.metrics_default PARTITION OF public.metrics_partitioned DEFAULT';
+END;
+$$;
+
+COMMENT ON TABLE public.metrics_default IS 'metrics synced on months without a partition';
+
+-- metrics_create_partitions creates the missing monthly (UTC) partitions of metrics, from the month of start
+-- to the month of finish, returning how many were created. Partitions are named metrics_pYYYYMM.
+CREATE FUNCTION public.metrics_create_partitions(start timestamp with time zone, finish timestamp with time zone) RETURNS integer
+    LANGUAGE plpgsql
+    AS $$
+DECLARE
+    parent regclass;
+    month_start timestamp := date_trunc('month', start AT TIME ZONE 'UTC');
+    partition_name text;
+    created integer := 0;
+BEGIN
+    SELECT c.oid INTO parent FROM pg_class c
+        JOIN pg_partitioned_table p ON p.partrelid = c.oid
+        WHERE c.relnamespace = 'public'::regnamespace AND c.relname IN ('metrics', 'metrics_partitioned')
+        ORDER BY c.relname = 'metrics' DESC
+        LIMIT 1;
+
+    IF parent IS NULL THEN
+        RAISE EXCEPTION 'metrics has no partitioned table';
+    END IF;
+
+    WHILE month_start <= finish AT TIME ZONE 'UTC' LOOP
+        partition_name := 'metrics_p' || to_char(month_start, 'YYYYMM');
+
+        IF to_regclass('public.' || partition_name) IS NULL THEN
+            EXECUTE format('CREATE TABLE public.%I PARTITION OF %s FOR VALUES FROM (%L) TO (%L)',
+                partition_name, parent, month_start AT TIME ZONE 'UTC', (month_start + interval '1 month') AT TIME ZONE 'UTC');
+            created := created + 1;
+        END IF;
+
+        month_start := month_start + interval '1 month';
+    END LOOP;
+
+    RETURN created;
+END;
+$$;
+
+SELECT public.metrics_create_partitions(COALESCE(MIN(sync_time), CURRENT_TIMESTAMP), CURRENT_TIMESTAMP + interval '3 months')
+    FROM public.metrics;
+
+-- metrics_mirror copies changes on metrics to metrics_partitioned while the metrics are copied.
+CREATE FUNCTION public.metrics_mirror() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        DELETE FROM public.metrics_partitioned WHERE id = OLD.id AND sync_time = OLD.sync_time;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO public.metrics_partitioned (id, type, text, tags, extra, pid, sid, "timestamp", version, os, arch,
+            sync_time, request_id, sync_ip, sync_location, timestamp_db, sync_asn, sync_org)
+        VALUES (NEW.id, NEW.type, NEW.text, NEW.tags, NEW.extra, NEW.pid, NEW.sid, NEW."timestamp", NEW.version, NEW.os, NEW.arch,
+            NEW.sync_time, NEW.request_id, NEW.sync_ip, NEW.sync_location, NEW.timestamp_db, NEW.sync_asn, NEW.sync_org)
+        ON CONFLICT DO NOTHING;
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER metrics_mirror AFTER INSERT OR UPDATE OR DELETE ON public.metrics FOR EACH ROW EXECUTE PROCEDURE public.metrics_mirror();
+`
+
+const partitionMetricsDown = `DO $$
+BEGIN
+    IF to_regclass('public.metrics_partitioned') IS NULL THEN
+        RAISE EXCEPTION 'metrics is already partitioned: restore metrics_unpartitioned manually to revert';
+    END IF;
+END;
+$$;
+
+DROP TRIGGER metrics_mirror ON public.metrics;
+DROP FUNCTION public.metrics_mirror();
+DROP TABLE public.metrics_partitioned;
+DROP FUNCTION public.metrics_create_partitions(timestamp with time zone, timestamp with time zone);
+`
@@ -0,0 +1,455 @@
+// Package migrations applies the versioned migrations of the database schema.
+//
+// Migrations are embedded on the programs, registered by the files of this package in order of version
+// (a new migration is a new file, such as 0002_add_something.go, with the next version).
+// Applied migrations are recorded on the schema_migrations table, and a PostgreSQL advisory lock
+// stops concurrent runs (i.e., servers starting at the same time with auto-migrate).
+//
+// The initial migration is the schema of the climetrics.pgsql dump used before migrations existed.
+// The migrations of the features added to the dump later on (up to 0010_user_sessions) create what is missing only,
+// so they can be applied after the baseline of a database created from any version of the dump.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/jmoiron/sqlx"
+)
+
+// LockID of the advisory lock held while migrating.
+const LockID int64 = 0x636c696d65747269
+
+// BaselineVersion is the version of the schema of the climetrics.pgsql dump used before migrations existed.
+const BaselineVersion = 1
+
+const createTable = `CREATE TABLE IF NOT EXISTS public.schema_migrations (
+    version bigint NOT NULL PRIMARY KEY,
+    name character varying(255) NOT NULL,
+    applied timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+)`
+
+// Migration of the database schema.
+type Migration struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+
+	// Up and Down SQL statements. Each one runs in a transaction.
+	Up   string `json:"-"`
+	Down string `json:"-"`
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+var registry = map[int64]Migration{}
+
+func register(m Migration) {
+	if _, ok := registry[m.Version]; ok {
+		panic(fmt.Sprintf("migration version %d registered twice", m.Version))
+	}
+
+	if m.Version < 1 || m.Name == "" || m.Up == "" || m.Down == "" {
+		panic(fmt.Sprintf("invalid migration %v", m))
+	}
+
+	registry[m.Version] = m
+}
+
+// All migrations, from the oldest to the newest.
+func All() []Migration {
+	var all = make([]Migration, 0, len(registry))
+
+	for _, m := range registry {
+		all = append(all, m)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Version < all[j].Version
+	})
+
+	return all
+}
+
+// Latest version of the schema.
+func Latest() int64 {
+	var all = All()
+
+	if len(all) == 0 {
+		return 0
+	}
+
+	return all[len(all)-1].Version
+}
+
+// Status of a migration.
+type Status struct {
+	Migration
+
+	// Applied time, or nil if the migration is pending.
+	Applied *time.Time `json:"applied"`
+
+	// Unknown migrations were applied by a newer version of the program.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+type record struct {
+	Version int64
+	Name    string
+	Applied time.Time
+}
+
+type records map[int64]record
+
+// unknown migrations on the database, from the oldest to the newest.
+func (r records) unknown(all []Migration) []record {
+	var known = map[int64]bool{}
+
+	for _, m := range all {
+		known[m.Version] = true
+	}
+
+	var unknown []record
+
+	for v, rec := range r {
+		if !known[v] {
+			unknown = append(unknown, rec)
+		}
+	}
+
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Version < unknown[j].Version
+	})
+
+	return unknown
+}
+
+func (r records) newer(all []Migration) error {
+	if unknown := r.unknown(all); len(unknown) != 0 {
+		return fmt.Errorf("database has migration %04d_%s unknown to this version of the program", unknown[0].Version, unknown[0].Name)
+	}
+
+	return nil
+}
+
+// pending migrations up to the target version (zero for all of them).
+func pending(all []Migration, applied records, target int64) ([]Migration, error) {
+	if err := applied.newer(all); err != nil {
+		return nil, err
+	}
+
+	var p []Migration
+	var found = target == 0
+
+	for _, m := range all {
+		if target != 0 && m.Version > target {
+			break
+		}
+
+		found = found || m.Version == target
+
+		if _, ok := applied[m.Version]; !ok {
+			p = append(p, m)
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown migration version %d", target)
+	}
+
+	return p, nil
+}
+
+// rollback the newest applied migrations.
+func rollback(all []Migration, applied records, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, errors.New("the number of migrations to revert must be at least 1")
+	}
+
+	if err := applied.newer(all); err != nil {
+		return nil, err
+	}
+
+	var r []Migration
+
+	for i := len(all) - 1; i >= 0 && len(r) < steps; i-- {
+		if _, ok := applied[all[i].Version]; ok {
+			r = append(r, all[i])
+		}
+	}
+
+	return r, nil
+}
+
+func statuses(all []Migration, applied records) []Status {
+	var s []Status
+
+	for _, m := range all {
+		var st = Status{
+			Migration: m,
+		}
+
+		if rec, ok := applied[m.Version]; ok {
+			var t = rec.Applied
+			st.Applied = &t
+		}
+
+		s = append(s, st)
+	}
+
+	for _, rec := range applied.unknown(all) {
+		var t = rec.Applied
+
+		s = append(s, Status{
+			Migration: Migration{
+				Version: rec.Version,
+				Name:    rec.Name,
+			},
+			Applied: &t,
+			Unknown: true,
+		})
+	}
+
+	return s
+}
+
+// locked runs fn on a connection holding the migrations advisory lock.
+func locked(ctx context.Context, db *sqlx.DB, fn func(conn *sql.Conn, applied records) error) error {
+	conn, err := db.DB.Conn(ctx)
+
+	if err != nil {
+		return errwrap.Wrapf("can't connect to database: {{err}}", err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", LockID); err != nil {
+		return errwrap.Wrapf("can't acquire migrations lock: {{err}}", err)
+	}
+
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", LockID)
+	}()
+
+	if _, err = conn.ExecContext(ctx, createTable); err != nil {
+		return errwrap.Wrapf("can't create schema_migrations table: {{err}}", err)
+	}
+
+	applied, err := load(ctx, conn)
+
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+func load(ctx context.Context, conn *sql.Conn) (records, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied FROM schema_migrations")
+
+	if err != nil {
+		return nil, errwrap.Wrapf("can't list applied migrations: {{err}}", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var applied = records{}
+
+	for rows.Next() {
+		var r record
+
+		if err := rows.Scan(&r.Version, &r.Name, &r.Applied); err != nil {
+			return nil, err
+		}
+
+		applied[r.Version] = r
+	}
+
+	return applied, rows.Err()
+}
+
+// run SQL and record the change on schema_migrations in a transaction.
+func run(ctx context.Context, conn *sql.Conn, query, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	if query != "" {
+		if _, err = tx.ExecContext(ctx, query); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Up applies the pending migrations up to the target version (zero for all of them).
+func Up(ctx context.Context, db *sqlx.DB, target int64) (done []Migration, err error) {
+	err = locked(ctx, db, func(conn *sql.Conn, applied records) error {
+		p, err := pending(All(), applied, target)
+
+		if err != nil {
+			return err
+		}
+
+		for _, m := range p {
+			if err := run(ctx, conn, m.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("can't apply migration %v: {{err}}", m), err)
+			}
+
+			done = append(done, m)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the newest applied migrations.
+func Down(ctx context.Context, db *sqlx.DB, steps int) (done []Migration, err error) {
+	err = locked(ctx, db, func(conn *sql.Conn, applied records) error {
+		r, err := rollback(All(), applied, steps)
+
+		if err != nil {
+			return err
+		}
+
+		for _, m := range r {
+			if err := run(ctx, conn, m.Down, "DELETE FROM schema_migrations WHERE version = $1", m.Version); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("can't revert migration %v: {{err}}", m), err)
+			}
+
+			done = append(done, m)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Baseline marks the migrations up to the version as applied, without running them,
+// for databases created before migrations existed (from the climetrics.pgsql dump).
+// It refuses to do so if any table or column of the initial schema is missing.
+func Baseline(ctx context.Context, db *sqlx.DB, version int64) (done []Migration, err error) {
+	err = locked(ctx, db, func(conn *sql.Conn, applied records) error {
+		if len(applied) != 0 {
+			return errors.New("database already has migrations: baseline is only for databases created before migrations existed")
+		}
+
+		have, err := columns(ctx, conn)
+
+		if err != nil {
+			return err
+		}
+
+		if len(have) == 0 {
+			return errors.New("database has no schema to baseline: use up to create it")
+		}
+
+		if m := missing(initialColumns, have); len(m) != 0 {
+			return fmt.Errorf("database doesn't have the schema of the climetrics.pgsql dump: missing %s", strings.Join(m, ", "))
+		}
+
+		p, err := pending(All(), applied, version)
+
+		if err != nil {
+			return err
+		}
+
+		for _, m := range p {
+			if err := run(ctx, conn, "", "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name); err != nil {
+				return err
+			}
+
+			done = append(done, m)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// columns of the tables of the public schema (but schema_migrations), as table.column.
+func columns(ctx context.Context, conn *sql.Conn) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = 'public' AND table_name <> 'schema_migrations'")
+
+	if err != nil {
+		return nil, errwrap.Wrapf("can't list columns: {{err}}", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var have = map[string]bool{}
+
+	for rows.Next() {
+		var table, column string
+
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+
+		have[table+"."+column] = true
+	}
+
+	return have, rows.Err()
+}
+
+// missing tables and columns, sorted.
+func missing(want map[string][]string, have map[string]bool) []string {
+	var m []string
+
+	for table, cols := range want {
+		for _, c := range cols {
+			if !have[table+"."+c] {
+				m = append(m, table+"."+c)
+			}
+		}
+	}
+
+	sort.Strings(m)
+	return m
+}
+
+// List the status of the migrations.
+func List(ctx context.Context, db *sqlx.DB) (s []Status, err error) {
+	err = locked(ctx, db, func(conn *sql.Conn, applied records) error {
+		s = statuses(All(), applied)
+		return nil
+	})
+
+	return s, err
+}
+
+// Pending migrations.
+func Pending(ctx context.Context, db *sqlx.DB) (p []Migration, err error) {
+	err = locked(ctx, db, func(conn *sql.Conn, applied records) error {
+		p, err = pending(All(), applied, 0)
+		return err
+	})
+
+	return p, err
+}
@@ -0,0 +1,217 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+var all = []Migration{
+	{Version: 1, Name: "initial"},
+	{Version: 2, Name: "add_a"},
+	{Version: 3, Name: "add_b"},
+	{Version: 4, Name: "add_c"},
+}
+
+func applied(versions ...int64) records {
+	var r = records{}
+
+	for _, v := range versions {
+		r[v] = record{
+			Version: v,
+			Name:    "applied",
+			Applied: now,
+		}
+	}
+
+	return r
+}
+
+func versions(m []Migration) []int64 {
+	var v []int64
+
+	for _, mm := range m {
+		v = append(v, mm.Version)
+	}
+
+	return v
+}
+
+func TestRegistry(t *testing.T) {
+	var migrations = All()
+
+	if len(migrations) == 0 || migrations[0].Version != BaselineVersion {
+		t.Fatalf("Expected the first migration to be the baseline")
+	}
+
+	for i, m := range migrations {
+		if i != 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("Expected migrations to be sorted by version")
+		}
+
+		if strings.Contains(m.Up, "set_config") || strings.Contains(m.Up, "\nSET ") {
+			t.Errorf("Expected migration %v not to change the settings of the connection", m)
+		}
+	}
+
+	if Latest() != migrations[len(migrations)-1].Version {
+		t.Errorf("Expected latest version to be %d, got %d instead", migrations[len(migrations)-1].Version, Latest())
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected duplicate migration to panic")
+		}
+	}()
+
+	register(Migration{Version: BaselineVersion, Name: "duplicate", Up: "SELECT 1", Down: "SELECT 1"})
+}
+
+func TestMigrationString(t *testing.T) {
+	if got := all[1].String(); got != "0002_add_a" {
+		t.Errorf("Expected 0002_add_a, got %s instead", got)
+	}
+}
+
+func TestPending(t *testing.T) {
+	var cases = []struct {
+		applied records
+		target  int64
+		want    []int64
+	}{
+		{applied(), 0, []int64{1, 2, 3, 4}},
+		{applied(1, 2), 0, []int64{3, 4}},
+		{applied(1, 3), 0, []int64{2, 4}},
+		{applied(1), 3, []int64{2, 3}},
+		{applied(1, 2, 3, 4), 0, nil},
+		{applied(1, 2, 3), 2, nil},
+	}
+
+	for _, c := range cases {
+		got, err := pending(all, c.applied, c.target)
+
+		if err != nil {
+			t.Errorf("Expected no error, got %v instead", err)
+		}
+
+		if !reflect.DeepEqual(versions(got), c.want) {
+			t.Errorf("Expected pending migrations to be %v, got %v instead", c.want, versions(got))
+		}
+	}
+}
+
+func TestPendingUnknownTarget(t *testing.T) {
+	if _, err := pending(all, applied(), 7); err == nil || err.Error() != "unknown migration version 7" {
+		t.Errorf("Expected unknown migration version error, got %v instead", err)
+	}
+}
+
+func TestPendingNewerDatabase(t *testing.T) {
+	if _, err := pending(all, applied(1, 2, 3, 4, 5), 0); err == nil || !strings.Contains(err.Error(), "0005_applied unknown") {
+		t.Errorf("Expected unknown migration error, got %v instead", err)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	got, err := rollback(all, applied(1, 2, 3), 2)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if want := []int64{3, 2}; !reflect.DeepEqual(versions(got), want) {
+		t.Errorf("Expected rollback of %v, got %v instead", want, versions(got))
+	}
+
+	if got, _ = rollback(all, applied(1), 5); !reflect.DeepEqual(versions(got), []int64{1}) {
+		t.Errorf("Expected rollback of [1], got %v instead", versions(got))
+	}
+
+	if _, err = rollback(all, applied(1), 0); err == nil {
+		t.Errorf("Expected error reverting zero migrations")
+	}
+
+	if _, err = rollback(all, applied(1, 9), 1); err == nil {
+		t.Errorf("Expected error reverting migrations of a newer database")
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	var s = statuses(all, applied(1, 2, 8))
+
+	if len(s) != 5 {
+		t.Fatalf("Expected 5 statuses, got %d instead", len(s))
+	}
+
+	if s[0].Applied == nil || !s[0].Applied.Equal(now) || s[2].Applied != nil {
+		t.Errorf("Expected applied and pending migrations, got %+v instead", s)
+	}
+
+	if !s[4].Unknown || s[4].Version != 8 || s[4].Name != "applied" {
+		t.Errorf("Expected unknown migration, got %+v instead", s[4])
+	}
+}
+
+func TestMissing(t *testing.T) {
+	var have = map[string]bool{}
+
+	for table, cols := range initialColumns {
+		for _, c := range cols {
+			have[table+"."+c] = true
+		}
+	}
+
+	if m := missing(initialColumns, have); len(m) != 0 {
+		t.Errorf("Expected no missing columns, got %v instead", m)
+	}
+
+	delete(have, "metrics.sync_ip")
+	delete(have, "authentication.role")
+
+	if m := missing(initialColumns, have); !reflect.DeepEqual(m, []string{"authentication.role", "metrics.sync_ip"}) {
+		t.Errorf("Expected missing columns authentication.role and metrics.sync_ip, got %v instead", m)
+	}
+}
+
+func TestInitialColumns(t *testing.T) {
+	if got := strings.Count(initialUp, "CREATE TABLE "); got != len(initialColumns) {
+		t.Errorf("Expected %d tables on the initial schema, got %d instead", len(initialColumns), got)
+	}
+
+	for table, cols := range initialColumns {
+		var start = strings.Index(initialUp, "CREATE TABLE public."+table+" (\n")
+
+		if start == -1 {
+			t.Errorf("Expected table %s on the initial schema", table)
+			continue
+		}
+
+		var def = initialUp[start : start+strings.Index(initialUp[start:], "\n);")]
+
+		if got := strings.Count(def, "\n    "); got != len(cols) {
+			t.Errorf("Expected %d columns on table %s, got %d instead", len(cols), table, got)
+		}
+
+		for _, c := range cols {
+			if !strings.Contains(def, "\n    "+c+" ") && !strings.Contains(def, "\n    \""+c+"\" ") {
+				t.Errorf("Expected column %s.%s on the initial schema", table, c)
+			}
+		}
+	}
+}
+
+func TestPartitionMetricsChecksVersion(t *testing.T) {
+	if !strings.HasPrefix(partitionMetricsUp, "DO $$") || !strings.Contains(partitionMetricsUp, "server_version_num')::int < 110000") {
+		t.Errorf("Expected partition_metrics to start checking the version of PostgreSQL")
+	}
+
+	// PostgreSQL 10 fails to parse the whole migration on any statement with syntax of 11
+	if strings.Contains(partitionMetricsUp, "\nCREATE TABLE public.metrics_default PARTITION OF public.metrics_partitioned DEFAULT;") {
+		t.Errorf("Expected default partition to be created dynamically")
+	}
+}
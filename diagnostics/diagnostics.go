@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/hashicorp/errwrap"
+	"github.com/henvic/climetrics/cursor"
 	"github.com/henvic/climetrics/db"
 	"github.com/henvic/climetrics/timejson"
 	"github.com/kisielk/sqlstruct"
@@ -97,6 +100,9 @@ type Filter struct {
 	Username         string
 	UsernameOperator Op
 
+	// Cursor to list reports after (used instead of Page).
+	Cursor *cursor.Cursor
+
 	Page    int
 	PerPage int
 }
@@ -115,29 +121,88 @@ const (
 	Equal Op = "equal"
 )
 
-// Count reports.
-func Count(ctx context.Context, f Filter) (int, error) {
-	var q = []string{"SELECT COUNT(id) FROM diagnostics"}
-	var args = []interface{}{}
+// ParseFilter from query parameters.
+func ParseFilter(query url.Values) (f Filter, err error) {
+	var page = 1
 
-	if f.Username != "" {
-		q = append(q, "WHERE")
+	if len(query["page"]) != 0 {
+		page, err = strconv.Atoi(query["page"][0])
+
+		if err != nil {
+			return f, err
+		}
+
+		if page == 0 {
+			page = 1
+		}
+	}
+
+	var usernameOp = Contains
+
+	if query.Get("op") != "" {
+		usernameOp = Op(query.Get("op"))
+	}
+
+	switch usernameOp {
+	case Contains, Like, Equal:
+	default:
+		return f, fmt.Errorf(`invalid username equality operator "%s"`, usernameOp)
+	}
+
+	f = Filter{
+		Username:         query.Get("username"),
+		UsernameOperator: usernameOp,
+
+		Page:    page,
+		PerPage: 50,
+	}
 
+	return f, nil
+}
+
+func filter(f Filter) (args []interface{}, where string, err error) {
+	var w = []string{}
+
+	if f.Username != "" {
 		switch f.UsernameOperator {
 		case Contains:
-			q = append(q, "username ILIKE $1")
+			w = append(w, "username ILIKE $1")
 			args = append(args, `%`+f.Username+`%`)
 		case Like:
-			q = append(q, "username ILIKE $1")
+			w = append(w, "username ILIKE $1")
 			args = append(args, f.Username)
 		case Equal:
-			q = append(q, "username = $1")
+			w = append(w, "username = $1")
 			args = append(args, f.Username)
 		default:
-			return 0, fmt.Errorf(`invalid username equality operator "%s"`, f.UsernameOperator)
+			return nil, "", fmt.Errorf(`invalid username equality operator "%s"`, f.UsernameOperator)
 		}
 	}
 
+	if f.Cursor != nil {
+		cond, cargs := f.Cursor.Condition("sync_time", "id", len(args)+1)
+		w = append(w, cond)
+		args = append(args, cargs...)
+	}
+
+	return args, strings.Join(w, " AND "), nil
+}
+
+// Count reports.
+func Count(ctx context.Context, f Filter) (int, error) {
+	var q = []string{"SELECT COUNT(id) FROM diagnostics"}
+
+	f.Cursor = nil
+	var args, where, err = filter(f)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
 	conn := db.Conn()
 	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
 
@@ -166,46 +231,60 @@ func Count(ctx context.Context, f Filter) (int, error) {
 	return count, nil
 }
 
-// List diagnostics
+// List diagnostics, without their reports.
 func List(ctx context.Context, f Filter) (reports []Report, err error) {
-	var q = []string{
-		"SELECT id, username, timestamp, timestamp_db, sync_time FROM diagnostics",
-	}
-
 	if f.Page == 0 {
 		f.Page = 1
 	}
 
-	var args = []interface{}{
-		f.PerPage,
-		(f.Page - 1) * f.PerPage,
+	err = walk(ctx, f, "id, username, timestamp, timestamp_db, sync_time", func(r Report) error {
+		reports = append(reports, r)
+		return nil
+	})
+
+	return reports, err
+}
+
+// Walk through the diagnostics matching the filter, with their reports, most recent first.
+// There is no limit if PerPage is zero.
+func Walk(ctx context.Context, f Filter, fn func(Report) error) error {
+	return walk(ctx, f, "id, username, report, timestamp, timestamp_db, sync_time", fn)
+}
+
+func walk(ctx context.Context, f Filter, columns string, fn func(Report) error) error {
+	var q = []string{
+		"SELECT " + columns + " FROM diagnostics",
 	}
 
-	if f.Username != "" {
-		q = append(q, "WHERE")
+	args, where, err := filter(f)
 
-		switch f.UsernameOperator {
-		case Contains:
-			q = append(q, "username ILIKE $3")
-			args = append(args, `%`+f.Username+`%`)
-		case Like:
-			q = append(q, "username ILIKE $3")
-			args = append(args, f.Username)
-		case Equal:
-			q = append(q, "username = $3")
-			args = append(args, f.Username)
-		default:
-			return nil, fmt.Errorf(`invalid username equality operator "%s"`, f.UsernameOperator)
-		}
+	if err != nil {
+		return err
 	}
 
-	q = append(q, "ORDER BY sync_time DESC LIMIT $1 OFFSET $2")
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	q = append(q, "ORDER BY sync_time DESC, id DESC")
+
+	var pos = len(args) + 1
+
+	switch {
+	case f.PerPage == 0:
+	case f.Cursor == nil && f.Page > 1:
+		q = append(q, fmt.Sprintf("LIMIT $%d OFFSET $%d", pos, pos+1))
+		args = append(args, f.PerPage, (f.Page-1)*f.PerPage)
+	default:
+		q = append(q, fmt.Sprintf("LIMIT $%d", pos))
+		args = append(args, f.PerPage)
+	}
 
 	conn := db.Conn()
 	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer func() {
@@ -215,21 +294,26 @@ func List(ctx context.Context, f Filter) (reports []Report, err error) {
 	rows, err := stmt.QueryxContext(ctx, args...)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer func() {
+		_ = rows.Close()
+	}()
+
 	for rows.Next() {
 		var r Report
-		err = sqlstruct.Scan(&r, rows)
 
-		if err != nil {
-			return nil, err
+		if err = sqlstruct.Scan(&r, rows); err != nil {
+			return err
 		}
 
-		reports = append(reports, r)
+		if err = fn(r); err != nil {
+			return err
+		}
 	}
 
-	return reports, err
+	return rows.Err()
 }
 
 // Get diagnostics report
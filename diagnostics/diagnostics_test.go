@@ -0,0 +1,71 @@
+package diagnostics
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/cursor"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(url.Values{
+		"username": {"alice@example.com"},
+		"op":       {"equal"},
+		"page":     {"2"},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = Filter{
+		Username:         "alice@example.com",
+		UsernameOperator: Equal,
+		Page:             2,
+		PerPage:          50,
+	}
+
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("Expected filter %+v, got %+v instead", want, f)
+	}
+}
+
+func TestParseFilterFailure(t *testing.T) {
+	var cases = []url.Values{
+		{"page": {"x"}},
+		{"op": {"regexp"}},
+	}
+
+	for _, c := range cases {
+		if _, err := ParseFilter(c); err == nil {
+			t.Errorf("Expected error parsing filter %v", c)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var c = &cursor.Cursor{
+		Time: time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC),
+		ID:   "0b4e0ab3-6ad1-4a6b-9ba5-7e7c4ef4f1c4",
+	}
+
+	args, where, err := filter(Filter{
+		Username:         "alice",
+		UsernameOperator: Contains,
+		Cursor:           c,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if want := "username ILIKE $1 AND (sync_time, id) < ($2::timestamptz, $3::uuid)"; where != want {
+		t.Errorf("Expected where clause %v, got %v instead", want, where)
+	}
+
+	if want := []interface{}{"%alice%", c.Time, c.ID}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected arguments %v, got %v instead", want, args)
+	}
+}
@@ -7,13 +7,15 @@ import (
 	"html"
 	"html/template"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/audit"
 	"github.com/henvic/climetrics/diagnostics"
+	"github.com/henvic/climetrics/export"
 	"github.com/henvic/climetrics/server"
 	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,10 +26,13 @@ func init() {
 	server.Protected.Unsafe("/diagnostics/report")
 
 	router().Handle("/diagnostics",
-		server.AuthenticatedHandler(listOrReadHandler))
+		server.RequirePermission(users.ViewDiagnostics, listOrReadHandler))
+
+	router().Handle("/diagnostics/export",
+		server.RequirePermission(users.ViewDiagnostics, exportHandler))
 
 	router().Handle("/diagnostics/{id}",
-		server.AuthenticatedHandler(readHandler))
+		server.RequirePermission(users.ViewDiagnostics, readHandler))
 }
 
 func listOrReadHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
@@ -41,41 +46,11 @@ func listOrReadHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 }
 
 func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
-	var query = r.URL.Query()
-	var username = ""
-
-	if usernames, ok := query["username"]; ok && len(usernames) != 0 {
-		username = usernames[0]
-	}
-
-	var page = 1
-	var err error
-
-	if len(query["page"]) != 0 {
-		page, err = strconv.Atoi(query["page"][0])
-
-		if err != nil {
-			server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-
-		if page == 0 {
-			page = 1
-		}
-	}
+	f, err := diagnostics.ParseFilter(r.URL.Query())
 
-	var usernameOp = diagnostics.Contains
-
-	if query.Get("op") != "" {
-		usernameOp = diagnostics.Op(query.Get("op"))
-	}
-
-	f := diagnostics.Filter{
-		Username:         username,
-		UsernameOperator: diagnostics.Op(usernameOp),
-
-		Page:    page,
-		PerPage: 50,
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
 	}
 
 	count, err := diagnostics.Count(r.Context(), f)
@@ -117,6 +92,8 @@ func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 			"Operators": operators,
 			"Filter":    f,
 			"URL":       r.URL,
+			"Formats":   export.Formats,
+			"Columns":   export.Diagnostics.Columns(),
 		},
 		Request:        r,
 		ResponseWriter: w,
@@ -125,6 +102,42 @@ func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 	t.Respond()
 }
 
+// exportHandler streams the diagnostics matching the filter, with their reports.
+func exportHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	var query = r.URL.Query()
+	f, err := diagnostics.ParseFilter(query)
+
+	if err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	o, err := export.ParseOptions(export.Diagnostics, query)
+
+	if err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server.Audit(r, audit.Entry{
+		Action:  audit.DiagnosticsExport,
+		Details: query.Encode(),
+	})
+
+	started, err := export.Serve(w, export.Diagnostics, o, func(ew *export.Writer) error {
+		return export.WriteDiagnostics(r.Context(), ew, f)
+	})
+
+	switch {
+	case err != nil && started:
+		// the response is already on its way, so the export ends truncated.
+		log.Errorf("failed to export diagnostics: %+v", err)
+	case err != nil:
+		log.Errorf("failed to export diagnostics: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
 func readHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 	vars := mux.Vars(r)
 	var report, err = diagnostics.Get(r.Context(), vars["id"])
@@ -140,6 +153,11 @@ func readHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 		return
 	}
 
+	server.Audit(r, audit.Entry{
+		Action: audit.DiagnosticsView,
+		Target: report.ID,
+	})
+
 	var t = &server.Template{
 		Title:     "Diagnostics " + report.ID,
 		Section:   "diagnostics",
@@ -0,0 +1,260 @@
+package export
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/henvic/climetrics/archive"
+	"github.com/henvic/climetrics/cursor"
+	"github.com/henvic/climetrics/diagnostics"
+	"github.com/henvic/climetrics/metrics"
+)
+
+// Dataset that can be exported.
+type Dataset struct {
+	Name string
+
+	all []column
+}
+
+type column struct {
+	name  string
+	value func(row interface{}, a *anonymizer) interface{}
+}
+
+// Columns of the dataset, in their default order.
+func (d *Dataset) Columns() []string {
+	var names = make([]string, len(d.all))
+
+	for i, c := range d.all {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// columns by name, or all of them if names is empty.
+func (d *Dataset) columns(names []string) ([]column, error) {
+	if len(names) == 0 {
+		return d.all, nil
+	}
+
+	var cs []column
+	var seen = map[string]bool{}
+
+	for _, name := range names {
+		c, ok := d.column(name)
+
+		if !ok {
+			return nil, fmt.Errorf(`unknown %s column "%s" (use %s)`, d.Name, name, strings.Join(d.Columns(), ", "))
+		}
+
+		if seen[name] {
+			return nil, fmt.Errorf(`duplicated column "%s"`, name)
+		}
+
+		seen[name] = true
+		cs = append(cs, c)
+	}
+
+	return cs, nil
+}
+
+func (d *Dataset) column(name string) (column, bool) {
+	for _, c := range d.all {
+		if c.name == name {
+			return c, true
+		}
+	}
+
+	return column{}, false
+}
+
+// pageSize is the number of rows read at once.
+const pageSize = 1000
+
+// Metrics dataset. The columns match the fields of the metrics sent by the CLI tool,
+// so NDJSON exports can be imported back, followed by the location they were sent from.
+var Metrics = &Dataset{
+	Name: "metrics",
+	all: []column{
+		{"id", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.ID })},
+		{"event_type", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.Type })},
+		{"text", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.Text })},
+		{"tags", metric(func(m metrics.Metric, a *anonymizer) interface{} {
+			if len(m.Tags) == 0 {
+				return nil
+			}
+
+			return m.Tags
+		})},
+		{"extra", metric(func(m metrics.Metric, a *anonymizer) interface{} {
+			if len(m.Extra) == 0 {
+				return nil
+			}
+
+			return m.Extra
+		})},
+		{"pid", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.PID })},
+		{"sid", metric(func(m metrics.Metric, a *anonymizer) interface{} { return a.pseudonym(m.SID) })},
+		{"time", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.Timestamp })},
+		{"version", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.Version })},
+		{"os", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.OS })},
+		{"arch", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.Arch })},
+		{"sync_time", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.SyncTime })},
+		{"request_id", metric(func(m metrics.Metric, a *anonymizer) interface{} { return m.RequestID })},
+		{"sync_ip", metric(func(m metrics.Metric, a *anonymizer) interface{} { return a.ip(m.SyncIP) })},
+		{"sync_city", location(func(l metrics.Location) string { return l.City })},
+		{"sync_region", location(func(l metrics.Location) string { return l.Region })},
+		{"sync_country", location(func(l metrics.Location) string { return l.Country })},
+		{"sync_coordinates", location(func(l metrics.Location) string { return l.Coordinates })},
+		{"sync_asn", location(func(l metrics.Location) string { return l.ASN() })},
+		{"sync_org", location(func(l metrics.Location) string { return l.OrganizationName() })},
+	},
+}
+
+func metric(fn func(m metrics.Metric, a *anonymizer) interface{}) func(interface{}, *anonymizer) interface{} {
+	return func(row interface{}, a *anonymizer) interface{} {
+		return fn(row.(metrics.Metric), a)
+	}
+}
+
+func location(fn func(l metrics.Location) string) func(interface{}, *anonymizer) interface{} {
+	return func(row interface{}, a *anonymizer) interface{} {
+		var l = row.(metrics.Metric).SyncLocation
+
+		if l == nil || l.Error != nil {
+			return ""
+		}
+
+		return fn(*l)
+	}
+}
+
+// Diagnostics dataset. Reports are exported as they were sent.
+var Diagnostics = &Dataset{
+	Name: "diagnostics",
+	all: []column{
+		{"id", report(func(r diagnostics.Report, a *anonymizer) interface{} { return r.ID })},
+		{"username", report(func(r diagnostics.Report, a *anonymizer) interface{} { return a.pseudonym(r.Username) })},
+		{"time", report(func(r diagnostics.Report, a *anonymizer) interface{} { return r.Timestamp })},
+		{"sync_time", report(func(r diagnostics.Report, a *anonymizer) interface{} { return r.SyncTime })},
+		{"report", report(func(r diagnostics.Report, a *anonymizer) interface{} { return r.Report })},
+	},
+}
+
+func report(fn func(r diagnostics.Report, a *anonymizer) interface{}) func(interface{}, *anonymizer) interface{} {
+	return func(row interface{}, a *anonymizer) interface{} {
+		return fn(row.(diagnostics.Report), a)
+	}
+}
+
+// WriteMetrics matching the filter, from the newest to the oldest, ignoring its pagination.
+// Archived metrics are included after the ones on the database, if the filter asks for them.
+func WriteMetrics(ctx context.Context, w *Writer, f metrics.Filter) (err error) {
+	f.Page, f.PerPage = 0, pageSize
+
+	for {
+		var n int
+		var last metrics.Metric
+
+		err = metrics.Walk(ctx, f, func(m metrics.Metric) error {
+			n++
+			last = m
+			return w.Write(m)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if n < f.PerPage {
+			break
+		}
+
+		if f.Cursor, err = cursor.New(last.SyncTime, last.ID); err != nil {
+			return err
+		}
+	}
+
+	if !f.Archive {
+		return nil
+	}
+
+	return archive.Default.Walk(ctx, f, func(m metrics.Metric) error {
+		return w.Write(m)
+	})
+}
+
+// WriteDiagnostics matching the filter, from the newest to the oldest, ignoring its pagination.
+func WriteDiagnostics(ctx context.Context, w *Writer, f diagnostics.Filter) (err error) {
+	f.Page, f.PerPage = 0, pageSize
+
+	for {
+		var n int
+		var last diagnostics.Report
+
+		err = diagnostics.Walk(ctx, f, func(r diagnostics.Report) error {
+			n++
+			last = r
+			return w.Write(r)
+		})
+
+		if err != nil || n < f.PerPage {
+			return err
+		}
+
+		if f.Cursor, err = cursor.New(last.SyncTime, last.ID); err != nil {
+			return err
+		}
+	}
+}
+
+// anonymizer of personal data. A nil anonymizer keeps values as they are.
+type anonymizer struct {
+	key []byte
+}
+
+func newAnonymizer() (*anonymizer, error) {
+	var a = &anonymizer{
+		key: make([]byte, 32),
+	}
+
+	_, err := rand.Read(a.key)
+	return a, err
+}
+
+// ip keeps the network of an IP address only: /24 for IPv4, and /48 for IPv6.
+func (a *anonymizer) ip(s string) string {
+	if a == nil || s == "" {
+		return s
+	}
+
+	var ip = net.ParseIP(s)
+
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return ip.Mask(net.CIDRMask(24, 32)).String()
+	default:
+		return ip.Mask(net.CIDRMask(48, 128)).String()
+	}
+}
+
+// pseudonym for a value, using a keyed hash.
+func (a *anonymizer) pseudonym(s string) string {
+	if a == nil || s == "" {
+		return s
+	}
+
+	var mac = hmac.New(sha256.New, a.key)
+	_, _ = mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
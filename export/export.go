@@ -0,0 +1,342 @@
+// Package export writes metrics and diagnostics as CSV, newline-delimited JSON, or Parquet.
+//
+// Rows are read in pages and written as they come, so exports don't hold everything in memory.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/parquet"
+)
+
+// Format of an export.
+type Format string
+
+const (
+	// CSV with a header row. Values that aren't strings are encoded as JSON.
+	CSV Format = "csv"
+
+	// NDJSON (newline-delimited JSON) with an object per row.
+	NDJSON Format = "ndjson"
+
+	// Parquet with a string column for each column, compressed with gzip.
+	Parquet Format = "parquet"
+)
+
+// Formats available.
+var Formats = []Format{CSV, NDJSON, Parquet}
+
+// ParseFormat by name.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+
+	return "", fmt.Errorf(`invalid format "%s": use csv, ndjson, or parquet`, s)
+}
+
+// ContentType of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Filename for an export of a dataset, such as metrics-20181020T030000Z.csv.
+func (f Format) Filename(d *Dataset, t time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", d.Name, t.UTC().Format("20060102T150405Z"), f)
+}
+
+// Options of an export.
+type Options struct {
+	Format Format
+
+	// Columns to export, in order (default: every column of the dataset).
+	Columns []string
+
+	// Anonymize IP addresses (keeping only their network) and identifiers of sessions and users
+	// (replacing them with pseudonyms that are consistent within an export, but not across exports).
+	Anonymize bool
+}
+
+// ParseOptions from the format, columns (comma-separated), and anonymize query parameters.
+func ParseOptions(d *Dataset, query url.Values) (o Options, err error) {
+	o.Format = NDJSON
+
+	if v := query.Get("format"); v != "" {
+		if o.Format, err = ParseFormat(v); err != nil {
+			return o, err
+		}
+	}
+
+	for _, v := range query["columns"] {
+		for _, c := range strings.Split(v, ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				o.Columns = append(o.Columns, c)
+			}
+		}
+	}
+
+	if _, err = d.columns(o.Columns); err != nil {
+		return o, err
+	}
+
+	switch query.Get("anonymize") {
+	case "", "0", "false", "off":
+	default:
+		o.Anonymize = true
+	}
+
+	return o, nil
+}
+
+// Query parameters of the options.
+func (o Options) Query() url.Values {
+	var q = url.Values{}
+	q.Set("format", string(o.Format))
+
+	if len(o.Columns) != 0 {
+		q.Set("columns", strings.Join(o.Columns, ","))
+	}
+
+	if o.Anonymize {
+		q.Set("anonymize", "1")
+	}
+
+	return q
+}
+
+// bufferSize of the output, so that an export failing early doesn't write anything.
+const bufferSize = 32 << 10
+
+// Writer of the rows of a dataset.
+type Writer struct {
+	buf     *bufio.Writer
+	columns []column
+	anon    *anonymizer
+	rows    rowWriter
+	values  []interface{}
+	n       int
+}
+
+type rowWriter interface {
+	write(values []interface{}) error
+	close() error
+}
+
+// NewWriter of the rows of a dataset.
+func NewWriter(w io.Writer, d *Dataset, o Options) (*Writer, error) {
+	columns, err := d.columns(o.Columns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var names = make([]string, len(columns))
+
+	for i, c := range columns {
+		names[i] = c.name
+	}
+
+	var ew = &Writer{
+		buf:     bufio.NewWriterSize(w, bufferSize),
+		columns: columns,
+		values:  make([]interface{}, len(columns)),
+	}
+
+	if o.Anonymize {
+		if ew.anon, err = newAnonymizer(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch o.Format {
+	case CSV:
+		ew.rows, err = newCSVWriter(ew.buf, names)
+	case NDJSON, "":
+		ew.rows = newNDJSONWriter(ew.buf, names)
+	case Parquet:
+		ew.rows, err = newParquetWriter(ew.buf, d, names, o)
+	default:
+		err = fmt.Errorf(`invalid format "%s"`, o.Format)
+	}
+
+	return ew, err
+}
+
+// Write a row (a value of the type of the dataset).
+func (w *Writer) Write(row interface{}) error {
+	for i, c := range w.columns {
+		w.values[i] = c.value(row, w.anon)
+	}
+
+	if err := w.rows.write(w.values); err != nil {
+		return err
+	}
+
+	w.n++
+	return nil
+}
+
+// Count of rows written.
+func (w *Writer) Count() int {
+	return w.n
+}
+
+// Close the export, flushing it. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.rows.close(); err != nil {
+		return err
+	}
+
+	return w.buf.Flush()
+}
+
+// text of a value, for formats with string values only.
+func text(v interface{}) (string, error) {
+	switch s := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return s, nil
+	}
+
+	b, err := json.Marshal(v)
+
+	if err != nil || string(b) == "null" {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func texts(values []interface{}, record []string) (err error) {
+	for i, v := range values {
+		if record[i], err = text(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	var cw = &csvWriter{
+		w:      csv.NewWriter(w),
+		record: make([]string, len(columns)),
+	}
+
+	return cw, cw.w.Write(columns)
+}
+
+func (c *csvWriter) write(values []interface{}) error {
+	if err := texts(values, c.record); err != nil {
+		return err
+	}
+
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter) close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type ndjsonWriter struct {
+	w    io.Writer
+	keys [][]byte
+	line []byte
+}
+
+func newNDJSONWriter(w io.Writer, columns []string) *ndjsonWriter {
+	var nw = &ndjsonWriter{
+		w: w,
+	}
+
+	for _, c := range columns {
+		// keys are written as they are, to keep the order of the columns.
+		k, _ := json.Marshal(c)
+		nw.keys = append(nw.keys, append(k, ':'))
+	}
+
+	return nw
+}
+
+func (n *ndjsonWriter) write(values []interface{}) error {
+	n.line = append(n.line[:0], '{')
+
+	for i, v := range values {
+		b, err := json.Marshal(v)
+
+		if err != nil {
+			return err
+		}
+
+		if i != 0 {
+			n.line = append(n.line, ',')
+		}
+
+		n.line = append(n.line, n.keys[i]...)
+		n.line = append(n.line, b...)
+	}
+
+	n.line = append(n.line, '}', '\n')
+	_, err := n.w.Write(n.line)
+	return err
+}
+
+func (n *ndjsonWriter) close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	w      *parquet.Writer
+	record []string
+}
+
+func newParquetWriter(w io.Writer, d *Dataset, columns []string, o Options) (*parquetWriter, error) {
+	pw, err := parquet.NewWriter(w, columns, parquet.Gzip)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pw.SetMetadata("climetrics.dataset", d.Name)
+	pw.SetMetadata("climetrics.anonymized", fmt.Sprint(o.Anonymize))
+
+	return &parquetWriter{
+		w:      pw,
+		record: make([]string, len(columns)),
+	}, nil
+}
+
+func (p *parquetWriter) write(values []interface{}) error {
+	if err := texts(values, p.record); err != nil {
+		return err
+	}
+
+	return p.w.Write(p.record)
+}
+
+func (p *parquetWriter) close() error {
+	return p.w.Close()
+}
@@ -0,0 +1,213 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/diagnostics"
+	"github.com/henvic/climetrics/metrics"
+)
+
+var sample = metrics.Metric{
+	ID:        "a6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+	Type:      "cmd",
+	Text:      "deploy",
+	Tags:      metrics.Tags{"quiet", "remote"},
+	Extra:     metrics.Extra{"exit": "0"},
+	PID:       "123",
+	SID:       "session",
+	Timestamp: "Sat Oct 20 03:00:00 +0000 2018",
+	Version:   "1.0.0",
+	OS:        "linux",
+	Arch:      "amd64",
+	SyncTime:  "2018-10-20T03:00:01Z",
+	RequestID: "c6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+	SyncIP:    "203.0.113.45",
+	SyncLocation: &metrics.Location{
+		City:         "Recife",
+		Region:       "Pernambuco",
+		Country:      "BR",
+		Coordinates:  "-8.0539,-34.8811",
+		Organization: "AS15169 Google LLC",
+	},
+}
+
+func TestParseOptions(t *testing.T) {
+	o, err := ParseOptions(Metrics, url.Values{
+		"format":    []string{"csv"},
+		"columns":   []string{"id, event_type", "sync_asn"},
+		"anonymize": []string{"1"},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = Options{
+		Format:    CSV,
+		Columns:   []string{"id", "event_type", "sync_asn"},
+		Anonymize: true,
+	}
+
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("Expected options to be %+v, got %+v instead", want, o)
+	}
+
+	if q := o.Query().Encode(); q != "anonymize=1&columns=id%2Cevent_type%2Csync_asn&format=csv" {
+		t.Errorf("Expected query of the options, got %v instead", q)
+	}
+
+	if o, err = ParseOptions(Diagnostics, url.Values{}); err != nil || !reflect.DeepEqual(o, Options{Format: NDJSON}) {
+		t.Errorf("Expected default options, got %+v (error: %v) instead", o, err)
+	}
+}
+
+func TestParseOptionsFailure(t *testing.T) {
+	var cases = map[string]string{
+		"format=xml":                `invalid format "xml": use csv, ndjson, or parquet`,
+		"columns=id,report":         `unknown metrics column "report" (use ` + strings.Join(Metrics.Columns(), ", ") + ")",
+		"columns=id&columns=id":     `duplicated column "id"`,
+		"format=parquet&columns=ip": `unknown metrics column "ip" (use ` + strings.Join(Metrics.Columns(), ", ") + ")",
+	}
+
+	for query, want := range cases {
+		q, _ := url.ParseQuery(query)
+
+		if _, err := ParseOptions(Metrics, q); err == nil || err.Error() != want {
+			t.Errorf("Expected error %q for %s, got %v instead", want, query, err)
+		}
+	}
+}
+
+func export(t *testing.T, d *Dataset, o Options, rows ...interface{}) string {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, d, o)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Expected no error, got %v instead", err)
+		}
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected output to be buffered until the export is closed")
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if w.Count() != len(rows) {
+		t.Errorf("Expected %d rows, got %d instead", len(rows), w.Count())
+	}
+
+	return buf.String()
+}
+
+func TestExportCSV(t *testing.T) {
+	var o = Options{
+		Format:  CSV,
+		Columns: []string{"id", "tags", "extra", "sync_country", "sync_asn", "sync_org"},
+	}
+
+	var got = export(t, Metrics, o, sample, metrics.Metric{ID: "b6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c"})
+
+	var want = `id,tags,extra,sync_country,sync_asn,sync_org
+a6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c,"[""quiet"",""remote""]","{""exit"":""0""}",BR,AS15169,Google LLC
+b6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c,,,,,
+`
+
+	if got != want {
+		t.Errorf("Expected CSV to be %q, got %q instead", want, got)
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	var got = export(t, Metrics, Options{Format: NDJSON}, sample)
+
+	if !strings.HasPrefix(got, `{"id":"a6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c","event_type":"cmd","text":"deploy",`) ||
+		!strings.HasSuffix(got, `"sync_asn":"AS15169","sync_org":"Google LLC"}`+"\n") {
+		t.Errorf("Expected columns in order, got %v instead", got)
+	}
+
+	// exports can be imported back.
+	var m metrics.Metric
+
+	if err := json.Unmarshal([]byte(got), &m); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = sample
+	want.SyncLocation = nil
+
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Expected metric to be %+v, got %+v instead", want, m)
+	}
+}
+
+func TestExportParquet(t *testing.T) {
+	var got = export(t, Diagnostics, Options{Format: Parquet}, diagnostics.Report{ID: "id", Report: "report"})
+
+	if !strings.HasPrefix(got, "PAR1") || !strings.HasSuffix(got, "PAR1") ||
+		!strings.Contains(got, "climetrics.dataset") {
+		t.Errorf("Expected Parquet file, got %q instead", got)
+	}
+}
+
+func TestExportAnonymize(t *testing.T) {
+	var o = Options{
+		Format:    CSV,
+		Columns:   []string{"sid", "sync_ip"},
+		Anonymize: true,
+	}
+
+	var ipv6 = sample
+	ipv6.SyncIP = "2001:db8:85a3:8d3:1319:8a2e:370:7348"
+
+	var lines = strings.Split(export(t, Metrics, o, sample, ipv6, metrics.Metric{}), "\n")
+
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, got %q instead", lines)
+	}
+
+	var sid = strings.Split(lines[1], ",")[0]
+
+	if len(sid) != 32 || sid == sample.SID {
+		t.Errorf("Expected session ID to be replaced, got %v instead", sid)
+	}
+
+	var want = []string{
+		"sid,sync_ip",
+		sid + ",203.0.113.0",
+		sid + ",2001:db8:85a3::",
+		",",
+		"",
+	}
+
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("Expected lines to be %q, got %q instead", want, lines)
+	}
+
+	var other = strings.Split(export(t, Metrics, o, sample), "\n")[1]
+
+	if strings.HasPrefix(other, sid) {
+		t.Errorf("Expected pseudonyms to change across exports, got %v instead", other)
+	}
+}
+
+func TestFilename(t *testing.T) {
+	var tm = time.Date(2018, 10, 20, 3, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+
+	if got := Parquet.Filename(Diagnostics, tm); got != "diagnostics-20181020T060000Z.parquet" {
+		t.Errorf("Expected filename in UTC, got %v instead", got)
+	}
+}
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// response sends the headers of an export on its first write.
+type response struct {
+	w        http.ResponseWriter
+	o        Options
+	started  bool
+	filename string
+}
+
+func (r *response) Write(p []byte) (int, error) {
+	if !r.started {
+		r.start()
+	}
+
+	return r.w.Write(p)
+}
+
+func (r *response) start() {
+	r.started = true
+	r.w.Header().Set("Content-Type", r.o.Format.ContentType())
+	r.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, r.filename))
+}
+
+// Serve an export as a file attachment, writing the rows with fn.
+// Nothing is sent before the output starts, so an export failing early can still respond with an error:
+// started tells if the response is already on its way.
+func Serve(w http.ResponseWriter, d *Dataset, o Options, fn func(*Writer) error) (started bool, err error) {
+	var r = &response{
+		w:        w,
+		o:        o,
+		filename: o.Format.Filename(d, time.Now()),
+	}
+
+	ew, err := NewWriter(r, d, o)
+
+	if err != nil {
+		return false, err
+	}
+
+	if err = fn(ew); err != nil {
+		return r.started, err
+	}
+
+	if err = ew.Close(); err != nil {
+		return r.started, err
+	}
+
+	if !r.started {
+		r.start()
+	}
+
+	return true, nil
+}
@@ -0,0 +1,170 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/kisielk/sqlstruct"
+)
+
+// Entry on the geolocation cache.
+type Entry struct {
+	IP        string    `db:"ip"`
+	Cache     []byte    `db:"cache"`
+	Timestamp time.Time `db:"timestamp"`
+	Fresh     bool      `db:"fresh"`
+}
+
+// Filter for the geolocation cache entries.
+type Filter struct {
+	// Network (IP or CIDR) containing the entries.
+	Network string
+
+	// Fresh or Stale entries only.
+	Fresh bool
+	Stale bool
+
+	Page    int
+	PerPage int
+}
+
+// Validate filter.
+func (f Filter) Validate() error {
+	if f.Network == "" {
+		return nil
+	}
+
+	if _, _, err := net.ParseCIDR(f.Network); err != nil && net.ParseIP(f.Network) == nil {
+		return fmt.Errorf("invalid network %q: use an IP or the CIDR notation (i.e., 10.20.0.0/16)", f.Network)
+	}
+
+	return nil
+}
+
+func (f Filter) where() (args []interface{}, where string) {
+	var w []string
+
+	if f.Network != "" {
+		args = append(args, f.Network)
+		w = append(w, fmt.Sprintf("ip <<= $%d::inet", len(args)))
+	}
+
+	if f.Fresh {
+		args = append(args, ttl)
+		w = append(w, fmt.Sprintf("timestamp >= NOW() - $%d::interval", len(args)))
+	}
+
+	if f.Stale {
+		args = append(args, ttl)
+		w = append(w, fmt.Sprintf("timestamp < NOW() - $%d::interval", len(args)))
+	}
+
+	return args, strings.Join(w, " AND ")
+}
+
+// Count cache entries.
+func Count(ctx context.Context, f Filter) (int, error) {
+	var q = []string{"SELECT COUNT(ip) FROM geolocation"}
+	var args, where = f.where()
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	var count int
+	err = stmt.QueryRowxContext(ctx, args...).Scan(&count)
+	return count, err
+}
+
+// List cache entries, from the most recent to the oldest.
+func List(ctx context.Context, f Filter) (entries []Entry, err error) {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+
+	var args, where = f.where()
+	var pos = len(args) + 1
+
+	var q = []string{fmt.Sprintf(`SELECT ip, cache, timestamp,
+	timestamp >= NOW() - $%d::interval AS fresh FROM geolocation`, pos)}
+	args = append(args, ttl)
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	q = append(q, fmt.Sprintf("ORDER BY timestamp DESC LIMIT $%d OFFSET $%d", pos+1, pos+2))
+	args = append(args, f.PerPage, (f.Page-1)*f.PerPage)
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var e Entry
+		err = sqlstruct.Scan(&e, rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Expires tells when the cache entry stops being fresh.
+func (e Entry) Expires() time.Time {
+	return e.Timestamp.Add(ttlDuration)
+}
+
+// Invalidate cache entries of IPs on a given network (IP or CIDR).
+func Invalidate(ctx context.Context, network string) (int64, error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM geolocation WHERE ip <<= $1::inet`)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, network)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,17 @@
+package geolocation
+
+import "testing"
+
+func TestFilterValidate(t *testing.T) {
+	for _, n := range []string{"", "203.0.113.1", "203.0.113.0/24", "2001:db8::/32"} {
+		if err := (Filter{Network: n}).Validate(); err != nil {
+			t.Errorf("Expected network %q to be valid, got %v instead", n, err)
+		}
+	}
+
+	for _, n := range []string{"203.0.113", "203.0.113.0/33", "localhost", "203.0.113.1 "} {
+		if err := (Filter{Network: n}).Validate(); err == nil {
+			t.Errorf("Expected network %q to be invalid, got nil instead", n)
+		}
+	}
+}
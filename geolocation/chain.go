@@ -0,0 +1,46 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Chain of providers. Each provider is tried in order until one succeeds.
+type Chain []Provider
+
+// Name of the providers on the chain.
+func (c Chain) Name() string {
+	var names []string
+
+	for _, p := range c {
+		names = append(names, p.Name())
+	}
+
+	return strings.Join(names, ",")
+}
+
+// Fetch geolocation information from the first provider that has it.
+func (c Chain) Fetch(ctx context.Context, ip string) ([]byte, error) {
+	var errs []string
+
+	for _, p := range c {
+		b, err := p.Fetch(ctx, ip)
+
+		if err == nil {
+			return setProvider(b, p.Name())
+		}
+
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+	}
+
+	if len(errs) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return nil, fmt.Errorf("no geolocation provider succeeded: %s", strings.Join(errs, "; "))
+}
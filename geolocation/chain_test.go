@@ -0,0 +1,96 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type fakeProvider struct {
+	name string
+	data string
+	err  error
+}
+
+func (f fakeProvider) Name() string {
+	return f.name
+}
+
+func (f fakeProvider) Fetch(ctx context.Context, ip string) ([]byte, error) {
+	return []byte(f.data), f.err
+}
+
+func TestChain(t *testing.T) {
+	var c = Chain{
+		fakeProvider{name: "first", err: ErrNotFound},
+		fakeProvider{name: "second", data: `{"ip": "8.8.8.8", "country": "US"}`},
+		fakeProvider{name: "third", err: errors.New("should not be called")},
+	}
+
+	if name := c.Name(); name != "first,second,third" {
+		t.Errorf("Expected chain name to be first,second,third, got %v instead", name)
+	}
+
+	b, err := c.Fetch(context.Background(), "8.8.8.8")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var v map[string]string
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if v["country"] != "US" || v["provider"] != "second" {
+		t.Errorf("Expected data from the second provider, got %v instead", v)
+	}
+}
+
+func TestChainFailure(t *testing.T) {
+	var c = Chain{
+		fakeProvider{name: "first", err: ErrNotFound},
+		fakeProvider{name: "second", err: errors.New("unavailable")},
+	}
+
+	var want = "no geolocation provider succeeded: first: geolocation not found; second: unavailable"
+
+	if _, err := c.Fetch(context.Background(), "8.8.8.8"); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v instead", want, err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse(Options{Providers: "ipinfo", MonthlyQuota: 100})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	i, ok := p.(*IPInfo)
+
+	if !ok {
+		t.Fatalf("Expected ipinfo provider, got %T instead", p)
+	}
+
+	if i.Limiter == nil || i.Limiter.Monthly != 100 {
+		t.Errorf("Expected ipinfo provider to have a monthly quota of 100 requests, got %+v instead", i.Limiter)
+	}
+}
+
+func TestParseFailure(t *testing.T) {
+	var cases = []Options{
+		{},
+		{Providers: "unknown"},
+		{Providers: "mmdb"},
+		{Providers: "mmdb,ipinfo", Databases: "not-found.mmdb"},
+	}
+
+	for _, c := range cases {
+		if _, err := Parse(c); err == nil {
+			t.Errorf("Expected error parsing options %+v", c)
+		}
+	}
+}
@@ -3,10 +3,9 @@ package geolocation
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,13 +13,41 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-// Host for the service.
-const Host = "https://ipinfo.io/"
+// ttlDuration is how long a cache entry is considered fresh.
+const ttlDuration = 48 * time.Hour
 
-// TTL is how long a cache entry is considered fresh (stored in PostgreSQL style)
-const ttl = "48 hours"
+// ttl is ttlDuration as a PostgreSQL interval.
+var ttl = fmt.Sprintf("%d seconds", int64(ttlDuration/time.Second))
 
-const backoff429 = 3 * time.Hour // backoff for 'Too Many Requests'
+// ErrNotFound is returned by providers without information about an IP.
+var ErrNotFound = errors.New("geolocation not found")
+
+// Provider of geolocation information.
+type Provider interface {
+	// Name of the provider.
+	Name() string
+
+	// Fetch geolocation information for a given IP as a JSON object
+	// on the format of the ipinfo.io API (see metrics.Location).
+	Fetch(ctx context.Context, ip string) ([]byte, error)
+}
+
+var provider Provider = &IPInfo{}
+var providerM sync.RWMutex
+
+// Use provider for refreshing geolocation information.
+func Use(p Provider) {
+	providerM.Lock()
+	defer providerM.Unlock()
+	provider = p
+}
+
+// Current provider for refreshing geolocation information.
+func Current() Provider {
+	providerM.RLock()
+	defer providerM.RUnlock()
+	return provider
+}
 
 type cache struct {
 	IP        string    `db:"ip"`
@@ -29,7 +56,14 @@ type cache struct {
 }
 
 // Get geolocation for a given IP. Updates cache if needed.
+// Overrides are used before any cached data or provider.
 func Get(ctx context.Context, ip string) (data []byte, err error) {
+	var ok bool
+
+	if data, ok, err = override(ctx, ip); ok || err != nil {
+		return data, err
+	}
+
 	data, err = Cached(ctx, ip)
 
 	if err != nil {
@@ -71,12 +105,34 @@ func Cached(ctx context.Context, ip string) (data []byte, err error) {
 
 // Refresh cache.
 func Refresh(ctx context.Context, ip string) ([]byte, error) {
-	b, err := fetch(ctx, ip)
+	if data, ok, err := override(ctx, ip); ok || err != nil {
+		return data, err
+	}
+
+	var p = Current()
+	b, err := p.Fetch(ctx, ip)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// validate JSON as a last step.
+	var v map[string]interface{}
+
+	if err = json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+
+	if _, ok := v["provider"]; !ok {
+		v["provider"] = p.Name()
+	}
+
+	v["cached"] = time.Now().Format(time.RFC3339)
+
+	if b, err = json.Marshal(v); err != nil {
+		return nil, err
+	}
+
 	_, err = upsert(ctx, ip, json.RawMessage(b))
 	return b, err
 }
@@ -115,96 +171,75 @@ func upsert(ctx context.Context, ip string, d json.RawMessage) (created bool, er
 	return rows != 0, err
 }
 
-func fetch(ctx context.Context, ip string) (b []byte, err error) {
-	if err = green429(); err != nil {
-		return nil, err
-	}
-
-	u, err := url.Parse(Host)
-
-	if err != nil {
-		return nil, err
-	}
-
-	u.Path = fmt.Sprintf("/%s/json", url.PathEscape(ip))
+// Options for the providers.
+type Options struct {
+	// Providers is a comma-separated list of providers (ipinfo, mmdb) in order of preference.
+	Providers string
 
-	var c = http.DefaultClient
+	// Databases is a comma-separated list of MaxMind database files used by the mmdb provider.
+	Databases string
 
-	var req *http.Request
-	req, err = http.NewRequest("GET", u.String(), nil)
-
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("User-Agent", "climetrics/alpha (+https://github.com/henvic/climetrics)")
-	req.Header.Set("Accept", "application/json; charset=utf-8")
-
-	req = req.WithContext(ctx)
-
-	var resp *http.Response
-	resp, err = c.Do(req)
-
-	if err != nil {
-		return nil, err
-	}
+	// DailyQuota and MonthlyQuota of requests to the ipinfo provider (zero means unlimited).
+	DailyQuota   int
+	MonthlyQuota int
+}
 
-	defer func() {
-		ec := resp.Body.Close()
+// Parse options into a provider.
+func Parse(o Options) (Provider, error) {
+	var c Chain
+	var files []string
 
-		if err == nil {
-			err = ec
+	for _, f := range strings.Split(o.Databases, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
 		}
-	}()
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		slowdown429() // could as well check the Retry-After header
+	}
 
-		if err = green429(); err != nil {
-			return nil, err
+	for _, name := range strings.Split(o.Providers, ",") {
+		switch strings.TrimSpace(name) {
+		case "ipinfo":
+			c = append(c, &IPInfo{
+				Limiter: &Limiter{
+					Provider: "ipinfo",
+					Daily:    o.DailyQuota,
+					Monthly:  o.MonthlyQuota,
+				},
+			})
+		case "mmdb":
+			if len(files) == 0 {
+				return nil, errors.New("mmdb geolocation provider requires at least one MaxMind database file")
+			}
+
+			m, err := OpenMMDB(files...)
+
+			if err != nil {
+				return nil, err
+			}
+
+			c = append(c, m)
+		case "":
+		default:
+			return nil, fmt.Errorf(`unknown geolocation provider "%s"`, name)
 		}
 	}
 
-	if resp.StatusCode < 200 && resp.StatusCode > 299 {
-		return nil, fmt.Errorf("response has status code %d", resp.StatusCode)
+	switch len(c) {
+	case 0:
+		return nil, errors.New("no geolocation provider")
+	case 1:
+		return c[0], nil
 	}
 
-	b, err = ioutil.ReadAll(resp.Body)
-
-	if err != nil {
-		return nil, err
-	}
+	return c, nil
+}
 
-	// validate JSON as a last step.
+func setProvider(b []byte, name string) ([]byte, error) {
 	var v map[string]interface{}
 
-	err = json.Unmarshal(b, &v)
-
-	if err != nil {
+	if err := json.Unmarshal(b, &v); err != nil {
 		return nil, err
 	}
 
-	v["cached"] = time.Now().Format(time.RFC3339)
+	v["provider"] = name
 	return json.Marshal(v)
 }
-
-var deadline429 = time.Now()
-var m429 sync.RWMutex
-
-func green429() error {
-	// TODO(henvic): Add a counter watch to fire warnings (or soft errors) if reaching near the limit.
-	m429.RLock()
-	defer m429.RUnlock()
-
-	if time.Since(deadline429) <= 0 {
-		return fmt.Errorf("too many requests: canceling any requests until %v", deadline429)
-	}
-
-	return nil
-}
-
-func slowdown429() {
-	m429.Lock()
-	defer m429.Unlock()
-	deadline429 = time.Now().Add(backoff429)
-}
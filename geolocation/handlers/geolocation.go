@@ -0,0 +1,295 @@
+package geolocationhandlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/geolocation"
+	"github.com/henvic/climetrics/metrics"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
+	log "github.com/sirupsen/logrus"
+)
+
+var router = server.Instance.Mux
+
+func init() {
+	router().Handle("/geolocation", server.RequirePermission(users.ManageSettings, listHandler))
+	router().Handle("/geolocation/refresh", server.RequirePermission(users.ManageSettings, refreshHandler))
+	router().Handle("/geolocation/overrides", server.RequirePermission(users.ManageSettings, overridesHandler))
+	router().Handle("/geolocation/overrides/{id}/apply", server.RequirePermission(users.ManageSettings, applyOverrideHandler))
+	router().Handle("/geolocation/overrides/{id}/delete", server.RequirePermission(users.ManageSettings, deleteOverrideHandler))
+}
+
+type entry struct {
+	geolocation.Entry
+	Location metrics.Location
+}
+
+func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	var query = r.URL.Query()
+	var page = 1
+	var err error
+
+	if len(query["page"]) != 0 {
+		page, err = strconv.Atoi(query["page"][0])
+
+		if err != nil {
+			server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		if page == 0 {
+			page = 1
+		}
+	}
+
+	var show = query.Get("show")
+
+	f := geolocation.Filter{
+		Network: query.Get("network"),
+		Fresh:   show == "fresh",
+		Stale:   show == "stale",
+
+		Page:    page,
+		PerPage: 100,
+	}
+
+	switch show {
+	case "", "fresh", "stale":
+	default:
+		server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	if err = f.Validate(); err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count, err := geolocation.Count(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to count geolocation cache entries: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	list, err := geolocation.List(r.Context(), f)
+
+	if err != nil {
+		log.Errorf("failed to list geolocation cache entries: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var entries = []entry{}
+
+	for _, e := range list {
+		var l metrics.Location
+
+		if err := json.Unmarshal(e.Cache, &l); err != nil {
+			l.ErrorJSON = err
+		}
+
+		entries = append(entries, entry{Entry: e, Location: l})
+	}
+
+	var maxPage = count / f.PerPage
+
+	if count%f.PerPage != 0 {
+		maxPage++
+	}
+
+	var t = &server.Template{
+		Title:     "Geolocation cache",
+		Section:   "geolocation",
+		Filenames: []string{"gui/geolocation/list.html"},
+		Data: map[string]interface{}{
+			"List":     entries,
+			"Count":    count,
+			"MaxPage":  maxPage,
+			"Filter":   f,
+			"Show":     show,
+			"Provider": geolocation.Current().Name(),
+			"URL":      r.URL,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+func refreshHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var ip = r.PostFormValue("ip")
+
+	if ip == "" {
+		server.ErrorHandler(w, r, "Missing IP parameter", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := geolocation.Refresh(r.Context(), ip); err != nil {
+		log.Errorf("can't refresh geolocation for IP %s: %+v", ip, err)
+		server.ErrorHandler(w, r, fmt.Sprintf("Can't refresh geolocation for IP %s: %v", ip, err), http.StatusBadGateway)
+		return
+	}
+
+	updated, err := metrics.ApplyGeolocation(r.Context(), ip)
+
+	if err != nil {
+		log.Errorf("can't update metrics geolocation for IP %s: %+v", ip, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("geolocation for IP %s refreshed by %s: %d metrics updated", ip, s.User.Username, updated)
+	http.Redirect(w, r, "/geolocation?"+url.Values{"network": []string{ip}}.Encode(), http.StatusSeeOther)
+}
+
+func overridesHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	switch r.Method {
+	case http.MethodGet:
+		listOverridesHandler(w, r, s)
+	case http.MethodPost:
+		createOverrideHandler(w, r, s)
+	default:
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func listOverridesHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	list, err := geolocation.Overrides(r.Context())
+
+	if err != nil {
+		log.Errorf("failed to list geolocation overrides: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var t = &server.Template{
+		Title:     "Geolocation overrides",
+		Section:   "geolocation",
+		Filenames: []string{"gui/geolocation/overrides.html"},
+		Data: map[string]interface{}{
+			"List": list,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+func createOverrideHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	var o = geolocation.Override{
+		Network:      r.PostFormValue("network"),
+		Organization: r.PostFormValue("organization"),
+		City:         r.PostFormValue("city"),
+		Region:       r.PostFormValue("region"),
+		Country:      r.PostFormValue("country"),
+		Coordinates:  r.PostFormValue("coordinates"),
+		Note:         r.PostFormValue("note"),
+	}
+
+	if err := o.Validate(); err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	o, err := geolocation.CreateOverride(r.Context(), o)
+
+	if err != nil {
+		log.Errorf("can't create geolocation override: %+v", err)
+		server.ErrorHandler(w, r, "Internal Server Error: saving override", http.StatusInternalServerError)
+		return
+	}
+
+	applyOverride(w, r, s, o)
+}
+
+func applyOverrideHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	o, err := geolocation.GetOverride(r.Context(), mux.Vars(r)["id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't get geolocation override: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	applyOverride(w, r, s, o)
+}
+
+func applyOverride(w http.ResponseWriter, r *http.Request, s us.Session, o geolocation.Override) {
+	updated, err := metrics.ApplyGeolocation(r.Context(), o.Network)
+
+	if err != nil {
+		log.Errorf("can't apply geolocation override for %s: %+v", o.Network, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	log.Infof("geolocation override for %s applied by %s: %d metrics updated", o.Network, s.User.Username, updated)
+	http.Redirect(w, r, "/geolocation/overrides", http.StatusSeeOther)
+}
+
+func deleteOverrideHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	o, err := geolocation.DeleteOverride(r.Context(), mux.Vars(r)["id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		log.Errorf("can't delete geolocation override: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	// metrics of the network go back to the geolocation queue (and the next fixgeoip run).
+	ips, err := metrics.NetworkIPs(r.Context(), o.Network)
+
+	if err == nil {
+		_, err = metrics.ResetOverriddenGeolocation(r.Context(), o.Network)
+	}
+
+	if err != nil {
+		log.Errorf("can't reset geolocation of metrics for %s: %+v", o.Network, err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	for _, ip := range ips {
+		metrics.Geolocation.Enqueue(ip)
+	}
+
+	log.Infof("geolocation override for %s deleted by %s", o.Network, s.User.Username)
+	http.Redirect(w, r, "/geolocation/overrides", http.StatusSeeOther)
+}
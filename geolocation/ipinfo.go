@@ -0,0 +1,124 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Host for the ipinfo.io service.
+const Host = "https://ipinfo.io/"
+
+// IPInfo provider uses the ipinfo.io API.
+type IPInfo struct {
+	// Host of the service (default: Host).
+	Host string
+
+	// Client for the HTTP requests (default: http.DefaultClient).
+	Client *http.Client
+
+	// Limiter of requests (optional).
+	Limiter *Limiter
+}
+
+// Name of the provider.
+func (i *IPInfo) Name() string {
+	return "ipinfo"
+}
+
+// Fetch geolocation information from ipinfo.io.
+func (i *IPInfo) Fetch(ctx context.Context, ip string) (b []byte, err error) {
+	if i.Limiter != nil {
+		if err = i.Limiter.Take(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var host = i.Host
+
+	if host == "" {
+		host = Host
+	}
+
+	u, err := url.Parse(host)
+
+	if err != nil {
+		return nil, err
+	}
+
+	u.Path = fmt.Sprintf("/%s/json", url.PathEscape(ip))
+
+	var c = i.Client
+
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	var req *http.Request
+	req, err = http.NewRequest("GET", u.String(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "climetrics/alpha (+https://github.com/henvic/climetrics)")
+	req.Header.Set("Accept", "application/json; charset=utf-8")
+
+	req = req.WithContext(ctx)
+
+	var resp *http.Response
+	resp, err = c.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		ec := resp.Body.Close()
+
+		if err == nil {
+			err = ec
+		}
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, i.backoff(ctx, resp.Header.Get("Retry-After"))
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (i *IPInfo) backoff(ctx context.Context, retryAfter string) error {
+	var now = time.Now()
+	until, ok := parseRetryAfter(retryAfter, now)
+
+	if !ok {
+		until = now.Add(DefaultBackoff)
+	}
+
+	var rle = &RateLimitError{
+		Provider: i.Name(),
+		Until:    until,
+		Reason:   "too many requests",
+	}
+
+	if i.Limiter == nil {
+		return rle
+	}
+
+	if err := i.Limiter.Backoff(ctx, until); err != nil {
+		log.Errorf("can't persist backoff for %s geolocation provider: %v", i.Name(), err)
+	}
+
+	return rle
+}
@@ -0,0 +1,85 @@
+package geolocation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIPInfoFetch(t *testing.T) {
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/8.8.8.8/json":
+			_, _ = w.Write([]byte(`{"ip": "8.8.8.8", "country": "US"}`))
+		case "/203.0.113.1/json":
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/203.0.113.2/json":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	var ts = httptest.NewServer(http.HandlerFunc(handler))
+	defer ts.Close()
+
+	var i = &IPInfo{Host: ts.URL}
+	var ctx = context.Background()
+
+	b, err := i.Fetch(ctx, "8.8.8.8")
+
+	if err != nil || string(b) != `{"ip": "8.8.8.8", "country": "US"}` {
+		t.Errorf("Expected geolocation data, got %s (error: %v) instead", b, err)
+	}
+
+	var before = time.Now()
+	_, err = i.Fetch(ctx, "203.0.113.1")
+	rle, ok := err.(*RateLimitError)
+
+	if !ok {
+		t.Fatalf("Expected rate limit error, got %v instead", err)
+	}
+
+	if rle.Until.Before(before.Add(120*time.Second)) || rle.Until.After(time.Now().Add(120*time.Second)) {
+		t.Errorf("Expected to backoff for 120 seconds, got %v instead", rle.Until)
+	}
+
+	_, err = i.Fetch(ctx, "203.0.113.2")
+	se, ok := err.(*StatusError)
+
+	if !ok || se.StatusCode != http.StatusBadGateway || !se.Temporary() || Permanent(err) {
+		t.Errorf("Expected temporary status error, got %v instead", err)
+	}
+
+	if _, err = i.Fetch(ctx, "203.0.113.3"); err != ErrNotFound || !Permanent(err) {
+		t.Errorf("Expected error to be %v, got %v instead", ErrNotFound, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	var now = time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var cases = []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"", time.Time{}, false},
+		{"-1", time.Time{}, false},
+		{"soon", time.Time{}, false},
+		{"0", now, true},
+		{"3600", now.Add(time.Hour), true},
+		{"Mon, 01 Oct 2018 15:00:00 GMT", time.Date(2018, 10, 1, 15, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, c := range cases {
+		got, ok := parseRetryAfter(c.value, now)
+
+		if ok != c.ok || !got.Equal(c.want) {
+			t.Errorf("Expected Retry-After %q to be parsed as %v (%v), got %v (%v) instead", c.value, c.want, c.ok, got, ok)
+		}
+	}
+}
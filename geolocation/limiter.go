@@ -0,0 +1,251 @@
+package geolocation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultBackoff is used when a provider rejects requests without telling when to retry.
+const DefaultBackoff = 3 * time.Hour
+
+// DefaultWarning is the fraction of a quota used before warnings are logged.
+const DefaultWarning = 0.9
+
+// RateLimitError is returned when requests to a provider are paused
+// due to a quota or to the provider rejecting requests.
+type RateLimitError struct {
+	Provider string
+	Until    time.Time
+	Reason   string
+}
+
+func (r *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %s: canceling any requests until %v", r.Provider, r.Reason, r.Until.Format(time.RFC3339))
+}
+
+// StatusError is returned when a provider responds with an unexpected HTTP status code.
+type StatusError struct {
+	StatusCode int
+}
+
+func (s *StatusError) Error() string {
+	return fmt.Sprintf("response has status code %d", s.StatusCode)
+}
+
+// Temporary tells if the request might succeed if retried.
+func (s *StatusError) Temporary() bool {
+	return s.StatusCode >= 500
+}
+
+// Permanent tells if an error is unlikely to go away by retrying the request soon.
+func Permanent(err error) bool {
+	switch e := err.(type) {
+	case *RateLimitError:
+		return true
+	case *StatusError:
+		return !e.Temporary()
+	}
+
+	return err == ErrNotFound
+}
+
+// Limiter of requests to a provider.
+// Its state is stored on the database, so it is shared by every process using it.
+type Limiter struct {
+	// Provider name.
+	Provider string
+
+	// Daily and Monthly quota of requests (zero means unlimited).
+	Daily   int
+	Monthly int
+
+	// Warning is the fraction of a quota used before warnings are logged (default: DefaultWarning).
+	Warning float64
+}
+
+type limiterState struct {
+	DayRequests   int
+	MonthRequests int
+	BackoffUntil  *time.Time
+}
+
+// Take permission to do a request, counting it towards the quota.
+// The quota is checked and counted on a single statement, so concurrent requests (even from other processes) can't overrun it.
+func (l *Limiter) Take(ctx context.Context) error {
+	// the request is counted again once if the state changed meanwhile (i.e., the backoff ended or the day changed)
+	for attempt := 0; attempt < 2; attempt++ {
+		s, err := l.count(ctx)
+
+		if err == nil {
+			l.warn("daily", s.DayRequests, l.Daily)
+			l.warn("monthly", s.MonthRequests, l.Monthly)
+			return nil
+		}
+
+		if err != sql.ErrNoRows {
+			return err
+		}
+
+		if s, err = l.state(ctx); err != nil {
+			return err
+		}
+
+		if rle := l.limited(s, time.Now()); rle != nil {
+			return rle
+		}
+	}
+
+	return fmt.Errorf("%s: request not counted towards the quota: try again later", l.Provider)
+}
+
+// limited explains why a request wasn't counted, given the state of the quota, or returns nil if it doesn't.
+func (l *Limiter) limited(s limiterState, now time.Time) *RateLimitError {
+	if s.BackoffUntil != nil && s.BackoffUntil.After(now) {
+		return &RateLimitError{
+			Provider: l.Provider,
+			Until:    *s.BackoffUntil,
+			Reason:   "too many requests",
+		}
+	}
+
+	if l.Monthly > 0 && s.MonthRequests >= l.Monthly {
+		return &RateLimitError{
+			Provider: l.Provider,
+			Until:    time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location()),
+			Reason:   fmt.Sprintf("monthly quota of %d requests exceeded", l.Monthly),
+		}
+	}
+
+	if l.Daily > 0 && s.DayRequests >= l.Daily {
+		return &RateLimitError{
+			Provider: l.Provider,
+			Until:    time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()),
+			Reason:   fmt.Sprintf("daily quota of %d requests exceeded", l.Daily),
+		}
+	}
+
+	return nil
+}
+
+func (l *Limiter) warn(period string, used, quota int) {
+	var warning = l.Warning
+
+	if warning == 0 {
+		warning = DefaultWarning
+	}
+
+	if quota > 0 && used == int(math.Ceil(float64(quota)*warning)) {
+		log.Warnf("%s geolocation provider used %d of its %s quota of %d requests", l.Provider, used, period, quota)
+	}
+}
+
+// Backoff stops requests to the provider until the given time.
+func (l *Limiter) Backoff(ctx context.Context, until time.Time) error {
+	conn := db.Conn()
+
+	stmt, err := conn.PreparexContext(ctx, `INSERT INTO geolocation_quota
+	(provider, backoff_until) VALUES ($1, $2)
+	ON CONFLICT ON CONSTRAINT geolocation_quota_pkey
+	DO UPDATE SET backoff_until = GREATEST(geolocation_quota.backoff_until, $2)`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, l.Provider, until)
+	return err
+}
+
+func (l *Limiter) state(ctx context.Context) (s limiterState, err error) {
+	conn := db.Conn()
+
+	stmt, err := conn.PreparexContext(ctx, `SELECT
+	CASE WHEN day = CURRENT_DATE THEN day_requests ELSE 0 END AS day_requests,
+	CASE WHEN month = date_trunc('month', CURRENT_DATE)::date THEN month_requests ELSE 0 END AS month_requests,
+	backoff_until
+	FROM geolocation_quota WHERE provider = $1`)
+
+	if err != nil {
+		return s, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, l.Provider).Scan(&s.DayRequests, &s.MonthRequests, &s.BackoffUntil)
+
+	if err == sql.ErrNoRows {
+		return s, nil
+	}
+
+	return s, err
+}
+
+// count a request if the quota allows it and requests aren't paused, returning sql.ErrNoRows otherwise.
+func (l *Limiter) count(ctx context.Context) (s limiterState, err error) {
+	conn := db.Conn()
+
+	stmt, err := conn.PreparexContext(ctx, `INSERT INTO geolocation_quota
+	(provider, day, day_requests, month, month_requests)
+	VALUES ($1, CURRENT_DATE, 1, date_trunc('month', CURRENT_DATE)::date, 1)
+	ON CONFLICT ON CONSTRAINT geolocation_quota_pkey
+	DO UPDATE SET
+	day_requests = CASE WHEN geolocation_quota.day = CURRENT_DATE
+		THEN geolocation_quota.day_requests + 1 ELSE 1 END,
+	day = CURRENT_DATE,
+	month_requests = CASE WHEN geolocation_quota.month = date_trunc('month', CURRENT_DATE)::date
+		THEN geolocation_quota.month_requests + 1 ELSE 1 END,
+	month = date_trunc('month', CURRENT_DATE)::date
+	WHERE ($2 = 0 OR geolocation_quota.day <> CURRENT_DATE OR geolocation_quota.day_requests < $2)
+	AND ($3 = 0 OR geolocation_quota.month <> date_trunc('month', CURRENT_DATE)::date OR geolocation_quota.month_requests < $3)
+	AND (geolocation_quota.backoff_until IS NULL OR geolocation_quota.backoff_until <= now())
+	RETURNING day_requests, month_requests, backoff_until`)
+
+	if err != nil {
+		return s, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, l.Provider, l.Daily, l.Monthly).Scan(&s.DayRequests, &s.MonthRequests, &s.BackoffUntil)
+	return s, err
+}
+
+// parseRetryAfter parses the Retry-After HTTP header (in seconds or as a HTTP date).
+func parseRetryAfter(value string, now time.Time) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return time.Time{}, false
+		}
+
+		return now.Add(time.Duration(seconds) * time.Second), true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,108 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/db/dbtest"
+)
+
+func TestLimited(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+	var later = now.Add(time.Hour)
+	var l = &Limiter{Provider: "ipinfo", Daily: 10, Monthly: 100}
+
+	var cases = []struct {
+		s      limiterState
+		until  time.Time
+		reason string
+	}{
+		{limiterState{BackoffUntil: &later}, later, "too many requests"},
+		{limiterState{DayRequests: 10, MonthRequests: 50}, time.Date(2018, 10, 21, 0, 0, 0, 0, time.UTC), "daily quota of 10 requests exceeded"},
+		{limiterState{DayRequests: 10, MonthRequests: 100}, time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC), "monthly quota of 100 requests exceeded"},
+	}
+
+	for _, c := range cases {
+		err := l.limited(c.s, now)
+
+		if err == nil || !err.Until.Equal(c.until) || err.Reason != c.reason || !Permanent(err) {
+			t.Errorf("Expected rate limit until %v (%v), got %+v instead", c.until, c.reason, err)
+		}
+	}
+}
+
+func TestLimitedNotExplained(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+	var earlier = now.Add(-time.Hour)
+
+	var cases = []struct {
+		l *Limiter
+		s limiterState
+	}{
+		{&Limiter{Provider: "ipinfo", Daily: 10, Monthly: 100}, limiterState{DayRequests: 5, MonthRequests: 50, BackoffUntil: &earlier}},
+		{&Limiter{Provider: "ipinfo", Monthly: 100}, limiterState{DayRequests: 10, MonthRequests: 50}},
+		{&Limiter{Provider: "ipinfo"}, limiterState{DayRequests: 10, MonthRequests: 100}},
+	}
+
+	for _, c := range cases {
+		if err := c.l.limited(c.s, now); err != nil {
+			t.Errorf("Expected no rate limit for %+v, got %+v instead", c.s, err)
+		}
+	}
+}
+
+func TestLimiterTakeConcurrently(t *testing.T) {
+	dbtest.Load(t)
+
+	var ctx = context.Background()
+	var l = &Limiter{
+		Provider: fmt.Sprintf("test-%d", time.Now().UnixNano()),
+		Daily:    10,
+		Monthly:  100,
+	}
+
+	defer func() {
+		_, _ = db.Conn().ExecContext(ctx, "DELETE FROM geolocation_quota WHERE provider = $1", l.Provider)
+	}()
+
+	var wg sync.WaitGroup
+	var errs = make(chan error, 50)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			errs <- l.Take(ctx)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	var taken int
+
+	for err := range errs {
+		switch err.(type) {
+		case nil:
+			taken++
+		case *RateLimitError:
+		default:
+			t.Errorf("Expected no error or rate limit error, got %v instead", err)
+		}
+	}
+
+	if taken != l.Daily {
+		t.Errorf("Expected %d requests to be taken, got %d instead", l.Daily, taken)
+	}
+
+	s, err := l.state(ctx)
+
+	if err != nil || s.DayRequests != l.Daily {
+		t.Errorf("Expected %d requests counted, got %d (error: %v) instead", l.Daily, s.DayRequests, err)
+	}
+}
@@ -0,0 +1,201 @@
+package geolocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/henvic/climetrics/geolocation/mmdb"
+)
+
+// MMDB provider uses local MaxMind databases (GeoLite2 or GeoIP2),
+// such as the City and ASN databases.
+type MMDB struct {
+	readers []*mmdb.Reader
+}
+
+// OpenMMDB opens MaxMind database files to use as a provider.
+func OpenMMDB(filenames ...string) (*MMDB, error) {
+	var m = &MMDB{}
+
+	for _, f := range filenames {
+		r, err := mmdb.Open(f)
+
+		if err != nil {
+			return nil, fmt.Errorf("can't open MaxMind database %s: %v", f, err)
+		}
+
+		m.readers = append(m.readers, r)
+	}
+
+	return m, nil
+}
+
+// Name of the provider.
+func (m *MMDB) Name() string {
+	return "mmdb"
+}
+
+// Fetch geolocation information from the databases, merging the results.
+func (m *MMDB) Fetch(ctx context.Context, ip string) ([]byte, error) {
+	var addr = net.ParseIP(ip)
+
+	if addr == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", ip)
+	}
+
+	var l = mmdbLocation{
+		IP: ip,
+	}
+
+	if isBogon(addr) {
+		l.Bogon = true
+		return json.Marshal(l)
+	}
+
+	var found bool
+
+	for _, r := range m.readers {
+		v, err := r.Lookup(addr)
+
+		if err == mmdb.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if record, ok := v.(map[string]interface{}); ok {
+			l.merge(record)
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, ErrNotFound
+	}
+
+	return json.Marshal(l)
+}
+
+// mmdbLocation has the same fields as ipinfo.io responses.
+type mmdbLocation struct {
+	IP           string `json:"ip"`
+	City         string `json:"city,omitempty"`
+	Region       string `json:"region,omitempty"`
+	Country      string `json:"country,omitempty"`
+	Coordinates  string `json:"loc,omitempty"`
+	Organization string `json:"org,omitempty"`
+	Postal       string `json:"postal,omitempty"`
+	Timezone     string `json:"timezone,omitempty"`
+	Bogon        bool   `json:"bogon,omitempty"`
+}
+
+func (l *mmdbLocation) merge(record map[string]interface{}) {
+	if city := englishName(record["city"]); city != "" {
+		l.City = city
+	}
+
+	if subdivisions, ok := record["subdivisions"].([]interface{}); ok && len(subdivisions) != 0 {
+		l.Region = englishName(subdivisions[0])
+	}
+
+	if country, ok := record["country"].(map[string]interface{}); ok {
+		if code, ok := country["iso_code"].(string); ok {
+			l.Country = code
+		}
+	}
+
+	if location, ok := record["location"].(map[string]interface{}); ok {
+		lat, latok := location["latitude"].(float64)
+		lon, lonok := location["longitude"].(float64)
+
+		if latok && lonok {
+			l.Coordinates = fmt.Sprintf("%.4f,%.4f", lat, lon)
+		}
+
+		if tz, ok := location["time_zone"].(string); ok {
+			l.Timezone = tz
+		}
+	}
+
+	if postal, ok := record["postal"].(map[string]interface{}); ok {
+		if code, ok := postal["code"].(string); ok {
+			l.Postal = code
+		}
+	}
+
+	// ASN databases
+	asn, asnok := record["autonomous_system_number"].(uint64)
+	org, _ := record["autonomous_system_organization"].(string)
+
+	switch {
+	case asnok:
+		l.Organization = strings.TrimSpace(fmt.Sprintf("AS%d %s", asn, org))
+	case org != "":
+		l.Organization = org
+	}
+}
+
+func englishName(v interface{}) string {
+	m, ok := v.(map[string]interface{})
+
+	if !ok {
+		return ""
+	}
+
+	names, ok := m["names"].(map[string]interface{})
+
+	if !ok {
+		return ""
+	}
+
+	name, _ := names["en"].(string)
+	return name
+}
+
+var bogons []*net.IPNet
+
+func init() {
+	for _, cidr := range []string{
+		"0.0.0.0/8",
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"127.0.0.0/8",
+		"169.254.0.0/16",
+		"172.16.0.0/12",
+		"192.0.0.0/24",
+		"192.0.2.0/24",
+		"192.168.0.0/16",
+		"198.18.0.0/15",
+		"198.51.100.0/24",
+		"203.0.113.0/24",
+		"224.0.0.0/3",
+		"::/128",
+		"::1/128",
+		"fc00::/7",
+		"fe80::/10",
+		"2001:db8::/32",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			panic(err)
+		}
+
+		bogons = append(bogons, n)
+	}
+}
+
+func isBogon(ip net.IP) bool {
+	for _, n := range bogons {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+package mmdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+type dataType int
+
+const (
+	typeExtended dataType = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+var errOutOfRange = errors.New("invalid MaxMind DB: unexpected end of data")
+
+type decoder struct {
+	buffer []byte
+}
+
+// decode value at offset, returning it and the offset of the next value.
+func (d decoder) decode(offset uint) (interface{}, uint, error) {
+	t, size, offset, err := d.control(offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if t == typePointer {
+		var pointer uint
+		pointer, offset, err = d.pointer(size, offset)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		v, _, err := d.decode(pointer)
+		return v, offset, err
+	}
+
+	return d.value(t, size, offset)
+}
+
+func (d decoder) control(offset uint) (t dataType, size uint, next uint, err error) {
+	if offset >= uint(len(d.buffer)) {
+		return 0, 0, 0, errOutOfRange
+	}
+
+	var ctrl = d.buffer[offset]
+	offset++
+
+	t = dataType(ctrl >> 5)
+
+	if t == typeExtended {
+		if offset >= uint(len(d.buffer)) {
+			return 0, 0, 0, errOutOfRange
+		}
+
+		t = dataType(d.buffer[offset] + 7)
+		offset++
+	}
+
+	if t == typePointer {
+		// pointers use the size bits of the control byte differently.
+		return t, uint(ctrl & 0x1f), offset, nil
+	}
+
+	size = uint(ctrl & 0x1f)
+
+	if size < 29 {
+		return t, size, offset, nil
+	}
+
+	var extra = size - 28
+
+	if offset+extra > uint(len(d.buffer)) {
+		return 0, 0, 0, errOutOfRange
+	}
+
+	var n = uint(uint64FromBytes(d.buffer[offset : offset+extra]))
+	offset += extra
+
+	switch extra {
+	case 1:
+		size = 29 + n
+	case 2:
+		size = 285 + n
+	default:
+		size = 65821 + n
+	}
+
+	return t, size, offset, nil
+}
+
+func (d decoder) pointer(size, offset uint) (uint, uint, error) {
+	var n = (size >> 3) + 1
+
+	if offset+n > uint(len(d.buffer)) {
+		return 0, 0, errOutOfRange
+	}
+
+	var b = d.buffer[offset : offset+n]
+	var prefix = size & 0x7
+
+	switch n {
+	case 1:
+		return prefix<<8 | uint(uint64FromBytes(b)), offset + n, nil
+	case 2:
+		return (prefix<<16 | uint(uint64FromBytes(b))) + 2048, offset + n, nil
+	case 3:
+		return (prefix<<24 | uint(uint64FromBytes(b))) + 526336, offset + n, nil
+	default:
+		return uint(uint64FromBytes(b)), offset + n, nil
+	}
+}
+
+func (d decoder) value(t dataType, size, offset uint) (interface{}, uint, error) {
+	switch t {
+	case typeMap:
+		return d.decodeMap(size, offset)
+	case typeArray:
+		return d.decodeArray(size, offset)
+	case typeBool:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(d.buffer)) {
+		return nil, 0, errOutOfRange
+	}
+
+	var b = d.buffer[offset : offset+size]
+	var next = offset + size
+
+	switch t {
+	case typeString:
+		return string(b), next, nil
+	case typeBytes:
+		return append([]byte{}, b...), next, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid MaxMind DB: double of size %d", size)
+		}
+
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid MaxMind DB: float of size %d", size)
+		}
+
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("invalid MaxMind DB: unsigned integer of size %d", size)
+		}
+
+		return uint64FromBytes(b), next, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("invalid MaxMind DB: int32 of size %d", size)
+		}
+
+		var n = uint32(uint64FromBytes(b))
+		return int64(int32(n)), next, nil
+	case typeUint128:
+		return new(big.Int).SetBytes(b), next, nil
+	}
+
+	return nil, 0, fmt.Errorf("invalid MaxMind DB: unexpected data type %d", t)
+}
+
+func (d decoder) decodeMap(size, offset uint) (interface{}, uint, error) {
+	var m = make(map[string]interface{}, size)
+
+	for i := uint(0); i < size; i++ {
+		k, next, err := d.decode(offset)
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		key, ok := k.(string)
+
+		if !ok {
+			return nil, 0, errors.New("invalid MaxMind DB: map key is not a string")
+		}
+
+		m[key], offset, err = d.decode(next)
+
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return m, offset, nil
+}
+
+func (d decoder) decodeArray(size, offset uint) (interface{}, uint, error) {
+	var a = make([]interface{}, size)
+
+	for i := range a {
+		var err error
+		a[i], offset, err = d.decode(offset)
+
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return a, offset, nil
+}
+
+func uint64FromBytes(b []byte) uint64 {
+	var n uint64
+
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+
+	return n
+}
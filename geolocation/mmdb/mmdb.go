@@ -0,0 +1,201 @@
+// Package mmdb reads MaxMind DB files (such as GeoLite2 and GeoIP2 databases).
+//
+// See https://maxmind.github.io/MaxMind-DB/ for the specification.
+package mmdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+)
+
+var metadataStartMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparatorSize is the size of the zeroed separator between the search tree and the data section.
+const dataSectionSeparatorSize = 16
+
+// ErrNotFound is returned when the IP address is not on the database.
+var ErrNotFound = errors.New("IP address not found on database")
+
+// Metadata of the database.
+type Metadata struct {
+	NodeCount    uint
+	RecordSize   uint
+	IPVersion    uint
+	DatabaseType string
+	Languages    []string
+	BuildEpoch   uint64
+	Description  map[string]string
+}
+
+// Reader for a MaxMind DB.
+type Reader struct {
+	Metadata Metadata
+
+	buffer    []byte
+	tree      []byte
+	data      decoder
+	ipv4Start uint
+}
+
+// Open database file.
+func Open(filename string) (*Reader, error) {
+	b, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return FromBytes(b)
+}
+
+// FromBytes creates a reader from the contents of a database.
+func FromBytes(b []byte) (*Reader, error) {
+	var start = bytes.LastIndex(b, metadataStartMarker)
+
+	if start == -1 {
+		return nil, errors.New("invalid MaxMind DB: metadata section not found")
+	}
+
+	start += len(metadataStartMarker)
+	md := decoder{buffer: b[start:]}
+	v, _, err := md.decode(0)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid MaxMind DB metadata: %v", err)
+	}
+
+	m, ok := v.(map[string]interface{})
+
+	if !ok {
+		return nil, errors.New("invalid MaxMind DB metadata: not a map")
+	}
+
+	r := &Reader{
+		buffer: b,
+		Metadata: Metadata{
+			NodeCount:    uint(toUint(m["node_count"])),
+			RecordSize:   uint(toUint(m["record_size"])),
+			IPVersion:    uint(toUint(m["ip_version"])),
+			DatabaseType: toString(m["database_type"]),
+			BuildEpoch:   toUint(m["build_epoch"]),
+			Description:  map[string]string{},
+		},
+	}
+
+	if langs, ok := m["languages"].([]interface{}); ok {
+		for _, l := range langs {
+			r.Metadata.Languages = append(r.Metadata.Languages, toString(l))
+		}
+	}
+
+	if desc, ok := m["description"].(map[string]interface{}); ok {
+		for k, v := range desc {
+			r.Metadata.Description[k] = toString(v)
+		}
+	}
+
+	switch r.Metadata.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("unsupported MaxMind DB record size: %d", r.Metadata.RecordSize)
+	}
+
+	var treeSize = r.Metadata.NodeCount * r.Metadata.RecordSize / 4
+
+	if treeSize+dataSectionSeparatorSize > uint(len(b)) {
+		return nil, errors.New("invalid MaxMind DB: search tree is larger than the file")
+	}
+
+	r.tree = b[:treeSize]
+	r.data = decoder{buffer: b[treeSize+dataSectionSeparatorSize : start-len(metadataStartMarker)]}
+
+	if r.Metadata.IPVersion == 6 {
+		var node uint
+
+		for i := 0; i < 96 && node < r.Metadata.NodeCount; i++ {
+			node = r.record(node, 0)
+		}
+
+		r.ipv4Start = node
+	}
+
+	return r, nil
+}
+
+// Lookup IP address on the database.
+// The returned value is usually a map[string]interface{} with the record.
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+	if ip == nil {
+		return nil, errors.New("invalid IP address")
+	}
+
+	var node uint
+	var bits = 128
+
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 32
+		node = r.ipv4Start
+	} else if r.Metadata.IPVersion == 4 {
+		return nil, fmt.Errorf("can't look up IPv6 address %v on an IPv4-only database", ip)
+	}
+
+	for i := 0; i < bits && node < r.Metadata.NodeCount; i++ {
+		bit := uint(ip[i>>3]>>(7-uint(i%8))) & 1
+		node = r.record(node, bit)
+	}
+
+	switch {
+	case node == r.Metadata.NodeCount:
+		return nil, ErrNotFound
+	case node < r.Metadata.NodeCount:
+		return nil, errors.New("invalid MaxMind DB: search tree is too deep")
+	}
+
+	var offset = node - r.Metadata.NodeCount - dataSectionSeparatorSize
+
+	if offset >= uint(len(r.data.buffer)) {
+		return nil, errors.New("invalid MaxMind DB: pointer to data is out of range")
+	}
+
+	v, _, err := r.data.decode(offset)
+	return v, err
+}
+
+func (r *Reader) record(node, bit uint) uint {
+	switch r.Metadata.RecordSize {
+	case 24:
+		b := r.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := r.tree[node*7:]
+
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		b := r.tree[node*8+bit*4:]
+		return uint(b[0])<<24 | uint(b[1])<<16 | uint(b[2])<<8 | uint(b[3])
+	}
+}
+
+func toUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		return uint64(n)
+	}
+
+	return 0
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
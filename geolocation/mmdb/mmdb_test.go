@@ -0,0 +1,151 @@
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func encodeControl(t dataType, size int) []byte {
+	if t > typeMap {
+		return []byte{byte(size), byte(t - 7)}
+	}
+
+	return []byte{byte(t)<<5 | byte(size)}
+}
+
+func encodeString(s string) []byte {
+	return append(encodeControl(typeString, len(s)), s...)
+}
+
+func encodeUint16(n uint16) []byte {
+	return append(encodeControl(typeUint16, 2), byte(n>>8), byte(n))
+}
+
+func encodeUint32(n uint32) []byte {
+	var b = make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return append(encodeControl(typeUint32, 4), b...)
+}
+
+func encodeDouble(f float64) []byte {
+	var b = make([]byte, 8)
+	binary.BigEndian.PutUint64(b, math.Float64bits(f))
+	return append(encodeControl(typeDouble, 8), b...)
+}
+
+func encodeMap(pairs ...[]byte) []byte {
+	var b = encodeControl(typeMap, len(pairs)/2)
+
+	for _, p := range pairs {
+		b = append(b, p...)
+	}
+
+	return b
+}
+
+func encodeArray(items ...[]byte) []byte {
+	var b = encodeControl(typeArray, len(items))
+
+	for _, i := range items {
+		b = append(b, i...)
+	}
+
+	return b
+}
+
+// database builds an IPv4 database with a single node:
+// 0.0.0.0/1 maps to the given record and 128.0.0.0/1 is empty.
+func database(record []byte) []byte {
+	const nodeCount = 1
+	var dataPointer = nodeCount + dataSectionSeparatorSize
+
+	var b bytes.Buffer
+	b.Write([]byte{0, 0, byte(dataPointer), 0, 0, nodeCount})
+	b.Write(make([]byte, dataSectionSeparatorSize))
+	b.Write(record)
+	b.Write(metadataStartMarker)
+	b.Write(encodeMap(
+		encodeString("node_count"), encodeUint32(nodeCount),
+		encodeString("record_size"), encodeUint16(24),
+		encodeString("ip_version"), encodeUint16(4),
+		encodeString("database_type"), encodeString("Test-City"),
+		encodeString("languages"), encodeArray(encodeString("en")),
+	))
+
+	return b.Bytes()
+}
+
+func TestLookup(t *testing.T) {
+	var record = encodeMap(
+		encodeString("city"), encodeMap(
+			encodeString("names"), encodeMap(encodeString("en"), encodeString("Recife")),
+		),
+		encodeString("location"), encodeMap(
+			encodeString("latitude"), encodeDouble(-8.05),
+			encodeString("longitude"), encodeDouble(-34.9),
+		),
+		// pointer to the "city" key, at the beginning of the data section
+		encodeString("alias"), []byte{byte(typePointer) << 5, 1},
+	)
+
+	r, err := FromBytes(database(record))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if r.Metadata.DatabaseType != "Test-City" || r.Metadata.NodeCount != 1 || r.Metadata.IPVersion != 4 {
+		t.Errorf("Unexpected metadata: %+v", r.Metadata)
+	}
+
+	if !reflect.DeepEqual(r.Metadata.Languages, []string{"en"}) {
+		t.Errorf("Expected languages to be [en], got %v instead", r.Metadata.Languages)
+	}
+
+	v, err := r.Lookup(net.ParseIP("1.2.3.4"))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = map[string]interface{}{
+		"city": map[string]interface{}{
+			"names": map[string]interface{}{"en": "Recife"},
+		},
+		"location": map[string]interface{}{
+			"latitude":  -8.05,
+			"longitude": -34.9,
+		},
+		"alias": "city",
+	}
+
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Expected record %+v, got %+v instead", want, v)
+	}
+}
+
+func TestLookupNotFound(t *testing.T) {
+	r, err := FromBytes(database(encodeString("x")))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if _, err := r.Lookup(net.ParseIP("200.1.1.1")); err != ErrNotFound {
+		t.Errorf("Expected error to be %v, got %v instead", ErrNotFound, err)
+	}
+
+	if _, err := r.Lookup(net.ParseIP("2001:db8::1")); err == nil {
+		t.Error("Expected error looking up IPv6 address on IPv4 database")
+	}
+}
+
+func TestFromBytesInvalid(t *testing.T) {
+	if _, err := FromBytes([]byte("not a database")); err == nil {
+		t.Error("Expected error for invalid database")
+	}
+}
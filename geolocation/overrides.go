@@ -0,0 +1,245 @@
+package geolocation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/kisielk/sqlstruct"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Override of the geolocation information of a network,
+// used to correct the information of providers (i.e., for corporate egress IPs).
+type Override struct {
+	ID           string    `db:"id"`
+	Network      string    `db:"network"`
+	Organization string    `db:"organization"`
+	City         string    `db:"city"`
+	Region       string    `db:"region"`
+	Country      string    `db:"country"`
+	Coordinates  string    `db:"coordinates"`
+	Note         string    `db:"note"`
+	Created      time.Time `db:"created"`
+}
+
+// Data returns the geolocation information for an IP on the format of the cache.
+func (o Override) Data(ip string) ([]byte, error) {
+	var v = map[string]interface{}{
+		"ip":       ip,
+		"provider": "override",
+		"network":  o.Network,
+		"cached":   time.Now().Format(time.RFC3339),
+	}
+
+	var fields = map[string]string{
+		"org":     o.Organization,
+		"city":    o.City,
+		"region":  o.Region,
+		"country": o.Country,
+		"loc":     o.Coordinates,
+	}
+
+	for k, f := range fields {
+		if f != "" {
+			v[k] = f
+		}
+	}
+
+	return json.Marshal(v)
+}
+
+// Validate override.
+func (o *Override) Validate() error {
+	_, n, err := net.ParseCIDR(strings.TrimSpace(o.Network))
+
+	if err != nil {
+		ip := net.ParseIP(strings.TrimSpace(o.Network))
+
+		if ip == nil {
+			return fmt.Errorf("invalid network %q: use the CIDR notation (i.e., 10.20.0.0/16)", o.Network)
+		}
+
+		var bits = 128
+
+		if ip.To4() != nil {
+			bits = 32
+		}
+
+		n = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+
+	o.Network = n.String()
+	o.Country = strings.ToUpper(strings.TrimSpace(o.Country))
+
+	if o.Country != "" && len(o.Country) != 2 {
+		return errors.New("country must be a ISO 3166-1 alpha-2 code")
+	}
+
+	if o.Organization == "" && o.City == "" && o.Region == "" && o.Country == "" && o.Coordinates == "" {
+		return errors.New("override has no geolocation information")
+	}
+
+	return nil
+}
+
+const overrideColumns = `id, network, organization, city, region, country, coordinates, note, created`
+
+// Overrides lists the geolocation overrides.
+func Overrides(ctx context.Context) (list []Override, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`SELECT `+overrideColumns+` FROM geolocation_overrides ORDER BY network`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var o Override
+		err = sqlstruct.Scan(&o, rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, o)
+	}
+
+	return list, nil
+}
+
+// GetOverride by ID. Invalid IDs aren't found.
+func GetOverride(ctx context.Context, id string) (o Override, err error) {
+	if _, err = uuid.FromString(id); err != nil {
+		return o, sql.ErrNoRows
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`SELECT `+overrideColumns+` FROM geolocation_overrides WHERE id = $1`)
+
+	if err != nil {
+		return o, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, id).StructScan(&o)
+	return o, err
+}
+
+// MatchOverride returns the most specific override for an IP.
+func MatchOverride(ctx context.Context, ip string) (o Override, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx,
+		`SELECT `+overrideColumns+` FROM geolocation_overrides
+		WHERE network >>= $1::inet ORDER BY masklen(network) DESC LIMIT 1`)
+
+	if err != nil {
+		return o, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx, ip).StructScan(&o)
+	return o, err
+}
+
+// CreateOverride for a network. The cached information for IPs on the network is invalidated.
+func CreateOverride(ctx context.Context, o Override) (Override, error) {
+	if err := o.Validate(); err != nil {
+		return o, err
+	}
+
+	o.ID = uuid.NewV4().String()
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `INSERT INTO geolocation_overrides
+	(id, network, organization, city, region, country, coordinates, note)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING created`)
+
+	if err != nil {
+		return o, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowxContext(ctx,
+		o.ID, o.Network, o.Organization, o.City, o.Region, o.Country, o.Coordinates, o.Note,
+	).Scan(&o.Created)
+
+	if err != nil {
+		return o, err
+	}
+
+	_, err = Invalidate(ctx, o.Network)
+	return o, err
+}
+
+// DeleteOverride by ID. The cached information for IPs on the network is invalidated.
+func DeleteOverride(ctx context.Context, id string) (o Override, err error) {
+	if o, err = GetOverride(ctx, id); err != nil {
+		return o, err
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `DELETE FROM geolocation_overrides WHERE id = $1`)
+
+	if err != nil {
+		return o, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	if _, err = stmt.ExecContext(ctx, id); err != nil {
+		return o, err
+	}
+
+	_, err = Invalidate(ctx, o.Network)
+	return o, err
+}
+
+// override returns the geolocation information for an IP from an override, if any.
+func override(ctx context.Context, ip string) (data []byte, ok bool, err error) {
+	o, err := MatchOverride(ctx, ip)
+
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if data, err = o.Data(ip); err != nil {
+		return nil, false, err
+	}
+
+	_, err = upsert(ctx, ip, json.RawMessage(data))
+	return data, true, err
+}
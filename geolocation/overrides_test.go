@@ -0,0 +1,95 @@
+package geolocation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+)
+
+func TestOverrideValidate(t *testing.T) {
+	var cases = []struct {
+		in      Override
+		network string
+		country string
+		err     bool
+	}{
+		{Override{Network: "10.20.30.0/16", Organization: "Office"}, "10.20.0.0/16", "", false},
+		{Override{Network: " 192.168.1.10 ", Country: "br"}, "192.168.1.10/32", "BR", false},
+		{Override{Network: "2001:db8::1", City: "Recife"}, "2001:db8::1/128", "", false},
+		{Override{Network: "not a network", City: "Recife"}, "", "", true},
+		{Override{Network: "10.0.0.0/8", Country: "BRA"}, "", "", true},
+		{Override{Network: "10.0.0.0/8", Note: "no data"}, "", "", true},
+	}
+
+	for _, c := range cases {
+		var o = c.in
+		err := o.Validate()
+
+		if (err != nil) != c.err {
+			t.Errorf("Expected error for %q to be %v, got %v instead", c.in.Network, c.err, err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if o.Network != c.network || o.Country != c.country {
+			t.Errorf("Expected %q to be normalized to %s (country %q), got %s (country %q) instead",
+				c.in.Network, c.network, c.country, o.Network, o.Country)
+		}
+	}
+}
+
+func TestOverrideData(t *testing.T) {
+	var o = Override{
+		Network:      "10.0.0.0/8",
+		Organization: "Office",
+		Country:      "BR",
+	}
+
+	b, err := o.Data("10.1.2.3")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var v map[string]interface{}
+
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = map[string]string{
+		"ip":       "10.1.2.3",
+		"provider": "override",
+		"network":  "10.0.0.0/8",
+		"org":      "Office",
+		"country":  "BR",
+	}
+
+	for k, w := range want {
+		if v[k] != w {
+			t.Errorf("Expected %s to be %q, got %v instead", k, w, v[k])
+		}
+	}
+
+	if _, ok := v["city"]; ok {
+		t.Errorf("Expected empty city to be omitted, got %v instead", v["city"])
+	}
+}
+
+func TestGetOverrideInvalidID(t *testing.T) {
+	for _, id := range []string{"", "1", "not-a-uuid"} {
+		if _, err := GetOverride(context.Background(), id); err != sql.ErrNoRows {
+			t.Errorf("Expected override %q to be not found, got %v instead", id, err)
+		}
+	}
+}
+
+func TestTTL(t *testing.T) {
+	if ttl != "172800 seconds" {
+		t.Errorf("Expected TTL interval of 172800 seconds, got %v instead", ttl)
+	}
+}
@@ -0,0 +1,341 @@
+// Package keyring manages the secret keys signing the session and CSRF cookies.
+//
+// Each purpose can have multiple keys: the newest one signs, and all of them verify,
+// so keys can be rotated without invalidating the cookies signed with the previous ones.
+//
+// A keyring file has one key per line, as "<purpose> <created (RFC 3339)> <key (base64)>".
+// Empty lines and lines starting with # are ignored.
+package keyring
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Purpose of a key.
+type Purpose string
+
+const (
+	// Session keys sign the session cookies.
+	Session Purpose = "session"
+
+	// CSRF keys sign the cookies with the CSRF tokens.
+	CSRF Purpose = "csrf"
+)
+
+// Purposes of the keys on a keyring.
+var Purposes = []Purpose{Session, CSRF}
+
+// Valid tells if the purpose exists.
+func (p Purpose) Valid() bool {
+	for _, purpose := range Purposes {
+		if p == purpose {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EnvVar with the keys for the purpose, which takes precedence over the keyring file.
+// Its value is a comma-separated list of base64 keys, from the newest to the oldest.
+func (p Purpose) EnvVar() string {
+	return strings.ToUpper(string(p)) + "_KEYS"
+}
+
+// KeyLength of generated keys, in bytes.
+const KeyLength = 32
+
+// MinKeyLength of keys, in bytes.
+const MinKeyLength = 32
+
+// DefaultRetire is how long keys are kept after being replaced.
+// It matches how long session cookies last, so rotating keys doesn't log anybody out.
+const DefaultRetire = 30 * 24 * time.Hour
+
+// Key for signing cookies.
+type Key struct {
+	Purpose Purpose
+	Created time.Time
+	Secret  []byte
+}
+
+// Fingerprint identifies the key without revealing it.
+func (k Key) Fingerprint() string {
+	var h = sha256.Sum256(k.Secret)
+	return hex.EncodeToString(h[:4])
+}
+
+// Generate key for the purpose.
+func Generate(p Purpose, now time.Time) (Key, error) {
+	var k = Key{
+		Purpose: p,
+		Created: now.UTC().Truncate(time.Second),
+		Secret:  make([]byte, KeyLength),
+	}
+
+	_, err := rand.Read(k.Secret)
+	return k, err
+}
+
+// Keyring of keys.
+type Keyring []Key
+
+// Keys for the purpose, from the newest to the oldest.
+func (kr Keyring) Keys(p Purpose) [][]byte {
+	var keys = kr.Entries(p)
+	var secrets = make([][]byte, len(keys))
+
+	for i, k := range keys {
+		secrets[i] = k.Secret
+	}
+
+	return secrets
+}
+
+// Entries for the purpose, from the newest to the oldest.
+func (kr Keyring) Entries(p Purpose) []Key {
+	var keys []Key
+
+	for _, k := range kr {
+		if k.Purpose == p {
+			keys = append(keys, k)
+		}
+	}
+
+	// stable, so keys from the environment (all created at the same time) keep their order
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Created.After(keys[j].Created)
+	})
+
+	return keys
+}
+
+// Validate keyring. Every purpose must have at least one key.
+func (kr Keyring) Validate() error {
+	for _, k := range kr {
+		if !k.Purpose.Valid() {
+			return fmt.Errorf(`invalid key purpose "%s"`, k.Purpose)
+		}
+
+		if len(k.Secret) < MinKeyLength {
+			return fmt.Errorf("%s key %s is too short: keys must have at least %d bytes", k.Purpose, k.Fingerprint(), MinKeyLength)
+		}
+	}
+
+	for _, p := range Purposes {
+		if len(kr.Entries(p)) == 0 {
+			return fmt.Errorf("missing %s key", p)
+		}
+	}
+
+	return nil
+}
+
+// Missing purposes without keys.
+func (kr Keyring) Missing() []Purpose {
+	var missing []Purpose
+
+	for _, p := range Purposes {
+		if len(kr.Entries(p)) == 0 {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing
+}
+
+// Replace the keys for the purpose.
+func (kr Keyring) Replace(p Purpose, keys []Key) Keyring {
+	var r Keyring
+
+	for _, k := range kr {
+		if k.Purpose != p {
+			r = append(r, k)
+		}
+	}
+
+	return append(r, keys...)
+}
+
+// Rotate keys: a new key is added for every purpose, and keys replaced more than retire ago are removed.
+func (kr Keyring) Rotate(now time.Time, retire time.Duration) (Keyring, error) {
+	var r Keyring
+
+	for _, p := range Purposes {
+		k, err := Generate(p, now)
+
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, k)
+
+		// a key was replaced when its successor was created
+		var replaced = k.Created
+
+		for _, old := range kr.Entries(p) {
+			if now.Sub(replaced) > retire {
+				break
+			}
+
+			r = append(r, old)
+			replaced = old.Created
+		}
+	}
+
+	return r, nil
+}
+
+// ParseEnv parses a comma-separated list of base64 keys for the purpose, from the newest to the oldest.
+func ParseEnv(p Purpose, value string) ([]Key, error) {
+	var keys []Key
+
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(v)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s key on %s: %v", p, p.EnvVar(), err)
+		}
+
+		keys = append(keys, Key{
+			Purpose: p,
+			Secret:  secret,
+		})
+	}
+
+	return keys, nil
+}
+
+// Parse keyring file.
+func Parse(r io.Reader) (Keyring, error) {
+	var kr Keyring
+	var scanner = bufio.NewScanner(r)
+	var n int
+
+	for scanner.Scan() {
+		n++
+		var line = strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields = strings.Fields(line)
+
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: expected purpose, creation time, and key", n)
+		}
+
+		var k = Key{
+			Purpose: Purpose(fields[0]),
+		}
+
+		if !k.Purpose.Valid() {
+			return nil, fmt.Errorf(`line %d: invalid key purpose "%s"`, n, k.Purpose)
+		}
+
+		var err error
+
+		if k.Created, err = time.Parse(time.RFC3339, fields[1]); err != nil {
+			return nil, fmt.Errorf("line %d: invalid creation time: %v", n, err)
+		}
+
+		if k.Secret, err = base64.StdEncoding.DecodeString(fields[2]); err != nil {
+			return nil, fmt.Errorf("line %d: invalid key: %v", n, err)
+		}
+
+		kr = append(kr, k)
+	}
+
+	return kr, scanner.Err()
+}
+
+// Write keyring file.
+func (kr Keyring) Write(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString("# climetrics keyring: the newest key of each purpose signs, and all of them verify.\n")
+	b.WriteString("# Keep this file secret. Rotate keys with: keyring rotate\n")
+
+	for _, p := range Purposes {
+		for _, k := range kr.Entries(p) {
+			fmt.Fprintf(&b, "%s %s %s\n", k.Purpose, k.Created.UTC().Format(time.RFC3339), base64.StdEncoding.EncodeToString(k.Secret))
+		}
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// Load keyring file. A missing file is an empty keyring.
+func Load(filename string) (Keyring, error) {
+	f, err := os.Open(filename)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	kr, err := Parse(f)
+
+	if err != nil {
+		return nil, fmt.Errorf("can't parse keyring %s: %v", filename, err)
+	}
+
+	return kr, nil
+}
+
+// Save keyring file, replacing it atomically.
+func (kr Keyring) Save(filename string) error {
+	if len(kr) == 0 {
+		return errors.New("refusing to save an empty keyring")
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if err = tmp.Chmod(0600); err == nil {
+		err = kr.Write(tmp)
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
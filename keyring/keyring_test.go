@@ -0,0 +1,201 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+func key(t *testing.T, p Purpose, created time.Time) Key {
+	k, err := Generate(p, created)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestParseWrite(t *testing.T) {
+	var kr = Keyring{
+		key(t, CSRF, now.Add(-time.Hour)),
+		key(t, Session, now.Add(-time.Hour)),
+		key(t, Session, now),
+	}
+
+	var b bytes.Buffer
+
+	if err := kr.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(&b)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 keys, got %d instead", len(got))
+	}
+
+	var sessions = got.Keys(Session)
+
+	if len(sessions) != 2 || !bytes.Equal(sessions[0], kr[2].Secret) || !bytes.Equal(sessions[1], kr[1].Secret) {
+		t.Errorf("Expected session keys from the newest to the oldest")
+	}
+
+	if err = got.Validate(); err != nil {
+		t.Errorf("Expected keyring to be valid, got %v instead", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	var cases = []string{
+		"session 2018-10-20T03:00:00Z",
+		"cookie 2018-10-20T03:00:00Z YWJj",
+		"session yesterday YWJj",
+		"session 2018-10-20T03:00:00Z not-base64!",
+	}
+
+	for _, c := range cases {
+		if _, err := Parse(strings.NewReader("# comment\n\n" + c + "\n")); err == nil {
+			t.Errorf("Expected %q to be invalid", c)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := (Keyring{key(t, Session, now)}).Validate(); err == nil {
+		t.Error("Expected keyring without CSRF key to be invalid")
+	}
+
+	var short = Keyring{key(t, Session, now), {Purpose: CSRF, Secret: []byte("short")}}
+
+	if err := short.Validate(); err == nil {
+		t.Error("Expected keyring with short key to be invalid")
+	}
+
+	if missing := (Keyring{key(t, CSRF, now)}).Missing(); len(missing) != 1 || missing[0] != Session {
+		t.Errorf("Expected session keys to be missing, got %v instead", missing)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	var kr = Keyring{
+		key(t, Session, now.Add(-90*24*time.Hour)),
+		key(t, Session, now.Add(-60*24*time.Hour)),
+		key(t, Session, now.Add(-10*24*time.Hour)),
+		key(t, CSRF, now.Add(-10*24*time.Hour)),
+	}
+
+	rotated, err := kr.Rotate(now, 30*24*time.Hour)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sessions = rotated.Entries(Session)
+
+	// the key created 60 days ago was replaced 10 days ago, and the oldest one 60 days ago
+	if len(sessions) != 3 {
+		t.Fatalf("Expected 3 session keys, got %d instead", len(sessions))
+	}
+
+	if !sessions[0].Created.Equal(now) || !sessions[1].Created.Equal(kr[2].Created) || !sessions[2].Created.Equal(kr[1].Created) {
+		t.Errorf("Unexpected session keys after rotation: %+v", sessions)
+	}
+
+	if csrf := rotated.Entries(CSRF); len(csrf) != 2 {
+		t.Errorf("Expected 2 CSRF keys, got %d instead", len(csrf))
+	}
+
+	if err = rotated.Validate(); err != nil {
+		t.Errorf("Expected rotated keyring to be valid, got %v instead", err)
+	}
+}
+
+func TestRotateEmpty(t *testing.T) {
+	kr, err := Keyring(nil).Rotate(now, DefaultRetire)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = kr.Validate(); err != nil {
+		t.Errorf("Expected new keyring to be valid, got %v instead", err)
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	var a, b = key(t, CSRF, now), key(t, CSRF, now)
+	var value = base64.StdEncoding.EncodeToString(a.Secret) + ", " + base64.StdEncoding.EncodeToString(b.Secret)
+
+	keys, err := ParseEnv(CSRF, value)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var kr = Keyring{key(t, CSRF, now.Add(-time.Hour)), key(t, Session, now)}.Replace(CSRF, keys)
+	var csrf = kr.Keys(CSRF)
+
+	if len(csrf) != 2 || !bytes.Equal(csrf[0], a.Secret) || !bytes.Equal(csrf[1], b.Secret) {
+		t.Errorf("Expected CSRF keys from the environment, in order")
+	}
+
+	if _, err = ParseEnv(CSRF, "not-base64!"); err == nil {
+		t.Error("Expected invalid key to fail")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	var filename = filepath.Join(dir, "keyring")
+
+	if kr, err := Load(filename); err != nil || len(kr) != 0 {
+		t.Fatalf("Expected missing keyring to be empty, got %v (%v) instead", kr, err)
+	}
+
+	kr, err := Keyring(nil).Rotate(now, DefaultRetire)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = kr.Save(filename); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	fi, err := os.Stat(filename)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi.Mode().Perm() != 0600 {
+		t.Errorf("Expected keyring to be only readable by its owner, got %v instead", fi.Mode())
+	}
+
+	loaded, err := Load(filename)
+
+	if err != nil || len(loaded) != len(kr) {
+		t.Errorf("Expected saved keyring to load, got %v (%v) instead", loaded, err)
+	}
+}
@@ -0,0 +1,246 @@
+// Package mailer sends emails, such as invitations and password resets.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Message to send. The body is plain text.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends messages.
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Options of the mailer.
+type Options struct {
+	// Mailer to use (smtp, file, log).
+	Mailer string
+
+	// From address of the messages.
+	From string
+
+	// SMTP server address (host:port) and credentials, for the smtp mailer.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// Dir to write messages to, for the file mailer.
+	Dir string
+}
+
+// Parse options into a mailer.
+func Parse(o Options) (Mailer, error) {
+	if _, err := mail.ParseAddress(o.From); err != nil {
+		return nil, errwrap.Wrapf("invalid email sender address: {{err}}", err)
+	}
+
+	switch o.Mailer {
+	case "smtp":
+		if _, _, err := net.SplitHostPort(o.SMTPAddr); err != nil {
+			return nil, errwrap.Wrapf("invalid SMTP server address: {{err}}", err)
+		}
+
+		return &SMTP{
+			Addr:     o.SMTPAddr,
+			Username: o.SMTPUsername,
+			Password: o.SMTPPassword,
+			From:     o.From,
+		}, nil
+	case "file":
+		if o.Dir == "" {
+			return nil, errors.New("missing directory for the file mailer")
+		}
+
+		return &File{
+			Dir:  o.Dir,
+			From: o.From,
+		}, nil
+	case "log":
+		return &Log{
+			From: o.From,
+		}, nil
+	default:
+		return nil, fmt.Errorf(`unknown mailer "%s"`, o.Mailer)
+	}
+}
+
+var (
+	current  Mailer = &Log{From: "climetrics@localhost"}
+	currentM sync.RWMutex
+)
+
+// Use mailer.
+func Use(m Mailer) {
+	currentM.Lock()
+	defer currentM.Unlock()
+	current = m
+}
+
+// Send message with the current mailer.
+func Send(ctx context.Context, m Message) error {
+	currentM.RLock()
+	var c = current
+	currentM.RUnlock()
+	return c.Send(ctx, m)
+}
+
+// Render message body from a text template file.
+func Render(filename string, data interface{}) (string, error) {
+	t, err := template.ParseFiles(filename)
+
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	err = t.Execute(&b, data)
+	return b.String(), err
+}
+
+// Encode message in the Internet Message Format (RFC 5322).
+func Encode(from string, m Message, date time.Time) ([]byte, error) {
+	if _, err := mail.ParseAddress(m.To); err != nil {
+		return nil, errwrap.Wrapf("invalid email recipient address: {{err}}", err)
+	}
+
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, errors.New("invalid email subject")
+	}
+
+	var b bytes.Buffer
+
+	for _, h := range [][2]string{
+		{"From", from},
+		{"To", m.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@climetrics>", uuid.NewV4())},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	} {
+		fmt.Fprintf(&b, "%s: %s\r\n", h[0], h[1])
+	}
+
+	b.WriteString("\r\n")
+	b.WriteString(strings.Replace(strings.Replace(m.Body, "\r\n", "\n", -1), "\n", "\r\n", -1))
+	return b.Bytes(), nil
+}
+
+// SMTP mailer. The connection is upgraded with STARTTLS when the server supports it.
+type SMTP struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send message.
+func (s *SMTP) Send(ctx context.Context, m Message) error {
+	msg, err := Encode(s.From, m, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(s.From)
+
+	if err != nil {
+		return err
+	}
+
+	to, err := mail.ParseAddress(m.To)
+
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	var ec = make(chan error, 1)
+
+	go func() {
+		ec <- smtp.SendMail(s.Addr, auth, from.Address, []string{to.Address}, msg)
+	}()
+
+	select {
+	case err = <-ec:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		return errwrap.Wrapf("can't send email: {{err}}", err)
+	}
+
+	return nil
+}
+
+// File mailer writes each message to a file on a directory, for local testing.
+type File struct {
+	Dir  string
+	From string
+}
+
+// Send message.
+func (f *File) Send(ctx context.Context, m Message) error {
+	var now = time.Now()
+	msg, err := Encode(f.From, m, now)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+
+	var name = filepath.Join(f.Dir, fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000Z"), uuid.NewV4()))
+	return ioutil.WriteFile(name, msg, 0600)
+}
+
+// Log mailer logs messages, for local testing.
+type Log struct {
+	From string
+}
+
+// Send message.
+func (l *Log) Send(ctx context.Context, m Message) error {
+	msg, err := Encode(l.From, m, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	log.Infof("email message:\n%s", msg)
+	return nil
+}
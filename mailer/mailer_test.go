@@ -0,0 +1,228 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var date = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+func TestEncode(t *testing.T) {
+	msg, err := Encode("CLI metrics <climetrics@example.com>", Message{
+		To:      "alice@example.com",
+		Subject: "Olá",
+		Body:    "Hi\nthere\r\n",
+	}, date)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var got = string(msg)
+
+	for _, want := range []string{
+		"From: CLI metrics <climetrics@example.com>\r\n",
+		"To: alice@example.com\r\n",
+		"Subject: =?utf-8?q?Ol=C3=A1?=\r\n",
+		"Date: Sat, 20 Oct 2018 03:00:00 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nHi\r\nthere\r\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected message to contain %q, got %q instead", want, got)
+		}
+	}
+}
+
+func TestEncodeInvalid(t *testing.T) {
+	var cases = []Message{
+		{To: "not an address", Subject: "Hi"},
+		{To: "alice@example.com", Subject: "Hi\r\nBcc: mallory@example.com"},
+	}
+
+	for _, c := range cases {
+		if _, err := Encode("climetrics@example.com", c, date); err == nil {
+			t.Errorf("Expected message %+v to be invalid", c)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	var cases = []struct {
+		o     Options
+		valid bool
+	}{
+		{Options{Mailer: "log", From: "climetrics@example.com"}, true},
+		{Options{Mailer: "file", From: "climetrics@example.com", Dir: "mail"}, true},
+		{Options{Mailer: "file", From: "climetrics@example.com"}, false},
+		{Options{Mailer: "smtp", From: "climetrics@example.com", SMTPAddr: "localhost:25"}, true},
+		{Options{Mailer: "smtp", From: "climetrics@example.com", SMTPAddr: "localhost"}, false},
+		{Options{Mailer: "log", From: "invalid"}, false},
+		{Options{Mailer: "pigeon", From: "climetrics@example.com"}, false},
+	}
+
+	for _, c := range cases {
+		if _, err := Parse(c.o); (err == nil) != c.valid {
+			t.Errorf("Expected options %+v valid = %v, got error %v instead", c.o, c.valid, err)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mailer")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	var f = &File{
+		Dir:  filepath.Join(dir, "mail"),
+		From: "climetrics@example.com",
+	}
+
+	if err = f.Send(context.Background(), Message{To: "alice@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "mail", "*.eml"))
+
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one message file, got %v (%v) instead", files, err)
+	}
+
+	b, err := ioutil.ReadFile(files[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(string(b), "\r\n\r\nHello") {
+		t.Errorf("Unexpected message %q", b)
+	}
+}
+
+func TestRender(t *testing.T) {
+	body, err := Render("../gui/email/password-reset.txt", map[string]interface{}{
+		"Username": "alice",
+		"Link":     "https://climetrics.example.com/password/reset/abc",
+		"Expires":  date,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	for _, want := range []string{"Hi alice,", "https://climetrics.example.com/password/reset/abc", "Oct 20, 2018 03:00 UTC"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected body to contain %q, got %q instead", want, body)
+		}
+	}
+}
+
+// fakeSMTP server accepting a single message.
+func fakeSMTP(t *testing.T) (addr string, received chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received = make(chan string, 1)
+
+	go func() {
+		defer func() {
+			_ = l.Close()
+		}()
+
+		conn, err := l.Accept()
+
+		if err != nil {
+			return
+		}
+
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		var r = bufio.NewReader(conn)
+		var data []string
+		var inData bool
+
+		reply := func(s string) {
+			_, _ = conn.Write([]byte(s + "\r\n"))
+		}
+
+		reply("220 localhost ESMTP")
+
+		for {
+			line, err := r.ReadString('\n')
+
+			if err != nil {
+				return
+			}
+
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					received <- strings.Join(data, "")
+					reply("250 OK")
+					continue
+				}
+
+				data = append(data, line)
+				continue
+			}
+
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	return l.Addr().String(), received
+}
+
+func TestSMTP(t *testing.T) {
+	addr, received := fakeSMTP(t)
+
+	var s = &SMTP{
+		Addr: addr,
+		From: "CLI metrics <climetrics@example.com>",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Send(ctx, Message{To: "alice@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	select {
+	case msg := <-received:
+		if !strings.Contains(msg, "To: alice@example.com\r\n") || !strings.Contains(msg, "\r\n\r\nHello") {
+			t.Errorf("Unexpected message %q", msg)
+		}
+	case <-ctx.Done():
+		t.Error("Expected message to be received")
+	}
+}
@@ -2,26 +2,55 @@ package main
 
 import (
 	"context"
+	"errors"
 	_ "expvar"
-	"flag"
+	"fmt"
 	"math/rand"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/henvic/climetrics/archive"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/oidc"
+	"github.com/henvic/climetrics/cli"
+	"github.com/henvic/climetrics/config"
+	"github.com/henvic/climetrics/keyring"
+	"github.com/henvic/climetrics/metrics"
 	_ "github.com/henvic/climetrics/modules"
+	"github.com/henvic/climetrics/retention"
 	"github.com/henvic/climetrics/server"
-	"github.com/henvic/ctxsignal"
 	_ "github.com/lib/pq"
 	log "github.com/sirupsen/logrus"
 )
 
-var params = server.Params{}
+var params = server.Params{
+	Lockout: lockout.DefaultConfig,
+}
+
+func init() {
+	cli.Register(&cli.Command{
+		Name:    "serve",
+		Summary: "Serve the web interface and the API (the default command)",
+		Flags:   serveFlags,
+		Run:     serve,
+	})
+}
 
 func main() {
 	rand.Seed(time.Now().UTC().UnixNano())
-	flag.Parse()
+
+	if err := cli.Main(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
 
 	var debug = (os.Getenv("DEBUG") != "")
 
@@ -33,12 +62,65 @@ func main() {
 		go profiler()
 	}
 
-	ctx, cancel := ctxsignal.WithTermination(context.Background())
-	defer cancel()
+	server.Instance.Background(retention.Job.Run)
+	server.Instance.Background(metrics.PartitionJob.Run)
+	return server.Start(ctx, params)
+}
 
-	if err := server.Start(ctx, params); err != nil {
-		log.Fatal(err)
+var (
+	keyringFile        string
+	keyringEnv         = map[keyring.Purpose]*string{}
+	oidcAllowedDomains string
+	oidcGroupRoles     string
+)
+
+// loadKeyring from the keyring file, with keys set directly (i.e., on the environment) taking precedence.
+func loadKeyring() (err error) {
+	if keyringFile != "" {
+		if params.Keyring, err = keyring.Load(keyringFile); err != nil {
+			return err
+		}
+
+		if len(params.Keyring) == 0 {
+			return fmt.Errorf("no keys on keyring %s: create them with the keyring rotate command", keyringFile)
+		}
+	}
+
+	for _, p := range keyring.Purposes {
+		v := *keyringEnv[p]
+
+		if v == "" {
+			continue
+		}
+
+		keys, err := keyring.ParseEnv(p, v)
+
+		if err != nil {
+			return err
+		}
+
+		params.Keyring = params.Keyring.Replace(p, keys)
+	}
+
+	return nil
+}
+
+func parseOIDC() (err error) {
+	for _, d := range strings.Split(oidcAllowedDomains, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			params.OIDC.AllowedDomains = append(params.OIDC.AllowedDomains, d)
+		}
 	}
+
+	if params.OIDC.GroupRoles, err = oidc.ParseGroupRoles(oidcGroupRoles); err != nil {
+		return err
+	}
+
+	if !params.OIDC.Enabled() {
+		return nil
+	}
+
+	return params.OIDC.Validate()
 }
 
 func profiler() {
@@ -47,8 +129,82 @@ func profiler() {
 	log.Fatal(http.ListenAndServe("localhost:8081", nil))
 }
 
-func init() {
-	flag.StringVar(&params.Address, "addr", "127.0.0.1:8080", "Serving address")
-	flag.StringVar(&params.DSN, "dsn", "postgres://admin@/climetrics?sslmode=disable", "dsn (PostgreSQL)")
-	flag.BoolVar(&params.ExposeDebug, "expose-debug", false, "Expose debugging tools over HTTP (on port 8081)")
+func validateLockout() error {
+	// IPs are locked out for as long as accounts
+	params.Lockout.IP.Lockout = params.Lockout.Account.Lockout
+	params.Lockout.IP.Window = params.Lockout.Account.Window
+
+	if params.Lockout.Account.MaxFailures < 1 || params.Lockout.IP.MaxFailures < 1 {
+		return errors.New("the maximum number of failed login attempts must be at least 1")
+	}
+
+	return nil
+}
+
+func validateQueue() error {
+	var q = metrics.Geolocation
+
+	if q.Workers < 1 || q.Size < 1 || q.BatchSize < 1 {
+		return errors.New("the geolocation queue workers, size, and batch size must be at least 1")
+	}
+
+	return nil
+}
+
+func serveFlags(c *config.Config) {
+	c.String(&params.Address, "addr", "address", "127.0.0.1:8080", "Serving address")
+	c.String(&params.BaseURL, "base-url", "base_url", "http://localhost:8080", "Base URL of the service, used on links sent by email")
+	c.Database(&params.DSN)
+	c.Bool(&params.AutoMigrate, "auto-migrate", "database.auto_migrate", false, "Apply pending database migrations on start")
+	c.Int(&metrics.PartitionJob.Ahead, "partitions-ahead", "database.partitions_ahead", metrics.PartitionJob.Ahead, "Months with partitions of the metrics created ahead of time")
+	c.Validate(metrics.PartitionJob.Validate)
+	c.Bool(&params.ExposeDebug, "expose-debug", "expose_debug", false, "Expose debugging tools over HTTP (on port 8081)")
+
+	c.String(&keyringFile, "keyring", "keyring.file", "", "Keyring file with the keys signing the session and CSRF cookies")
+
+	for _, p := range keyring.Purposes {
+		keyringEnv[p] = new(string)
+		c.String(keyringEnv[p], string(p)+"-keys", "keyring."+string(p)+"_keys", "",
+			fmt.Sprintf("Comma-separated base64 %s keys, from the newest to the oldest (takes precedence over the keyring file)", p)).
+			Secret().Alias(p.EnvVar())
+	}
+
+	c.Geolocation(&params.Geolocation)
+	c.Int(&metrics.Geolocation.Workers, "geolocation-workers", "geolocation.queue.workers", metrics.Geolocation.Workers, "Workers resolving the geolocation of new metrics")
+	c.Int(&metrics.Geolocation.Size, "geolocation-queue-size", "geolocation.queue.size", metrics.Geolocation.Size, "Maximum number of IPs waiting for geolocation (IPs are dropped when the queue is full)")
+	c.Int(&metrics.Geolocation.BatchSize, "geolocation-batch-size", "geolocation.queue.batch_size", metrics.Geolocation.BatchSize, "Maximum number of IPs updated on the metrics at once")
+	c.Duration(&metrics.Geolocation.Timeout, "geolocation-timeout", "geolocation.queue.timeout", metrics.Geolocation.Timeout, "Timeout for each geolocation request")
+	c.Int(&metrics.Geolocation.Retries, "geolocation-retries", "geolocation.queue.retries", metrics.Geolocation.Retries, "Retries of failed geolocation requests")
+
+	c.Archive(archive.Default)
+	c.Retention(retention.Job)
+	c.Duration(&retention.Job.Interval, "retention-interval", "retention.interval", 0, "Interval between runs pruning metrics and diagnostics past retention (0 disables it)")
+
+	c.String(&params.OIDC.Issuer, "oidc-issuer", "oidc.issuer", "", "OpenID Connect issuer URL for single sign-on (i.e., https://accounts.google.com)")
+	c.String(&params.OIDC.ClientID, "oidc-client-id", "oidc.client_id", "", "OpenID Connect client ID")
+	c.String(&params.OIDC.ClientSecret, "oidc-client-secret", "oidc.client_secret", "", "OpenID Connect client secret").Secret().Alias("OIDC_CLIENT_SECRET")
+	c.String(&params.OIDC.RedirectURL, "oidc-redirect-url", "oidc.redirect_url", "", "OpenID Connect redirect URL (i.e., https://climetrics.example.com/login/oidc/callback)")
+	c.String(&oidcAllowedDomains, "oidc-allowed-domains", "oidc.allowed_domains", "", "Email domains of users created on their first single sign-on (i.e., example.com,example.org)")
+	c.String(&params.OIDC.DefaultRole, "oidc-default-role", "oidc.default_role", "member", "Role of users created on their first single sign-on")
+	c.String(&params.OIDC.GroupsClaim, "oidc-groups-claim", "oidc.groups_claim", "groups", "OpenID Connect claim with the groups of the user")
+	c.String(&oidcGroupRoles, "oidc-group-roles", "oidc.group_roles", "", "Map groups to roles, enforced on every single sign-on (i.e., engineering=member,ops=admin)")
+
+	c.Int(&params.Lockout.Account.MaxFailures, "login-max-failures", "lockout.max_failures", params.Lockout.Account.MaxFailures, "Failed login attempts before an account is locked out")
+	c.Int(&params.Lockout.IP.MaxFailures, "login-ip-max-failures", "lockout.ip_max_failures", params.Lockout.IP.MaxFailures, "Failed login attempts before an IP is locked out")
+	c.Duration(&params.Lockout.Account.Delay, "login-delay", "lockout.delay", params.Lockout.Account.Delay, "Delay after a failed login attempt on an account, doubled after each failure")
+	c.Duration(&params.Lockout.Account.Lockout, "login-lockout", "lockout.duration", params.Lockout.Account.Lockout, "Lockout duration after too many failed login attempts")
+	c.Duration(&params.Lockout.Account.Window, "login-failures-window", "lockout.window", params.Lockout.Account.Window, "Failed login attempts are forgotten after this duration without failures")
+
+	c.String(&params.Mailer.Mailer, "mailer", "mailer.type", "log", "Mailer for invitations and password resets (smtp, file, log)")
+	c.String(&params.Mailer.From, "mail-from", "mailer.from", "CLI metrics <climetrics@localhost>", "Sender address of emails")
+	c.String(&params.Mailer.SMTPAddr, "smtp-addr", "mailer.smtp_addr", "localhost:25", "SMTP server address for the smtp mailer")
+	c.String(&params.Mailer.SMTPUsername, "smtp-username", "mailer.smtp_username", "", "SMTP username for the smtp mailer")
+	c.String(&params.Mailer.SMTPPassword, "smtp-password", "mailer.smtp_password", "", "SMTP password for the smtp mailer").Secret().Alias("SMTP_PASSWORD")
+	c.String(&params.Mailer.Dir, "mail-dir", "mailer.dir", "mail", "Directory where the file mailer writes emails to")
+
+	c.Own("", "archive", "database", "geolocation", "geolocation.queue", "keyring", "lockout", "mailer", "oidc", "retention")
+	c.Validate(validateLockout)
+	c.Validate(validateQueue)
+	c.Validate(parseOIDC)
+	c.Validate(loadKeyring)
 }
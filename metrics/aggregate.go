@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/henvic/climetrics/countrycode"
+)
+
+// Aggregate counts events and distinct sessions by a key, mirroring the SQL aggregates,
+// for metrics that aren't all on the database (i.e., including archives).
+type Aggregate struct {
+	key      func(Metric) string
+	events   map[string]int
+	sessions map[string]map[string]struct{}
+}
+
+// NewAggregate of the metrics by a key.
+func NewAggregate(key func(Metric) string) *Aggregate {
+	return &Aggregate{
+		key:      key,
+		events:   map[string]int{},
+		sessions: map[string]map[string]struct{}{},
+	}
+}
+
+// Add metric to the aggregate.
+func (a *Aggregate) Add(m Metric) error {
+	var k = a.key(m)
+	a.events[k]++
+
+	if a.sessions[k] == nil {
+		a.sessions[k] = map[string]struct{}{}
+	}
+
+	a.sessions[k][m.SID] = struct{}{}
+	return nil
+}
+
+// Len is the number of keys.
+func (a *Aggregate) Len() int {
+	return len(a.events)
+}
+
+func (a *Aggregate) each(fn func(key string, events, sessions int)) {
+	for k, events := range a.events {
+		fn(k, events, len(a.sessions[k]))
+	}
+}
+
+// LocationKey of the metrics by country or continent code, as on Locations.
+func LocationKey(g Grouping) func(Metric) string {
+	return func(m Metric) string {
+		if m.SyncLocation == nil {
+			return ""
+		}
+
+		var code = m.SyncLocation.Country
+
+		if g != GroupByContinent {
+			return code
+		}
+
+		if c, ok := countrycode.Lookup(code); ok && c.Alpha2 == code {
+			return string(c.Continent)
+		}
+
+		return ""
+	}
+}
+
+// Locations of the aggregate by LocationKey, ordered by events.
+func (a *Aggregate) Locations() []LocationCount {
+	var lcs []LocationCount
+
+	a.each(func(key string, events, sessions int) {
+		lcs = append(lcs, LocationCount{Code: key, Events: events, Sessions: sessions})
+	})
+
+	sort.SliceStable(lcs, func(i, j int) bool {
+		if lcs[i].Events != lcs[j].Events {
+			return lcs[i].Events > lcs[j].Events
+		}
+
+		return lcs[i].Code < lcs[j].Code
+	})
+
+	return lcs
+}
+
+// OrganizationKey of the metrics, as on Organizations.
+func OrganizationKey(m Metric) string {
+	var l Location
+
+	if m.SyncLocation != nil {
+		l = *m.SyncLocation
+	}
+
+	return l.ASN() + "\n" + l.OrganizationName()
+}
+
+// Organizations of the aggregate by OrganizationKey, ranked as on Organizations, with the filter pagination.
+func (a *Aggregate) Organizations(f Filter) []OrganizationCount {
+	var ocs []OrganizationCount
+
+	a.each(func(key string, events, sessions int) {
+		var parts = strings.SplitN(key, "\n", 2)
+		ocs = append(ocs, OrganizationCount{ASN: parts[0], Organization: parts[1], Events: events, Sessions: sessions})
+	})
+
+	sort.Slice(ocs, func(i, j int) bool {
+		switch {
+		case ocs[i].Sessions != ocs[j].Sessions:
+			return ocs[i].Sessions > ocs[j].Sessions
+		case ocs[i].Events != ocs[j].Events:
+			return ocs[i].Events > ocs[j].Events
+		case ocs[i].Organization != ocs[j].Organization:
+			return ocs[i].Organization < ocs[j].Organization
+		}
+
+		return ocs[i].ASN < ocs[j].ASN
+	})
+
+	if f.Page == 0 {
+		f.Page = 1
+	}
+
+	var start, end = (f.Page - 1) * f.PerPage, f.Page * f.PerPage
+
+	if start > len(ocs) {
+		start = len(ocs)
+	}
+
+	if end > len(ocs) {
+		end = len(ocs)
+	}
+
+	return ocs[start:end]
+}
+
+// VersionKey of the metrics, as on VersionCounts.
+func VersionKey(m Metric) string {
+	return m.Version
+}
+
+// VersionCounts of the aggregate by VersionKey, ordered as on VersionCounts.
+func (a *Aggregate) VersionCounts() []VersionCount {
+	var vcs []VersionCount
+
+	a.each(func(key string, events, sessions int) {
+		vcs = append(vcs, VersionCount{Version: key, Events: events, Sessions: sessions})
+	})
+
+	sort.Slice(vcs, func(i, j int) bool {
+		if c := compareVersions(vcs[i].Version, vcs[j].Version); c != 0 {
+			return c > 0
+		}
+
+		return vcs[i].Version < vcs[j].Version
+	})
+
+	return vcs
+}
+
+// compareVersions by their numeric parts, like the versions are ordered on the database.
+func compareVersions(a, b string) int {
+	var pa, pb = versionParts(a), versionParts(b)
+
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		switch {
+		case pa[i] < pb[i]:
+			return -1
+		case pa[i] > pb[i]:
+			return 1
+		}
+	}
+
+	return len(pa) - len(pb)
+}
+
+// versionParts keeps only the digits and dots, and splits the version by the dots.
+func versionParts(v string) []int {
+	var b strings.Builder
+
+	for _, r := range v {
+		if r == '.' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+
+	var parts []int
+
+	for _, s := range strings.Split(b.String(), ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+
+	return parts
+}
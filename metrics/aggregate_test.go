@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregateLocations(t *testing.T) {
+	var a = NewAggregate(LocationKey(GroupByCountry))
+
+	for _, m := range []Metric{
+		{SID: "a", SyncLocation: &Location{Country: "BR"}},
+		{SID: "a", SyncLocation: &Location{Country: "BR"}},
+		{SID: "b", SyncLocation: &Location{Country: "US"}},
+		{SID: "c", SyncLocation: &Location{Country: "US"}},
+		{SID: "c"},
+	} {
+		_ = a.Add(m)
+	}
+
+	var want = []LocationCount{
+		{Code: "BR", Events: 2, Sessions: 1},
+		{Code: "US", Events: 2, Sessions: 2},
+		{Code: "", Events: 1, Sessions: 1},
+	}
+
+	if got := a.Locations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected locations %+v, got %+v instead", want, got)
+	}
+}
+
+func TestAggregateOrganizations(t *testing.T) {
+	var a = NewAggregate(OrganizationKey)
+
+	for _, m := range []Metric{
+		{SID: "a", SyncLocation: &Location{Organization: "AS15169 Google LLC"}},
+		{SID: "a", SyncLocation: &Location{Organization: "AS15169 Google LLC"}},
+		{SID: "b", SyncLocation: &Location{Organization: "AS16509 Amazon.com, Inc."}},
+		{SID: "c", SyncLocation: &Location{Organization: "AS16509 Amazon.com, Inc."}},
+	} {
+		_ = a.Add(m)
+	}
+
+	var want = []OrganizationCount{
+		{ASN: "AS16509", Organization: "Amazon.com, Inc.", Events: 2, Sessions: 2},
+		{ASN: "AS15169", Organization: "Google LLC", Events: 2, Sessions: 1},
+	}
+
+	if got := a.Organizations(Filter{PerPage: 10}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected organizations %+v, got %+v instead", want, got)
+	}
+
+	if got := a.Organizations(Filter{Page: 2, PerPage: 1}); !reflect.DeepEqual(got, want[1:]) {
+		t.Errorf("Expected second page %+v, got %+v instead", want[1:], got)
+	}
+
+	if got := a.Organizations(Filter{Page: 3, PerPage: 1}); len(got) != 0 {
+		t.Errorf("Expected no organizations past the last page, got %+v instead", got)
+	}
+}
+
+func TestAggregateVersionCounts(t *testing.T) {
+	var a = NewAggregate(VersionKey)
+
+	for _, v := range []string{"1.9.0", "1.10.0", "1.10.0", "v1.2.0-beta"} {
+		_ = a.Add(Metric{SID: "a", Version: v})
+	}
+
+	var got []string
+
+	for _, vc := range a.VersionCounts() {
+		got = append(got, vc.Version)
+	}
+
+	if want := []string{"1.10.0", "1.9.0", "v1.2.0-beta"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected versions %v, got %v instead", want, got)
+	}
+}
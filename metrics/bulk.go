@@ -0,0 +1,25 @@
+package metrics
+
+// BulkStats of metrics added at once (i.e., on a request to /metrics/bulk).
+type BulkStats struct {
+	RequestID string `json:"request_id,omitempty"`
+
+	Added int `json:"added"`
+	Noop  int `json:"noop"`
+	Error int `json:"error"`
+
+	Broken []int `json:"broken_lines,omitempty"`
+}
+
+// Count the result of adding the metric on a line.
+func (b *BulkStats) Count(line int, added bool, err error) {
+	switch {
+	case err != nil:
+		b.Error++
+		b.Broken = append(b.Broken, line)
+	case added:
+		b.Added++
+	default:
+		b.Noop++
+	}
+}
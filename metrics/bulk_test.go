@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBulkStatsCount(t *testing.T) {
+	var b BulkStats
+	b.Count(1, true, nil)
+	b.Count(2, false, nil)
+	b.Count(3, false, errors.New("invalid UUID"))
+	b.Count(4, true, nil)
+	b.Count(5, false, errors.New("invalid UUID"))
+
+	var want = BulkStats{
+		Added:  2,
+		Noop:   1,
+		Error:  2,
+		Broken: []int{3, 5},
+	}
+
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("Expected stats to be %+v, got %+v instead", want, b)
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/db/dbtest"
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCreateConcurrently(t *testing.T) {
+	dbtest.Load(t)
+
+	var ctx = context.Background()
+	var id = uuid.NewV4().String()
+
+	defer func() {
+		_, _ = db.Conn().ExecContext(ctx, "DELETE FROM metrics WHERE id = $1", id)
+	}()
+
+	var wg sync.WaitGroup
+	var created = make(chan bool, 10)
+
+	for i := 0; i < cap(created); i++ {
+		wg.Add(1)
+
+		// the same metric synced at different times (i.e., retried requests) goes to different partitions
+		go func(i int) {
+			defer wg.Done()
+
+			added, err := Create(ctx, Metric{
+				ID:        id,
+				Type:      "cmd",
+				SID:       uuid.NewV4().String(),
+				RequestID: uuid.NewV4().String(),
+				Timestamp: "Sat Oct 20 03:00:00 +0000 2018",
+				SyncIP:    "203.0.113.1",
+				SyncTime:  time.Date(2018, time.Month(i%12+1), 1, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			})
+
+			if err != nil {
+				t.Errorf("Expected no error, got %v instead", err)
+			}
+
+			created <- added
+		}(i)
+	}
+
+	wg.Wait()
+	close(created)
+
+	var added int
+
+	for a := range created {
+		if a {
+			added++
+		}
+	}
+
+	var n int
+
+	if err := db.Conn().QueryRowContext(ctx, "SELECT COUNT(*) FROM metrics WHERE id = $1", id).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	if added != 1 || n != 1 {
+		t.Errorf("Expected metric to be added once, got %d (%d rows) instead", added, n)
+	}
+}
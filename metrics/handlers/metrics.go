@@ -2,18 +2,19 @@ package metricshandlers
 
 import (
 	"bufio"
-	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/archive"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/export"
 	"github.com/henvic/climetrics/metrics"
 	"github.com/henvic/climetrics/server"
 	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
 	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
@@ -23,26 +24,21 @@ import (
 var router = server.Instance.Mux
 
 func init() {
-	router().Handle("/metrics", server.AuthenticatedHandler(listHandler))
+	router().Handle("/metrics", server.RequirePermission(users.ViewMetrics, listHandler))
 	router().HandleFunc("/metrics/bulk", bulkAddHandler)
 	server.Protected.Unsafe("/metrics/bulk")
-	router().Handle("/metrics/{id}", server.AuthenticatedHandler(readHandler))
-}
-
-type bulkStats struct {
-	RequestID string `json:"request_id"`
-
-	Added int `json:"added"`
-	Noop  int `json:"noop"`
-	Error int `json:"error"`
-
-	Broken []int `json:"broken_lines,omitempty"`
+	server.Instance.Background(metrics.Geolocation.Run)
+	router().Handle("/metrics/locations", server.RequirePermission(users.ViewMetrics, locationsHandler))
+	router().Handle("/metrics/organizations", server.RequirePermission(users.ViewMetrics, organizationsHandler))
+	router().Handle("/metrics/organizations/versions", server.RequirePermission(users.ViewMetrics, organizationVersionsHandler))
+	router().Handle("/metrics/export", server.RequirePermission(users.ViewMetrics, exportHandler))
+	router().Handle("/metrics/{id}", server.RequirePermission(users.ViewMetrics, readHandler))
 }
 
 func bulkAddHandler(w http.ResponseWriter, r *http.Request) {
 	var requestID = uuid.NewV4().String()
 
-	var b = bulkStats{
+	var b = metrics.BulkStats{
 		RequestID: requestID,
 	}
 
@@ -57,39 +53,30 @@ func bulkAddHandler(w http.ResponseWriter, r *http.Request) {
 		m, err := unmarshalMetric(mt)
 		m.RequestID = requestID
 		m.SyncIP = ip
+		m.SyncTime = ""
 
 		if err != nil {
 			log.Debugf("can't unmarshal metric: %+v: %+v", mt, err)
-			b.Error++
-			b.Broken = append(b.Broken, line)
+			b.Count(line, false, err)
 			continue
 		}
 
 		added, err := metrics.Create(r.Context(), m)
 
-		if err != nil {
-			b.Error++
-			b.Broken = append(b.Broken, line)
-
-			switch err.(type) {
-			case *pq.Error:
-				log.Error(err)
-			default:
-				log.Debugf("can't create metric on DB: %+v: %+v", mt, err)
-			}
-
-			continue
-		}
-
-		switch added {
-		case true:
-			b.Added++
+		switch err.(type) {
+		case nil:
+		case *pq.Error:
+			log.Error(err)
 		default:
-			b.Noop++
+			log.Debugf("can't create metric on DB: %+v: %+v", mt, err)
 		}
+
+		b.Count(line, added, err)
 	}
 
-	go addGeolocation(ip)
+	if b.Added != 0 {
+		metrics.Geolocation.Enqueue(ip)
+	}
 
 	if err := s.Err(); err != nil {
 		log.Error(s)
@@ -104,63 +91,17 @@ func bulkAddHandler(w http.ResponseWriter, r *http.Request) {
 	_, _ = fmt.Fprintf(w, "%s\n", bj)
 }
 
-func addGeolocation(ip string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	log.Debugf("trying to add geolocation for IP %s", ip)
-
-	if _, err := metrics.AddGeolocationIP(ctx, ip); err != nil {
-		log.Errorf("can't add geolocation for IP %s: %+v", ip, err)
-	}
-}
-
 func unmarshalMetric(s string) (m metrics.Metric, err error) {
 	err = json.Unmarshal([]byte(s), &m)
 	return m, err
 }
 
 func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
-	var query = r.URL.Query()
-
-	var page = 1
-	var err error
-
-	if len(query["page"]) != 0 {
-		page, err = strconv.Atoi(query["page"][0])
-
-		if err != nil {
-			server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
+	f, err := metrics.ParseFilter(r.URL.Query())
 
-		if page == 0 {
-			page = 1
-		}
-	}
-
-	var fType, text, version string
-
-	if len(query["type"]) != 0 {
-		fType = query["type"][0]
-	}
-
-	if len(query["text"]) != 0 {
-		text = query["text"][0]
-	}
-
-	if len(query["version"]) != 0 {
-		version = query["version"][0]
-	}
-
-	f := metrics.Filter{
-		Type:       fType,
-		Text:       text,
-		Version:    version,
-		NotVersion: len(query["not-version"]) != 0,
-
-		Page:    page,
-		PerPage: 100,
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
 	}
 
 	count, err := metrics.Count(r.Context(), f)
@@ -213,6 +154,8 @@ func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 			"URL":      r.URL,
 			"Types":    types,
 			"Versions": versions,
+			"Formats":  export.Formats,
+			"Columns":  export.Metrics.Columns(),
 		},
 		Request:        r,
 		ResponseWriter: w,
@@ -221,6 +164,42 @@ func listHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 	t.Respond()
 }
 
+// exportHandler streams the metrics matching the filter.
+func exportHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	var query = r.URL.Query()
+	f, err := metrics.ParseFilter(query)
+
+	if err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	o, err := export.ParseOptions(export.Metrics, query)
+
+	if err != nil {
+		server.ErrorHandler(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	server.Audit(r, audit.Entry{
+		Action:  audit.MetricsExport,
+		Details: query.Encode(),
+	})
+
+	started, err := export.Serve(w, export.Metrics, o, func(ew *export.Writer) error {
+		return export.WriteMetrics(r.Context(), ew, f)
+	})
+
+	switch {
+	case err != nil && started:
+		// the response is already on its way, so the export ends truncated.
+		log.Errorf("failed to export metrics: %+v", err)
+	case err != nil:
+		log.Errorf("failed to export metrics: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
+
 func readHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 	vars := mux.Vars(r)
 	var m, err = metrics.Get(r.Context(), vars["id"])
@@ -250,3 +229,202 @@ func readHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 
 	t.Respond()
 }
+
+func locationsHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	var query = r.URL.Query()
+	f, err := metrics.ParseFilter(query)
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var group = metrics.GroupByCountry
+
+	if query.Get("group") != "" {
+		group = metrics.Grouping(query.Get("group"))
+	}
+
+	switch group {
+	case metrics.GroupByCountry, metrics.GroupByContinent:
+	default:
+		server.ErrorHandler(w, r, "Invalid location grouping", http.StatusBadRequest)
+		return
+	}
+
+	var list []metrics.LocationCount
+
+	switch f.Archive {
+	case true:
+		var agg *metrics.Aggregate
+
+		if agg, err = archive.Default.Aggregate(r.Context(), f, metrics.LocationKey(group)); err == nil {
+			list = agg.Locations()
+		}
+	default:
+		list, err = metrics.Locations(r.Context(), f, group)
+	}
+
+	if err != nil {
+		log.Errorf("failed to count metrics by location: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	types, err := metrics.Types(r.Context())
+
+	if err != nil {
+		log.Errorf("failed to list metrics types: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	versions, err := metrics.Versions(r.Context())
+
+	if err != nil {
+		log.Errorf("failed to list metrics versions: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var t = &server.Template{
+		Title:     "Metrics by location",
+		Section:   "metrics",
+		Filenames: []string{"gui/metrics/locations.html"},
+		Data: map[string]interface{}{
+			"List":      list,
+			"Filter":    f,
+			"Group":     group,
+			"Groupings": []metrics.Grouping{metrics.GroupByCountry, metrics.GroupByContinent},
+			"Types":     types,
+			"Versions":  versions,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+func organizationsHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	f, err := metrics.ParseFilter(r.URL.Query())
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var count int
+	var list []metrics.OrganizationCount
+
+	switch f.Archive {
+	case true:
+		var agg *metrics.Aggregate
+
+		if agg, err = archive.Default.Aggregate(r.Context(), f, metrics.OrganizationKey); err == nil {
+			count, list = agg.Len(), agg.Organizations(f)
+		}
+	default:
+		if count, err = metrics.CountOrganizations(r.Context(), f); err == nil {
+			list, err = metrics.Organizations(r.Context(), f)
+		}
+	}
+
+	if err != nil {
+		log.Errorf("failed to count metrics by organization: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var maxPage = count / f.PerPage
+
+	if count%f.PerPage != 0 {
+		maxPage++
+	}
+
+	types, err := metrics.Types(r.Context())
+
+	if err != nil {
+		log.Errorf("failed to list metrics types: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	versions, err := metrics.Versions(r.Context())
+
+	if err != nil {
+		log.Errorf("failed to list metrics versions: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var t = &server.Template{
+		Title:     "Metrics by organization",
+		Section:   "metrics",
+		Filenames: []string{"gui/metrics/organizations.html"},
+		Data: map[string]interface{}{
+			"List":     list,
+			"Count":    count,
+			"MaxPage":  maxPage,
+			"Filter":   f,
+			"URL":      r.URL,
+			"Networks": metrics.NetworkClasses,
+			"Types":    types,
+			"Versions": versions,
+			"Formats":  export.Formats,
+			"Columns":  export.Metrics.Columns(),
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
+
+func organizationVersionsHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	f, err := metrics.ParseFilter(r.URL.Query())
+
+	if err != nil {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	var list []metrics.VersionCount
+
+	switch f.Archive {
+	case true:
+		var agg *metrics.Aggregate
+
+		if agg, err = archive.Default.Aggregate(r.Context(), f, metrics.VersionKey); err == nil {
+			list = agg.VersionCounts()
+		}
+	default:
+		list, err = metrics.VersionCounts(r.Context(), f)
+	}
+
+	if err != nil {
+		log.Errorf("failed to count metrics by version: %+v", err)
+		server.ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var o = metrics.OrganizationCount{
+		ASN:          f.ASN,
+		Organization: f.Organization,
+	}
+
+	var t = &server.Template{
+		Title:     "Versions used by " + o.Name(),
+		Section:   "metrics",
+		Filenames: []string{"gui/metrics/organization.html"},
+		Data: map[string]interface{}{
+			"List":         list,
+			"Filter":       f,
+			"Organization": o,
+		},
+		Request:        r,
+		ResponseWriter: w,
+	}
+
+	t.Respond()
+}
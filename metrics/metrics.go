@@ -2,20 +2,27 @@ package metrics
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/hashicorp/errwrap"
 	"github.com/henvic/climetrics/countrycode"
+	"github.com/henvic/climetrics/cursor"
 	"github.com/henvic/climetrics/db"
 	"github.com/henvic/climetrics/geolocation"
 	"github.com/henvic/climetrics/timejson"
 	"github.com/kisielk/sqlstruct"
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -70,17 +77,48 @@ type Location struct {
 	Organization string `json:"org,omitempty"`
 	Bogon        bool   `json:"bogon,omitempty"`
 	Cached       string `json:"cached,omitempty"`
+	Provider     string `json:"provider,omitempty"`
 
 	Error     map[string]string `json:"error,omitempty"`
 	ErrorJSON error             `json:"error_json,omitempty"`
 }
 
+// Grouping of locations.
+type Grouping string
+
+const (
+	// GroupByCity groups locations by city, region, and country.
+	GroupByCity Grouping = "city"
+
+	// GroupByCountry groups locations by country.
+	GroupByCountry Grouping = "country"
+
+	// GroupByContinent groups locations by continent.
+	GroupByContinent Grouping = "continent"
+)
+
 // Address (human readable) for the location.
 func (l Location) Address() string {
+	return l.AddressBy(GroupByCity)
+}
+
+// AddressBy returns the human readable address for the location on a given grouping level.
+func (l Location) AddressBy(g Grouping) string {
 	if l.Error != nil {
 		return ""
 	}
 
+	switch g {
+	case GroupByContinent:
+		return l.Continent()
+	case GroupByCountry:
+		if l.Country == "" {
+			return ""
+		}
+
+		return countrycode2name(l.Country)
+	}
+
 	var addr []string
 
 	if l.City != "" {
@@ -98,6 +136,41 @@ func (l Location) Address() string {
 	return strings.Join(addr, ", ")
 }
 
+// Continent for the location.
+func (l Location) Continent() string {
+	return string(countrycode.GetContinent(l.Country))
+}
+
+// organizationRegexp splits organizations such as "AS15169 Google LLC" into ASN and name.
+// setLocation mirrors it on SQL.
+var organizationRegexp = regexp.MustCompile(`^(AS[0-9]+)\s*(.*)$`)
+
+// ASN (autonomous system number) of the organization, such as AS15169.
+func (l Location) ASN() string {
+	if m := organizationRegexp.FindStringSubmatch(l.Organization); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// OrganizationName without the ASN.
+func (l Location) OrganizationName() string {
+	if m := organizationRegexp.FindStringSubmatch(l.Organization); m != nil {
+		return m[2]
+	}
+
+	return l.Organization
+}
+
+// setLocation returns the SET clause to replace the geolocation info of metrics with a JSON value,
+// keeping the organization columns in sync with it.
+func setLocation(value string) string {
+	return fmt.Sprintf(`sync_location = %[1]s,
+	sync_asn = substring(%[1]s->>'org' from '^(AS[0-9]+)'),
+	sync_org = NULLIF(regexp_replace(%[1]s->>'org', '^AS[0-9]+\s*', ''), '')`, value)
+}
+
 // Scan implements the Scanner interface.
 func (l *Location) Scan(value interface{}) error {
 	if err := json.Unmarshal(value.([]byte), &l); err != nil {
@@ -146,8 +219,9 @@ func (e Extra) Value() (driver.Value, error) {
 	return json.Marshal(e)
 }
 
-// Create report
-func Create(ctx context.Context, m Metric) (created bool, err error) {
+// Normalize and validate metric before saving it.
+// The sync time is optional (the current time is used if empty), and is normalized to RFC 3339.
+func Normalize(m *Metric) error {
 	if m.Type == "command_exec" {
 		m.Type = "cmd"
 	}
@@ -158,14 +232,13 @@ func Create(ctx context.Context, m Metric) (created bool, err error) {
 
 	// check if report.ID is on the RFC4122 version 4 format with no urn prefix:
 	if strings.HasPrefix(m.ID, "urn:") {
-		return false, errors.New("expected no urn: on report ID")
+		return errors.New("expected no urn: on report ID")
 	}
 
-	var u uuid.UUID
-	u, err = uuid.FromString(m.ID)
+	u, err := uuid.FromString(m.ID)
 
 	if err != nil || u.Version() != 4 || u.Variant() != uuid.VariantRFC4122 {
-		return false, errors.New("invalid UUID")
+		return errors.New("invalid UUID")
 	}
 
 	m.ID = strings.ToLower(u.String())
@@ -173,21 +246,66 @@ func Create(ctx context.Context, m Metric) (created bool, err error) {
 	ts, err := time.Parse(time.RubyDate, m.Timestamp)
 
 	if err != nil {
-		return false, errwrap.Wrapf("invalid diagnostics timestamp: {{err}}", err)
+		return errwrap.Wrapf("invalid diagnostics timestamp: {{err}}", err)
 	}
 
 	m.TimestampDB = timejson.RubyDate(ts)
 
+	switch {
+	case m.SyncIP == "":
+		return errors.New("missing sync IP")
+	case net.ParseIP(m.SyncIP) == nil:
+		return fmt.Errorf(`invalid sync IP "%s"`, m.SyncIP)
+	}
+
+	if m.SyncTime != "" {
+		st, err := time.Parse(time.RFC3339Nano, m.SyncTime)
+
+		if err != nil {
+			return errwrap.Wrapf("invalid sync time: {{err}}", err)
+		}
+
+		m.SyncTime = st.Format(time.RFC3339Nano)
+	}
+
+	return nil
+}
+
+// Create report
+func Create(ctx context.Context, m Metric) (created bool, err error) {
+	if err = Normalize(&m); err != nil {
+		return false, err
+	}
+
 	conn := db.Conn()
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Once metrics is partitioned, its primary key is (id, sync_time),
+	// so the same metric synced again at another time doesn't conflict: check the ID first,
+	// holding a lock on the ID until the end of the transaction, so concurrent requests adding it don't race.
+	if _, err = tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1::text))`, m.ID); err != nil {
+		return false, err
+	}
 
-	stmt, err := conn.PreparexContext(ctx, `
+	stmt, err := tx.PreparexContext(ctx, `
 INSERT INTO metrics (
 	"id", "type", "text", "tags", "extra", "pid", "sid",
 	"timestamp", "version", "os", "arch",
-	"request_id", "sync_ip", "sync_location", "timestamp_db")
-	VALUES (
-		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
-	)
+	"request_id", "sync_ip", "sync_location", "timestamp_db",
+	"sync_asn", "sync_org", "sync_time")
+	SELECT
+		$1::uuid, $2::varchar, $3::text, $4::json, $5::json, $6::varchar, $7::uuid, $8::text,
+		$9::varchar, $10::varchar, $11::varchar, $12::uuid, $13::inet, $14::json, $15::timestamptz,
+		$16::varchar, $17::varchar, COALESCE($18::timestamptz, CURRENT_TIMESTAMP)
+	WHERE NOT EXISTS (SELECT 1 FROM metrics WHERE id = $1::uuid)
 	ON CONFLICT DO NOTHING
 `)
 
@@ -199,6 +317,13 @@ INSERT INTO metrics (
 		_ = stmt.Close()
 	}()
 
+	var asn, org sql.NullString
+
+	if m.SyncLocation != nil {
+		asn = nullString(m.SyncLocation.ASN())
+		org = nullString(m.SyncLocation.OrganizationName())
+	}
+
 	var args = []interface{}{
 		m.ID,
 		m.Type,
@@ -215,6 +340,9 @@ INSERT INTO metrics (
 		m.SyncIP,
 		m.SyncLocation,
 		m.TimestampDB,
+		asn,
+		org,
+		nullString(m.SyncTime),
 	}
 
 	res, err := stmt.ExecContext(ctx, args...)
@@ -225,7 +353,15 @@ INSERT INTO metrics (
 
 	rows, err := res.RowsAffected()
 
-	return rows != 0, err
+	if err != nil {
+		return false, err
+	}
+
+	return rows != 0, tx.Commit()
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
 }
 
 // Filter sets the filter settings
@@ -235,13 +371,122 @@ type Filter struct {
 	Version    string
 	NotVersion bool
 
+	// ASN and Organization (name, without the ASN) the metrics were sent from.
+	ASN          string
+	Organization string
+
+	// Network class of the organization.
+	Network NetworkClass
+
+	// Since and Until (exclusive) sync time, pruning the partitions of other months.
+	Since time.Time
+	Until time.Time
+
+	// Archive includes the archived metrics (on exports and aggregates only).
+	Archive bool
+
+	// Cursor to list metrics after (used instead of Page).
+	Cursor *cursor.Cursor
+
 	Page    int
 	PerPage int
 }
 
+// ParseFilter from query parameters.
+func ParseFilter(query url.Values) (f Filter, err error) {
+	var page = 1
+
+	if len(query["page"]) != 0 {
+		page, err = strconv.Atoi(query["page"][0])
+
+		if err != nil {
+			return f, err
+		}
+
+		if page == 0 {
+			page = 1
+		}
+	}
+
+	var fType, text, version string
+
+	if len(query["type"]) != 0 {
+		fType = query["type"][0]
+	}
+
+	if len(query["text"]) != 0 {
+		text = query["text"][0]
+	}
+
+	if len(query["version"]) != 0 {
+		version = query["version"][0]
+	}
+
+	var network = NetworkClass(query.Get("network"))
+
+	if network != "" && !network.Valid() {
+		return f, fmt.Errorf(`invalid network class "%s"`, network)
+	}
+
+	f = Filter{
+		Type:       fType,
+		Text:       text,
+		Version:    version,
+		NotVersion: len(query["not-version"]) != 0,
+
+		ASN:          query.Get("asn"),
+		Organization: query.Get("org"),
+		Network:      network,
+		Archive:      query.Get("archive") != "",
+
+		Page:    page,
+		PerPage: 100,
+	}
+
+	if s := query.Get("since"); s != "" {
+		if f.Since, err = time.Parse(dateLayout, s); err != nil {
+			return f, fmt.Errorf("invalid since date: %v", err)
+		}
+	}
+
+	if s := query.Get("until"); s != "" {
+		if f.Until, err = time.Parse(dateLayout, s); err != nil {
+			return f, fmt.Errorf("invalid until date: %v", err)
+		}
+
+		// until is inclusive
+		f.Until = f.Until.AddDate(0, 0, 1)
+	}
+
+	return f, nil
+}
+
+// dateLayout for the since and until filters.
+const dateLayout = "2006-01-02"
+
+// SinceDate of the filter, for the date input.
+func (f Filter) SinceDate() string {
+	if f.Since.IsZero() {
+		return ""
+	}
+
+	return f.Since.Format(dateLayout)
+}
+
+// UntilDate of the filter (inclusive), for the date input.
+func (f Filter) UntilDate() string {
+	if f.Until.IsZero() {
+		return ""
+	}
+
+	return f.Until.AddDate(0, 0, -1).Format(dateLayout)
+}
+
 // Changed tells if values are not default (besides pagination)
 func (f Filter) Changed() bool {
-	if f.Type != "" || f.Text != "" || f.Version != "" || f.NotVersion {
+	if f.Type != "" || f.Text != "" || f.Version != "" || f.NotVersion ||
+		f.ASN != "" || f.Organization != "" || f.Network != "" ||
+		!f.Since.IsZero() || !f.Until.IsZero() || f.Archive {
 		return true
 	}
 
@@ -287,33 +532,62 @@ func Count(ctx context.Context, f Filter) (int, error) {
 
 // List metrics
 func List(ctx context.Context, f Filter) (ms []Metric, err error) {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+
+	err = Walk(ctx, f, func(m Metric) error {
+		ms = append(ms, m)
+		return nil
+	})
+
+	return ms, err
+}
+
+// Walk through the metrics matching the filter, most recent first.
+// There is no limit if PerPage is zero.
+func Walk(ctx context.Context, f Filter, fn func(Metric) error) error {
 	var q = []string{`SELECT
 	id, type, text, tags, extra, pid, sid, timestamp,
 	version, os, arch, sync_time, request_id,
 	sync_ip, sync_location, timestamp_db FROM metrics`}
 
-	if f.Page == 0 {
-		f.Page = 1
-	}
-
 	var args, where = filter(f)
 	var pos = len(args) + 1
 
+	if f.Cursor != nil {
+		cond, cargs := f.Cursor.Condition("sync_time", "id", pos)
+		args = append(args, cargs...)
+		pos += len(cargs)
+
+		if len(where) != 0 {
+			where += " AND "
+		}
+
+		where += cond
+	}
+
 	if len(where) != 0 {
 		q = append(q, "WHERE", where)
 	}
 
-	var order = fmt.Sprintf("ORDER BY sync_time DESC LIMIT $%d OFFSET $%d", pos, pos+1)
-	args = append(args, f.PerPage, (f.Page-1)*f.PerPage)
-	pos++
+	q = append(q, "ORDER BY sync_time DESC, id DESC")
 
-	q = append(q, order)
+	switch {
+	case f.PerPage == 0:
+	case f.Cursor == nil && f.Page > 1:
+		q = append(q, fmt.Sprintf("LIMIT $%d OFFSET $%d", pos, pos+1))
+		args = append(args, f.PerPage, (f.Page-1)*f.PerPage)
+	default:
+		q = append(q, fmt.Sprintf("LIMIT $%d", pos))
+		args = append(args, f.PerPage)
+	}
 
 	conn := db.Conn()
 	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer func() {
@@ -323,21 +597,26 @@ func List(ctx context.Context, f Filter) (ms []Metric, err error) {
 	rows, err := stmt.QueryxContext(ctx, args...)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	defer func() {
+		_ = rows.Close()
+	}()
+
 	for rows.Next() {
 		var m Metric
-		err = sqlstruct.Scan(&m, rows)
 
-		if err != nil {
-			return nil, err
+		if err = sqlstruct.Scan(&m, rows); err != nil {
+			return err
 		}
 
-		ms = append(ms, m)
+		if err = fn(m); err != nil {
+			return err
+		}
 	}
 
-	return ms, nil
+	return rows.Err()
 }
 
 func filter(f Filter) (args []interface{}, where string) {
@@ -374,9 +653,74 @@ func filter(f Filter) (args []interface{}, where string) {
 		}
 	}
 
+	if f.ASN != "" {
+		w = append(w, fmt.Sprintf("sync_asn = $%d", pos))
+		pos++
+		args = append(args, f.ASN)
+	}
+
+	if f.Organization != "" {
+		w = append(w, fmt.Sprintf("sync_org = $%d", pos))
+		pos++
+		args = append(args, f.Organization)
+	}
+
+	if !f.Since.IsZero() {
+		w = append(w, fmt.Sprintf("sync_time >= $%d", pos))
+		pos++
+		args = append(args, f.Since)
+	}
+
+	if !f.Until.IsZero() {
+		w = append(w, fmt.Sprintf("sync_time < $%d", pos))
+		pos++
+		args = append(args, f.Until)
+	}
+
+	if f.Network != "" {
+		cond, cargs := f.Network.condition(pos)
+		w = append(w, cond)
+		args = append(args, cargs...)
+	}
+
 	return args, strings.Join(w, " AND ")
 }
 
+// Match tells if the metric matches the filter, mirroring its SQL conditions, for metrics read from elsewhere
+// (i.e., archives). The text is matched as a case-insensitive substring, without wildcards.
+func (f Filter) Match(m Metric) bool {
+	var l Location
+
+	if m.SyncLocation != nil {
+		l = *m.SyncLocation
+	}
+
+	switch {
+	case f.Type != "" && m.Type != f.Type,
+		f.Text != "" && !strings.Contains(strings.ToLower(m.Text), strings.ToLower(f.Text)),
+		f.ASN != "" && l.ASN() != f.ASN,
+		f.Organization != "" && l.OrganizationName() != f.Organization,
+		f.Network != "" && ClassifyNetwork(l.ASN(), l.OrganizationName()) != f.Network:
+		return false
+	}
+
+	// the version column is NOT NULL, so the "version is NULL" condition never matches.
+	if f.Version == "" && f.NotVersion ||
+		f.Version != "" && (m.Version == f.Version) == f.NotVersion {
+		return false
+	}
+
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+
+	st, err := time.Parse(time.RFC3339Nano, m.SyncTime)
+
+	return err == nil &&
+		(f.Since.IsZero() || !st.Before(f.Since)) &&
+		(f.Until.IsZero() || st.Before(f.Until))
+}
+
 // Get metrics entry
 func Get(ctx context.Context, id string) (m Metric, err error) {
 	var q = `SELECT
@@ -485,9 +829,18 @@ func Versions(ctx context.Context) (versions []string, err error) {
 	return versions, nil
 }
 
-// MissingGeolocation returns a list of IPs where geolocation is missing.
-func MissingGeolocation(ctx context.Context) (ips []string, err error) {
-	var q = `SELECT sync_ip FROM metrics WHERE sync_location IS NULL GROUP BY sync_ip`
+// MissingGeolocation returns a list of IPs where geolocation is missing,
+// from the most recently synced. If since is not zero, only metrics synced after it are considered.
+func MissingGeolocation(ctx context.Context, since time.Time) (ips []string, err error) {
+	var q = `SELECT host(sync_ip) FROM metrics WHERE sync_location IS NULL`
+	var args []interface{}
+
+	if !since.IsZero() {
+		q += ` AND sync_time >= $1`
+		args = append(args, since)
+	}
+
+	q += ` GROUP BY sync_ip ORDER BY MAX(sync_time) DESC`
 
 	conn := db.Conn()
 	stmt, err := conn.PreparexContext(ctx, q)
@@ -500,7 +853,7 @@ func MissingGeolocation(ctx context.Context) (ips []string, err error) {
 		_ = stmt.Close()
 	}()
 
-	rows, err := stmt.QueryxContext(ctx)
+	rows, err := stmt.QueryxContext(ctx, args...)
 
 	if err != nil {
 		return nil, err
@@ -517,7 +870,7 @@ func MissingGeolocation(ctx context.Context) (ips []string, err error) {
 		ips = append(ips, ip)
 	}
 
-	return ips, nil
+	return ips, rows.Err()
 }
 
 // AddGeolocationIP adds geolocation info to metrics of a given IP without it.
@@ -528,8 +881,9 @@ func AddGeolocationIP(ctx context.Context, ip string) (updated int64, err error)
 	}
 
 	var q = `UPDATE metrics
-	SET sync_location = (SELECT cache FROM geolocation WHERE ip = $1)
-	WHERE sync_ip = $1 AND sync_location IS NULL`
+	SET ` + setLocation("geolocation.cache") + `
+	FROM geolocation
+	WHERE metrics.sync_ip = $1 AND geolocation.ip = $1 AND metrics.sync_location IS NULL`
 
 	conn := db.Conn()
 
@@ -551,3 +905,218 @@ func AddGeolocationIP(ctx context.Context, ip string) (updated int64, err error)
 
 	return res.RowsAffected()
 }
+
+// LocationCount is the number of events and sessions for a location.
+type LocationCount struct {
+	Code     string `db:"code"`
+	Events   int    `db:"events"`
+	Sessions int    `db:"sessions"`
+}
+
+// Name (human readable) of the location.
+func (l LocationCount) Name() string {
+	if l.Code == "" {
+		return "Unknown"
+	}
+
+	return countrycode2name(l.Code)
+}
+
+// Locations counts the filtered metrics by country or continent.
+func Locations(ctx context.Context, f Filter, g Grouping) (lcs []LocationCount, err error) {
+	var args, where = filter(f)
+	var q []string
+
+	switch g {
+	case GroupByCountry:
+		q = append(q, `SELECT COALESCE(sync_location->>'country', '') AS code,
+		COUNT(id) AS events, COUNT(DISTINCT sid) AS sessions FROM metrics`)
+	case GroupByContinent:
+		var codes, continents []string
+
+		for _, c := range countrycode.List("") {
+			codes = append(codes, c.Alpha2)
+			continents = append(continents, string(c.Continent))
+		}
+
+		q = append(q, fmt.Sprintf(`SELECT COALESCE(c.continent, '') AS code,
+		COUNT(id) AS events, COUNT(DISTINCT sid) AS sessions FROM metrics
+		LEFT JOIN unnest($%d::text[], $%d::text[]) AS c(country, continent)
+		ON c.country = sync_location->>'country'`, len(args)+1, len(args)+2))
+		args = append(args, pq.Array(codes), pq.Array(continents))
+	default:
+		return nil, fmt.Errorf(`invalid location grouping "%s"`, g)
+	}
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	q = append(q, "GROUP BY code ORDER BY events DESC")
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var lc LocationCount
+		err = sqlstruct.Scan(&lc, rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lcs = append(lcs, lc)
+	}
+
+	return lcs, nil
+}
+
+// SetGeolocation copies the cached geolocation info of the given IPs to their metrics without it.
+func SetGeolocation(ctx context.Context, ips []string) (updated int64, err error) {
+	var q = `UPDATE metrics
+	SET ` + setLocation("geolocation.cache") + `
+	FROM geolocation
+	WHERE metrics.sync_ip = geolocation.ip AND metrics.sync_location IS NULL
+	AND geolocation.ip = ANY($1::inet[])`
+
+	conn := db.Conn()
+
+	stmt, err := conn.PreparexContext(ctx, q)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, pq.Array(ips))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// NetworkIPs lists the IPs with metrics on a network (IP or CIDR).
+func NetworkIPs(ctx context.Context, network string) (ips []string, err error) {
+	var q = `SELECT host(sync_ip) FROM metrics WHERE sync_ip <<= $1::inet GROUP BY sync_ip`
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, q)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, network)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var ip string
+		err = rows.Scan(&ip)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ips = append(ips, ip)
+	}
+
+	return ips, rows.Err()
+}
+
+// ApplyGeolocation gets the geolocation of the IPs on a network (IP or CIDR),
+// and replaces the geolocation info of their metrics.
+func ApplyGeolocation(ctx context.Context, network string) (updated int64, err error) {
+	ips, err := NetworkIPs(ctx, network)
+
+	if err != nil {
+		return 0, err
+	}
+
+	for _, ip := range ips {
+		if _, err = geolocation.Get(ctx, ip); err != nil {
+			return 0, err
+		}
+	}
+
+	var q = `UPDATE metrics
+	SET ` + setLocation("geolocation.cache") + `
+	FROM geolocation
+	WHERE metrics.sync_ip = geolocation.ip AND metrics.sync_ip <<= $1::inet`
+
+	conn := db.Conn()
+
+	stmt, err := conn.PreparexContext(ctx, q)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, network)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ResetOverriddenGeolocation removes the geolocation info from metrics of a network (IP or CIDR)
+// that came from an override, so it can be added again.
+func ResetOverriddenGeolocation(ctx context.Context, network string) (updated int64, err error) {
+	var q = `UPDATE metrics SET sync_location = NULL, sync_asn = NULL, sync_org = NULL
+	WHERE sync_ip <<= $1::inet AND sync_location->>'provider' = 'override'`
+
+	conn := db.Conn()
+
+	stmt, err := conn.PreparexContext(ctx, q)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, network)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
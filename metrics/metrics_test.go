@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter(url.Values{
+		"type":        {"cmd"},
+		"text":        {"deploy"},
+		"version":     {"1.2.0"},
+		"not-version": {""},
+		"asn":         {"AS15169"},
+		"org":         {"Google LLC"},
+		"network":     {string(CloudNetwork)},
+		"since":       {"2018-10-01"},
+		"until":       {"2018-10-31"},
+		"page":        {"3"},
+		"archive":     {"1"},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = Filter{
+		Type:         "cmd",
+		Text:         "deploy",
+		Version:      "1.2.0",
+		NotVersion:   true,
+		ASN:          "AS15169",
+		Organization: "Google LLC",
+		Network:      CloudNetwork,
+		Since:        time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC),
+		Until:        time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC),
+		Page:         3,
+		PerPage:      100,
+		Archive:      true,
+	}
+
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("Expected filter %+v, got %+v instead", want, f)
+	}
+
+	if f.SinceDate() != "2018-10-01" || f.UntilDate() != "2018-10-31" {
+		t.Errorf("Expected dates 2018-10-01 and 2018-10-31, got %v and %v instead", f.SinceDate(), f.UntilDate())
+	}
+}
+
+func TestFilterSyncTime(t *testing.T) {
+	var since = time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	var until = time.Date(2018, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	args, where := filter(Filter{Type: "cmd", Since: since, Until: until})
+
+	if want := "type = $1 AND sync_time >= $2 AND sync_time < $3"; where != want {
+		t.Errorf("Expected condition %v, got %v instead", want, where)
+	}
+
+	if want := []interface{}{"cmd", since, until}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected arguments %v, got %v instead", want, args)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	var m = Metric{
+		Type:     "cmd",
+		Text:     "Deploy --remote",
+		Version:  "1.2.0",
+		SyncTime: "2018-10-15T10:00:00Z",
+		SyncLocation: &Location{
+			Organization: "AS15169 Google LLC",
+		},
+	}
+
+	var since = time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	var cases = []struct {
+		f    Filter
+		want bool
+	}{
+		{Filter{}, true},
+		{Filter{Type: "cmd", Text: "deploy", ASN: "AS15169", Organization: "Google LLC"}, true},
+		{Filter{Type: "debug"}, false},
+		{Filter{Text: "build"}, false},
+		{Filter{ASN: "AS16509"}, false},
+		{Filter{Version: "1.2.0"}, true},
+		{Filter{Version: "1.2.0", NotVersion: true}, false},
+		{Filter{Version: "1.1.0", NotVersion: true}, true},
+		{Filter{Since: since, Until: since.AddDate(0, 1, 0)}, true},
+		{Filter{Since: since.AddDate(0, 1, 0)}, false},
+		{Filter{Until: since}, false},
+	}
+
+	for _, c := range cases {
+		if got := c.f.Match(m); got != c.want {
+			t.Errorf("Expected match of %+v to be %v, got %v instead", c.f, c.want, got)
+		}
+	}
+}
+
+func TestParseFilterDefaults(t *testing.T) {
+	f, err := ParseFilter(url.Values{"page": {"0"}})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if f.Page != 1 || f.PerPage != 100 || f.Changed() {
+		t.Errorf("Unexpected default filter %+v", f)
+	}
+}
+
+func TestParseFilterFailure(t *testing.T) {
+	var cases = []url.Values{
+		{"page": {"x"}},
+		{"network": {"moon"}},
+		{"since": {"yesterday"}},
+		{"until": {"2018-13-01"}},
+	}
+
+	for _, c := range cases {
+		if _, err := ParseFilter(c); err == nil {
+			t.Errorf("Expected error parsing filter %v", c)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	var m = Metric{
+		ID:        "A6A2B8A1-2D3E-4F5A-9B6C-7D8E9F0A1B2C",
+		Type:      "command_exec",
+		Timestamp: "Sat Oct 20 03:00:00 +0000 2018",
+		SyncTime:  "2018-10-20T05:00:00+02:00",
+		SyncIP:    "203.0.113.1",
+	}
+
+	if err := Normalize(&m); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if m.ID != "a6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c" {
+		t.Errorf("Expected ID to be lowercase, got %v instead", m.ID)
+	}
+
+	if m.Type != "cmd" {
+		t.Errorf("Expected type command_exec to be normalized to cmd, got %v instead", m.Type)
+	}
+
+	if want := time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC); !time.Time(m.TimestampDB).Equal(want) {
+		t.Errorf("Expected timestamp to be %v, got %v instead", want, time.Time(m.TimestampDB))
+	}
+
+	if m.SyncTime != "2018-10-20T05:00:00+02:00" {
+		t.Errorf("Expected sync time to be kept, got %v instead", m.SyncTime)
+	}
+}
+
+func TestNormalizeFailure(t *testing.T) {
+	var valid = Metric{
+		ID:        "a6a2b8a1-2d3e-4f5a-9b6c-7d8e9f0a1b2c",
+		Timestamp: "Sat Oct 20 03:00:00 +0000 2018",
+		SyncIP:    "2001:db8::1",
+	}
+
+	if err := Normalize(&valid); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var cases = map[string]func(m *Metric){
+		"urn prefix":        func(m *Metric) { m.ID = "urn:uuid:" + m.ID },
+		"invalid UUID":      func(m *Metric) { m.ID = "foo" },
+		"UUID version 1":    func(m *Metric) { m.ID = "a6a2b8a1-2d3e-1f5a-9b6c-7d8e9f0a1b2c" },
+		"invalid timestamp": func(m *Metric) { m.Timestamp = "2018-10-20T03:00:00Z" },
+		"invalid sync time": func(m *Metric) { m.SyncTime = "yesterday" },
+		"missing sync IP":   func(m *Metric) { m.SyncIP = "" },
+		"invalid sync IP":   func(m *Metric) { m.SyncIP = "localhost" },
+	}
+
+	for name, change := range cases {
+		var m = valid
+		change(&m)
+
+		if err := Normalize(&m); err == nil {
+			t.Errorf("Expected error for %s, got nil instead", name)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// NetworkClass classifies the network metrics are sent from.
+type NetworkClass string
+
+const (
+	// CINetwork is a continuous integration service.
+	CINetwork NetworkClass = "ci"
+
+	// CloudNetwork is a cloud or hosting provider.
+	CloudNetwork NetworkClass = "cloud"
+
+	// EndUserNetwork is any other network (ISPs, companies, universities, etc.).
+	EndUserNetwork NetworkClass = "end-user"
+
+	// UnknownNetwork is used when the organization is unknown.
+	UnknownNetwork NetworkClass = "unknown"
+)
+
+// NetworkClasses available.
+var NetworkClasses = []NetworkClass{
+	CINetwork,
+	CloudNetwork,
+	EndUserNetwork,
+	UnknownNetwork,
+}
+
+// KnownNetwork is a cloud or CI provider.
+type KnownNetwork struct {
+	Name  string
+	Class NetworkClass
+
+	// ASNs of the provider.
+	ASNs []string
+
+	// Organizations are case-insensitive substrings of the provider organization names.
+	Organizations []string
+}
+
+// KnownNetworks are the providers classified apart from end-user networks.
+// CI services often run on public clouds, and are classified as such in this case.
+var KnownNetworks = []KnownNetwork{
+	{Name: "GitHub", Class: CINetwork, ASNs: []string{"AS36459"}, Organizations: []string{"GitHub"}},
+	{Name: "CircleCI", Class: CINetwork, Organizations: []string{"CircleCI", "Circle Internet Services"}},
+	{Name: "Travis CI", Class: CINetwork, Organizations: []string{"Travis CI"}},
+	{Name: "GitLab", Class: CINetwork, Organizations: []string{"GitLab"}},
+	{Name: "Buildkite", Class: CINetwork, Organizations: []string{"Buildkite"}},
+	{Name: "Bitrise", Class: CINetwork, Organizations: []string{"Bitrise"}},
+
+	{Name: "Amazon Web Services", Class: CloudNetwork, ASNs: []string{"AS16509", "AS14618", "AS8987"}, Organizations: []string{"Amazon"}},
+	{Name: "Google Cloud", Class: CloudNetwork, ASNs: []string{"AS15169", "AS396982"}, Organizations: []string{"Google LLC", "Google Cloud"}},
+	{Name: "Microsoft Azure", Class: CloudNetwork, ASNs: []string{"AS8075"}, Organizations: []string{"Microsoft"}},
+	{Name: "DigitalOcean", Class: CloudNetwork, ASNs: []string{"AS14061"}, Organizations: []string{"DigitalOcean"}},
+	{Name: "Linode", Class: CloudNetwork, ASNs: []string{"AS63949"}, Organizations: []string{"Linode"}},
+	{Name: "Hetzner", Class: CloudNetwork, ASNs: []string{"AS24940"}, Organizations: []string{"Hetzner"}},
+	{Name: "OVH", Class: CloudNetwork, ASNs: []string{"AS16276"}, Organizations: []string{"OVH"}},
+	{Name: "Vultr", Class: CloudNetwork, ASNs: []string{"AS20473"}, Organizations: []string{"Vultr", "Choopa"}},
+	{Name: "Oracle Cloud", Class: CloudNetwork, ASNs: []string{"AS31898"}, Organizations: []string{"Oracle"}},
+	{Name: "IBM Cloud", Class: CloudNetwork, ASNs: []string{"AS36351"}, Organizations: []string{"SoftLayer"}},
+	{Name: "Alibaba Cloud", Class: CloudNetwork, ASNs: []string{"AS45102", "AS37963"}, Organizations: []string{"Alibaba"}},
+	{Name: "Tencent Cloud", Class: CloudNetwork, ASNs: []string{"AS132203"}, Organizations: []string{"Tencent"}},
+	{Name: "Scaleway", Class: CloudNetwork, ASNs: []string{"AS12876"}, Organizations: []string{"Scaleway", "Online S.A.S."}},
+}
+
+// Match tells if the ASN or organization belongs to the provider.
+func (k KnownNetwork) Match(asn, organization string) bool {
+	for _, a := range k.ASNs {
+		if asn != "" && strings.EqualFold(asn, a) {
+			return true
+		}
+	}
+
+	organization = strings.ToLower(organization)
+
+	for _, o := range k.Organizations {
+		if organization != "" && strings.Contains(organization, strings.ToLower(o)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClassifyNetwork of an organization.
+func ClassifyNetwork(asn, organization string) NetworkClass {
+	if asn == "" && organization == "" {
+		return UnknownNetwork
+	}
+
+	for _, class := range []NetworkClass{CINetwork, CloudNetwork} {
+		for _, k := range KnownNetworks {
+			if k.Class == class && k.Match(asn, organization) {
+				return class
+			}
+		}
+	}
+
+	return EndUserNetwork
+}
+
+// Valid tells if the network class exists.
+func (n NetworkClass) Valid() bool {
+	for _, c := range NetworkClasses {
+		if n == c {
+			return true
+		}
+	}
+
+	return false
+}
+
+// knownNetworksMatch is a SQL condition matching the organizations of the known networks of a class.
+func knownNetworksMatch(class NetworkClass, pos int) (cond string, args []interface{}) {
+	var asns, patterns = []string{}, []string{}
+
+	for _, k := range KnownNetworks {
+		if k.Class != class {
+			continue
+		}
+
+		asns = append(asns, k.ASNs...)
+
+		for _, o := range k.Organizations {
+			patterns = append(patterns, "%"+o+"%")
+		}
+	}
+
+	cond = fmt.Sprintf(`(COALESCE(sync_asn, '') = ANY($%d::text[]) OR COALESCE(sync_org, '') ILIKE ANY($%d::text[]))`,
+		pos, pos+1)
+	return cond, []interface{}{pq.Array(asns), pq.Array(patterns)}
+}
+
+// condition is the SQL condition (on the metrics table) for the network class.
+// It mirrors ClassifyNetwork.
+func (n NetworkClass) condition(pos int) (cond string, args []interface{}) {
+	const unknown = "sync_asn IS NULL AND sync_org IS NULL"
+
+	if n == UnknownNetwork {
+		return unknown, nil
+	}
+
+	ci, ciArgs := knownNetworksMatch(CINetwork, pos)
+
+	switch n {
+	case CINetwork:
+		return ci, ciArgs
+	case CloudNetwork:
+		cloud, cloudArgs := knownNetworksMatch(CloudNetwork, pos+len(ciArgs))
+		return fmt.Sprintf("%s AND NOT %s", cloud, ci), append(ciArgs, cloudArgs...)
+	}
+
+	cloud, cloudArgs := knownNetworksMatch(CloudNetwork, pos+len(ciArgs))
+	return fmt.Sprintf("NOT (%s) AND NOT %s AND NOT %s", unknown, ci, cloud), append(ciArgs, cloudArgs...)
+}
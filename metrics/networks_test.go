@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocationOrganization(t *testing.T) {
+	var cases = []struct {
+		org  string
+		asn  string
+		name string
+	}{
+		{"AS15169 Google LLC", "AS15169", "Google LLC"},
+		{"AS28573", "AS28573", ""},
+		{"Office VPN", "", "Office VPN"},
+		{"", "", ""},
+	}
+
+	for _, c := range cases {
+		var l = Location{Organization: c.org}
+
+		if asn := l.ASN(); asn != c.asn {
+			t.Errorf("Expected ASN of %q to be %q, got %q instead", c.org, c.asn, asn)
+		}
+
+		if name := l.OrganizationName(); name != c.name {
+			t.Errorf("Expected organization name of %q to be %q, got %q instead", c.org, c.name, name)
+		}
+	}
+}
+
+func TestClassifyNetwork(t *testing.T) {
+	var cases = []struct {
+		asn  string
+		org  string
+		want NetworkClass
+	}{
+		{"AS16509", "Amazon.com, Inc.", CloudNetwork},
+		{"AS8075", "Microsoft Corporation", CloudNetwork},
+		{"", "DigitalOcean, LLC", CloudNetwork},
+		{"AS36459", "GitHub, Inc.", CINetwork},
+		{"AS99999", "Circle Internet Services, Inc.", CINetwork},
+		{"AS7922", "Comcast Cable Communications, LLC", EndUserNetwork},
+		{"", "Office VPN", EndUserNetwork},
+		{"", "", UnknownNetwork},
+	}
+
+	for _, c := range cases {
+		if got := ClassifyNetwork(c.asn, c.org); got != c.want {
+			t.Errorf("Expected %s %q to be classified as %s, got %s instead", c.asn, c.org, c.want, got)
+		}
+	}
+}
+
+func TestNetworkClassCondition(t *testing.T) {
+	for _, n := range NetworkClasses {
+		cond, args := n.condition(3)
+
+		if n == UnknownNetwork {
+			if len(args) != 0 {
+				t.Errorf("Expected no arguments for %s, got %v instead", n, args)
+			}
+
+			continue
+		}
+
+		if !strings.Contains(cond, "$3::text[]") {
+			t.Errorf("Expected condition for %s to start at $3, got %q instead", n, cond)
+		}
+
+		// each parameter is referenced once
+		if params := strings.Count(cond, "$"); len(args) != params {
+			t.Errorf("Expected %d arguments for %s, got %d instead", params, n, len(args))
+		}
+	}
+}
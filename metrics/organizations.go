@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/kisielk/sqlstruct"
+)
+
+// OrganizationCount is the number of events and sessions for an organization.
+type OrganizationCount struct {
+	ASN          string `db:"asn"`
+	Organization string `db:"organization"`
+	Events       int    `db:"events"`
+	Sessions     int    `db:"sessions"`
+}
+
+// Name (human readable) of the organization.
+func (o OrganizationCount) Name() string {
+	switch {
+	case o.Organization != "":
+		return o.Organization
+	case o.ASN != "":
+		return o.ASN
+	}
+
+	return "Unknown"
+}
+
+// Network class of the organization.
+func (o OrganizationCount) Network() NetworkClass {
+	return ClassifyNetwork(o.ASN, o.Organization)
+}
+
+// CountOrganizations of the filtered metrics.
+func CountOrganizations(ctx context.Context, f Filter) (int, error) {
+	var q = []string{"SELECT COUNT(*) FROM (SELECT 1 FROM metrics"}
+	var args, where = filter(f)
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	q = append(q, "GROUP BY sync_asn, sync_org) AS organizations")
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	var count int
+	err = stmt.QueryRowxContext(ctx, args...).Scan(&count)
+	return count, err
+}
+
+// Organizations ranks the organizations of the filtered metrics by sessions and events.
+func Organizations(ctx context.Context, f Filter) (ocs []OrganizationCount, err error) {
+	var q = []string{`SELECT COALESCE(sync_asn, '') AS asn, COALESCE(sync_org, '') AS organization,
+	COUNT(id) AS events, COUNT(DISTINCT sid) AS sessions FROM metrics`}
+
+	if f.Page == 0 {
+		f.Page = 1
+	}
+
+	var args, where = filter(f)
+	var pos = len(args) + 1
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	q = append(q, fmt.Sprintf(`GROUP BY sync_asn, sync_org
+	ORDER BY sessions DESC, events DESC, organization LIMIT $%d OFFSET $%d`, pos, pos+1))
+	args = append(args, f.PerPage, (f.Page-1)*f.PerPage)
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var oc OrganizationCount
+		err = sqlstruct.Scan(&oc, rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ocs = append(ocs, oc)
+	}
+
+	return ocs, rows.Err()
+}
+
+// VersionCount is the number of events and sessions for a version.
+type VersionCount struct {
+	Version  string `db:"version"`
+	Events   int    `db:"events"`
+	Sessions int    `db:"sessions"`
+}
+
+// VersionCounts counts the filtered metrics by version.
+func VersionCounts(ctx context.Context, f Filter) (vcs []VersionCount, err error) {
+	var q = []string{`SELECT version, COUNT(id) AS events, COUNT(DISTINCT sid) AS sessions FROM metrics`}
+	var args, where = filter(f)
+
+	if len(where) != 0 {
+		q = append(q, "WHERE", where)
+	}
+
+	// same order as Versions
+	q = append(q, `GROUP BY version ORDER BY
+	string_to_array(regexp_replace(version, '[^0-9.]', '', 'g'), '.')::int[] DESC,
+	version`)
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, strings.Join(q, " "))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryxContext(ctx, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var vc VersionCount
+		err = sqlstruct.Scan(&vc, rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vcs = append(vcs, vc)
+	}
+
+	return vcs, rows.Err()
+}
@@ -0,0 +1,328 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/henvic/climetrics/cursor"
+	"github.com/henvic/climetrics/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// Partitioning of the metrics table, by month (UTC) of sync_time.
+//
+// The partition_metrics migration creates metrics_partitioned and mirrors changes on metrics to it.
+// CopyPartitioned copies the existing metrics to it, in batches, and SwapPartitioned replaces metrics with it.
+type Partitioning int
+
+const (
+	// Unpartitioned metrics table.
+	Unpartitioned Partitioning = iota
+
+	// Copying metrics to metrics_partitioned.
+	Copying
+
+	// Partitioned metrics table.
+	Partitioned
+)
+
+func (p Partitioning) String() string {
+	switch p {
+	case Copying:
+		return "copying"
+	case Partitioned:
+		return "partitioned"
+	default:
+		return "unpartitioned"
+	}
+}
+
+// PartitioningState of the metrics table.
+func PartitioningState(ctx context.Context) (Partitioning, error) {
+	var copying, partitioned bool
+
+	if err := db.QueryRow(ctx, `SELECT
+	to_regclass('public.metrics_partitioned') IS NOT NULL,
+	EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass('public.metrics'))`, nil, &copying, &partitioned); err != nil {
+		return Unpartitioned, err
+	}
+
+	switch {
+	case partitioned:
+		return Partitioned, nil
+	case copying:
+		return Copying, nil
+	default:
+		return Unpartitioned, nil
+	}
+}
+
+// partitionPrefix of the monthly partitions, followed by the year and month (YYYYMM).
+const partitionPrefix = "metrics_p"
+
+// Partition of the metrics table.
+type Partition struct {
+	Name string `json:"name"`
+
+	// From and To (exclusive) sync time of the monthly partitions.
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+
+	// Default partition, for metrics synced on months without a partition.
+	Default bool `json:"default,omitempty"`
+
+	// Rows estimated from the table statistics.
+	Rows int64 `json:"rows"`
+}
+
+// Monthly partition, named metrics_pYYYYMM.
+func (p Partition) Monthly() bool {
+	return p.From != nil
+}
+
+// PartitionName of the month of t (UTC).
+func PartitionName(t time.Time) string {
+	return partitionPrefix + t.UTC().Format("200601")
+}
+
+// partitionMonth from the name of a monthly partition.
+func partitionMonth(name string) (from, to *time.Time) {
+	var month = strings.TrimPrefix(name, partitionPrefix)
+
+	if month == name || len(month) != 6 {
+		return nil, nil
+	}
+
+	f, err := time.Parse("200601", month)
+
+	if err != nil {
+		return nil, nil
+	}
+
+	t := f.AddDate(0, 1, 0)
+	return &f, &t
+}
+
+// Partitions of the metrics table (or of metrics_partitioned while copying), oldest first.
+func Partitions(ctx context.Context) (ps []Partition, err error) {
+	state, err := PartitioningState(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var parent = "public.metrics"
+
+	switch state {
+	case Unpartitioned:
+		return nil, nil
+	case Copying:
+		parent = "public.metrics_partitioned"
+	}
+
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, `SELECT c.relname, c.reltuples::bigint, pg_get_expr(c.relpartbound, c.oid) = 'DEFAULT'
+FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = to_regclass($1)
+ORDER BY c.relname`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryContext(ctx, parent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var p Partition
+
+		if err = rows.Scan(&p.Name, &p.Rows, &p.Default); err != nil {
+			return nil, err
+		}
+
+		// never analyzed
+		if p.Rows < 0 {
+			p.Rows = 0
+		}
+
+		p.From, p.To = partitionMonth(p.Name)
+		ps = append(ps, p)
+	}
+
+	return ps, rows.Err()
+}
+
+// CreatePartitions for the months from the month of from to the month of until (UTC),
+// returning how many were created. Partitions can't be created for months with metrics on the default partition.
+func CreatePartitions(ctx context.Context, from, until time.Time) (created int, err error) {
+	err = db.QueryRow(ctx, `SELECT metrics_create_partitions($1, $2)`, []interface{}{from, until}, &created)
+	return created, err
+}
+
+// partitionColumns copied to metrics_partitioned.
+const partitionColumns = `id, type, text, tags, extra, pid, sid, "timestamp", version, os, arch,
+sync_time, request_id, sync_ip, sync_location, timestamp_db, sync_asn, sync_org`
+
+// CopyPartitioned copies a batch of metrics to metrics_partitioned, most recent first, starting after the cursor.
+// It returns how many metrics were read and the cursor of the last one (nil when there is nothing left to copy).
+// Metrics changed meanwhile are mirrored by a trigger, so copying the ones copied already is a no-op.
+func CopyPartitioned(ctx context.Context, after *cursor.Cursor, batchSize int) (n int, last *cursor.Cursor, err error) {
+	var where string
+	var args = []interface{}{batchSize}
+
+	if after != nil {
+		cond, cargs := after.Condition("sync_time", "id", 2)
+		where = "WHERE " + cond
+		args = append(args, cargs...)
+	}
+
+	var q = fmt.Sprintf(`WITH batch AS (
+	SELECT %[1]s FROM metrics %[2]s ORDER BY sync_time DESC, id DESC LIMIT $1 FOR SHARE
+), copied AS (
+	INSERT INTO metrics_partitioned (%[1]s) SELECT %[1]s FROM batch ON CONFLICT DO NOTHING
+)
+SELECT COUNT(*) OVER (), sync_time, id FROM batch ORDER BY sync_time, id LIMIT 1`, partitionColumns, where)
+
+	var c cursor.Cursor
+
+	switch err = db.QueryRow(ctx, q, args, &n, &c.Time, &c.ID); {
+	case err == nil:
+		return n, &c, nil
+	case err == sql.ErrNoRows:
+		return 0, nil, nil
+	default:
+		return 0, nil, err
+	}
+}
+
+// CountPartitioned counts the metrics on metrics and metrics_partitioned, on the same snapshot.
+// They are equal when all metrics were copied.
+func CountPartitioned(ctx context.Context) (metrics, partitioned int64, err error) {
+	err = db.QueryRow(ctx, `SELECT (SELECT COUNT(*) FROM metrics), (SELECT COUNT(*) FROM metrics_partitioned)`, nil,
+		&metrics, &partitioned)
+	return metrics, partitioned, err
+}
+
+// partitionedIndexes renamed when swapping the tables.
+var partitionedIndexes = []string{
+	"pkey",
+	"sync_time_idx",
+	"type_sync_time_idx",
+	"request_idx",
+	"sync_asn_idx",
+	"sync_org_idx",
+}
+
+// SwapPartitioned replaces metrics with metrics_partitioned, keeping the former as metrics_unpartitioned.
+// Writes wait for the lock of metrics, which is only held while the tables are renamed.
+func SwapPartitioned(ctx context.Context) error {
+	var q = []string{
+		"LOCK TABLE public.metrics IN ACCESS EXCLUSIVE MODE",
+		"DROP TRIGGER metrics_mirror ON public.metrics",
+		"DROP FUNCTION public.metrics_mirror()",
+		"ALTER TABLE public.metrics RENAME TO metrics_unpartitioned",
+	}
+
+	for _, index := range partitionedIndexes {
+		q = append(q, fmt.Sprintf("ALTER INDEX public.metrics_%[1]s RENAME TO metrics_unpartitioned_%[1]s", index))
+	}
+
+	q = append(q, "ALTER TABLE public.metrics_partitioned RENAME TO metrics")
+
+	for _, index := range append(partitionedIndexes, "id_idx") {
+		q = append(q, fmt.Sprintf("ALTER INDEX public.metrics_partitioned_%[1]s RENAME TO metrics_%[1]s", index))
+	}
+
+	conn := db.Conn()
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, s := range q {
+		if _, err = tx.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("can't swap the metrics tables: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// PartitionMaintainer creates the monthly partitions of the metrics ahead of time.
+type PartitionMaintainer struct {
+	// Ahead is the number of months with partitions ahead of the current one.
+	Ahead int
+
+	// Interval between checks.
+	Interval time.Duration
+}
+
+// PartitionJob is the partition maintainer used by the server.
+var PartitionJob = &PartitionMaintainer{
+	Ahead:    3,
+	Interval: 24 * time.Hour,
+}
+
+// Validate the partition maintainer settings.
+func (p *PartitionMaintainer) Validate() error {
+	if p.Ahead < 1 {
+		return errors.New("partitions must be created at least one month ahead")
+	}
+
+	return nil
+}
+
+// Run the partition maintainer on start, and then every interval, while the server is up.
+func (p *PartitionMaintainer) Run(ctx context.Context) {
+	var ticker = time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.create(ctx); err != nil && ctx.Err() == nil {
+			log.Errorf("failed to create partitions of the metrics: %+v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *PartitionMaintainer) create(ctx context.Context) error {
+	state, err := PartitioningState(ctx)
+
+	if err != nil || state == Unpartitioned {
+		return err
+	}
+
+	var now = time.Now()
+	created, err := CreatePartitions(ctx, now, now.AddDate(0, p.Ahead, 0))
+
+	if created != 0 {
+		log.Infof("created %d partitions of the metrics", created)
+	}
+
+	return err
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPartitionName(t *testing.T) {
+	var z = time.FixedZone("UTC-3", -3*60*60)
+
+	if got := PartitionName(time.Date(2018, 10, 31, 22, 0, 0, 0, z)); got != "metrics_p201811" {
+		t.Errorf("Expected partition metrics_p201811 (UTC), got %v instead", got)
+	}
+}
+
+func TestPartitionMonth(t *testing.T) {
+	from, to := partitionMonth("metrics_p201812")
+
+	if from == nil || to == nil ||
+		!from.Equal(time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC)) ||
+		!to.Equal(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected December 2018 bounds, got %v and %v instead", from, to)
+	}
+
+	for _, name := range []string{"metrics_default", "metrics_p2018", "metrics_p201813", "archive_p201812"} {
+		if from, to := partitionMonth(name); from != nil || to != nil {
+			t.Errorf("Expected %v not to be a monthly partition, got %v and %v instead", name, from, to)
+		}
+	}
+}
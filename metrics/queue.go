@@ -0,0 +1,353 @@
+package metrics
+
+import (
+	"context"
+	"expvar"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/henvic/climetrics/geolocation"
+	log "github.com/sirupsen/logrus"
+)
+
+type queueState int
+
+const (
+	queued queueState = iota
+	inflight
+	inflightDirty // new metrics arrived while the IP was being processed
+)
+
+// GeolocationQueue adds geolocation information to metrics in the background.
+// IPs are deduplicated while they are waiting or being processed,
+// and the metrics are updated in batches.
+type GeolocationQueue struct {
+	// Workers resolving geolocation information.
+	Workers int
+
+	// Size of the queue. IPs are dropped when the queue is full.
+	Size int
+
+	// BatchSize is the maximum number of IPs updated on the metrics table at once.
+	BatchSize int
+
+	// FlushInterval is the maximum time an IP waits to be updated on the metrics table.
+	FlushInterval time.Duration
+
+	// Timeout for each geolocation request.
+	Timeout time.Duration
+
+	// Retries of failed geolocation requests, with exponential backoff and jitter.
+	Retries    int
+	RetryDelay time.Duration
+
+	// DrainTimeout is how long to keep processing queued IPs after the context is canceled.
+	DrainTimeout time.Duration
+
+	ch       chan job
+	resolved chan string
+	state    map[string]queueState
+	closed   bool
+	retrying int
+	m        sync.Mutex
+	initOnce sync.Once
+
+	stats *expvar.Map
+
+	// resolve and update are replaceable for testing
+	resolve func(ctx context.Context, ip string) error
+	update  func(ctx context.Context, ips []string) (int64, error)
+}
+
+type job struct {
+	ip      string
+	attempt int
+}
+
+// Geolocation is the queue used by the server.
+var Geolocation = &GeolocationQueue{
+	Workers:       4,
+	Size:          10000,
+	BatchSize:     100,
+	FlushInterval: 2 * time.Second,
+	Timeout:       10 * time.Second,
+	Retries:       3,
+	RetryDelay:    5 * time.Second,
+	DrainTimeout:  10 * time.Second,
+}
+
+func init() {
+	Geolocation.stats = expvar.NewMap("geolocation_queue")
+	Geolocation.stats.Set("depth", expvar.Func(func() interface{} {
+		return Geolocation.Depth()
+	}))
+}
+
+func (q *GeolocationQueue) init() {
+	q.initOnce.Do(func() {
+		q.ch = make(chan job, q.Size)
+		q.resolved = make(chan string, q.Size)
+		q.state = map[string]queueState{}
+
+		if q.stats == nil {
+			q.stats = new(expvar.Map).Init()
+		}
+
+		if q.resolve == nil {
+			q.resolve = func(ctx context.Context, ip string) error {
+				_, err := geolocation.Get(ctx, ip)
+				return err
+			}
+		}
+
+		if q.update == nil {
+			q.update = SetGeolocation
+		}
+	})
+}
+
+// Depth of the queue (IPs waiting or being processed).
+func (q *GeolocationQueue) Depth() int {
+	q.init()
+	q.m.Lock()
+	defer q.m.Unlock()
+	return len(q.state)
+}
+
+// Enqueue IP to have geolocation added to its metrics.
+// It returns false if the IP is dropped because the queue is full or closed.
+func (q *GeolocationQueue) Enqueue(ip string) bool {
+	q.init()
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	if q.closed {
+		q.stats.Add("dropped", 1)
+		return false
+	}
+
+	switch s, ok := q.state[ip]; {
+	case ok && s == queued:
+		q.stats.Add("deduplicated", 1)
+		return true
+	case ok:
+		q.state[ip] = inflightDirty
+		q.stats.Add("deduplicated", 1)
+		return true
+	}
+
+	select {
+	case q.ch <- job{ip: ip}:
+		q.state[ip] = queued
+		q.stats.Add("enqueued", 1)
+		return true
+	default:
+		q.stats.Add("dropped", 1)
+		log.Errorf("geolocation queue is full: dropping IP %s", ip)
+		return false
+	}
+}
+
+// Run workers until the context is canceled, then drain the queue.
+func (q *GeolocationQueue) Run(ctx context.Context) {
+	q.init()
+
+	var workers sync.WaitGroup
+	var batcher = make(chan struct{})
+
+	drain, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-ctx.Done()
+
+		q.m.Lock()
+		q.closed = true
+		q.m.Unlock()
+
+		time.AfterFunc(q.DrainTimeout, cancel)
+	}()
+
+	for i := 0; i < q.Workers; i++ {
+		workers.Add(1)
+
+		go func() {
+			defer workers.Done()
+			q.work(ctx, drain)
+		}()
+	}
+
+	go func() {
+		defer close(batcher)
+		q.batch(drain)
+	}()
+
+	workers.Wait()
+	close(q.resolved)
+	<-batcher
+}
+
+func (q *GeolocationQueue) work(ctx, drain context.Context) {
+	for {
+		select {
+		case j := <-q.ch:
+			q.process(ctx, drain, j)
+		case <-ctx.Done():
+			q.workRemaining(ctx, drain)
+			return
+		}
+	}
+}
+
+func (q *GeolocationQueue) workRemaining(ctx, drain context.Context) {
+	for {
+		select {
+		case j := <-q.ch:
+			q.process(ctx, drain, j)
+			continue
+		default:
+		}
+
+		if q.pendingRetries() == 0 && len(q.ch) == 0 {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (q *GeolocationQueue) pendingRetries() int {
+	q.m.Lock()
+	defer q.m.Unlock()
+	return q.retrying
+}
+
+func (q *GeolocationQueue) process(ctx, drain context.Context, j job) {
+	q.m.Lock()
+	q.state[j.ip] = inflight
+	q.m.Unlock()
+
+	if drain.Err() != nil {
+		q.done(j.ip)
+		q.stats.Add("abandoned", 1)
+		return
+	}
+
+	reqCtx, cancel := context.WithTimeout(drain, q.Timeout)
+	defer cancel()
+
+	err := q.resolve(reqCtx, j.ip)
+
+	if err == nil {
+		q.resolved <- j.ip
+		return
+	}
+
+	if j.attempt >= q.Retries || ctx.Err() != nil || geolocation.Permanent(err) {
+		log.Errorf("can't add geolocation for IP %s: %+v", j.ip, err)
+		q.stats.Add("failed", 1)
+		q.done(j.ip)
+		return
+	}
+
+	log.Debugf("retrying geolocation for IP %s: %+v", j.ip, err)
+	q.stats.Add("retries", 1)
+	q.retry(ctx, job{ip: j.ip, attempt: j.attempt + 1})
+}
+
+func (q *GeolocationQueue) retry(ctx context.Context, j job) {
+	var delay = q.RetryDelay << uint(j.attempt-1)
+
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)))
+	}
+
+	q.m.Lock()
+	q.retrying++
+	q.m.Unlock()
+
+	go func() {
+		var t = time.NewTimer(delay)
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+
+		q.m.Lock()
+		q.state[j.ip] = queued
+		q.m.Unlock()
+
+		// the queue might be full: requeue on a best-effort basis.
+		select {
+		case q.ch <- j:
+		default:
+			log.Errorf("geolocation queue is full: dropping IP %s", j.ip)
+			q.stats.Add("dropped", 1)
+			q.done(j.ip)
+		}
+
+		q.m.Lock()
+		q.retrying--
+		q.m.Unlock()
+	}()
+}
+
+func (q *GeolocationQueue) batch(ctx context.Context) {
+	var ips []string
+	var ticker = time.NewTicker(q.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ip, ok := <-q.resolved:
+			if !ok {
+				q.flush(ctx, ips)
+				return
+			}
+
+			ips = append(ips, ip)
+
+			if len(ips) < q.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		q.flush(ctx, ips)
+		ips = nil
+	}
+}
+
+func (q *GeolocationQueue) flush(ctx context.Context, ips []string) {
+	if len(ips) == 0 {
+		return
+	}
+
+	updated, err := q.update(ctx, ips)
+
+	if err != nil {
+		log.Errorf("can't add geolocation to metrics of %d IPs: %+v", len(ips), err)
+		q.stats.Add("failed", int64(len(ips)))
+	} else {
+		log.Debugf("geolocation added to %d metrics of %d IPs", updated, len(ips))
+		q.stats.Add("updated", updated)
+	}
+
+	for _, ip := range ips {
+		q.done(ip)
+	}
+}
+
+func (q *GeolocationQueue) done(ip string) {
+	q.m.Lock()
+	var s = q.state[ip]
+	delete(q.state, ip)
+	q.m.Unlock()
+
+	if s == inflightDirty {
+		q.Enqueue(ip)
+	}
+}
@@ -0,0 +1,142 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeGeolocation struct {
+	resolved map[string]int
+	updated  []string
+	failures map[string]int
+	block    chan struct{}
+	m        sync.Mutex
+}
+
+func (f *fakeGeolocation) resolve(ctx context.Context, ip string) error {
+	if f.block != nil {
+		<-f.block
+	}
+
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.resolved[ip]++
+
+	if f.failures[ip] != 0 {
+		f.failures[ip]--
+		return errors.New("temporary failure")
+	}
+
+	return nil
+}
+
+func (f *fakeGeolocation) update(ctx context.Context, ips []string) (int64, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	f.updated = append(f.updated, ips...)
+	return int64(len(ips)), nil
+}
+
+func newTestQueue(f *fakeGeolocation) *GeolocationQueue {
+	return &GeolocationQueue{
+		Workers:       2,
+		Size:          100,
+		BatchSize:     10,
+		FlushInterval: time.Millisecond,
+		Timeout:       time.Second,
+		Retries:       2,
+		RetryDelay:    time.Millisecond,
+		DrainTimeout:  time.Second,
+
+		resolve: f.resolve,
+		update:  f.update,
+	}
+}
+
+func TestGeolocationQueueDeduplicates(t *testing.T) {
+	var f = &fakeGeolocation{
+		resolved: map[string]int{},
+		block:    make(chan struct{}),
+	}
+
+	var q = newTestQueue(f)
+
+	for i := 0; i < 5; i++ {
+		q.Enqueue("203.0.113.1")
+		q.Enqueue("203.0.113.2")
+	}
+
+	if depth := q.Depth(); depth != 2 {
+		t.Errorf("Expected queue depth to be 2, got %d instead", depth)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	close(f.block)
+
+	// canceled context: the queue must be drained before returning.
+	q.Run(ctx)
+
+	if f.resolved["203.0.113.1"] != 1 || f.resolved["203.0.113.2"] != 1 {
+		t.Errorf("Expected each IP to be resolved once, got %v instead", f.resolved)
+	}
+
+	sort.Strings(f.updated)
+
+	if len(f.updated) != 2 || f.updated[0] != "203.0.113.1" || f.updated[1] != "203.0.113.2" {
+		t.Errorf("Expected both IPs to be updated, got %v instead", f.updated)
+	}
+
+	if depth := q.Depth(); depth != 0 {
+		t.Errorf("Expected queue to be empty, got depth %d instead", depth)
+	}
+
+	if q.Enqueue("203.0.113.3") {
+		t.Error("Expected IP to be dropped after the queue is closed")
+	}
+}
+
+func TestGeolocationQueueRetries(t *testing.T) {
+	var f = &fakeGeolocation{
+		resolved: map[string]int{},
+		failures: map[string]int{
+			"203.0.113.1": 2,
+			"203.0.113.2": 5,
+		},
+	}
+
+	var q = newTestQueue(f)
+	q.Enqueue("203.0.113.1")
+	q.Enqueue("203.0.113.2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var done = make(chan struct{})
+
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+
+	for q.Depth() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+
+	if f.resolved["203.0.113.1"] != 3 {
+		t.Errorf("Expected IP to be resolved after 2 retries, got %d attempts instead", f.resolved["203.0.113.1"])
+	}
+
+	if f.resolved["203.0.113.2"] != 3 {
+		t.Errorf("Expected IP to give up after 2 retries, got %d attempts instead", f.resolved["203.0.113.2"])
+	}
+
+	if len(f.updated) != 1 || f.updated[0] != "203.0.113.1" {
+		t.Errorf("Expected only the resolved IP to be updated, got %v instead", f.updated)
+	}
+}
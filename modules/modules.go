@@ -4,6 +4,9 @@ import (
 	// diagnostics routes
 	_ "github.com/henvic/climetrics/diagnostics/handlers"
 
+	// geolocation routes
+	_ "github.com/henvic/climetrics/geolocation/handlers"
+
 	// metrics routes
 	_ "github.com/henvic/climetrics/metrics/handlers"
 
@@ -12,4 +15,10 @@ import (
 
 	// auth routes
 	_ "github.com/henvic/climetrics/auth/handlers"
+
+	// audit log routes
+	_ "github.com/henvic/climetrics/audit/handlers"
+
+	// JSON API routes
+	_ "github.com/henvic/climetrics/api"
 )
@@ -0,0 +1,189 @@
+package modules
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/api"
+	"github.com/henvic/climetrics/auth/tokens"
+	"github.com/henvic/climetrics/server"
+	"github.com/henvic/climetrics/users"
+	"github.com/lib/pq"
+)
+
+type access int
+
+const (
+	public access = iota
+	authenticated
+	restricted
+	token
+)
+
+// routes lists every route and who can access it.
+var routes = []struct {
+	path       string
+	access     access
+	permission users.Permission
+	member     bool
+}{
+	{"/", public, "", true},
+	{"/static", public, "", true},
+	{"/login", public, "", true},
+	{"/login/oidc", public, "", true},
+	{"/login/oidc/callback", public, "", true},
+	{"/login/two-factor", public, "", true},
+	{"/password/forgot", public, "", true},
+	{"/password/reset/{token}", public, "", true},
+	{"/invitation/{token}", public, "", true},
+	{"/account", authenticated, "", true},
+	{"/account/tokens", authenticated, "", true},
+	{"/account/tokens/{id}/revoke", authenticated, "", true},
+	{"/account/two-factor", authenticated, "", true},
+	{"/account/sessions", authenticated, "", true},
+	{"/account/sessions/revoke", authenticated, "", true},
+	{"/account/sessions/{id}/revoke", authenticated, "", true},
+	{"/logout", authenticated, "", true},
+	{"/metrics", restricted, users.ViewMetrics, true},
+	{"/metrics/bulk", public, "", true},
+	{"/metrics/locations", restricted, users.ViewMetrics, true},
+	{"/metrics/organizations", restricted, users.ViewMetrics, true},
+	{"/metrics/organizations/versions", restricted, users.ViewMetrics, true},
+	{"/metrics/export", restricted, users.ViewMetrics, true},
+	{"/metrics/{id}", restricted, users.ViewMetrics, true},
+	{"/diagnostics/report", public, "", true},
+	{"/diagnostics", restricted, users.ViewDiagnostics, true},
+	{"/diagnostics/export", restricted, users.ViewDiagnostics, true},
+	{"/diagnostics/{id}", restricted, users.ViewDiagnostics, true},
+	{"/geolocation", restricted, users.ManageSettings, false},
+	{"/geolocation/refresh", restricted, users.ManageSettings, false},
+	{"/geolocation/overrides", restricted, users.ManageSettings, false},
+	{"/geolocation/overrides/{id}/apply", restricted, users.ManageSettings, false},
+	{"/geolocation/overrides/{id}/delete", restricted, users.ManageSettings, false},
+	{"/users", restricted, users.ManageUsers, false},
+	{"/users/add", restricted, users.ManageUsers, false},
+	{"/users/two-factor", restricted, users.ManageUsers, false},
+	{"/users/{user_id}", restricted, users.ManageUsers, false},
+	{"/users/{user_id}/two-factor/reset", restricted, users.ManageUsers, false},
+	{"/users/{user_id}/unlock", restricted, users.ManageUsers, false},
+	{"/users/{user_id}/invite", restricted, users.ManageUsers, false},
+	{"/users/{user_id}/sessions/revoke", restricted, users.ManageUsers, false},
+	{"/users/{user_id}/sessions/{id}/revoke", restricted, users.ManageUsers, false},
+	{"/audit", restricted, users.ViewAuditLog, false},
+	{"/audit/export", restricted, users.ViewAuditLog, false},
+	{"/api/v1/metrics", token, users.ViewMetrics, true},
+	{"/api/v1/metrics/{id}", token, users.ViewMetrics, true},
+	{"/api/v1/diagnostics", token, users.ViewDiagnostics, true},
+	{"/api/v1/diagnostics/{id}", token, users.ViewDiagnostics, true},
+	{"/api/v1/users", token, users.ManageUsers, false},
+	{"/api/v1/users/{user_id}", token, users.ManageUsers, false},
+	{"/api/", public, "", true},
+}
+
+func handlers(t *testing.T) map[string]http.Handler {
+	var hs = map[string]http.Handler{}
+
+	err := server.Instance.Mux().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+
+		if err != nil {
+			return err
+		}
+
+		hs[path] = route.GetHandler()
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error walking routes, got %v instead", err)
+	}
+
+	return hs
+}
+
+func TestEveryRouteIsListed(t *testing.T) {
+	var listed = map[string]bool{}
+
+	for _, r := range routes {
+		listed[r.path] = true
+	}
+
+	for path := range handlers(t) {
+		if !listed[path] {
+			t.Errorf("Route %s is not listed on the access control tests", path)
+		}
+	}
+}
+
+func TestRouteAccess(t *testing.T) {
+	var hs = handlers(t)
+
+	for _, r := range routes {
+		h, ok := hs[r.path]
+
+		if !ok {
+			t.Errorf("Route %s not found", r.path)
+			continue
+		}
+
+		switch handler := h.(type) {
+		case *server.PermissionHandler:
+			if r.access != restricted {
+				t.Errorf("Expected route %s to not require a permission, got %s instead", r.path, handler.Permission)
+				continue
+			}
+
+			if handler.Permission != r.permission {
+				t.Errorf("Expected route %s to require permission %s, got %s instead", r.path, r.permission, handler.Permission)
+			}
+
+			var roles = map[string]bool{
+				users.Admin:   true,
+				users.Member:  r.member,
+				users.Revoked: false,
+			}
+
+			for role, want := range roles {
+				if got := handler.Allowed(users.User{Role: role}); got != want {
+					t.Errorf("Expected role %s access to %s to be %v, got %v instead", role, r.path, want, got)
+				}
+			}
+		case *api.Handler:
+			if r.access != token {
+				t.Errorf("Expected route %s to not require a token", r.path)
+				continue
+			}
+
+			if handler.Scope.Permission() != r.permission {
+				t.Errorf("Expected route %s to require a scope for permission %s, got %s instead", r.path, r.permission, handler.Scope)
+			}
+
+			var all = tokens.Token{Scopes: pq.StringArray{"metrics:read", "diagnostics:read", "users:read"}}
+
+			var roles = map[string]bool{
+				users.Admin:   true,
+				users.Member:  r.member,
+				users.Revoked: false,
+			}
+
+			for role, want := range roles {
+				if got := handler.Allowed(all, users.User{Role: role}); got != want {
+					t.Errorf("Expected role %s access to %s with a token to be %v, got %v instead", role, r.path, want, got)
+				}
+			}
+
+			if handler.Allowed(tokens.Token{}, users.User{Role: users.Admin}) {
+				t.Errorf("Expected route %s to require a token with the %s scope", r.path, handler.Scope)
+			}
+		case server.AuthenticatedHandler:
+			if r.access != authenticated {
+				t.Errorf("Expected route %s to require authentication only", r.path)
+			}
+		default:
+			if r.access != public {
+				t.Errorf("Expected route %s to be restricted, got public handler %T instead", r.path, h)
+			}
+		}
+	}
+}
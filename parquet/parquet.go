@@ -0,0 +1,336 @@
+// Package parquet writes Apache Parquet files with string columns.
+//
+// Rows are buffered and written one row group at a time, so large datasets can be streamed
+// with bounded memory. Every column is a required UTF-8 string, stored with the plain encoding
+// on a single data page for each row group, optionally compressed with gzip.
+//
+// See https://github.com/apache/parquet-format for the file format.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Codec for compressing pages.
+type Codec int32
+
+// Codecs available.
+const (
+	Uncompressed Codec = 0
+	Gzip         Codec = 2
+)
+
+const magic = "PAR1"
+
+// Row group limits: a row group is written when either is reached.
+const (
+	DefaultRowGroupRows  = 10000
+	DefaultRowGroupBytes = 64 << 20
+)
+
+// Values of the format used by the writer.
+const (
+	typeByteArray      = 6
+	repetitionRequired = 0
+	convertedUTF8      = 0
+	pageTypeData       = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+)
+
+// Writer of a Parquet file.
+type Writer struct {
+	// RowGroupRows and RowGroupBytes limit the size of row groups (before compression).
+	RowGroupRows  int
+	RowGroupBytes int
+
+	w       *countingWriter
+	columns []string
+	codec   Codec
+
+	metadata map[string]string
+
+	values [][]byte
+	rows   int
+	size   int
+
+	numRows   int64
+	rowGroups []rowGroup
+	closed    bool
+}
+
+type rowGroup struct {
+	columns   []columnChunk
+	numRows   int64
+	totalSize int64
+}
+
+type columnChunk struct {
+	name             string
+	offset           int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewWriter for a file with the given columns.
+func NewWriter(w io.Writer, columns []string, codec Codec) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: no columns")
+	}
+
+	var seen = map[string]bool{}
+
+	for _, c := range columns {
+		if c == "" || seen[c] {
+			return nil, fmt.Errorf(`parquet: invalid or duplicated column "%s"`, c)
+		}
+
+		seen[c] = true
+	}
+
+	if codec != Uncompressed && codec != Gzip {
+		return nil, fmt.Errorf("parquet: unsupported codec %d", codec)
+	}
+
+	var pw = &Writer{
+		RowGroupRows:  DefaultRowGroupRows,
+		RowGroupBytes: DefaultRowGroupBytes,
+
+		w:        &countingWriter{w: w},
+		columns:  columns,
+		codec:    codec,
+		metadata: map[string]string{},
+		values:   make([][]byte, len(columns)),
+	}
+
+	_, err := io.WriteString(pw.w, magic)
+	return pw, err
+}
+
+// SetMetadata sets a key-value pair on the footer of the file.
+func (w *Writer) SetMetadata(key, value string) {
+	w.metadata[key] = value
+}
+
+// Write a row, with a value for each column.
+func (w *Writer) Write(row []string) error {
+	if w.closed {
+		return errors.New("parquet: writer is closed")
+	}
+
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: expected %d values, got %d instead", len(w.columns), len(row))
+	}
+
+	var length [4]byte
+
+	for i, v := range row {
+		binary.LittleEndian.PutUint32(length[:], uint32(len(v)))
+		w.values[i] = append(w.values[i], length[:]...)
+		w.values[i] = append(w.values[i], v...)
+		w.size += len(v) + 4
+	}
+
+	w.rows++
+
+	if w.rows >= w.RowGroupRows || w.size >= w.RowGroupBytes {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// Flush the buffered rows as a row group.
+func (w *Writer) Flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+
+	var rg = rowGroup{
+		numRows: int64(w.rows),
+	}
+
+	for i, name := range w.columns {
+		c, err := w.writeColumn(name, w.values[i], w.rows)
+
+		if err != nil {
+			return err
+		}
+
+		rg.columns = append(rg.columns, c)
+		rg.totalSize += c.uncompressedSize
+		w.values[i] = w.values[i][:0]
+	}
+
+	w.rowGroups = append(w.rowGroups, rg)
+	w.numRows += rg.numRows
+	w.rows, w.size = 0, 0
+	return nil
+}
+
+func (w *Writer) writeColumn(name string, data []byte, n int) (c columnChunk, err error) {
+	var page = data
+
+	if w.codec == Gzip {
+		var buf bytes.Buffer
+		var gz = gzip.NewWriter(&buf)
+
+		if _, err = gz.Write(data); err != nil {
+			return c, err
+		}
+
+		if err = gz.Close(); err != nil {
+			return c, err
+		}
+
+		page = buf.Bytes()
+	}
+
+	var h compact
+	h.begin()
+	h.i32Field(1, pageTypeData)
+	h.i32Field(2, int32(len(data)))
+	h.i32Field(3, int32(len(page)))
+	h.structField(5)
+	h.i32Field(1, int32(n))
+	h.i32Field(2, encodingPlain)
+	h.i32Field(3, encodingRLE)
+	h.i32Field(4, encodingRLE)
+	h.end()
+	h.end()
+
+	c = columnChunk{
+		name:             name,
+		offset:           w.w.n,
+		numValues:        int64(n),
+		uncompressedSize: int64(len(h.b) + len(data)),
+		compressedSize:   int64(len(h.b) + len(page)),
+	}
+
+	if _, err = w.w.Write(h.b); err != nil {
+		return c, err
+	}
+
+	_, err = w.w.Write(page)
+	return c, err
+}
+
+// Close the file, writing any buffered rows and the footer.
+// It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	w.closed = true
+
+	var footer = w.footer()
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+
+	for _, b := range [][]byte{footer, length[:], []byte(magic)} {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// footer with the FileMetaData.
+func (w *Writer) footer() []byte {
+	var m compact
+	m.begin()
+	m.i32Field(1, 1)
+
+	m.listField(2, typeStruct, len(w.columns)+1)
+	m.begin()
+	m.binaryField(4, "schema")
+	m.i32Field(5, int32(len(w.columns)))
+	m.end()
+
+	for _, c := range w.columns {
+		m.begin()
+		m.i32Field(1, typeByteArray)
+		m.i32Field(3, repetitionRequired)
+		m.binaryField(4, c)
+		m.i32Field(6, convertedUTF8)
+		m.end()
+	}
+
+	m.i64Field(3, w.numRows)
+
+	m.listField(4, typeStruct, len(w.rowGroups))
+
+	for _, rg := range w.rowGroups {
+		m.begin()
+		m.listField(1, typeStruct, len(rg.columns))
+
+		for _, c := range rg.columns {
+			m.begin()
+			m.i64Field(2, c.offset)
+			m.structField(3)
+			m.i32Field(1, typeByteArray)
+			m.listField(2, typeI32, 2)
+			m.i32(encodingPlain)
+			m.i32(encodingRLE)
+			m.listField(3, typeBinary, 1)
+			m.binary(c.name)
+			m.i32Field(4, int32(w.codec))
+			m.i64Field(5, c.numValues)
+			m.i64Field(6, c.uncompressedSize)
+			m.i64Field(7, c.compressedSize)
+			m.i64Field(9, c.offset)
+			m.end()
+			m.end()
+		}
+
+		m.i64Field(2, rg.totalSize)
+		m.i64Field(3, rg.numRows)
+		m.end()
+	}
+
+	if len(w.metadata) != 0 {
+		var keys []string
+
+		for k := range w.metadata {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+		m.listField(5, typeStruct, len(keys))
+
+		for _, k := range keys {
+			m.begin()
+			m.binaryField(1, k)
+			m.binaryField(2, w.metadata[k])
+			m.end()
+		}
+	}
+
+	m.binaryField(6, "climetrics")
+	m.end()
+	return m.b
+}
@@ -0,0 +1,262 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decoder of the Thrift compact protocol, reading structs as maps of field IDs to values.
+type decoder struct {
+	b   []byte
+	pos int
+}
+
+func (d *decoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.b[d.pos:])
+	d.pos += n
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	var u = d.uvarint()
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (d *decoder) value(t byte) interface{} {
+	switch t {
+	case typeI32, typeI64:
+		return d.varint()
+	case typeBinary:
+		var n = int(d.uvarint())
+		var s = string(d.b[d.pos : d.pos+n])
+		d.pos += n
+		return s
+	case typeList:
+		var h = d.b[d.pos]
+		d.pos++
+		var n = int(h >> 4)
+
+		if n == 15 {
+			n = int(d.uvarint())
+		}
+
+		var list = []interface{}{}
+
+		for i := 0; i < n; i++ {
+			list = append(list, d.value(h&0x0f))
+		}
+
+		return list
+	case typeStruct:
+		var s = map[int16]interface{}{}
+		var last int16
+
+		for {
+			var h = d.b[d.pos]
+			d.pos++
+
+			if h == 0 {
+				return s
+			}
+
+			var id = last + int16(h>>4)
+
+			if h>>4 == 0 {
+				id = int16(d.varint())
+			}
+
+			s[id] = d.value(h & 0x0f)
+			last = id
+		}
+	}
+
+	panic(fmt.Sprintf("unexpected type %d", t))
+}
+
+type file struct {
+	metadata map[int16]interface{}
+	columns  map[string][]string
+}
+
+// read file, decoding the values of the columns.
+func read(t *testing.T, b []byte) file {
+	if !bytes.HasPrefix(b, []byte(magic)) || !bytes.HasSuffix(b, []byte(magic)) {
+		t.Fatalf("Expected file to start and end with %s", magic)
+	}
+
+	var length = int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	var d = &decoder{b: b[len(b)-8-length : len(b)-8]}
+	var f = file{
+		metadata: d.value(typeStruct).(map[int16]interface{}),
+		columns:  map[string][]string{},
+	}
+
+	if d.pos != length {
+		t.Fatalf("Expected metadata to have %d bytes, got %d instead", length, d.pos)
+	}
+
+	for _, rg := range f.metadata[4].([]interface{}) {
+		for _, c := range rg.(map[int16]interface{})[1].([]interface{}) {
+			var meta = c.(map[int16]interface{})[3].(map[int16]interface{})
+			var name = meta[3].([]interface{})[0].(string)
+			var pd = &decoder{b: b, pos: int(meta[9].(int64))}
+			var header = pd.value(typeStruct).(map[int16]interface{})
+			var page = b[pd.pos : pd.pos+int(header[3].(int64))]
+
+			if Codec(meta[4].(int64)) == Gzip {
+				r, err := gzip.NewReader(bytes.NewReader(page))
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if page, err = ioutil.ReadAll(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if int64(len(page)) != header[2].(int64) {
+				t.Errorf("Expected page to have %d bytes, got %d instead", header[2], len(page))
+			}
+
+			var n = header[5].(map[int16]interface{})[1].(int64)
+
+			for i := int64(0); i < n; i++ {
+				var l = int(binary.LittleEndian.Uint32(page))
+				f.columns[name] = append(f.columns[name], string(page[4:4+l]))
+				page = page[4+l:]
+			}
+		}
+	}
+
+	return f
+}
+
+func write(t *testing.T, codec Codec, rowGroupRows int, rows [][]string) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []string{"id", "name"}, codec)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	w.RowGroupRows = rowGroupRows
+	w.SetMetadata("dataset", "metrics")
+
+	for _, row := range rows {
+		if err = w.Write(row); err != nil {
+			t.Fatalf("Expected no error, got %v instead", err)
+		}
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestWriter(t *testing.T) {
+	var rows [][]string
+	var want = map[string][]string{}
+
+	for i := 0; i < 40; i++ {
+		var row = []string{fmt.Sprint(i), strings.Repeat("é", i)}
+		rows = append(rows, row)
+		want["id"] = append(want["id"], row[0])
+		want["name"] = append(want["name"], row[1])
+	}
+
+	for _, codec := range []Codec{Uncompressed, Gzip} {
+		var f = read(t, write(t, codec, 16, rows))
+
+		if !reflect.DeepEqual(f.columns, want) {
+			t.Errorf("Expected columns to be %v, got %v instead", want, f.columns)
+		}
+
+		if f.metadata[3] != int64(40) {
+			t.Errorf("Expected 40 rows, got %v instead", f.metadata[3])
+		}
+
+		if rgs := f.metadata[4].([]interface{}); len(rgs) != 3 {
+			t.Errorf("Expected 3 row groups, got %d instead", len(rgs))
+		}
+
+		var schema = f.metadata[2].([]interface{})
+
+		if len(schema) != 3 || schema[0].(map[int16]interface{})[5] != int64(2) || schema[2].(map[int16]interface{})[4] != "name" {
+			t.Errorf("Expected schema with the root and 2 columns, got %v instead", schema)
+		}
+
+		var kv = f.metadata[5].([]interface{})[0].(map[int16]interface{})
+
+		if kv[1] != "dataset" || kv[2] != "metrics" {
+			t.Errorf("Expected key-value metadata, got %v instead", kv)
+		}
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	var f = read(t, write(t, Uncompressed, 10, nil))
+
+	if f.metadata[3] != int64(0) || len(f.metadata[4].([]interface{})) != 0 || len(f.columns) != 0 {
+		t.Errorf("Expected empty file, got %v instead", f.metadata)
+	}
+}
+
+func TestWriterFailure(t *testing.T) {
+	if _, err := NewWriter(ioutil.Discard, nil, Uncompressed); err == nil {
+		t.Errorf("Expected error without columns, got nil instead")
+	}
+
+	if _, err := NewWriter(ioutil.Discard, []string{"a", "a"}, Uncompressed); err == nil {
+		t.Errorf("Expected error for duplicated columns, got nil instead")
+	}
+
+	if _, err := NewWriter(ioutil.Discard, []string{"a"}, Codec(1)); err == nil {
+		t.Errorf("Expected error for unsupported codec, got nil instead")
+	}
+
+	w, err := NewWriter(ioutil.Discard, []string{"a"}, Uncompressed)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	if err = w.Write([]string{"a", "b"}); err == nil {
+		t.Errorf("Expected error writing too many values, got nil instead")
+	}
+
+	_ = w.Close()
+
+	if err = w.Write([]string{"a"}); err == nil {
+		t.Errorf("Expected error writing after closing, got nil instead")
+	}
+}
+
+func TestCompactLongForms(t *testing.T) {
+	var c compact
+	c.begin()
+	c.i32Field(1, -1)
+	c.i64Field(20, 300)
+	c.listField(21, typeI32, 20)
+
+	for i := 0; i < 20; i++ {
+		c.i32(int32(i))
+	}
+
+	c.end()
+
+	var d = &decoder{b: c.b}
+	var s = d.value(typeStruct).(map[int16]interface{})
+
+	if s[1] != int64(-1) || s[20] != int64(300) || len(s[21].([]interface{})) != 20 || s[21].([]interface{})[19] != int64(19) {
+		t.Errorf("Unexpected decoded struct %v", s)
+	}
+}
@@ -0,0 +1,99 @@
+package parquet
+
+// Types of the Thrift compact protocol used by the metadata.
+const (
+	typeI32    = 5
+	typeI64    = 6
+	typeBinary = 8
+	typeList   = 9
+	typeStruct = 12
+)
+
+// compact encodes Thrift structs with the compact protocol.
+type compact struct {
+	b []byte
+
+	// last field ID of each struct being written.
+	last []int16
+}
+
+// begin a struct, either after its field header or as an element of a list.
+func (c *compact) begin() {
+	c.last = append(c.last, 0)
+}
+
+// end a struct.
+func (c *compact) end() {
+	c.b = append(c.b, 0)
+	c.last = c.last[:len(c.last)-1]
+}
+
+func (c *compact) field(id int16, t byte) {
+	var last = &c.last[len(c.last)-1]
+
+	if d := id - *last; d > 0 && d <= 15 {
+		c.b = append(c.b, byte(d)<<4|t)
+	} else {
+		c.b = append(c.b, t)
+		c.varint(int64(id))
+	}
+
+	*last = id
+}
+
+func (c *compact) uvarint(v uint64) {
+	for v >= 0x80 {
+		c.b = append(c.b, byte(v)|0x80)
+		v >>= 7
+	}
+
+	c.b = append(c.b, byte(v))
+}
+
+// varint with zigzag encoding.
+func (c *compact) varint(v int64) {
+	c.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (c *compact) i32(v int32) {
+	c.varint(int64(v))
+}
+
+func (c *compact) binary(s string) {
+	c.uvarint(uint64(len(s)))
+	c.b = append(c.b, s...)
+}
+
+func (c *compact) i32Field(id int16, v int32) {
+	c.field(id, typeI32)
+	c.i32(v)
+}
+
+func (c *compact) i64Field(id int16, v int64) {
+	c.field(id, typeI64)
+	c.varint(v)
+}
+
+func (c *compact) binaryField(id int16, s string) {
+	c.field(id, typeBinary)
+	c.binary(s)
+}
+
+// structField header, followed by the fields of the struct and end.
+func (c *compact) structField(id int16) {
+	c.field(id, typeStruct)
+	c.begin()
+}
+
+// listField header, followed by n elements of the given type.
+func (c *compact) listField(id int16, elem byte, n int) {
+	c.field(id, typeList)
+
+	if n < 15 {
+		c.b = append(c.b, byte(n)<<4|elem)
+		return
+	}
+
+	c.b = append(c.b, 0xf0|elem)
+	c.uvarint(uint64(n))
+}
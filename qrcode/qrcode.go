@@ -0,0 +1,503 @@
+// Package qrcode encodes text as QR codes (byte mode, error correction level M, versions 1 to 10).
+// It is meant for short content, such as otpauth:// URIs for two-factor authentication.
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// ErrTooLong is returned when the content doesn't fit on the largest supported version.
+var ErrTooLong = errors.New("content too long for a QR code")
+
+// version of a QR code, with its error correction level M block structure.
+type version struct {
+	number int
+
+	// ecPerBlock is the number of error correction codewords of each block.
+	ecPerBlock int
+
+	// blocks is the data codewords length of each block.
+	blocks []int
+
+	// alignment pattern center positions.
+	alignment []int
+}
+
+var versions = []version{
+	{1, 10, []int{16}, nil},
+	{2, 16, []int{28}, []int{6, 18}},
+	{3, 26, []int{44}, []int{6, 22}},
+	{4, 18, []int{32, 32}, []int{6, 26}},
+	{5, 24, []int{43, 43}, []int{6, 30}},
+	{6, 16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{7, 18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{8, 22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{9, 22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{10, 26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v version) size() int {
+	return 17 + 4*v.number
+}
+
+func (v version) dataCodewords() (n int) {
+	for _, b := range v.blocks {
+		n += b
+	}
+
+	return n
+}
+
+func (v version) countBits() int {
+	if v.number < 10 {
+		return 8
+	}
+
+	return 16
+}
+
+// Code is a QR code. Modules are true when dark.
+type Code struct {
+	Size    int
+	Modules [][]bool
+
+	// function modules (finder, timing, alignment, format, and version) aren't masked.
+	function [][]bool
+}
+
+// Encode text as a QR code.
+func Encode(text string) (*Code, error) {
+	var data = []byte(text)
+
+	for _, v := range versions {
+		if 4+v.countBits()+8*len(data) <= 8*v.dataCodewords() {
+			return encode(v, data), nil
+		}
+	}
+
+	return nil, ErrTooLong
+}
+
+func encode(v version, data []byte) *Code {
+	var c = &Code{
+		Size: v.size(),
+	}
+
+	c.Modules = make([][]bool, c.Size)
+	c.function = make([][]bool, c.Size)
+
+	for i := range c.Modules {
+		c.Modules[i] = make([]bool, c.Size)
+		c.function[i] = make([]bool, c.Size)
+	}
+
+	c.drawFunctionPatterns(v)
+	c.drawCodewords(interleave(v, dataCodewords(v, data)))
+
+	var best = -1
+	var bestPenalty int
+
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+
+		if p := c.penalty(); best == -1 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+
+		c.applyMask(mask) // undo
+	}
+
+	c.applyMask(best)
+	c.drawFormat(best)
+	return c
+}
+
+// dataCodewords encodes the data in byte mode, with terminator and padding.
+func dataCodewords(v version, data []byte) []byte {
+	var b bitBuffer
+	b.append(0x4, 4)
+	b.append(len(data), v.countBits())
+
+	for _, d := range data {
+		b.append(int(d), 8)
+	}
+
+	var capacity = 8 * v.dataCodewords()
+
+	for i := 0; i < 4 && len(b) < capacity; i++ {
+		b.append(0, 1)
+	}
+
+	for len(b)%8 != 0 {
+		b.append(0, 1)
+	}
+
+	for pad := 0xEC; len(b) < capacity; pad ^= 0xEC ^ 0x11 {
+		b.append(pad, 8)
+	}
+
+	return b.bytes()
+}
+
+// interleave data codewords split into blocks with their error correction codewords.
+func interleave(v version, data []byte) []byte {
+	var divisor = rsDivisor(v.ecPerBlock)
+	var blocks [][]byte
+	var ecs [][]byte
+	var max int
+
+	for _, n := range v.blocks {
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, rsRemainder(data[:n], divisor))
+		data = data[n:]
+
+		if n > max {
+			max = n
+		}
+	}
+
+	var result []byte
+
+	for i := 0; i < max; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				result = append(result, b[i])
+			}
+		}
+	}
+
+	for i := 0; i < v.ecPerBlock; i++ {
+		for _, ec := range ecs {
+			result = append(result, ec[i])
+		}
+	}
+
+	return result
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.Modules[y][x] = dark
+	c.function[y][x] = true
+}
+
+func (c *Code) drawFunctionPatterns(v version) {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	c.drawFinder(3, 3)
+	c.drawFinder(c.Size-4, 3)
+	c.drawFinder(3, c.Size-4)
+
+	var last = len(v.alignment) - 1
+
+	for i, x := range v.alignment {
+		for j, y := range v.alignment {
+			// skip the ones overlapping finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+
+			c.drawAlignment(x, y)
+		}
+	}
+
+	// reserve format areas, drawn after masking
+	c.drawFormat(0)
+	c.drawVersion(v)
+}
+
+func (c *Code) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			var x, y = cx + dx, cy + dy
+
+			if x < 0 || x >= c.Size || y < 0 || y >= c.Size {
+				continue
+			}
+
+			var d = max(abs(dx), abs(dy))
+			c.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (c *Code) drawAlignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// formatBits for error correction level M and the given mask.
+func formatBits(mask int) int {
+	var data = mask // level M is 00
+	var rem = data
+
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (c *Code) drawFormat(mask int) {
+	var bits = formatBits(mask)
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(bits, i))
+	}
+
+	c.set(8, 7, bit(bits, 6))
+	c.set(8, 8, bit(bits, 7))
+	c.set(7, 8, bit(bits, 8))
+
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(bits, i))
+	}
+
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(bits, i))
+	}
+
+	c.set(8, c.Size-8, true) // dark module
+}
+
+// versionBits for versions 7 and up.
+func versionBits(number int) int {
+	var rem = number
+
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+
+	return number<<12 | rem
+}
+
+func (c *Code) drawVersion(v version) {
+	if v.number < 7 {
+		return
+	}
+
+	var bits = versionBits(v.number)
+
+	for i := 0; i < 18; i++ {
+		var a, b = c.Size - 11 + i%3, i / 3
+		c.set(a, b, bit(bits, i))
+		c.set(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords in the zigzag order, skipping function modules.
+func (c *Code) drawCodewords(data []byte) {
+	var i int
+
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				var x = right - j
+				var y = vert
+
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+
+				if !c.function[y][x] && i < len(data)*8 {
+					c.Modules[y][x] = bit(int(data[i>>3]), 7-(i&7))
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.function[y][x] && masked(mask, x, y) {
+				c.Modules[y][x] = !c.Modules[y][x]
+			}
+		}
+	}
+}
+
+func masked(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty score of the code, used to pick the mask that is easier to read.
+func (c *Code) penalty() (p int) {
+	var dark int
+
+	for i := 0; i < c.Size; i++ {
+		var row, column = make([]bool, c.Size), make([]bool, c.Size)
+
+		for j := 0; j < c.Size; j++ {
+			row[j], column[j] = c.Modules[i][j], c.Modules[j][i]
+
+			if row[j] {
+				dark++
+			}
+		}
+
+		p += linePenalty(row) + linePenalty(column)
+	}
+
+	for y := 0; y < c.Size-1; y++ {
+		for x := 0; x < c.Size-1; x++ {
+			var m = c.Modules[y][x]
+
+			if m == c.Modules[y][x+1] && m == c.Modules[y+1][x] && m == c.Modules[y+1][x+1] {
+				p += 3
+			}
+		}
+	}
+
+	var total = c.Size * c.Size
+	p += 10 * (abs(dark*20-total*10) / total)
+	return p
+}
+
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+// linePenalty for runs of modules of the same color and finder-like patterns.
+func linePenalty(line []bool) (p int) {
+	var run = 1
+
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+
+		if run >= 5 {
+			p += 3 + run - 5
+		}
+
+		run = 1
+	}
+
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			if matches(line[i:i+11], pattern) {
+				p += 40
+			}
+		}
+	}
+
+	return p
+}
+
+func matches(line, pattern []bool) bool {
+	for i := range pattern {
+		if line[i] != pattern[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Image of the QR code with the given scale (pixels per module) and a 4 modules quiet zone.
+func (c *Code) Image(scale int) image.Image {
+	const quiet = 4
+	var size = (c.Size + 2*quiet) * scale
+	var img = image.NewGray(image.Rect(0, 0, size, size))
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var mx, my = x/scale - quiet, y/scale - quiet
+
+			if mx >= 0 && mx < c.Size && my >= 0 && my < c.Size && c.Modules[my][mx] {
+				img.SetGray(x, y, color.Gray{Y: 0})
+				continue
+			}
+
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+
+	return img
+}
+
+// PNG encoding of the QR code image.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, c.Image(scale))
+	return buf.Bytes(), err
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, bit(value, i))
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	var result = make([]byte, len(b)/8)
+
+	for i, v := range b {
+		if v {
+			result[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+
+	return result
+}
+
+func bit(x, i int) bool {
+	return (x>>uint(i))&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,113 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" on version 1-M (alphanumeric mode)
+	var data = []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	var want = []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := rsRemainder(data, rsDivisor(10)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected error correction codewords %v, got %v instead", want, got)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	var want = map[int]int{
+		0: 0x5412,
+		2: 0x5E7C,
+		5: 0x40CE,
+		7: 0x4AA0,
+	}
+
+	for mask, bits := range want {
+		if got := formatBits(mask); got != bits {
+			t.Errorf("Expected format bits for mask %d to be %015b, got %015b instead", mask, bits, got)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	var want = map[int]int{
+		7:  0x07C94,
+		8:  0x085BC,
+		9:  0x09A99,
+		10: 0x0A4D3,
+	}
+
+	for v, bits := range want {
+		if got := versionBits(v); got != bits {
+			t.Errorf("Expected version bits for version %d to be %018b, got %018b instead", v, bits, got)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	var cases = []struct {
+		length int
+		size   int
+	}{
+		{1, 21},
+		{14, 21},
+		{15, 25},
+		{106, 41},
+		{213, 57},
+	}
+
+	for _, c := range cases {
+		code, err := Encode(strings.Repeat("a", c.length))
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v instead", err)
+		}
+
+		if code.Size != c.size || len(code.Modules) != c.size {
+			t.Errorf("Expected QR code for %d bytes to have size %d, got %d instead", c.length, c.size, code.Size)
+		}
+
+		// finder patterns have a dark center and a light ring
+		for _, corner := range [][2]int{{3, 3}, {code.Size - 4, 3}, {3, code.Size - 4}} {
+			var x, y = corner[0], corner[1]
+
+			if !code.Modules[y][x] || code.Modules[y][x+2] || !code.Modules[y][x+3] {
+				t.Errorf("Expected finder pattern centered at (%d, %d)", x, y)
+			}
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("a", 214)); err != ErrTooLong {
+		t.Errorf("Expected error %v, got %v instead", ErrTooLong, err)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	code, err := Encode("otpauth://totp/CLI%20metrics:alice?secret=JBSWY3DPEHPK3PXP&issuer=CLI%20metrics")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	b, err := code.PNG(4)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+
+	if err != nil {
+		t.Fatalf("Expected no error decoding PNG, got %v instead", err)
+	}
+
+	if want := (code.Size + 8) * 4; img.Bounds().Dx() != want || img.Bounds().Dy() != want {
+		t.Errorf("Expected image to be %dx%d, got %v instead", want, want, img.Bounds())
+	}
+}
@@ -0,0 +1,52 @@
+package qrcode
+
+// gfMultiply multiplies two elements of GF(2^8) modulo the QR code polynomial x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var z int
+
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= ((int(y) >> uint(i)) & 1) * int(x)
+	}
+
+	return byte(z)
+}
+
+// rsDivisor is the Reed-Solomon generator polynomial of the given degree, without its leading term.
+func rsDivisor(degree int) []byte {
+	var result = make([]byte, degree)
+	result[degree-1] = 1
+
+	var root byte = 1
+
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+
+		root = gfMultiply(root, 0x02)
+	}
+
+	return result
+}
+
+// rsRemainder computes the error correction codewords of the data.
+func rsRemainder(data, divisor []byte) []byte {
+	var result = make([]byte, len(divisor))
+
+	for _, b := range data {
+		var factor = b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,363 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/metrics"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// Pruner deletes what is past the retention rules, in batches.
+type Pruner struct {
+	Rules
+
+	// BatchSize is the maximum number of rows deleted at once, so that locks are short.
+	BatchSize int
+
+	// Pause between batches.
+	Pause time.Duration
+
+	// Rollups adds daily counts of the deleted rows to the rollup tables, on the statement deleting them.
+	Rollups bool
+
+	// Interval between runs of the background job (zero disables it).
+	Interval time.Duration
+}
+
+// Job is the pruner used by the server and the retention prune command.
+var Job = &Pruner{
+	BatchSize: 1000,
+	Pause:     100 * time.Millisecond,
+}
+
+// Removed rows of a retention rule.
+type Removed struct {
+	Table string `json:"table"`
+
+	// Type of the metrics, if the rule is for a type.
+	Type string `json:"type,omitempty"`
+
+	// Except the types with rules of their own, if the rule is the default.
+	Except []string `json:"except,omitempty"`
+
+	// Partition of the metrics dropped at once, as all its rows were past every rule.
+	Partition string `json:"partition,omitempty"`
+
+	Before time.Time `json:"before"`
+	Rows   int64     `json:"rows"`
+}
+
+// Report of a pruning run.
+type Report struct {
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	DryRun   bool      `json:"dry_run"`
+	Rollups  bool      `json:"rollups"`
+	Removed  []Removed `json:"removed"`
+	Rows     int64     `json:"rows"`
+}
+
+// Details of the report, as recorded on the audit log.
+func (r Report) Details() string {
+	b, _ := json.Marshal(r)
+	return string(b)
+}
+
+// Validate the pruner settings.
+func (p *Pruner) Validate() error {
+	if p.BatchSize < 1 {
+		return errors.New("the retention batch size must be at least 1")
+	}
+
+	if p.Interval < 0 || p.Pause < 0 {
+		return errors.New("the retention interval and pause can't be negative")
+	}
+
+	return nil
+}
+
+// Prune the rows past the retention rules. A dry run counts them instead.
+// Monthly partitions of the metrics past every rule are dropped at once, before the rows are deleted in batches.
+// Rows removed before an error are still on the report.
+func (p *Pruner) Prune(ctx context.Context, dryRun bool) (r Report, err error) {
+	r = Report{
+		Started: time.Now(),
+		DryRun:  dryRun,
+		Rollups: p.Rollups && !dryRun,
+		Removed: []Removed{},
+	}
+
+	defer func() {
+		r.Duration = time.Since(r.Started).Round(time.Millisecond).String()
+	}()
+
+	if err = p.prunePartitions(ctx, &r); err != nil {
+		return r, err
+	}
+
+	for _, t := range p.targets(r.Started) {
+		var removed = Removed{
+			Table:  t.Table,
+			Type:   t.Type,
+			Except: t.Except,
+			Before: t.Before,
+		}
+
+		switch dryRun {
+		case true:
+			removed.Rows, err = p.count(ctx, t)
+		default:
+			removed.Rows, err = p.delete(ctx, t)
+		}
+
+		r.Removed = append(r.Removed, removed)
+		r.Rows += removed.Rows
+
+		if err != nil {
+			return r, err
+		}
+	}
+
+	return r, nil
+}
+
+// prunePartitions drops the monthly partitions of the metrics past every rule.
+func (p *Pruner) prunePartitions(ctx context.Context, r *Report) error {
+	var before = p.partitionsBefore(r.Started)
+
+	if before.IsZero() {
+		return nil
+	}
+
+	state, err := metrics.PartitioningState(ctx)
+
+	if err != nil || state != metrics.Partitioned {
+		return err
+	}
+
+	ps, err := metrics.Partitions(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, part := range expired(ps, before) {
+		var removed = Removed{
+			Table:     "metrics",
+			Partition: part.Name,
+			Before:    *part.To,
+		}
+
+		removed.Rows, err = p.dropPartition(ctx, part.Name, r.DryRun)
+		r.Removed = append(r.Removed, removed)
+		r.Rows += removed.Rows
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// expired monthly partitions, ending before the given time.
+func expired(ps []metrics.Partition, before time.Time) []metrics.Partition {
+	var e []metrics.Partition
+
+	for _, part := range ps {
+		if part.Monthly() && !part.To.After(before) {
+			e = append(e, part)
+		}
+	}
+
+	return e
+}
+
+// dropPartition detaches and drops a partition of the metrics, returning how many rows it had.
+// A dry run only counts them. Metrics added to the partition while it is counted
+// (only possible if they are synced with an old time) might be missing from the rollups.
+func (p *Pruner) dropPartition(ctx context.Context, name string, dryRun bool) (n int64, err error) {
+	var table = "public." + pq.QuoteIdentifier(name)
+	conn := db.Conn()
+
+	if dryRun {
+		err = db.QueryRow(ctx, "SELECT COUNT(*) FROM "+table, nil, &n)
+		return n, err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	var q = []string{
+		"ALTER TABLE public.metrics DETACH PARTITION " + table,
+		"DROP TABLE " + table,
+	}
+
+	if p.Rollups {
+		q = append([]string{fmt.Sprintf(rollups["metrics"], table)}, q...)
+	}
+
+	for _, s := range q {
+		if _, err = tx.ExecContext(ctx, s); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, tx.Commit()
+}
+
+func (p *Pruner) count(ctx context.Context, t target) (n int64, err error) {
+	where, args := t.where()
+	err = db.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", t.Table, where), args, &n)
+	return n, err
+}
+
+func (p *Pruner) delete(ctx context.Context, t target) (total int64, err error) {
+	where, args := t.where()
+	var q = t.deleteQuery(where, len(args)+1, p.Rollups)
+	args = append(args, p.BatchSize)
+	conn := db.Conn()
+	stmt, err := conn.PreparexContext(ctx, q)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	for {
+		var n int64
+
+		if err = stmt.QueryRowxContext(ctx, args...).Scan(&n); err != nil {
+			return total, err
+		}
+
+		total += n
+
+		if n < int64(p.BatchSize) {
+			return total, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return total, ctx.Err()
+		case <-time.After(p.Pause):
+		}
+	}
+}
+
+// where condition of the target.
+func (t target) where() (string, []interface{}) {
+	switch {
+	case t.Type != "":
+		return "type = $1 AND sync_time < $2", []interface{}{t.Type, t.Before}
+	case len(t.Except) != 0:
+		return "sync_time < $1 AND type <> ALL($2)", []interface{}{t.Before, pq.StringArray(t.Except)}
+	default:
+		return "sync_time < $1", []interface{}{t.Before}
+	}
+}
+
+// rollupColumns of each table, returned by the deleted rows.
+var rollupColumns = map[string]string{
+	"metrics":     "sync_time, type, version, os, arch, sync_location",
+	"diagnostics": "sync_time",
+}
+
+// rollups of each table, added from the deleted rows (or from a dropped partition).
+var rollups = map[string]string{
+	"metrics": `INSERT INTO metrics_rollups (day, type, version, os, arch, country, count)
+SELECT (sync_time AT TIME ZONE 'UTC')::date, type, version, os, arch, COALESCE(sync_location->>'country', ''), COUNT(*)
+FROM %s GROUP BY 1, 2, 3, 4, 5, 6
+ON CONFLICT (day, type, version, os, arch, country) DO UPDATE SET count = metrics_rollups.count + EXCLUDED.count`,
+	"diagnostics": `INSERT INTO diagnostics_rollups (day, count)
+SELECT (sync_time AT TIME ZONE 'UTC')::date, COUNT(*)
+FROM %s GROUP BY 1
+ON CONFLICT (day) DO UPDATE SET count = diagnostics_rollups.count + EXCLUDED.count`,
+}
+
+// deleteQuery deletes a batch of the oldest rows of the target, returning how many.
+// Rollups are written by the same statement, so they can't miss or count rows twice.
+func (t target) deleteQuery(where string, limit int, withRollups bool) string {
+	var returning = "id"
+
+	if withRollups {
+		returning = rollupColumns[t.Table]
+	}
+
+	var q = fmt.Sprintf(`WITH deleted AS (
+DELETE FROM %[1]s WHERE (id, sync_time) IN (SELECT id, sync_time FROM %[1]s WHERE %[2]s ORDER BY sync_time LIMIT $%[3]d) RETURNING %[4]s
+)`, t.Table, where, limit, returning)
+
+	if withRollups {
+		q += ", rollup AS (\n" + fmt.Sprintf(rollups[t.Table], "deleted") + "\n)"
+	}
+
+	return q + "\nSELECT COUNT(*) FROM deleted"
+}
+
+// Run the pruner every interval while the server is up, recording what it removes on the audit log.
+func (p *Pruner) Run(ctx context.Context) {
+	if p.Interval == 0 || len(p.targets(time.Now())) == 0 {
+		return
+	}
+
+	var ticker = time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.run(ctx)
+		}
+	}
+}
+
+func (p *Pruner) run(ctx context.Context) {
+	r, err := p.Prune(ctx, false)
+
+	if err != nil && ctx.Err() == nil {
+		log.Errorf("failed to prune data past retention: %+v", err)
+	}
+
+	if r.Rows == 0 {
+		return
+	}
+
+	log.Infof("pruned %d rows past retention", r.Rows)
+
+	// the context might be canceled already, but the record of what was removed shouldn't be lost.
+	rctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := audit.Record(rctx, audit.Entry{
+		Action:  audit.RetentionPrune,
+		Details: r.Details(),
+		Method:  "JOB",
+		Path:    "retention",
+	}); err != nil {
+		log.Errorf("failed to record pruning on the audit log: %+v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package retention
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/henvic/climetrics/metrics"
+	"github.com/lib/pq"
+)
+
+var before = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+func TestWhere(t *testing.T) {
+	var cases = []struct {
+		target target
+		where  string
+		args   []interface{}
+	}{
+		{
+			target{Table: "metrics", Type: "debug", Before: before},
+			"type = $1 AND sync_time < $2",
+			[]interface{}{"debug", before},
+		},
+		{
+			target{Table: "metrics", Except: []string{"cmd", "debug"}, Before: before},
+			"sync_time < $1 AND type <> ALL($2)",
+			[]interface{}{before, pq.StringArray{"cmd", "debug"}},
+		},
+		{
+			target{Table: "diagnostics", Before: before},
+			"sync_time < $1",
+			[]interface{}{before},
+		},
+	}
+
+	for _, c := range cases {
+		where, args := c.target.where()
+
+		if where != c.where || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("Expected %q %v for %+v, got %q %v instead", c.where, c.args, c.target, where, args)
+		}
+	}
+}
+
+func TestDeleteQuery(t *testing.T) {
+	var tg = target{Table: "metrics", Type: "debug", Before: before}
+	var q = tg.deleteQuery("type = $1 AND sync_time < $2", 3, false)
+
+	var want = `WITH deleted AS (
+DELETE FROM metrics WHERE (id, sync_time) IN (SELECT id, sync_time FROM metrics WHERE type = $1 AND sync_time < $2 ORDER BY sync_time LIMIT $3) RETURNING id
+)
+SELECT COUNT(*) FROM deleted`
+
+	if q != want {
+		t.Errorf("Expected query to be %q, got %q instead", want, q)
+	}
+
+	q = tg.deleteQuery("type = $1 AND sync_time < $2", 3, true)
+
+	if !strings.Contains(q, "RETURNING sync_time, type, version, os, arch, sync_location") ||
+		!strings.Contains(q, "rollup AS (\nINSERT INTO metrics_rollups") ||
+		!strings.HasSuffix(q, "SELECT COUNT(*) FROM deleted") {
+		t.Errorf("Expected query adding rollups of the deleted rows, got %q instead", q)
+	}
+
+	tg = target{Table: "diagnostics", Before: before}
+
+	if q = tg.deleteQuery("sync_time < $1", 2, true); !strings.Contains(q, "INSERT INTO diagnostics_rollups (day, count)") {
+		t.Errorf("Expected query adding rollups of the deleted diagnostics, got %q instead", q)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	var month = func(year int, m time.Month) (*time.Time, *time.Time) {
+		from := time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		return &from, &to
+	}
+
+	var ps = []metrics.Partition{
+		{Name: "metrics_default", Default: true},
+		{Name: "metrics_legacy"},
+	}
+
+	for _, m := range []time.Month{time.August, time.September, time.October} {
+		var p = metrics.Partition{Name: metrics.PartitionName(time.Date(2018, m, 1, 0, 0, 0, 0, time.UTC))}
+		p.From, p.To = month(2018, m)
+		ps = append(ps, p)
+	}
+
+	var got []string
+
+	for _, p := range expired(ps, time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		got = append(got, p.Name)
+	}
+
+	if want := []string{"metrics_p201808", "metrics_p201809"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected expired partitions %v, got %v instead", want, got)
+	}
+}
+
+func TestPruneNoRules(t *testing.T) {
+	var p = &Pruner{BatchSize: 10, Rollups: true}
+	r, err := p.Prune(context.Background(), true)
+
+	if err != nil || r.Rows != 0 || len(r.Removed) != 0 || !r.DryRun || r.Rollups {
+		t.Errorf("Expected dry run without rules to do nothing, got %+v (error: %v) instead", r, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Job.Validate(); err != nil {
+		t.Errorf("Expected default pruner to be valid, got %v instead", err)
+	}
+
+	for _, p := range []Pruner{{BatchSize: 0}, {BatchSize: 1, Interval: -time.Hour}, {BatchSize: 1, Pause: -time.Second}} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Expected error for %+v, got nil instead", p)
+		}
+	}
+}
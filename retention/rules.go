@@ -0,0 +1,194 @@
+// Package retention deletes metrics and diagnostics older than their retention rules,
+// optionally keeping daily rollups of what is deleted.
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration of retention. Zero keeps data forever.
+// It accepts days (i.e., 90d) besides the units of time.ParseDuration.
+type Duration time.Duration
+
+// ParseDuration such as 90d or 36h.
+func ParseDuration(s string) (Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf(`invalid retention "%s": use a number of days (i.e., 90d) or a duration (i.e., 36h)`, s)
+		}
+
+		return Duration(time.Duration(n) * 24 * time.Hour), nil
+	}
+
+	d, err := time.ParseDuration(s)
+
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf(`invalid retention "%s": use a number of days (i.e., 90d) or a duration (i.e., 36h)`, s)
+	}
+
+	return Duration(d), nil
+}
+
+// Set duration (flag.Value).
+func (d *Duration) Set(s string) (err error) {
+	*d, err = ParseDuration(s)
+	return err
+}
+
+func (d Duration) String() string {
+	var t = time.Duration(d)
+
+	switch {
+	case t == 0:
+		return "0"
+	case t%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", t/(24*time.Hour))
+	default:
+		return t.String()
+	}
+}
+
+// Types with their own retention, by event type.
+type Types map[string]Duration
+
+// Set types from comma-separated type=duration pairs (flag.Value), such as cmd=365d,debug=7d.
+func (t *Types) Set(s string) error {
+	var types = Types{}
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		var kv = strings.SplitN(pair, "=", 2)
+
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return fmt.Errorf(`invalid retention rule "%s": use type=duration (i.e., debug=7d)`, pair)
+		}
+
+		var name = strings.TrimSpace(kv[0])
+
+		if _, ok := types[name]; ok {
+			return fmt.Errorf(`duplicated retention rule for type "%s"`, name)
+		}
+
+		d, err := ParseDuration(strings.TrimSpace(kv[1]))
+
+		if err != nil {
+			return err
+		}
+
+		types[name] = d
+	}
+
+	*t = types
+	return nil
+}
+
+func (t Types) String() string {
+	var pairs []string
+
+	for _, name := range t.names() {
+		pairs = append(pairs, name+"="+t[name].String())
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// names of the types, sorted.
+func (t Types) names() []string {
+	var names = make([]string, 0, len(t))
+
+	for name := range t {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Rules of retention, by sync time.
+type Rules struct {
+	// Default retention of metrics of types without a rule of their own.
+	Default Duration
+
+	// Types of metrics with their own retention (zero keeps them forever, regardless of the default).
+	Types Types
+
+	// Diagnostics reports retention.
+	Diagnostics Duration
+}
+
+// target of a rule: the rows synced before a time.
+type target struct {
+	Table string
+
+	// Type of the metrics, if the rule is for a type.
+	Type string
+
+	// Except the types with rules of their own, if the rule is the default.
+	Except []string
+
+	Before time.Time
+}
+
+// targets of the rules at a given time, skipping the rules keeping data forever.
+func (r Rules) targets(now time.Time) []target {
+	var ts []target
+	var names = r.Types.names()
+
+	for _, name := range names {
+		if d := r.Types[name]; d != 0 {
+			ts = append(ts, target{
+				Table:  "metrics",
+				Type:   name,
+				Before: now.Add(-time.Duration(d)),
+			})
+		}
+	}
+
+	if r.Default != 0 {
+		ts = append(ts, target{
+			Table:  "metrics",
+			Except: names,
+			Before: now.Add(-time.Duration(r.Default)),
+		})
+	}
+
+	if r.Diagnostics != 0 {
+		ts = append(ts, target{
+			Table:  "diagnostics",
+			Before: now.Add(-time.Duration(r.Diagnostics)),
+		})
+	}
+
+	return ts
+}
+
+// partitionsBefore is the time before which monthly partitions of the metrics are past every rule,
+// or zero if metrics of any type are kept forever.
+func (r Rules) partitionsBefore(now time.Time) time.Time {
+	if r.Default == 0 {
+		return time.Time{}
+	}
+
+	var longest = r.Default
+
+	for _, d := range r.Types {
+		if d == 0 {
+			return time.Time{}
+		}
+
+		if d > longest {
+			longest = d
+		}
+	}
+
+	return now.Add(-time.Duration(longest))
+}
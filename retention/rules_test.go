@@ -0,0 +1,130 @@
+package retention
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	var cases = map[string]Duration{
+		"0":     0,
+		"90d":   Duration(90 * 24 * time.Hour),
+		"36h":   Duration(36 * time.Hour),
+		"1h30m": Duration(90 * time.Minute),
+	}
+
+	for in, want := range cases {
+		if got, err := ParseDuration(in); err != nil || got != want {
+			t.Errorf("Expected %v for %q, got %v (error: %v) instead", want, in, got, err)
+		}
+	}
+
+	for _, in := range []string{"", "d", "-1d", "1.5d", "-1h", "forever"} {
+		if _, err := ParseDuration(in); err == nil {
+			t.Errorf("Expected error for %q, got nil instead", in)
+		}
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	var cases = map[Duration]string{
+		0:                              "0",
+		Duration(365 * 24 * time.Hour): "365d",
+		Duration(36 * time.Hour):       "36h0m0s",
+	}
+
+	for d, want := range cases {
+		if got := d.String(); got != want {
+			t.Errorf("Expected %v, got %v instead", want, got)
+		}
+	}
+}
+
+func TestTypes(t *testing.T) {
+	var types Types
+
+	if err := types.Set(" debug=7d, cmd = 0,,login=36h"); err != nil {
+		t.Fatalf("Expected no error, got %v instead", err)
+	}
+
+	var want = Types{
+		"cmd":   0,
+		"debug": Duration(7 * 24 * time.Hour),
+		"login": Duration(36 * time.Hour),
+	}
+
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("Expected types to be %v, got %v instead", want, types)
+	}
+
+	if got := types.String(); got != "cmd=0,debug=7d,login=36h0m0s" {
+		t.Errorf("Expected types sorted by name, got %v instead", got)
+	}
+}
+
+func TestTypesFailure(t *testing.T) {
+	var cases = map[string]string{
+		"debug":             `invalid retention rule "debug": use type=duration (i.e., debug=7d)`,
+		"=7d":               `invalid retention rule "=7d": use type=duration (i.e., debug=7d)`,
+		"debug=7d,debug=1d": `duplicated retention rule for type "debug"`,
+		"debug=week":        `invalid retention "week": use a number of days (i.e., 90d) or a duration (i.e., 36h)`,
+	}
+
+	for in, want := range cases {
+		var types Types
+
+		if err := types.Set(in); err == nil || err.Error() != want {
+			t.Errorf("Expected error %q for %q, got %v instead", want, in, err)
+		}
+	}
+}
+
+func TestTargets(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+
+	var r = Rules{
+		Default: Duration(365 * 24 * time.Hour),
+		Types: Types{
+			"debug": Duration(7 * 24 * time.Hour),
+			"cmd":   0,
+		},
+		Diagnostics: Duration(30 * 24 * time.Hour),
+	}
+
+	var want = []target{
+		{Table: "metrics", Type: "debug", Before: now.AddDate(0, 0, -7)},
+		{Table: "metrics", Except: []string{"cmd", "debug"}, Before: now.AddDate(-1, 0, 0)},
+		{Table: "diagnostics", Before: now.AddDate(0, 0, -30)},
+	}
+
+	if got := r.targets(now); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected targets to be %+v, got %+v instead", want, got)
+	}
+
+	if got := (Rules{}).targets(now); len(got) != 0 {
+		t.Errorf("Expected no targets keeping data forever, got %+v instead", got)
+	}
+}
+
+func TestPartitionsBefore(t *testing.T) {
+	var now = time.Date(2018, 10, 20, 3, 0, 0, 0, time.UTC)
+	var day = Duration(24 * time.Hour)
+
+	var cases = []struct {
+		rules Rules
+		want  time.Time
+	}{
+		{Rules{}, time.Time{}},
+		{Rules{Types: Types{"debug": 7 * day}}, time.Time{}},
+		{Rules{Default: 365 * day, Types: Types{"cmd": 0}}, time.Time{}},
+		{Rules{Default: 365 * day}, now.AddDate(-1, 0, 0)},
+		{Rules{Default: 30 * day, Types: Types{"cmd": 730 * day, "debug": 7 * day}}, now.AddDate(0, 0, -730)},
+	}
+
+	for _, c := range cases {
+		if got := c.rules.partitionsBefore(now); !got.Equal(c.want) {
+			t.Errorf("Expected partitions before %v for %+v, got %v instead", c.want, c.rules, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/us"
+	log "github.com/sirupsen/logrus"
+	"github.com/tomasen/realip"
+)
+
+// recordAudit is replaced on tests.
+var recordAudit = audit.Record
+
+type auditTrailKey struct{}
+
+// auditTrail tells if an entry was recorded for a request.
+type auditTrail struct {
+	recorded bool
+}
+
+// Audit records an entry on the audit log for the request.
+// The actor is the signed in user, unless set, and the IP, method, and path come from the request.
+// Failures are logged rather than returned, so they don't interrupt the request.
+func Audit(r *http.Request, e audit.Entry) {
+	if s, ok := r.Context().Value(SessionCtx{}).(us.Session); ok && e.ActorID == "" && e.Actor == "" {
+		e.ActorID = s.User.UserID
+		e.Actor = s.User.Username
+	}
+
+	if t, ok := r.Context().Value(auditTrailKey{}).(*auditTrail); ok {
+		t.recorded = true
+	}
+
+	e.IP = realip.FromRequest(r)
+	e.Method = r.Method
+	e.Path = r.URL.Path
+
+	if err := recordAudit(r.Context(), e); err != nil {
+		log.Errorf("can't record %s on the audit log: %v", e.Action, err)
+	}
+}
+
+// audited calls the handler, recording requests that might change something on the audit log
+// unless the handler records a more specific entry.
+func audited(w http.ResponseWriter, r *http.Request, s us.Session, h AuthenticatedHandler) {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		h(w, r, s)
+		return
+	}
+
+	var trail = &auditTrail{}
+	r = r.WithContext(context.WithValue(r.Context(), auditTrailKey{}, trail))
+	var sw = &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+	h(sw, r, s)
+
+	if trail.recorded {
+		return
+	}
+
+	Audit(r, audit.Entry{
+		Action:  audit.Request,
+		Target:  r.URL.Path,
+		Details: fmt.Sprintf("%d %s", sw.status, http.StatusText(sw.status)),
+	})
+}
+
+// statusWriter keeps the status code of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusWriter) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
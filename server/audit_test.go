@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
+)
+
+var recorded []audit.Entry
+
+func init() {
+	recordAudit = func(ctx context.Context, e audit.Entry) error {
+		recorded = append(recorded, e)
+		return nil
+	}
+}
+
+func TestAudited(t *testing.T) {
+	var cases = []struct {
+		method string
+		hook   bool
+		want   []audit.Action
+	}{
+		{http.MethodGet, false, nil},
+		{http.MethodHead, false, nil},
+		{http.MethodPost, false, []audit.Action{audit.Request}},
+		{http.MethodPost, true, []audit.Action{audit.UserUpdate}},
+	}
+
+	var s = us.Session{
+		User: users.User{
+			UserID:   "9f6bc84c-3bc7-4a0c-8ba3-0d0b3c8e0c47",
+			Username: "alice",
+		},
+	}
+
+	for _, c := range cases {
+		recorded = nil
+
+		var r = httptest.NewRequest(c.method, "/users/x", nil)
+		r.RemoteAddr = "203.0.113.7:5123"
+		r = r.WithContext(context.WithValue(r.Context(), SessionCtx{}, s))
+		var w = httptest.NewRecorder()
+
+		audited(w, r, s, func(w http.ResponseWriter, r *http.Request, s us.Session) {
+			if c.hook {
+				Audit(r, audit.Entry{Action: audit.UserUpdate, Target: "bob"})
+			}
+
+			w.WriteHeader(http.StatusSeeOther)
+		})
+
+		if len(recorded) != len(c.want) {
+			t.Errorf("Expected %d entries for %s request, got %v instead", len(c.want), c.method, recorded)
+			continue
+		}
+
+		for i, e := range recorded {
+			if e.Action != c.want[i] {
+				t.Errorf("Expected action %s, got %s instead", c.want[i], e.Action)
+			}
+
+			if e.Actor != "alice" || e.ActorID != s.User.UserID || e.IP != "203.0.113.7" || e.Method != c.method || e.Path != "/users/x" {
+				t.Errorf("Unexpected entry %+v", e)
+			}
+
+			if e.Action == audit.Request && (e.Target != "/users/x" || e.Details != "303 See Other") {
+				t.Errorf("Unexpected request entry %+v", e)
+			}
+		}
+	}
+}
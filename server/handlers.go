@@ -2,17 +2,23 @@ package server
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gorilla/csrf"
+	"github.com/gorilla/sessions"
 	"github.com/hashicorp/errwrap"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/usersessions"
 	"github.com/henvic/climetrics/us"
 	"github.com/henvic/climetrics/users"
 	log "github.com/sirupsen/logrus"
+	"github.com/tomasen/realip"
 )
 
 func init() {
@@ -59,7 +65,40 @@ func (h AuthenticatedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	h(w, r, s)
+	if !s.User.TwoFactor() && !twoFactorExempt[r.URL.Path] {
+		policy, err := users.GetTwoFactorPolicy(r.Context())
+
+		if err != nil {
+			log.Errorf("can't get two-factor authentication policy: %v", err)
+			ErrorHandler(w, r, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if policy.Requires(s.User.Role) {
+			requireTwoFactor(w, r)
+			return
+		}
+	}
+
+	audited(w, r, s, h)
+}
+
+// TwoFactorPath is where users set up two-factor authentication.
+const TwoFactorPath = "/account/two-factor"
+
+// twoFactorExempt paths are available to users required to set up two-factor authentication.
+var twoFactorExempt = map[string]bool{
+	TwoFactorPath: true,
+	"/logout":     true,
+}
+
+func requireTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && !acceptsJSON(r) {
+		http.Redirect(w, r, TwoFactorPath, http.StatusSeeOther)
+		return
+	}
+
+	ErrorHandler(w, r, "Two-factor authentication is required. Please set it up first.", http.StatusForbidden)
 }
 
 func (h PublicHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -106,11 +145,54 @@ func serveHTTP(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *ht
 		return w, r, s, errwrap.Wrapf("can't retrieve user info: {{err}}", err)
 	}
 
+	if u.Role == users.Revoked {
+		if _, err = usersessions.RevokeAll(r.Context(), u.UserID, ""); err != nil {
+			return w, r, s, errwrap.Wrapf("can't revoke sessions of revoked user: {{err}}", err)
+		}
+
+		signOut(w, session)
+		return w, r, s, nil
+	}
+
+	active, err := usersessions.Get(r.Context(), session.ID)
+
+	if err == sql.ErrNoRows || (err == nil && active.UserID != u.UserID) {
+		// revoked session
+		if err = usersessions.End(r.Context(), session.ID); err != nil {
+			return w, r, s, errwrap.Wrapf("can't end revoked session: {{err}}", err)
+		}
+
+		signOut(w, session)
+		return w, r, s, nil
+	}
+
+	if err != nil {
+		return w, r, s, errwrap.Wrapf("can't retrieve session info: {{err}}", err)
+	}
+
+	var ip = realip.FromRequest(r)
+	var now = time.Now()
+
+	if active.Stale(ip, now) {
+		if err = usersessions.Touch(r.Context(), session.ID, ip, now); err != nil {
+			log.Errorf("can't update when session was last seen: %v", err)
+		}
+	}
+
 	s.User = u
 	ctx := context.WithValue(r.Context(), SessionCtx{}, s)
 	return w, r.WithContext(ctx), s, nil
 }
 
+// signOut the session for the rest of the request, and delete its cookie.
+// The session itself must have been ended already, so saving it again creates a new one.
+func signOut(w http.ResponseWriter, session *sessions.Session) {
+	session.Values = map[interface{}]interface{}{}
+	session.ID = ""
+	session.IsNew = true
+	deleteSessionCookie(w)
+}
+
 func deleteSessionCookie(w http.ResponseWriter) {
 	w.Header().Set("Set-Cookie", fmt.Sprintf(
 		"%s=; path=/; expires=Thu, 01 Jan 1970 00:00:00 GMT", UserSessionName))
@@ -150,10 +232,7 @@ func ErrorHandler(w http.ResponseWriter, r *http.Request, e string, code int) {
 		return
 	}
 
-	a := r.Header.Get("Accept")
-	ua := r.Header.Get("User-Agent")
-
-	if strings.Contains(a, "application/json") || (a == "*/*" && strings.HasPrefix(ua, "curl/")) {
+	if acceptsJSON(r) {
 		jsonErrorHandler(w, r, e, code)
 		return
 	}
@@ -172,6 +251,12 @@ func ErrorHandler(w http.ResponseWriter, r *http.Request, e string, code int) {
 	t.Respond()
 }
 
+func acceptsJSON(r *http.Request) bool {
+	a := r.Header.Get("Accept")
+	ua := r.Header.Get("User-Agent")
+	return strings.Contains(a, "application/json") || (a == "*/*" && strings.HasPrefix(ua, "curl/"))
+}
+
 func jsonErrorHandler(w http.ResponseWriter, r *http.Request, e string, code int) {
 	var m = map[string]interface{}{
 		"status":  code,
@@ -190,3 +275,41 @@ func bareErrorHandler(w http.ResponseWriter, r *http.Request, e string, code int
 	w.WriteHeader(code)
 	_, _ = fmt.Fprintf(w, "%s\n", e)
 }
+
+// PermissionHandler is a handler for authenticated requests by users with a given permission.
+type PermissionHandler struct {
+	Permission users.Permission
+	Handler    AuthenticatedHandler
+}
+
+// RequirePermission for a handler. Users without it get a 403 Forbidden response.
+func RequirePermission(p users.Permission, h AuthenticatedHandler) *PermissionHandler {
+	return &PermissionHandler{
+		Permission: p,
+		Handler:    h,
+	}
+}
+
+// Allowed tells if the user is allowed to use the handler.
+func (p *PermissionHandler) Allowed(u users.User) bool {
+	return u.Can(p.Permission)
+}
+
+func (p *PermissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	AuthenticatedHandler(p.serve).ServeHTTP(w, r)
+}
+
+func (p *PermissionHandler) serve(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if !p.Allowed(s.User) {
+		log.Debugf("user %s (%s) is not allowed to %s %s", s.User.Username, s.User.Role, r.Method, r.URL.Path)
+		Audit(r, audit.Entry{
+			Action:  audit.AccessDenied,
+			Target:  r.URL.Path,
+			Details: string(p.Permission),
+		})
+		ErrorHandler(w, r, "Access forbidden. You don't have permission to access this page.", http.StatusForbidden)
+		return
+	}
+
+	p.Handler(w, r, s)
+}
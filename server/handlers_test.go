@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
+)
+
+func init() {
+	SessionStore = sessions.NewCookieStore([]byte("test-session-store-secret-key-32"))
+}
+
+func TestPermissionHandler(t *testing.T) {
+	var cases = []struct {
+		role string
+		p    users.Permission
+		want int
+	}{
+		{users.Admin, users.ManageUsers, http.StatusOK},
+		{users.Member, users.ViewMetrics, http.StatusOK},
+		{users.Member, users.ManageUsers, http.StatusForbidden},
+		{users.Member, users.ManageSettings, http.StatusForbidden},
+		{users.Revoked, users.ViewMetrics, http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		var called bool
+		var h = RequirePermission(c.p, func(w http.ResponseWriter, r *http.Request, s us.Session) {
+			called = true
+		})
+
+		var r = httptest.NewRequest(http.MethodGet, "/test", nil)
+		r.Header.Set("Accept", "application/json")
+		var w = httptest.NewRecorder()
+
+		h.serve(w, r, us.Session{User: users.User{Role: c.role}})
+
+		if w.Code != c.want {
+			t.Errorf("Expected status %d for role %s and permission %s, got %d instead", c.want, c.role, c.p, w.Code)
+		}
+
+		if called != (c.want == http.StatusOK) {
+			t.Errorf("Expected handler to be called = %v for role %s and permission %s", c.want == http.StatusOK, c.role, c.p)
+		}
+
+		if c.want != http.StatusForbidden {
+			continue
+		}
+
+		var m map[string]interface{}
+
+		if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+			t.Errorf("Expected JSON error response, got %v instead", err)
+		}
+
+		if m["status"] != float64(http.StatusForbidden) {
+			t.Errorf("Expected error status to be 403, got %v instead", m["status"])
+		}
+	}
+}
+
+func TestPermissionHandlerUnauthenticated(t *testing.T) {
+	var h = RequirePermission(users.ViewMetrics, func(w http.ResponseWriter, r *http.Request, s us.Session) {
+		t.Error("Handler should not be called")
+	})
+
+	var r = httptest.NewRequest(http.MethodGet, "/test", nil)
+	r.Header.Set("Accept", "application/json")
+	var w = httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d instead", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestRequireTwoFactor(t *testing.T) {
+	var cases = []struct {
+		method   string
+		accept   string
+		code     int
+		location string
+	}{
+		{http.MethodGet, "text/html", http.StatusSeeOther, TwoFactorPath},
+		{http.MethodGet, "application/json", http.StatusForbidden, ""},
+		{http.MethodPost, "application/json", http.StatusForbidden, ""},
+	}
+
+	for _, c := range cases {
+		var r = httptest.NewRequest(c.method, "/metrics", nil)
+		r.Header.Set("Accept", c.accept)
+		var w = httptest.NewRecorder()
+
+		requireTwoFactor(w, r)
+
+		if w.Code != c.code || w.Header().Get("Location") != c.location {
+			t.Errorf("Expected %s request accepting %s to get status %d (location: %q), got %d (location: %q) instead",
+				c.method, c.accept, c.code, c.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/henvic/climetrics/keyring"
+	log "github.com/sirupsen/logrus"
+)
+
+// csrfCookieName used by gorilla/csrf.
+const csrfCookieName = "_gorilla_csrf"
+
+// useKeys of the keyring for the session and CSRF cookies, adding random keys for any missing purpose.
+func useKeys(kr *keyring.Keyring) error {
+	for _, p := range kr.Missing() {
+		log.Warnf("no %s keys configured: using a random key, valid until the server restarts", p)
+
+		k, err := keyring.Generate(p, time.Now())
+
+		if err != nil {
+			return err
+		}
+
+		*kr = append(*kr, k)
+	}
+
+	if err := kr.Validate(); err != nil {
+		return err
+	}
+
+	Protected.UseKeys(kr.Keys(keyring.CSRF))
+	return nil
+}
+
+// keyPairs for the session store: hash keys, without encryption keys.
+func keyPairs(keys [][]byte) [][]byte {
+	var pairs [][]byte
+
+	for _, k := range keys {
+		pairs = append(pairs, k, nil)
+	}
+
+	return pairs
+}
+
+func csrfCodec(key []byte) *securecookie.SecureCookie {
+	// the same as gorilla/csrf uses
+	var sc = securecookie.New(key, nil)
+	sc.SetSerializer(securecookie.JSONEncoder{})
+	sc.MaxAge(0)
+	return sc
+}
+
+// rekeyCSRFCookie signs the CSRF cookie of the request with the newest key, if it was signed with an older one,
+// so CSRF tokens issued before the keys were rotated are still valid.
+func rekeyCSRFCookie(r *http.Request, keys [][]byte) {
+	if len(keys) < 2 {
+		return
+	}
+
+	c, err := r.Cookie(csrfCookieName)
+
+	if err != nil {
+		return
+	}
+
+	var token []byte
+	var newest = csrfCodec(keys[0])
+
+	if newest.Decode(csrfCookieName, c.Value, &token) == nil {
+		return
+	}
+
+	for _, key := range keys[1:] {
+		if csrfCodec(key).Decode(csrfCookieName, c.Value, &token) != nil {
+			continue
+		}
+
+		encoded, err := newest.Encode(csrfCookieName, token)
+
+		if err != nil {
+			log.Errorf("can't sign CSRF cookie with the newest key: %v", err)
+			return
+		}
+
+		replaceCookie(r, csrfCookieName, encoded)
+		return
+	}
+}
+
+func replaceCookie(r *http.Request, name, value string) {
+	var cookies []string
+
+	for _, c := range r.Cookies() {
+		if c.Name == name {
+			c.Value = value
+		}
+
+		cookies = append(cookies, c.String())
+	}
+
+	r.Header.Set("Cookie", strings.Join(cookies, "; "))
+}
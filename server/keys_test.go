@@ -0,0 +1,60 @@
+package server
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRekeyCSRFCookie(t *testing.T) {
+	var older, newest = bytes.Repeat([]byte("o"), 32), bytes.Repeat([]byte("n"), 32)
+	var token = bytes.Repeat([]byte("t"), 32)
+
+	encoded, err := csrfCodec(older).Encode(csrfCookieName, token)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r = httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Cookie", "other=1; "+csrfCookieName+"="+encoded)
+
+	rekeyCSRFCookie(r, [][]byte{newest, older})
+
+	c, err := r.Cookie(csrfCookieName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []byte
+
+	if err = csrfCodec(newest).Decode(csrfCookieName, c.Value, &got); err != nil {
+		t.Fatalf("Expected cookie to be signed with the newest key, got %v instead", err)
+	}
+
+	if !bytes.Equal(got, token) {
+		t.Errorf("Expected token to be kept, got %q instead", got)
+	}
+
+	if other, err := r.Cookie("other"); err != nil || other.Value != "1" {
+		t.Errorf("Expected other cookies to be kept, got %v (%v) instead", other, err)
+	}
+}
+
+func TestRekeyCSRFCookieUnknownKey(t *testing.T) {
+	encoded, err := csrfCodec(bytes.Repeat([]byte("x"), 32)).Encode(csrfCookieName, []byte("token"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var r = httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("Cookie", csrfCookieName+"="+encoded)
+
+	rekeyCSRFCookie(r, [][]byte{bytes.Repeat([]byte("n"), 32), bytes.Repeat([]byte("o"), 32)})
+
+	if c, _ := r.Cookie(csrfCookieName); c == nil || c.Value != encoded {
+		t.Error("Expected cookie signed with an unknown key to be left alone")
+	}
+}
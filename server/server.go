@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -15,7 +16,14 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/hashicorp/errwrap"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/oidc"
 	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/db/migrations"
+	"github.com/henvic/climetrics/geolocation"
+	"github.com/henvic/climetrics/keyring"
+	"github.com/henvic/climetrics/mailer"
+	"github.com/jmoiron/sqlx"
 	"github.com/kisielk/sqlstruct"
 	log "github.com/sirupsen/logrus"
 )
@@ -33,22 +41,49 @@ var router = mux.NewRouter()
 
 // Params of the service
 type Params struct {
-	Address            string
-	DSN                string
-	UserSessionPrefix  string
-	SessionStoreSecret string
+	Address           string
+	DSN               string
+	UserSessionPrefix string
+
+	// AutoMigrate applies pending database migrations on start
+	AutoMigrate bool
+
+	// Keyring with the keys signing the session and CSRF cookies (random keys are used for missing ones)
+	Keyring keyring.Keyring
+
+	Geolocation geolocation.Options
+
+	// OIDC single sign-on (disabled when the issuer is not set)
+	OIDC oidc.Config
+
+	// Lockout policies for failed login attempts
+	Lockout lockout.Config
+
+	// BaseURL of the service, used on links sent by email (i.e., https://climetrics.example.com)
+	BaseURL string
+
+	// Mailer for invitations and password resets
+	Mailer mailer.Options
 
 	ExposeDebug bool
 }
 
 // ProtectedHandler does CSRF protection.
 type ProtectedHandler struct {
-	secret []byte
+	// keys signing the CSRF cookie, from the newest to the oldest
+	keys [][]byte
 
 	unsafe map[string]bool
 	m      sync.RWMutex
 }
 
+// UseKeys for signing the CSRF cookie. The first key signs, and all of them verify.
+func (p *ProtectedHandler) UseKeys(keys [][]byte) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.keys = keys
+}
+
 // Unsafe marks a endpoint as unprotected by CSRF.
 func (p *ProtectedHandler) Unsafe(path string) {
 	p.m.Lock()
@@ -59,13 +94,16 @@ func (p *ProtectedHandler) Unsafe(path string) {
 func (p *ProtectedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	p.m.RLock()
 	var unsafe = p.unsafe != nil && p.unsafe[r.URL.Path]
+	var keys = p.keys
 	p.m.RUnlock()
 
 	if unsafe {
 		r = csrf.UnsafeSkipCheck(r)
 	}
 
-	var pr = csrf.Protect(p.secret,
+	rekeyCSRFCookie(r, keys)
+
+	var pr = csrf.Protect(keys[0],
 		csrf.Secure(false),
 		csrf.ErrorHandler(csrfErrorHandler{}),
 	)(router)
@@ -75,7 +113,7 @@ func (p *ProtectedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // Protected handler.
 var Protected = &ProtectedHandler{
-	secret: secret(),
+	keys: [][]byte{randomKey()},
 
 	unsafe: map[string]bool{},
 }
@@ -93,9 +131,9 @@ func init() {
 	router.StrictSlash(true)
 }
 
-func secret() []byte {
-	// TODO(henvic): make it stick during restarts
-	b := make([]byte, 32)
+// randomKey is used until the keys are loaded, or when they aren't configured.
+func randomKey() []byte {
+	b := make([]byte, keyring.KeyLength)
 
 	if _, err := rand.Read(b); err != nil {
 		panic(err)
@@ -104,6 +142,11 @@ func secret() []byte {
 	return b
 }
 
+// URL on the service for the given path, for links sent by email.
+func URL(path string) string {
+	return strings.TrimSuffix(Instance.Params().BaseURL, "/") + path
+}
+
 // Start server for climetrics
 func Start(ctx context.Context, params Params) error {
 	return Instance.Serve(ctx, params)
@@ -118,6 +161,8 @@ type Server struct {
 	mux *mux.Router
 
 	httpServer *http.Server
+
+	background []func(ctx context.Context)
 }
 
 // Mux of the server
@@ -130,21 +175,88 @@ func (s *Server) Params() Params {
 	return s.params
 }
 
+// Background registers a task to run while the server is up.
+// The task must return once the context is done, and the server waits for it before exiting.
+func (s *Server) Background(task func(ctx context.Context)) {
+	s.background = append(s.background, task)
+}
+
+func (s *Server) runBackground(ctx context.Context) (wait func()) {
+	var wg sync.WaitGroup
+
+	for _, task := range s.background {
+		wg.Add(1)
+
+		go func(task func(ctx context.Context)) {
+			defer wg.Done()
+			task(ctx)
+		}(task)
+	}
+
+	return wg.Wait
+}
+
 // Serve handlers
 func (s *Server) Serve(ctx context.Context, params Params) error {
 	s.ctx = ctx
 	s.params = params
 
+	gp, err := geolocation.Parse(params.Geolocation)
+
+	if err != nil {
+		return err
+	}
+
+	geolocation.Use(gp)
+
+	if err := params.Lockout.Validate(); err != nil {
+		return err
+	}
+
+	lockout.Use(params.Lockout)
+
+	if _, err := url.Parse(params.BaseURL); err != nil || !strings.HasPrefix(params.BaseURL, "http") {
+		return fmt.Errorf("invalid base URL %q", params.BaseURL)
+	}
+
+	m, err := mailer.Parse(params.Mailer)
+
+	if err != nil {
+		return err
+	}
+
+	mailer.Use(m)
+
+	if params.OIDC.Enabled() {
+		op, err := oidc.New(ctx, params.OIDC)
+
+		if err != nil {
+			return errwrap.Wrapf("can't set up OpenID Connect: {{err}}", err)
+		}
+
+		oidc.Use(op)
+	}
+
+	if err := useKeys(&params.Keyring); err != nil {
+		return err
+	}
+
+	s.params = params
+
 	db, err := db.Load(ctx, params.DSN)
 
 	if err != nil {
 		return err
 	}
 
+	if err := migrate(ctx, db, params.AutoMigrate); err != nil {
+		return err
+	}
+
 	var ss *pgstore.PGStore
 	ss, err = pgstore.NewPGStoreFromPool(
 		db.DB,
-		[]byte(params.SessionStoreSecret),
+		keyPairs(params.Keyring.Keys(keyring.Session))...,
 	)
 
 	if err != nil {
@@ -158,7 +270,41 @@ func (s *Server) Serve(ctx context.Context, params Params) error {
 	// session garbage collector setup (fairly complicated)
 	defer ss.StopCleanup(ss.Cleanup(SessionGCInterval))
 
-	return s.http()
+	bctx, cancel := context.WithCancel(ctx)
+	wait := s.runBackground(bctx)
+
+	err = s.http()
+	cancel()
+	wait()
+	return err
+}
+
+// migrate database on start, or warn about pending migrations.
+func migrate(ctx context.Context, conn *sqlx.DB, auto bool) error {
+	if !auto {
+		pending, err := migrations.Pending(ctx, conn)
+
+		switch {
+		case err != nil:
+			log.Errorf("can't check database migrations: %v", err)
+		case len(pending) != 0:
+			log.Warnf("Database has %d pending migrations: run migrate up, or start with -auto-migrate", len(pending))
+		}
+
+		return nil
+	}
+
+	done, err := migrations.Up(ctx, conn, 0)
+
+	for _, m := range done {
+		log.Infof("Applied database migration %v", m)
+	}
+
+	if err != nil {
+		return errwrap.Wrapf("can't migrate database: {{err}}", err)
+	}
+
+	return nil
 }
 
 func getAddr(a string) string {
@@ -12,8 +12,10 @@ import (
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
+	"github.com/henvic/climetrics/auth/oidc"
 	"github.com/henvic/climetrics/timejson"
 	"github.com/henvic/climetrics/us"
+	"github.com/henvic/climetrics/users"
 
 	"github.com/gorilla/csrf"
 	"github.com/gorilla/securecookie"
@@ -68,6 +70,10 @@ func humanizeTime(t interface{}) (string, error) {
 		return humanize.Time(tv), nil
 	}
 
+	if tv, ok := t.(*time.Time); ok && tv != nil {
+		return humanize.Time(*tv), nil
+	}
+
 	if tv, ok := t.(timejson.RubyDate); ok {
 		return humanize.Time(time.Time(tv)), nil
 	}
@@ -107,11 +113,22 @@ func (t *Template) Execute() error {
 	files = append(files, t.Filenames...)
 
 	req := t.Request
+	reqCtx := req.Context()
+	si := reqCtx.Value(SessionCtx{})
+
+	var s us.Session
+
+	if sp, ok := si.(us.Session); ok {
+		s = sp
+	}
 
 	var to = template.New("").Funcs(basicFunctions).Funcs(template.FuncMap{
 		"isSectionActive":           t.isSectionActiveFunc,
 		"printSectionActive":        t.printSectionActiveFunc,
 		"printValueIfSectionActive": t.printValueIfSectionIsActiveFunc,
+		"can": func(p string) bool {
+			return s.User.Can(users.Permission(p))
+		},
 	})
 
 	_, err := to.ParseFiles(files...)
@@ -120,15 +137,6 @@ func (t *Template) Execute() error {
 		return err
 	}
 
-	reqCtx := req.Context()
-	si := reqCtx.Value(SessionCtx{})
-
-	var s us.Session
-
-	if sp, ok := si.(us.Session); ok {
-		s = sp
-	}
-
 	var values = map[string]interface{}{
 		"Title": t.Title,
 		"Data":  t.Data,
@@ -136,6 +144,8 @@ func (t *Template) Execute() error {
 		"Session": s.Session,
 		"User":    s.User,
 
+		"SSO": oidc.Current() != nil,
+
 		csrf.TemplateTag: csrf.TemplateField(req),
 	}
 
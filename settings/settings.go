@@ -0,0 +1,54 @@
+// Package settings stores application settings changed at runtime on the database.
+package settings
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/henvic/climetrics/db"
+)
+
+// Get setting value, or the fallback if it is not set.
+func Get(ctx context.Context, name, fallback string) (string, error) {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx, `SELECT value FROM settings WHERE name = $1`)
+
+	if err != nil {
+		return fallback, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	var value string
+	err = stmt.QueryRowContext(ctx, name).Scan(&value)
+
+	if err == sql.ErrNoRows {
+		return fallback, nil
+	}
+
+	if err != nil {
+		return fallback, err
+	}
+
+	return value, nil
+}
+
+// Set setting value.
+func Set(ctx context.Context, name, value string) error {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx, `INSERT INTO settings (name, value, modified) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value, modified = EXCLUDED.modified`)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, name, value)
+	return err
+}
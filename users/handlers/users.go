@@ -1,27 +1,40 @@
 package usershandlers
 
 import (
+	"crypto/rand"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"os"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/henvic/climetrics/audit"
+	"github.com/henvic/climetrics/auth/lockout"
+	"github.com/henvic/climetrics/auth/onetime"
+	"github.com/henvic/climetrics/auth/usersessions"
+	"github.com/henvic/climetrics/mailer"
 	"github.com/henvic/climetrics/server"
 	"github.com/henvic/climetrics/us"
 	"github.com/henvic/climetrics/users"
 	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var router = server.Instance.Mux
-var usernameRegex = regexp.MustCompile("^[a-z0-9][a-z0-9]*$")
 
 func init() {
-	router().Handle("/users", server.AuthenticatedHandler(usersHandler))
-	router().Handle("/users/add", server.AuthenticatedHandler(createHandler))
-	router().Handle("/users/{user_id}", server.AuthenticatedHandler(editHandler))
+	router().Handle("/users", server.RequirePermission(users.ManageUsers, usersHandler))
+	router().Handle("/users/add", server.RequirePermission(users.ManageUsers, createHandler))
+	router().Handle("/users/two-factor", server.RequirePermission(users.ManageUsers, twoFactorPolicyHandler))
+	router().Handle("/users/{user_id}", server.RequirePermission(users.ManageUsers, editHandler))
+	router().Handle("/users/{user_id}/two-factor/reset", server.RequirePermission(users.ManageUsers, twoFactorResetHandler))
+	router().Handle("/users/{user_id}/unlock", server.RequirePermission(users.ManageUsers, unlockHandler))
+	router().Handle("/users/{user_id}/invite", server.RequirePermission(users.ManageUsers, inviteHandler))
+	router().Handle("/users/{user_id}/sessions/revoke", server.RequirePermission(users.ManageUsers, revokeSessionsHandler))
+	router().Handle("/users/{user_id}/sessions/{id}/revoke", server.RequirePermission(users.ManageUsers, revokeSessionHandler))
 }
 
 func usersHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
@@ -43,7 +56,7 @@ func usersHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 		return
 	}
 
-	users, err := users.List(r.Context(), f)
+	list, err := users.List(r.Context(), f)
 
 	if err != nil {
 		server.ErrorHandler(w, r, "Can't get users list", http.StatusInternalServerError)
@@ -51,14 +64,25 @@ func usersHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 		return
 	}
 
+	policy, err := users.GetTwoFactorPolicy(r.Context())
+
+	if err != nil {
+		server.ErrorHandler(w, r, "Can't get two-factor authentication policy", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
 	var t = &server.Template{
 		Title:     "Users",
 		Section:   "users",
 		Filenames: []string{"gui/users/users.html"},
 		Data: map[string]interface{}{
-			"Users":     users,
+			"Users":     list,
 			"Operators": []string{"all", "active"},
 			"Show":      show,
+
+			"TwoFactorPolicy":   policy,
+			"TwoFactorPolicies": users.TwoFactorPolicies,
 		},
 		Request:        r,
 		ResponseWriter: w,
@@ -86,28 +110,34 @@ func createHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 	var email = r.PostFormValue("email")
 	var password = r.PostFormValue("password")
 
-	if !usernameRegex.MatchString(username) {
-		server.ErrorHandler(w, r, "Missing or invalid username", http.StatusBadRequest)
-		return
-	}
+	var role = r.PostFormValue("role")
 
-	if email == "" {
-		server.ErrorHandler(w, r, "Missing email parameter", http.StatusBadRequest)
+	if err := users.Validate(users.User{Username: username, Email: email, Role: role}); err != nil {
+		server.ErrorHandler(w, r, "Invalid user: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if password == "" {
-		server.ErrorHandler(w, r, "Missing password parameter", http.StatusBadRequest)
-		return
-	}
+	var invite = r.PostFormValue("invite") != ""
 
-	var role = r.PostFormValue("role")
+	switch {
+	case invite:
+		// the user sets the password when accepting the invitation
+		p, err := unusablePassword()
 
-	switch role {
-	case "admin", "member", "revoked":
-	default:
-		server.ErrorHandler(w, r, "Missing / invalid role parameter", http.StatusBadRequest)
+		if err != nil {
+			server.ErrorHandler(w, r, "Error generating password", http.StatusInternalServerError)
+			return
+		}
+
+		password = p
+	case password == "":
+		server.ErrorHandler(w, r, "Missing password parameter", http.StatusBadRequest)
 		return
+	default:
+		if err := users.ValidatePassword(password); err != nil {
+			server.ErrorHandler(w, r, "Invalid password: "+err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -131,7 +161,71 @@ func createHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 		return
 	}
 
-	http.Redirect(w, r, "/users", http.StatusSeeOther)
+	server.Audit(r, audit.Entry{
+		Action:  audit.UserCreate,
+		Target:  user.Username,
+		Details: fmt.Sprintf("email: %s, role: %s", user.Email, user.Role),
+	})
+
+	if !invite {
+		http.Redirect(w, r, "/users", http.StatusSeeOther)
+		return
+	}
+
+	if err := sendInvitation(r, s, user); err != nil {
+		server.ErrorHandler(w, r, "User created, but the invitation wasn't sent. Try to resend it.", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	http.Redirect(w, r, "/users/"+user.UserID, http.StatusSeeOther)
+}
+
+// unusablePassword for users who are yet to set their own password.
+func unusablePassword() (string, error) {
+	var b = make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", b), nil
+}
+
+// sendInvitation email for the user to set their password, replacing any previous invitation.
+func sendInvitation(r *http.Request, s us.Session, user users.User) error {
+	secret, expires, err := onetime.Create(r.Context(), user.UserID, onetime.Invitation)
+
+	if err != nil {
+		return err
+	}
+
+	body, err := mailer.Render("gui/email/invitation.txt", map[string]interface{}{
+		"Username":  user.Username,
+		"InvitedBy": s.User.Username,
+		"Link":      server.URL("/invitation/" + secret),
+		"Expires":   expires,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err = mailer.Send(r.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "You are invited to CLI metrics",
+		Body:    body,
+	}); err != nil {
+		return err
+	}
+
+	server.Audit(r, audit.Entry{
+		Action:  audit.UserInvite,
+		Target:  user.Username,
+		Details: fmt.Sprintf("email: %s", user.Email),
+	})
+
+	return nil
 }
 
 func editHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
@@ -162,12 +256,49 @@ func editHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
 }
 
 func editHandlerGetHandler(w http.ResponseWriter, r *http.Request, s us.Session, user users.User) {
+	failures, err := lockout.Get(r.Context(), lockout.AccountKey(user.UserID))
+
+	if err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: getting failed login attempts", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	invited, pendingInvitation, err := onetime.Pending(r.Context(), user.UserID, onetime.Invitation)
+
+	if err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: getting pending invitation", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	sessions, err := usersessions.List(r.Context(), user.UserID)
+
+	if err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: getting sessions", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	var policy = lockout.Current().Account
+	var now = time.Now()
+
 	var t = server.Template{
 		Title:     "Edit user access",
 		Section:   "users",
 		Filenames: []string{"gui/users/edit.html"},
 		Data: map[string]interface{}{
 			"User": user,
+
+			"LoginFailures":    failures,
+			"LoginLocked":      policy.Locked(failures, now),
+			"LoginLockedUntil": now.Add(policy.Wait(failures, now)),
+
+			"PendingInvitation": pendingInvitation,
+			"Invited":           invited,
+
+			"Sessions":       sessions,
+			"CurrentSession": s.Session.ID,
 		},
 		Request:        r,
 		ResponseWriter: w,
@@ -183,37 +314,24 @@ func editHandlerPostHandler(w http.ResponseWriter, r *http.Request, s us.Session
 	}
 
 	var username = r.PostFormValue("username")
-
-	if !usernameRegex.MatchString(username) {
-		server.ErrorHandler(w, r, "Missing or invalid username", http.StatusBadRequest)
-		return
-	}
-
 	var email = r.PostFormValue("email")
-
-	if !strings.Contains(email, "@") {
-		server.ErrorHandler(w, r, "Internal Server Error: email is invalid", http.StatusInternalServerError)
-		return
-	}
-
 	var role = r.PostFormValue("role")
 
-	switch role {
-	case "admin", "member", "revoked":
-	default:
-		server.ErrorHandler(w, r,
-			fmt.Sprintf("Internal Server Error: role is not recognized: %v", role),
-			http.StatusInternalServerError)
+	if err := users.Validate(users.User{Username: username, Email: email, Role: role}); err != nil {
+		server.ErrorHandler(w, r, "Invalid user: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if role == "revoked" && s.User.UserID == user.UserID {
+	if role != users.Admin && s.User.UserID == user.UserID {
 		server.ErrorHandler(w, r,
-			fmt.Sprintf("Internal Server Error: role is not recognized: %v", role),
+			"You can't remove your own admin role.",
 			http.StatusNotAcceptable)
 		return
 	}
 
+	var previousRole = user.Role
+	var changes = userChanges(user, username, email, role)
+
 	user.Username = username
 	user.Email = email
 	user.Role = role
@@ -221,6 +339,11 @@ func editHandlerPostHandler(w http.ResponseWriter, r *http.Request, s us.Session
 	var password = r.PostFormValue("password")
 
 	if password != "" {
+		if err := users.ValidatePassword(password); err != nil {
+			server.ErrorHandler(w, r, "Invalid password: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		var hash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 
 		if err != nil {
@@ -229,6 +352,7 @@ func editHandlerPostHandler(w http.ResponseWriter, r *http.Request, s us.Session
 		}
 
 		user.Password = string(hash)
+		changes = append(changes, "password changed")
 	}
 
 	if err := users.Update(r.Context(), user); err != nil {
@@ -237,5 +361,252 @@ func editHandlerPostHandler(w http.ResponseWriter, r *http.Request, s us.Session
 		return
 	}
 
+	var action = audit.UserUpdate
+
+	if role == users.Revoked && previousRole != users.Revoked {
+		action = audit.UserRevoke
+
+		if _, err := usersessions.RevokeAll(r.Context(), user.UserID, ""); err != nil {
+			// serveHTTP revokes them on their next request anyway
+			log.Errorf("can't revoke sessions of revoked user %s: %v", user.Username, err)
+		}
+	}
+
+	server.Audit(r, audit.Entry{
+		Action:  action,
+		Target:  user.Username,
+		Details: strings.Join(changes, ", "),
+	})
+
+	http.Redirect(w, r, "/users", http.StatusSeeOther)
+}
+
+// userChanges to the username, email, and role of an user, as recorded on the audit log.
+func userChanges(user users.User, username, email, role string) (changes []string) {
+	for _, c := range []struct {
+		field    string
+		from, to string
+	}{
+		{"username", user.Username, username},
+		{"email", user.Email, email},
+		{"role", user.Role, role},
+	} {
+		if c.from != c.to {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", c.field, c.from, c.to))
+		}
+	}
+
+	return changes
+}
+
+func twoFactorPolicyHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var policy = users.TwoFactorPolicy(r.PostFormValue("policy"))
+
+	if !policy.Valid() {
+		server.ErrorHandler(w, r, "Missing / invalid two-factor authentication policy", http.StatusBadRequest)
+		return
+	}
+
+	if err := users.SetTwoFactorPolicy(r.Context(), policy); err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: saving two-factor authentication policy", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	log.Infof("user %s changed the two-factor authentication policy to %s", s.User.Username, policy)
+	server.Audit(r, audit.Entry{
+		Action: audit.TwoFactorPolicy,
+		Target: string(policy),
+	})
+
 	http.Redirect(w, r, "/users", http.StatusSeeOther)
 }
+
+func twoFactorResetHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := users.Get(r.Context(), mux.Vars(r)["user_id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		server.ErrorHandler(w, r, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err = users.DisableTOTP(r.Context(), user.UserID); err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: resetting two-factor authentication", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	log.Infof("user %s reset the two-factor authentication of user %s", s.User.Username, user.Username)
+	server.Audit(r, audit.Entry{
+		Action: audit.TwoFactorReset,
+		Target: user.Username,
+	})
+
+	http.Redirect(w, r, "/users/"+user.UserID, http.StatusSeeOther)
+}
+
+func unlockHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := users.Get(r.Context(), mux.Vars(r)["user_id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		server.ErrorHandler(w, r, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err = lockout.Reset(r.Context(), lockout.AccountKey(user.UserID)); err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: unlocking user", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	log.Infof("user %s unlocked the login of user %s", s.User.Username, user.Username)
+	server.Audit(r, audit.Entry{
+		Action: audit.UserUnlock,
+		Target: user.Username,
+	})
+
+	http.Redirect(w, r, "/users/"+user.UserID, http.StatusSeeOther)
+}
+
+func inviteHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := users.Get(r.Context(), mux.Vars(r)["user_id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		server.ErrorHandler(w, r, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if user.Role == users.Revoked {
+		server.ErrorHandler(w, r, "You can't invite a revoked user.", http.StatusNotAcceptable)
+		return
+	}
+
+	if err = sendInvitation(r, s, user); err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: sending invitation", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	log.Infof("user %s invited user %s", s.User.Username, user.Username)
+	http.Redirect(w, r, "/users/"+user.UserID, http.StatusSeeOther)
+}
+
+func revokeSessionsHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, err := users.Get(r.Context(), mux.Vars(r)["user_id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		server.ErrorHandler(w, r, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// admins revoking their own sessions keep the current one
+	var except string
+
+	if user.UserID == s.User.UserID {
+		except = s.Session.ID
+	}
+
+	n, err := usersessions.RevokeAll(r.Context(), user.UserID, except)
+
+	if err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: revoking sessions", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	log.Infof("user %s revoked %d sessions of user %s", s.User.Username, n, user.Username)
+	server.Audit(r, audit.Entry{
+		Action:  audit.SessionRevokeAll,
+		Target:  user.Username,
+		Details: fmt.Sprintf("%d sessions", n),
+	})
+
+	http.Redirect(w, r, "/users/"+user.UserID, http.StatusSeeOther)
+}
+
+func revokeSessionHandler(w http.ResponseWriter, r *http.Request, s us.Session) {
+	if r.Method != http.MethodPost {
+		server.ErrorHandler(w, r, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var vars = mux.Vars(r)
+	user, err := users.Get(r.Context(), vars["user_id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		server.ErrorHandler(w, r, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	err = usersessions.Revoke(r.Context(), user.UserID, vars["id"])
+
+	if err == sql.ErrNoRows {
+		server.ErrorHandler(w, r, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		server.ErrorHandler(w, r, "Internal Server Error: revoking session", http.StatusInternalServerError)
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+
+	log.Infof("user %s revoked session %s of user %s", s.User.Username, vars["id"], user.Username)
+	server.Audit(r, audit.Entry{
+		Action:  audit.SessionRevoke,
+		Target:  user.Username,
+		Details: vars["id"],
+	})
+
+	http.Redirect(w, r, "/users/"+user.UserID, http.StatusSeeOther)
+}
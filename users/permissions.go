@@ -0,0 +1,72 @@
+package users
+
+// Roles of the users.
+const (
+	Admin   = "admin"
+	Member  = "member"
+	Revoked = "revoked"
+)
+
+// Roles available.
+var Roles = []string{Admin, Member, Revoked}
+
+// Permission to do something.
+type Permission string
+
+const (
+	// ViewMetrics permission.
+	ViewMetrics Permission = "view_metrics"
+
+	// ViewDiagnostics permission.
+	ViewDiagnostics Permission = "view_diagnostics"
+
+	// ManageUsers permission.
+	ManageUsers Permission = "manage_users"
+
+	// ManageSettings permission (such as geolocation).
+	ManageSettings Permission = "manage_settings"
+
+	// ViewAuditLog permission.
+	ViewAuditLog Permission = "view_audit_log"
+)
+
+// Permissions available.
+var Permissions = []Permission{
+	ViewMetrics,
+	ViewDiagnostics,
+	ManageUsers,
+	ManageSettings,
+	ViewAuditLog,
+}
+
+var rolePermissions = map[string][]Permission{
+	Admin:  Permissions,
+	Member: {ViewMetrics, ViewDiagnostics},
+}
+
+// ValidRole tells if the role exists.
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if role == r {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleCan tells if a role has a given permission.
+func RoleCan(role string, p Permission) bool {
+	for _, rp := range rolePermissions[role] {
+		if rp == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can tells if the user has a given permission.
+func (u User) Can(p Permission) bool {
+	return RoleCan(u.Role, p)
+}
@@ -0,0 +1,51 @@
+package users
+
+import "testing"
+
+func TestRoleCan(t *testing.T) {
+	var cases = []struct {
+		role string
+		p    Permission
+		want bool
+	}{
+		{Admin, ViewMetrics, true},
+		{Admin, ViewDiagnostics, true},
+		{Admin, ManageUsers, true},
+		{Admin, ManageSettings, true},
+		{Admin, ViewAuditLog, true},
+		{Member, ViewMetrics, true},
+		{Member, ViewDiagnostics, true},
+		{Member, ManageUsers, false},
+		{Member, ManageSettings, false},
+		{Member, ViewAuditLog, false},
+		{Revoked, ViewMetrics, false},
+		{Revoked, ViewDiagnostics, false},
+		{Revoked, ManageUsers, false},
+		{Revoked, ManageSettings, false},
+		{Revoked, ViewAuditLog, false},
+		{"", ViewMetrics, false},
+		{"unknown", ViewMetrics, false},
+	}
+
+	for _, c := range cases {
+		if got := RoleCan(c.role, c.p); got != c.want {
+			t.Errorf("Expected role %q to have permission %s = %v, got %v instead", c.role, c.p, c.want, got)
+		}
+
+		if got := (User{Role: c.role}).Can(c.p); got != c.want {
+			t.Errorf("Expected user with role %q to have permission %s = %v, got %v instead", c.role, c.p, c.want, got)
+		}
+	}
+}
+
+func TestValidRole(t *testing.T) {
+	for _, r := range Roles {
+		if !ValidRole(r) {
+			t.Errorf("Expected role %q to be valid", r)
+		}
+	}
+
+	if ValidRole("root") {
+		t.Error("Expected role root to be invalid")
+	}
+}
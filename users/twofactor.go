@@ -0,0 +1,227 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/henvic/climetrics/db"
+	"github.com/henvic/climetrics/settings"
+)
+
+// TwoFactorPolicy tells who is required to use two-factor authentication.
+type TwoFactorPolicy string
+
+const (
+	// TwoFactorOptional lets users choose.
+	TwoFactorOptional TwoFactorPolicy = "optional"
+
+	// TwoFactorAdmins requires admins to use two-factor authentication.
+	TwoFactorAdmins TwoFactorPolicy = "admins"
+
+	// TwoFactorEveryone requires everyone to use two-factor authentication.
+	TwoFactorEveryone TwoFactorPolicy = "everyone"
+)
+
+// TwoFactorPolicies available.
+var TwoFactorPolicies = []TwoFactorPolicy{TwoFactorOptional, TwoFactorAdmins, TwoFactorEveryone}
+
+const twoFactorPolicySetting = "two_factor_policy"
+
+// Valid tells if the policy exists.
+func (p TwoFactorPolicy) Valid() bool {
+	for _, tp := range TwoFactorPolicies {
+		if p == tp {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Requires tells if the policy requires a user with the given role to use two-factor authentication.
+func (p TwoFactorPolicy) Requires(role string) bool {
+	switch p {
+	case TwoFactorEveryone:
+		return role != Revoked
+	case TwoFactorAdmins:
+		return role == Admin
+	}
+
+	return false
+}
+
+// GetTwoFactorPolicy gets the current two-factor authentication policy.
+func GetTwoFactorPolicy(ctx context.Context) (TwoFactorPolicy, error) {
+	v, err := settings.Get(ctx, twoFactorPolicySetting, string(TwoFactorOptional))
+	return TwoFactorPolicy(v), err
+}
+
+// SetTwoFactorPolicy sets the two-factor authentication policy.
+func SetTwoFactorPolicy(ctx context.Context, p TwoFactorPolicy) error {
+	if !p.Valid() {
+		return fmt.Errorf(`invalid two-factor authentication policy "%s"`, p)
+	}
+
+	return settings.Set(ctx, twoFactorPolicySetting, string(p))
+}
+
+// TwoFactor tells if the user has two-factor authentication enabled.
+func (u User) TwoFactor() bool {
+	return u.TOTPSecret != ""
+}
+
+// EnableTOTP for the user, replacing their recovery codes.
+func EnableTOTP(ctx context.Context, userID, secret string, counter int64, recoveryHashes []string) error {
+	conn := db.Conn()
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, `UPDATE authentication SET totp_secret = $1, totp_counter = $2 WHERE user_id = $3`,
+		secret, counter, userID); err != nil {
+		return err
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DisableTOTP for the user, removing their recovery codes.
+func DisableTOTP(ctx context.Context, userID string) error {
+	conn := db.Conn()
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, `UPDATE authentication SET totp_secret = '', totp_counter = 0 WHERE user_id = $1`,
+		userID); err != nil {
+		return err
+	}
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceRecoveryCodes of the user.
+func ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	conn := db.Conn()
+	tx, err := conn.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx execer, userID string, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM authentication_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, h := range hashes {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO authentication_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseTOTPCounter marks a TOTP counter as used, returning false if it (or a later one) was already used.
+func UseTOTPCounter(ctx context.Context, userID string, counter int64) (bool, error) {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx,
+		`UPDATE authentication SET totp_counter = $1 WHERE user_id = $2 AND totp_counter < $1`)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, counter, userID)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode consumes a recovery code, returning false if it doesn't exist.
+func UseRecoveryCode(ctx context.Context, userID, hash string) (bool, error) {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx,
+		`DELETE FROM authentication_recovery_codes WHERE user_id = $1 AND code_hash = $2`)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	res, err := stmt.ExecContext(ctx, userID, hash)
+
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// RecoveryCodesLeft for the user.
+func RecoveryCodesLeft(ctx context.Context, userID string) (n int, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx,
+		`SELECT COUNT(*) FROM authentication_recovery_codes WHERE user_id = $1`)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	err = stmt.QueryRowContext(ctx, userID).Scan(&n)
+	return n, err
+}
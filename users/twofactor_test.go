@@ -0,0 +1,49 @@
+package users
+
+import "testing"
+
+func TestTwoFactorPolicyRequires(t *testing.T) {
+	var cases = []struct {
+		policy TwoFactorPolicy
+		role   string
+		want   bool
+	}{
+		{TwoFactorOptional, Admin, false},
+		{TwoFactorOptional, Member, false},
+		{TwoFactorAdmins, Admin, true},
+		{TwoFactorAdmins, Member, false},
+		{TwoFactorEveryone, Admin, true},
+		{TwoFactorEveryone, Member, true},
+		{TwoFactorEveryone, Revoked, false},
+		{TwoFactorPolicy("unknown"), Admin, false},
+	}
+
+	for _, c := range cases {
+		if got := c.policy.Requires(c.role); got != c.want {
+			t.Errorf("Expected policy %s to require two-factor authentication for %s = %v, got %v instead",
+				c.policy, c.role, c.want, got)
+		}
+	}
+}
+
+func TestTwoFactorPolicyValid(t *testing.T) {
+	for _, p := range TwoFactorPolicies {
+		if !p.Valid() {
+			t.Errorf("Expected policy %s to be valid", p)
+		}
+	}
+
+	if TwoFactorPolicy("always").Valid() {
+		t.Errorf("Expected unknown policy to be invalid")
+	}
+}
+
+func TestUserTwoFactor(t *testing.T) {
+	if (User{}).TwoFactor() {
+		t.Errorf("Expected user without TOTP secret to not have two-factor authentication")
+	}
+
+	if !(User{TOTPSecret: "JBSWY3DPEHPK3PXP"}).TwoFactor() {
+		t.Errorf("Expected user with TOTP secret to have two-factor authentication")
+	}
+}
@@ -2,10 +2,14 @@ package users
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"database/sql"
 
+	"github.com/henvic/climetrics/auth/onetime"
 	"github.com/henvic/climetrics/db"
 	"github.com/kisielk/sqlstruct"
 )
@@ -17,6 +21,9 @@ type User struct {
 	Email    string `schema:"email"`
 	Role     string `schema:"role"`
 	Password string `schema:"password"`
+
+	// TOTPSecret for two-factor authentication (empty if not enabled).
+	TOTPSecret string `schema:"-" sql:"totp_secret"`
 }
 
 // Filter for users.
@@ -26,13 +33,13 @@ type Filter struct {
 
 // List users
 func List(ctx context.Context, f Filter) (users []User, err error) {
-	q := []string{"SELECT user_id, username, email, role FROM authentication"}
+	q := []string{"SELECT user_id, username, email, role, totp_secret FROM authentication"}
 	args := []interface{}{}
 
 	if f.Active {
 		q = append(q, "WHERE")
 		q = append(q, "role != $1")
-		args = append(args, "revoked")
+		args = append(args, Revoked)
 	}
 
 	conn := db.Conn()
@@ -70,7 +77,7 @@ func List(ctx context.Context, f Filter) (users []User, err error) {
 func Get(ctx context.Context, userID string) (u User, err error) {
 	conn := db.Conn()
 	stmt, err := conn.PrepareContext(ctx,
-		`SELECT user_id, username, email, password, role FROM authentication WHERE user_id = $1`)
+		`SELECT user_id, username, email, password, role, totp_secret FROM authentication WHERE user_id = $1`)
 
 	if err != nil {
 		return u, err
@@ -94,6 +101,56 @@ func Get(ctx context.Context, userID string) (u User, err error) {
 	return u, err
 }
 
+// GetByEmail gets an user row by email (case-insensitive)
+func GetByEmail(ctx context.Context, email string) (u User, err error) {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx,
+		`SELECT user_id, username, email, password, role, totp_secret FROM authentication WHERE lower(email) = lower($1)`)
+
+	if err != nil {
+		return u, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	rows, err := stmt.QueryContext(ctx, email)
+
+	if err != nil {
+		return u, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	if ok := rows.Next(); !ok {
+		return u, sql.ErrNoRows
+	}
+
+	err = sqlstruct.Scan(&u, rows)
+	return u, err
+}
+
+// UsernameTaken tells if there is an user with the given username
+func UsernameTaken(ctx context.Context, username string) (bool, error) {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx, `SELECT EXISTS(SELECT 1 FROM authentication WHERE username = $1)`)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	var taken bool
+	err = stmt.QueryRowContext(ctx, username).Scan(&taken)
+	return taken, err
+}
+
 // Create user
 func Create(ctx context.Context, user User) error {
 	conn := db.Conn()
@@ -127,3 +184,62 @@ func Update(ctx context.Context, u User) error {
 	_, err = stmt.ExecContext(ctx, u.Username, u.Email, u.Password, u.Role, u.UserID)
 	return err
 }
+
+// SetPassword of an user (bcrypt hash)
+func SetPassword(ctx context.Context, userID, hash string) error {
+	conn := db.Conn()
+	stmt, err := conn.PrepareContext(ctx, "UPDATE authentication SET password = $1 WHERE user_id = $2")
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = stmt.Close()
+	}()
+
+	_, err = stmt.ExecContext(ctx, hash, userID)
+	return err
+}
+
+// SetPasswordWithToken sets the password of the user of a one-time token (i.e., an invitation),
+// using the token on the same transaction, so it is only used if the password is changed.
+func SetPasswordWithToken(ctx context.Context, secret string, p onetime.Purpose, hash string) (userID string, err error) {
+	conn := db.Conn()
+	tx, err := conn.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if userID, err = onetime.Use(ctx, tx, secret, p); err != nil {
+		return "", err
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE authentication SET password = $1 WHERE user_id = $2", hash, userID); err != nil {
+		return "", err
+	}
+
+	return userID, tx.Commit()
+}
+
+// MinPasswordLength for passwords chosen by users.
+const MinPasswordLength = 8
+
+// ValidatePassword chosen by an user.
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return fmt.Errorf("password must have at least %d characters", MinPasswordLength)
+	}
+
+	// bcrypt ignores anything after 72 bytes
+	if len(password) > 72 {
+		return errors.New("password must have at most 72 bytes")
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package users
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	var cases = []struct {
+		password string
+		valid    bool
+	}{
+		{"", false},
+		{"short", false},
+		{"1234567", false},
+		{"12345678", true},
+		{"correct horse battery staple", true},
+		{"ççççççç", false},
+		{"çççççççç", true},
+		{strings.Repeat("a", 72), true},
+		{strings.Repeat("a", 73), false},
+	}
+
+	for _, c := range cases {
+		if err := ValidatePassword(c.password); (err == nil) != c.valid {
+			t.Errorf("Expected password %q to be valid = %v, got error %v instead", c.password, c.valid, err)
+		}
+	}
+}
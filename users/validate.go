@@ -0,0 +1,89 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MaxUsernameLength of the username column.
+	MaxUsernameLength = 36
+
+	// MaxEmailLength of the email column.
+	MaxEmailLength = 254
+)
+
+var usernameRegex = regexp.MustCompile("^[a-z0-9][a-z0-9]*$")
+
+// ValidateUsername checks if the username only has lowercase letters and digits.
+func ValidateUsername(username string) error {
+	if username == "" {
+		return errors.New("missing username")
+	}
+
+	if utf8.RuneCountInString(username) > MaxUsernameLength {
+		return fmt.Errorf("invalid username: use at most %d characters", MaxUsernameLength)
+	}
+
+	if !usernameRegex.MatchString(username) {
+		return fmt.Errorf(`invalid username "%s": use only lowercase letters and digits`, username)
+	}
+
+	return nil
+}
+
+// ValidateEmail checks if the email is a plain address (i.e., user@example.com).
+func ValidateEmail(email string) error {
+	if email == "" {
+		return errors.New("missing email")
+	}
+
+	if utf8.RuneCountInString(email) > MaxEmailLength {
+		return fmt.Errorf("invalid email: use at most %d characters", MaxEmailLength)
+	}
+
+	a, err := mail.ParseAddress(email)
+
+	if err != nil || a.Address != email || a.Name != "" {
+		return fmt.Errorf(`invalid email "%s"`, email)
+	}
+
+	return nil
+}
+
+// ValidateRole checks if the role exists.
+func ValidateRole(role string) error {
+	if !ValidRole(role) {
+		return fmt.Errorf(`invalid role "%s": use %s`, role, strings.Join(Roles, ", "))
+	}
+
+	return nil
+}
+
+// ValidatePasswordHash checks if the password is a bcrypt hash.
+func ValidatePasswordHash(hash string) error {
+	if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+		return errors.New("invalid password hash: use a bcrypt hash")
+	}
+
+	return nil
+}
+
+// Validate the username, email, and role of an user.
+func Validate(u User) error {
+	if err := ValidateUsername(u.Username); err != nil {
+		return err
+	}
+
+	if err := ValidateEmail(u.Email); err != nil {
+		return err
+	}
+
+	return ValidateRole(u.Role)
+}
@@ -0,0 +1,100 @@
+package users
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateUsername(t *testing.T) {
+	var cases = []struct {
+		username string
+		valid    bool
+	}{
+		{"", false},
+		{"alice", true},
+		{"alice2", true},
+		{"2alice", true},
+		{"Alice", false},
+		{"alice.smith", false},
+		{"alice smith", false},
+		{"alice@example.com", false},
+		{strings.Repeat("a", 36), true},
+		{strings.Repeat("a", 37), false},
+	}
+
+	for _, c := range cases {
+		if err := ValidateUsername(c.username); (err == nil) != c.valid {
+			t.Errorf("Expected username %q to be valid = %v, got error %v instead", c.username, c.valid, err)
+		}
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	var cases = []struct {
+		email string
+		valid bool
+	}{
+		{"", false},
+		{"alice@example.com", true},
+		{"alice+metrics@example.com", true},
+		{"alice", false},
+		{"@example.com", false},
+		{"Alice <alice@example.com>", false},
+		{" alice@example.com", false},
+		{"alice@example.com, bob@example.com", false},
+		{strings.Repeat("a", 242) + "@example.com", true},
+		{strings.Repeat("a", 243) + "@example.com", false},
+	}
+
+	for _, c := range cases {
+		if err := ValidateEmail(c.email); (err == nil) != c.valid {
+			t.Errorf("Expected email %q to be valid = %v, got error %v instead", c.email, c.valid, err)
+		}
+	}
+}
+
+func TestValidateRole(t *testing.T) {
+	for _, role := range Roles {
+		if err := ValidateRole(role); err != nil {
+			t.Errorf("Expected role %s to be valid, got %v instead", role, err)
+		}
+	}
+
+	if err := ValidateRole("root"); err == nil || err.Error() != `invalid role "root": use admin, member, revoked` {
+		t.Errorf("Expected invalid role error, got %v instead", err)
+	}
+}
+
+func TestValidatePasswordHash(t *testing.T) {
+	if err := ValidatePasswordHash("$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"); err != nil {
+		t.Errorf("Expected bcrypt hash to be valid, got %v instead", err)
+	}
+
+	if err := ValidatePasswordHash("secret password"); err == nil {
+		t.Errorf("Expected error for password that isn't a hash, got nil instead")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var u = User{
+		Username: "alice",
+		Email:    "alice@example.com",
+		Role:     Member,
+	}
+
+	if err := Validate(u); err != nil {
+		t.Errorf("Expected user to be valid, got %v instead", err)
+	}
+
+	var invalid = []User{
+		{Username: "Alice", Email: u.Email, Role: u.Role},
+		{Username: u.Username, Email: "alice", Role: u.Role},
+		{Username: u.Username, Email: u.Email, Role: ""},
+	}
+
+	for _, i := range invalid {
+		if err := Validate(i); err == nil {
+			t.Errorf("Expected user %+v to be invalid, got nil instead", i)
+		}
+	}
+}